@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
+	"github.com/codysoyland/cmdhooks/pkg/policy"
 	"github.com/codysoyland/cmdhooks/pkg/wrapper"
 )
 
@@ -18,6 +21,16 @@ func main() {
 	switch os.Args[1] {
 	case "run":
 		runCommand()
+	case "install":
+		installCommand()
+	case "uninstall":
+		uninstallCommand()
+	case "reload":
+		reloadCommand()
+	case "explain":
+		explainCommand()
+	case "lockfile":
+		lockfileCommand()
 	case "-h", "--help", "help":
 		printUsage()
 	default:
@@ -31,17 +44,30 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, "cmdhooks - Command hook system for intercepting and controlling command execution\n\n")
 	fmt.Fprintf(os.Stderr, "Usage:\n")
 	fmt.Fprintf(os.Stderr, "  cmdhooks run [-v] <command> [args...]\n")
+	fmt.Fprintf(os.Stderr, "  cmdhooks run [-v] -batch <line>\n")
+	fmt.Fprintf(os.Stderr, "  cmdhooks install [-wrapper <path>] <dir> <command...>\n")
+	fmt.Fprintf(os.Stderr, "  cmdhooks uninstall <dir>\n")
+	fmt.Fprintf(os.Stderr, "  cmdhooks reload [-seqpacket] <socket>\n")
+	fmt.Fprintf(os.Stderr, "  cmdhooks explain [-seqpacket] <socket> <command> [args...]\n")
+	fmt.Fprintf(os.Stderr, "  cmdhooks lockfile generate [-o <file>] <command...>\n")
 	fmt.Fprintf(os.Stderr, "  cmdhooks help\n\n")
 	fmt.Fprintf(os.Stderr, "Commands:\n")
-	fmt.Fprintf(os.Stderr, "  run     Execute a command with hook evaluation (used internally by wrapper scripts)\n")
-	fmt.Fprintf(os.Stderr, "  help    Show this help message\n\n")
+	fmt.Fprintf(os.Stderr, "  run        Execute a command with hook evaluation (used internally by wrapper scripts)\n")
+	fmt.Fprintf(os.Stderr, "  install    Write persistent wrapper scripts for commands into a directory\n")
+	fmt.Fprintf(os.Stderr, "  uninstall  Remove the wrapper scripts written by install from a directory\n")
+	fmt.Fprintf(os.Stderr, "  reload     Ask a running interceptor to reload its hook (token in $CMDHOOKS_RELOAD_TOKEN)\n")
+	fmt.Fprintf(os.Stderr, "  explain    Show how a running interceptor would decide a command, and why\n")
+	fmt.Fprintf(os.Stderr, "  lockfile   Record the binary digests of commands for policy.NewLockfileAllowlist\n")
+	fmt.Fprintf(os.Stderr, "  help       Show this help message\n\n")
 	fmt.Fprintf(os.Stderr, "Flags:\n")
 	fmt.Fprintf(os.Stderr, "  -v      Enable verbose output\n")
+	fmt.Fprintf(os.Stderr, "  -batch  Evaluate a shell command line as one pre-run batch\n")
 }
 
 func runCommand() {
 	runFlags := flag.NewFlagSet("run", flag.ExitOnError)
 	verbose := runFlags.Bool("v", false, "Enable verbose output")
+	batch := runFlags.String("batch", "", "Evaluate a shell command `line` as one pre-run batch instead of running a command")
 
 	runFlags.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: cmdhooks run [-v] <command> [args...]\n")
@@ -54,6 +80,19 @@ func runCommand() {
 		log.Fatal(err)
 	}
 
+	var wrapperOpts []wrapper.WrapperOption
+	if *verbose {
+		wrapperOpts = append(wrapperOpts, wrapper.WithVerbose(true))
+	}
+
+	// Batches are sent by the BASH_ENV shim before a command list runs
+	if *batch != "" {
+		if err := wrapper.RunBatch(*batch, wrapperOpts...); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	args := runFlags.Args()
 	if len(args) == 0 {
 		fmt.Fprintf(os.Stderr, "Error: no command specified\n\n")
@@ -61,14 +100,222 @@ func runCommand() {
 		os.Exit(1)
 	}
 
-	var wrapperOpts []wrapper.WrapperOption
-	if *verbose {
-		wrapperOpts = append(wrapperOpts, wrapper.WithVerbose(true))
-	}
-
 	// The wrapper.Run function will automatically detect the socket path
-	// from the CMDHOOKS_SOCKET environment variable
+	// from the CMDHOOKS_SOCKET environment variable. The command's non-zero
+	// exit code comes back as an ExitError for us to exit with.
 	if err := wrapper.Run(args, wrapperOpts...); err != nil {
+		var exitErr *wrapper.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		log.Fatal(err)
+	}
+}
+
+func installCommand() {
+	installFlags := flag.NewFlagSet("install", flag.ExitOnError)
+	wrapperPath := installFlags.String("wrapper", "", "cmdhooks binary the wrappers run (default: this binary)")
+
+	installFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cmdhooks install [-wrapper <path>] <dir> <command...>\n")
+		fmt.Fprintf(os.Stderr, "\nWrite persistent wrapper scripts for commands into dir. Put dir first on\n")
+		fmt.Fprintf(os.Stderr, "PATH and set CMDHOOKS_SOCKET to route the commands through an interceptor.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		installFlags.PrintDefaults()
+	}
+
+	if err := installFlags.Parse(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+
+	args := installFlags.Args()
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "Error: a directory and at least one command are required\n\n")
+		installFlags.Usage()
+		os.Exit(1)
+	}
+
+	binary := *wrapperPath
+	if binary == "" {
+		var err error
+		if binary, err = os.Executable(); err != nil {
+			log.Fatalf("cannot determine the cmdhooks binary path, use -wrapper: %v", err)
+		}
+	}
+	binary, err := filepath.Abs(binary)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dir, commands := args[0], args[1:]
+	if err := wrapper.Install(dir, []string{binary, "run"}, commands); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "Installed %d wrapper(s) in %s\n", len(commands), dir)
+}
+
+func uninstallCommand() {
+	uninstallFlags := flag.NewFlagSet("uninstall", flag.ExitOnError)
+
+	uninstallFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cmdhooks uninstall <dir>\n")
+		fmt.Fprintf(os.Stderr, "\nRemove the wrapper scripts written by install from dir. Other files are kept.\n")
+	}
+
+	if err := uninstallFlags.Parse(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+
+	args := uninstallFlags.Args()
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: exactly one directory is required\n\n")
+		uninstallFlags.Usage()
+		os.Exit(1)
+	}
+
+	removed, err := wrapper.Uninstall(args[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "Removed %d wrapper(s) from %s\n", len(removed), args[0])
+}
+
+func reloadCommand() {
+	reloadFlags := flag.NewFlagSet("reload", flag.ExitOnError)
+	seqpacket := reloadFlags.Bool("seqpacket", false, "Connect to a seqpacket socket")
+
+	reloadFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cmdhooks reload [-seqpacket] <socket>\n")
+		fmt.Fprintf(os.Stderr, "\nAsk the interceptor listening on socket to reload its hook. The token\n")
+		fmt.Fprintf(os.Stderr, "configured with WithHookReloadEndpoint is read from %s.\n\n", wrapper.EnvReloadToken)
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		reloadFlags.PrintDefaults()
+	}
+
+	if err := reloadFlags.Parse(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+
+	args := reloadFlags.Args()
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: exactly one socket path is required\n\n")
+		reloadFlags.Usage()
+		os.Exit(1)
+	}
+	token := os.Getenv(wrapper.EnvReloadToken)
+	if token == "" {
+		log.Fatalf("%s is not set", wrapper.EnvReloadToken)
+	}
+
+	network := wrapper.NetworkStream
+	if *seqpacket {
+		network = wrapper.NetworkSeqpacket
+	}
+	name, err := wrapper.RequestReload(network, args[0], token)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "Reloaded hook %s\n", name)
+}
+
+func explainCommand() {
+	explainFlags := flag.NewFlagSet("explain", flag.ExitOnError)
+	seqpacket := explainFlags.Bool("seqpacket", false, "Connect to a seqpacket socket")
+
+	explainFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cmdhooks explain [-seqpacket] <socket> <command> [args...]\n")
+		fmt.Fprintf(os.Stderr, "\nAsk the interceptor listening on socket how it would decide command at\n")
+		fmt.Fprintf(os.Stderr, "pre-run, and print the rules and hooks consulted. Nothing is run.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		explainFlags.PrintDefaults()
+	}
+
+	if err := explainFlags.Parse(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+
+	args := explainFlags.Args()
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "Error: a socket path and a command are required\n\n")
+		explainFlags.Usage()
+		os.Exit(1)
+	}
+
+	network := wrapper.NetworkStream
+	if *seqpacket {
+		network = wrapper.NetworkSeqpacket
+	}
+	resp, steps, err := wrapper.RequestExplanation(network, args[0], args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if reason := resp.BlockReason(); reason != "" {
+		fmt.Printf("%s: %s\n", resp.Decision(), reason)
+	} else {
+		fmt.Printf("%s\n", resp.Decision())
+	}
+	for _, step := range steps {
+		stage := step.Stage
+		if step.Hook != "" {
+			stage += " " + step.Hook
+		}
+		if step.Reason != "" {
+			fmt.Printf("  %s: %s (%s)\n", stage, step.Decision, step.Reason)
+		} else {
+			fmt.Printf("  %s: %s\n", stage, step.Decision)
+		}
+	}
+}
+
+func lockfileCommand() {
+	lockfileFlags := flag.NewFlagSet("lockfile", flag.ExitOnError)
+	output := lockfileFlags.String("o", "", "Write the lockfile to `file` instead of stdout")
+
+	lockfileFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cmdhooks lockfile generate [-o <file>] <command...>\n")
+		fmt.Fprintf(os.Stderr, "\nResolve each command in PATH and record the SHA-256 digest of its binary.\n")
+		fmt.Fprintf(os.Stderr, "Run it in a trusted environment and enforce the result with\n")
+		fmt.Fprintf(os.Stderr, "policy.NewLockfileAllowlist.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		lockfileFlags.PrintDefaults()
+	}
+
+	if len(os.Args) < 3 || os.Args[2] != "generate" {
+		lockfileFlags.Usage()
+		os.Exit(1)
+	}
+	if err := lockfileFlags.Parse(os.Args[3:]); err != nil {
+		log.Fatal(err)
+	}
+
+	commands := lockfileFlags.Args()
+	if len(commands) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: at least one command is required\n\n")
+		lockfileFlags.Usage()
+		os.Exit(1)
+	}
+
+	lockfile, err := policy.GenerateLockfile(commands)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *output == "" {
+		if err := lockfile.Write(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := lockfile.Write(f); err != nil {
+		f.Close()
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
 		log.Fatal(err)
 	}
+	fmt.Fprintf(os.Stderr, "Wrote digests of %d command(s) to %s\n", len(lockfile.Commands), *output)
 }
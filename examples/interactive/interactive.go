@@ -101,7 +101,7 @@ func (p *InteractiveHook) promptUserPreRun(req *hook.Request) *hook.Response {
 	}
 	log.Printf("Command: %s", cmd)
 	log.Printf("Args: %v", args)
-	log.Printf("Continue? [Y/n]: ")
+	log.Printf("Continue? [Y/n/a(lways for this run)]: ")
 
 	return p.getUserInput()
 }
@@ -142,6 +142,11 @@ func (p *InteractiveHook) getUserInput() *hook.Response {
 		return &hook.Response{
 			Exit: true,
 		}
+	case "a", "always":
+		// Approve this command for the rest of the run (pre-run only)
+		return &hook.Response{
+			Metadata: map[string]interface{}{hook.MetadataRememberForRun: true},
+		}
 	default:
 		// Default to yes for any other input
 		return &hook.Response{}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -34,6 +35,10 @@ func main() {
 			wrapperOpts = append(wrapperOpts, wrapper.WithVerbose(true))
 		}
 		if err := wrapper.Run(os.Args[2:], wrapperOpts...); err != nil {
+			var exitErr *wrapper.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.ExitCode())
+			}
 			log.Fatal(err)
 		}
 		return
@@ -48,6 +53,8 @@ func main() {
 	// Set up CmdHooks options
 	var opts []cmdhooks.Option
 	opts = append(opts, cmdhooks.WithHook(interactiveHook))
+	// Let "always" answers skip the prompt for the rest of the run
+	opts = append(opts, cmdhooks.WithPreRunConfirmationCache())
 
 	// Override to use this binary as the wrapper (for self-contained example)
 	// Normally, applications would rely on the installed 'cmdhooks' binary
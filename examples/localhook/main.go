@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -66,6 +67,10 @@ func main() {
 
 		w := wrapper.NewWrapperCommand(localHook, wopts...)
 		if err := w.Run(os.Args[2:]); err != nil {
+			var exitErr *wrapper.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.ExitCode())
+			}
 			log.Fatal(err)
 		}
 		return
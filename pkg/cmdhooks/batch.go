@@ -0,0 +1,109 @@
+package cmdhooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/wrapper"
+)
+
+const (
+	// batchShimName is the BASH_ENV startup file written to the wrapper
+	// directory when pre-run batch evaluation is enabled
+	batchShimName = ".cmdhooks_batch_env.sh"
+
+	// envOriginalBashEnv carries a BASH_ENV that was already set, so the
+	// shim can source it
+	envOriginalBashEnv = "CMDHOOKS_BASH_ENV"
+)
+
+// batchShimTemplate is sourced by every non-interactive bash. Its DEBUG
+// trap runs before each simple command; the first time a line holding a
+// list or pipeline operator runs, the line is passed to the wrapper for
+// batch evaluation. Lines are read from the running script, or from the
+// string given to `bash -c`. The wrapper scripts themselves are skipped.
+//
+// The shim shadows the trap builtin so that DEBUG traps, whether set by the
+// original BASH_ENV or by the script itself, are chained after the batch
+// hook instead of replacing it.
+const batchShimTemplate = `# CmdHooks pre-run batch evaluation (enabled via WithPreRunBatchEvaluation)
+if [ -n "${CMDHOOKS_BASH_ENV:-}" ]; then . "$CMDHOOKS_BASH_ENV"; fi
+case "$0" in "${CMDHOOKS_WRAPPER_DIR:-}"/*) return 0 ;; esac
+
+__cmdhooks_batch_shim=${BASH_SOURCE[0]:-}
+__cmdhooks_batch_src=
+__cmdhooks_batch_user_trap=
+__cmdhooks_batch_lines=()
+__cmdhooks_batch_seen=
+
+__cmdhooks_batch() {
+	local src=$1 lineno=$2 key text line
+	[ -n "$src" ] || src=-c
+	key="|$src:$lineno|"
+	case "$__cmdhooks_batch_seen" in *"$key"*) return 0 ;; esac
+	__cmdhooks_batch_seen="$__cmdhooks_batch_seen$key"
+
+	if [ "$src" != "$__cmdhooks_batch_src" ]; then
+		__cmdhooks_batch_src=$src
+		__cmdhooks_batch_lines=()
+		if [ "$src" = -c ]; then
+			while IFS= read -r line || [ -n "$line" ]; do
+				__cmdhooks_batch_lines+=("$line")
+			done <<<"${BASH_EXECUTION_STRING:-}"
+		elif [ -r "$src" ]; then
+			while IFS= read -r line || [ -n "$line" ]; do
+				__cmdhooks_batch_lines+=("$line")
+			done <"$src"
+		fi
+	fi
+
+	text=${__cmdhooks_batch_lines[lineno-1]:-}
+	case "$text" in *'&&'* | *'||'* | *'|'* | *';'* | *'&'*) ;; *) return 0 ;; esac
+	%s -batch "$text" || exit $?
+}
+
+trap() {
+	local action sig others=()
+	case "${1:-}" in -l | -p | -lp | -pl) builtin trap "$@"; return ;; --) shift ;; esac
+	if [ $# -eq 1 ]; then set -- - "$1"; fi
+	if [ $# -lt 2 ]; then builtin trap "$@"; return; fi
+	action=$1
+	shift
+	for sig in "$@"; do
+		case "$sig" in
+		[Dd][Ee][Bb][Uu][Gg])
+			if [ "$action" = - ]; then __cmdhooks_batch_user_trap=; else __cmdhooks_batch_user_trap=$action; fi
+			;;
+		*) others+=("$sig") ;;
+		esac
+	done
+	if [ ${#others[@]} -gt 0 ]; then builtin trap -- "$action" "${others[@]}"; fi
+}
+
+eval "$(builtin trap -p DEBUG)"
+set -o functrace
+builtin trap '[ "${BASH_SOURCE[0]:-}" = "$__cmdhooks_batch_shim" ] || { __cmdhooks_batch "${BASH_SOURCE[0]:-}" "$LINENO"; eval "$__cmdhooks_batch_user_trap"; }' DEBUG
+`
+
+// writeBatchShim writes the BASH_ENV startup file that sends command lists
+// to wrapperCmd for batch evaluation
+func writeBatchShim(dir string, wrapperCmd []string) error {
+	quoted := make([]string, 0, len(wrapperCmd))
+	for _, part := range wrapperCmd {
+		quoted = append(quoted, wrapper.ShellQuote(part))
+	}
+	script := fmt.Sprintf(batchShimTemplate, strings.Join(quoted, " "))
+	return os.WriteFile(filepath.Join(dir, batchShimName), []byte(script), 0600)
+}
+
+// batchEnv returns the environment entries that make bash source the batch
+// shim in wrapperDir
+func batchEnv(wrapperDir string) []string {
+	env := []string{"BASH_ENV=" + filepath.Join(wrapperDir, batchShimName)}
+	if orig := os.Getenv("BASH_ENV"); orig != "" {
+		env = append(env, envOriginalBashEnv+"="+orig)
+	}
+	return env
+}
@@ -0,0 +1,54 @@
+package cmdhooks
+
+import (
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// shellBuiltins lists the bash builtins and reserved words a script runs
+// without a PATH lookup. Some (echo, test, kill, ...) also exist as
+// binaries, but a plain invocation in a script still uses the builtin.
+var shellBuiltins = map[string]bool{
+	".": true, ":": true, "[": true, "[[": true, "alias": true, "bg": true,
+	"bind": true, "break": true, "builtin": true, "caller": true, "cd": true,
+	"command": true, "compgen": true, "complete": true, "compopt": true,
+	"continue": true, "declare": true, "dirs": true, "disown": true,
+	"echo": true, "enable": true, "eval": true, "exec": true, "exit": true,
+	"export": true, "false": true, "fc": true, "fg": true, "getopts": true,
+	"hash": true, "help": true, "history": true, "jobs": true, "kill": true,
+	"let": true, "local": true, "logout": true, "mapfile": true, "popd": true,
+	"printf": true, "pushd": true, "pwd": true, "read": true,
+	"readarray": true, "readonly": true, "return": true, "set": true,
+	"shift": true, "shopt": true, "source": true, "suspend": true,
+	"test": true, "time": true, "times": true, "trap": true, "true": true,
+	"type": true, "typeset": true, "ulimit": true, "umask": true,
+	"unalias": true, "unset": true, "wait": true,
+}
+
+// shadowedBuiltins returns the commands that are commonly shell builtins,
+// in the order given
+func shadowedBuiltins(commands []string, caseInsensitive bool) []string {
+	var shadowed []string
+	for _, command := range commands {
+		name := command
+		if caseInsensitive {
+			name = strings.ToLower(name)
+		}
+		if shellBuiltins[name] {
+			shadowed = append(shadowed, command)
+		}
+	}
+	return shadowed
+}
+
+// warnShadowedBuiltins warns that the hook's commands which are commonly
+// shell builtins may run without passing through their wrappers
+func warnShadowedBuiltins(printf func(format string, v ...any), h hook.Hook, caseInsensitive bool) {
+	shadowed := shadowedBuiltins(h.Commands(), caseInsensitive)
+	if len(shadowed) == 0 {
+		return
+	}
+	printf("Warning: hook %s handles commands that are commonly shell builtins and may bypass interception: %s",
+		h.Name(), strings.Join(shadowed, ", "))
+}
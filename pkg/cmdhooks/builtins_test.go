@@ -0,0 +1,40 @@
+package cmdhooks
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadowedBuiltins(t *testing.T) {
+	tests := []struct {
+		name            string
+		commands        []string
+		caseInsensitive bool
+		want            []string
+	}{
+		{name: "builtins", commands: []string{"curl", "echo", "git", "test", "["}, want: []string{"echo", "test", "["}},
+		{name: "no builtins", commands: []string{"curl", "git"}, want: nil},
+		{name: "case sensitive", commands: []string{"ECHO"}, want: nil},
+		{name: "case insensitive", commands: []string{"ECHO"}, caseInsensitive: true, want: []string{"ECHO"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, shadowedBuiltins(tt.commands, tt.caseInsensitive))
+		})
+	}
+}
+
+func TestWarnShadowedBuiltins(t *testing.T) {
+	var buf bytes.Buffer
+	printf := log.New(&buf, "", 0).Printf
+	warnShadowedBuiltins(printf, newMockHook("shell-policy", []string{"echo", "curl", "test"}), false)
+	assert.Equal(t, "Warning: hook shell-policy handles commands that are commonly shell builtins and may bypass interception: echo, test\n", buf.String())
+
+	buf.Reset()
+	warnShadowedBuiltins(printf, newMockHook("net-policy", []string{"curl", "wget"}), false)
+	assert.Empty(t, buf.String())
+}
@@ -3,17 +3,17 @@
 package cmdhooks
 
 import (
+    "context"
     "fmt"
-    "log"
     "os"
     "os/exec"
     "path/filepath"
     "strings"
-    "time"
 
 	"github.com/codysoyland/cmdhooks/pkg/executor"
 	"github.com/codysoyland/cmdhooks/pkg/hook"
 	"github.com/codysoyland/cmdhooks/pkg/interceptor"
+	"github.com/codysoyland/cmdhooks/pkg/wrapper"
 )
 
 // validateCommand checks if a command slice is valid (non-empty)
@@ -55,6 +55,27 @@ func New(opts ...Option) (*CmdHooks, error) {
 	if config.Hook == nil {
 		return nil, fmt.Errorf("must provide hook")
 	}
+	// Warnings from here on go to the configured logger
+	c := &CmdHooks{config: config}
+	if err := validateHook(config.Hook, c.printf); err != nil {
+		return nil, err
+	}
+	config.Hook = caseFoldedHook(config.Hook, config.CaseInsensitiveMatching)
+	if config.ShadowWarning {
+		warnShadowedBuiltins(c.printf, config.Hook, config.CaseInsensitiveMatching)
+	}
+	if config.ChaosRate > 0 {
+		c.printf("Warning: chaos testing is enabled; %g%% of %s commands will be blocked or failed at random",
+			config.ChaosRate*100, strings.Join(config.ChaosCommands, ", "))
+	}
+
+	// An adopted listener already has a socket path wrappers can dial
+	if config.Listener != nil && config.SocketPath == "" {
+		config.SocketPath = config.Listener.Addr().String()
+		if config.SocketPath == "" {
+			return nil, fmt.Errorf("listener has no address; provide WithSocketPath")
+		}
+	}
 
 	// Always create interceptor for consistent behavior
     var createdSocketDir string
@@ -73,29 +94,123 @@ func New(opts ...Option) (*CmdHooks, error) {
         // MkdirTemp should create it with 0700 on Unix, but explicitly enforce
         // to normalize across OS/umask variations.
         if chmodErr := os.Chmod(dir, 0o700); chmodErr != nil {
-            // Non-fatal: continue but surface a warning to stderr.
-            fmt.Fprintf(os.Stderr, "Warning: failed to set permissions 0700 on socket dir %s: %v\n", dir, chmodErr)
+            // Non-fatal: continue but surface a warning.
+            c.printf("Warning: failed to set permissions 0700 on socket dir %s: %v", dir, chmodErr)
         }
         createdSocketDir = dir
         config.SocketPath = filepath.Join(dir, "hook.sock")
     }
+	if config.SocketGroup != nil {
+		c.printf("Warning: socket %s is accessible to every member of group %d", config.SocketPath, *config.SocketGroup)
+		// Group members must also be able to reach the socket inside the
+		// directory created for it
+		if createdSocketDir != "" {
+			if err := shareSocketDir(createdSocketDir, *config.SocketGroup); err != nil {
+				_ = os.RemoveAll(createdSocketDir)
+				return nil, fmt.Errorf("failed to grant group access to socket dir: %w", err)
+			}
+		}
+	}
     i := interceptor.New(config.SocketPath, config.Verbose, config.Hook)
     // Apply timeout as provided; zero/negative means no timeout.
     i.SetEvaluateTimeout(config.InterceptorTimeout)
+	i.SetMaxExitHistory(config.MaxPendingExits)
+	i.SetDedupWindow(config.DedupWindow)
+	i.SetConnectionTimeout(config.ConnectionTimeout)
+	i.SetTimeQuota(config.TimeQuota)
+	i.SetPerCommandTimeQuota(config.PerCommandTimeQuotas)
+	if config.QuotaPerUser {
+		i.SetQuotaKey(hook.PeerUIDKey)
+	}
+	i.SetCommandNormalizer(config.AuditNormalizer)
+	i.SetRequestCompression(config.RequestCompression)
+	i.SetBindRetry(config.BindRetries, config.BindBackoff)
+	if config.SocketGroup != nil {
+		i.SetSocketGroup(*config.SocketGroup)
+	}
+	i.SetScriptExtensionPolicy(config.ScriptExtensions)
+	i.SetArgAllowlist(config.ArgAllowlist)
+	if config.ChaosRate > 0 {
+		i.SetChaos(&interceptor.ChaosConfig{Rate: config.ChaosRate, Commands: config.ChaosCommands})
+	}
+	if config.Listener != nil {
+		i.SetListener(config.Listener)
+	}
+	for _, e := range config.Enrichers {
+		i.AddEnricher(e)
+	}
+	for _, t := range config.ResponseTransformers {
+		i.AddResponseTransformer(t)
+	}
+	if config.RewriteLog != nil {
+		i.SetRewriteLog(interceptor.NewRewriteLog(config.RewriteLog))
+	}
+	if config.DecisionLog != nil {
+		i.SetDecisionLog(interceptor.NewDecisionLog(config.DecisionLog))
+	}
+	if config.Transcript != nil {
+		i.SetTranscript(interceptor.NewTranscript(config.Transcript))
+	}
+	i.SetMetadataRedactor(config.MetadataRedactor)
+	i.SetRememberDecisions(config.RememberDecisions)
+	i.SetEvaluationLimiter(config.EvaluationLimiter)
+	i.SetTimeoutDecision(config.TimeoutDecision)
+	i.SetSocketType(config.SocketType)
+	i.SetTLS(config.TLSConfig)
+	i.SetDecisionCallback(config.OnDecision)
+	i.SetTimingCallback(config.TimingCallback)
+	i.SetLogger(config.Logger)
+	i.SetPauseTimeout(config.PauseTimeout, config.PauseDecision)
+	i.SetExplain(config.ExplainDecisions)
+	var sysLog *interceptor.Syslog
+	if config.SyslogTag != "" {
+		var err error
+		if sysLog, err = interceptor.NewSyslog(config.SyslogTag); err != nil {
+			c.printf("Warning: decisions will not be sent to syslog: %v", err)
+		}
+		i.SetSyslog(sysLog)
+	}
+	if config.HookLoader != nil {
+		i.SetReloadEndpoint(config.ReloadToken, validatedLoader(config.HookLoader, c.printf))
+	}
 
-    return &CmdHooks{
-        config:      config,
-        interceptor: i,
-        hook:        config.Hook,
-        socketDir:   createdSocketDir,
-    }, nil
+	if config.PersistentInterceptor {
+		if err := i.Start(); err != nil {
+			if createdSocketDir != "" {
+				_ = os.RemoveAll(createdSocketDir)
+			}
+			if sysLog != nil {
+				_ = sysLog.Close()
+			}
+			return nil, fmt.Errorf("failed to start interceptor: %w", err)
+		}
+	}
+
+    c.interceptor = i
+    c.socketDir = createdSocketDir
+    c.syslog = sysLog
+    return c, nil
 }
 
-// ExecuteScript executes a script with CmdHooks interception
+// Execute runs cmd with CmdHooks interception. The returned error tells the
+// outcomes apart:
+//   - *BlockedError: a hook blocked a command and the script was terminated
+//   - *ExitError (wrapped, use errors.As): the script exited non-zero
+//   - any other error: setup or execution failed before the script finished
 func (c *CmdHooks) Execute(cmd []string) error {
+	return c.ExecuteContext(context.Background(), cmd)
+}
+
+// ExecuteContext is Execute with cancellation. When ctx is done before the
+// script finishes, its process tree is killed, cleanup runs as usual and the
+// returned error wraps ctx.Err().
+func (c *CmdHooks) ExecuteContext(ctx context.Context, cmd []string) error {
 	if err := validateCommand(cmd); err != nil {
 		return err
 	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("execution cancelled: %w", err)
+	}
 
 	// Setup execution environment
 	sb, cleanup, err := c.setupExecutor(cmd)
@@ -104,40 +219,80 @@ func (c *CmdHooks) Execute(cmd []string) error {
 	}
 	defer cleanup()
 
-	if c.config.Verbose {
-		log.Printf("[INFO] Starting script execution: %s", cmd[0])
-	}
+	c.logf("[INFO] Starting script execution: %s", cmd[0])
 
-	// Monitor execution with exit signal handling
-	return c.execute(sb)
+	c.interceptor.ResetProgress()
+	c.interceptor.ResetTimeUsed()
+	c.startProgressReporter()
+	defer c.stopProgressReporter()
+
+	// Monitor execution with exit signal and cancellation handling
+	return c.execute(ctx, sb)
 }
 
-// SetHook changes the hook used for request evaluation
+// SetHook changes the hook used for request evaluation. It is safe to call
+// while commands are being evaluated.
 func (c *CmdHooks) SetHook(h hook.Hook) {
-	c.hook = h
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h = caseFoldedHook(h, c.config.CaseInsensitiveMatching)
 	c.config.Hook = h
 	c.interceptor.SetHook(h)
 }
 
+// caseFoldedHook returns h, or a copy of it matching commands ignoring case
+// when h is a hook.Multi and caseInsensitive is set. The caller's Multi is
+// left untouched, since it may be shared or in use elsewhere.
+func caseFoldedHook(h hook.Hook, caseInsensitive bool) hook.Hook {
+	m, ok := h.(*hook.Multi)
+	if !ok || !caseInsensitive || m.CaseInsensitive {
+		return h
+	}
+	folded := hook.NewMulti(m.Hooks()...)
+	folded.CaseInsensitive = true
+	return folded
+}
+
 // GetHook returns the current hook
 func (c *CmdHooks) GetHook() hook.Hook {
-	return c.hook
+	return c.interceptor.Hook()
+}
+
+// Stats returns a snapshot of the interceptor counters
+func (c *CmdHooks) Stats() interceptor.Stats {
+	return c.interceptor.Stats()
+}
+
+// Pause holds new requests instead of evaluating them, e.g. while a hook's
+// backend is under maintenance, until Resume is called or each request's
+// wait times out (see WithInterceptorPauseResume)
+func (c *CmdHooks) Pause() {
+	c.interceptor.Pause()
+}
+
+// Resume evaluates the requests held by Pause, and new requests again
+func (c *CmdHooks) Resume() {
+	c.interceptor.Resume()
+}
+
+// History returns every exit request recorded during execution, oldest first
+func (c *CmdHooks) History() []interceptor.ExitRecord {
+	return c.interceptor.History()
 }
 
 // Close cleans up resources
 func (c *CmdHooks) Close() error {
+    c.stopProgressReporter()
     c.interceptor.Stop()
 
 	if c.executor != nil {
 		if err := c.executor.Cleanup(); err != nil {
-			if c.config.Verbose {
-				log.Printf("[ERROR] Failed to cleanup executor: %v", err)
-			}
+			c.logf("[ERROR] Failed to cleanup executor: %v", err)
 		}
 	}
 
-    // Clean up socket file
-    if c.config.SocketPath != "" {
+    // Clean up socket file, unless it belongs to an adopted listener
+    if c.config.SocketPath != "" && c.config.Listener == nil {
         os.Remove(c.config.SocketPath)
     }
 
@@ -147,6 +302,11 @@ func (c *CmdHooks) Close() error {
         c.socketDir = ""
     }
 
+	if c.syslog != nil {
+		_ = c.syslog.Close()
+		c.syslog = nil
+	}
+
     return nil
 }
 
@@ -160,7 +320,7 @@ func (c *CmdHooks) createWrappers() (string, func(), error) {
     // Normalize wrapper directory permissions to 0700 for safety.
     if chmodErr := os.Chmod(tmpDir, 0o700); chmodErr != nil {
         // Non-fatal: log warning, as some filesystems/OS may behave differently.
-        fmt.Fprintf(os.Stderr, "Warning: failed to set permissions 0700 on wrapper dir %s: %v\n", tmpDir, chmodErr)
+        c.printf("Warning: failed to set permissions 0700 on wrapper dir %s: %v", tmpDir, chmodErr)
     }
 
 	cleanup := func() {
@@ -168,7 +328,7 @@ func (c *CmdHooks) createWrappers() (string, func(), error) {
 		// macOS sometimes creates system files we can't delete
 		if cleanupErr := os.RemoveAll(tmpDir); cleanupErr != nil {
 			// Log the error but don't fail - this is usually just macOS system files
-			fmt.Fprintf(os.Stderr, "Warning: failed to cleanup temp directory %s: %v\n", tmpDir, cleanupErr)
+			c.printf("Warning: failed to cleanup temp directory %s: %v", tmpDir, cleanupErr)
 		}
 	}
 
@@ -186,45 +346,55 @@ func (c *CmdHooks) createWrappers() (string, func(), error) {
 		wrapperCmd = []string{cmdHooksPath, "run"}
 	}
 
-    // Get commands from hook
-    commands := c.hook.Commands()
+	if c.config.PreRunBatchEvaluation {
+		if err := writeBatchShim(tmpDir, wrapperCmd); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+	}
+
+    // Get commands from hook, with glob patterns expanded, plus the
+    // interpreters the script extension policy inspects
+    commands := c.wrappedCommands()
     if len(commands) == 0 {
         // If no commands specified, don't create any wrappers
         return tmpDir, cleanup, nil
     }
 
+	// Command names become wrapper filenames, so reject anything that could
+	// write outside the wrapper directory. New validates too, but the hook
+	// may have been replaced since via SetHook.
+	for _, cmd := range commands {
+		if err := wrapper.ValidateCommandName(cmd); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+	}
+
     // Guard against wrapping shells that can cause recursion in wrapper shebangs.
     // For now, explicitly reject capturing "bash" to avoid common pitfalls.
     for _, cmd := range commands {
-        if cmd == "bash" {
+        if cmd == "bash" || (c.config.CaseInsensitiveMatching && strings.EqualFold(cmd, "bash")) {
             cleanup()
             return "", nil, fmt.Errorf("invalid monitored command 'bash': wrapping bash can cause recursive invocation; remove 'bash' from Hook.Commands or invoke only external tools")
         }
     }
 
-	// Create wrapper script for each command
+	// Create wrapper script for each command, once even if several entries
+	// produce it. With case-insensitive matching, names differing only in
+	// case share one wrapper, since they would collide on a case-insensitive
+	// filesystem anyway.
+	seen := make(map[string]bool, len(commands))
 	for _, command := range commands {
-		wrapperPath := filepath.Join(tmpDir, command)
-		// Build exec command with safe shell quoting: wrapperCmd + command + "$@"
-		quotedWrapper := make([]string, 0, len(wrapperCmd))
-		for _, part := range wrapperCmd {
-			quotedWrapper = append(quotedWrapper, shellQuote(part))
+		key := command
+		if c.config.CaseInsensitiveMatching {
+			key = strings.ToLower(command)
 		}
-		quotedCommand := shellQuote(command)
-		wrapperExec := strings.Join(append(quotedWrapper, quotedCommand), " ") + " \"$@\""
-
-		wrapperScript := fmt.Sprintf(`#!/usr/bin/env bash
-# CmdHooks wrapper for %s (defaults to 'cmdhooks run', configurable via WithWrapperPath)
-exec %s
-`, command, wrapperExec)
-
-		if err := os.WriteFile(wrapperPath, []byte(wrapperScript), 0600); err != nil {
-			cleanup()
-			return "", nil, err
+		if seen[key] {
+			continue
 		}
-
-		// Make wrapper executable
-		if err := os.Chmod(wrapperPath, 0700); err != nil {
+		seen[key] = true
+		if err := wrapper.WriteScript(tmpDir, wrapperCmd, command); err != nil {
 			cleanup()
 			return "", nil, err
 		}
@@ -233,85 +403,222 @@ exec %s
 	return tmpDir, cleanup, nil
 }
 
-// shellQuote returns a shell-safe single-quoted string. It wraps the input in single
-// quotes and escapes existing single quotes using the POSIX-safe pattern: '
-// becomes '\” inside the quoted string.
-func shellQuote(s string) string {
-	if s == "" {
-		return "''"
-	}
-	// Replace every single quote ' with '\''
-	// This closes the existing quote, inserts an escaped single quote, and reopens the quote.
-	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
-}
+// resultFileName is the result file written to the wrapper directory when
+// WithCommandResultEnvExport is enabled
+const resultFileName = ".cmdhooks_result.env"
 
 // setupExecutor prepares the execution environment
 func (c *CmdHooks) setupExecutor(cmd []string) (*executor.Executor, func(), error) {
-	// Start interceptor
-	if err := c.interceptor.Start(); err != nil {
+	// Start interceptor, or re-arm the long-lived one left running by New
+	persistent := c.config.PersistentInterceptor
+	// Approvals remembered by hooks last for a single run
+	c.interceptor.ClearRememberedDecisions()
+	if persistent {
+		c.interceptor.ResetExitSignal()
+	} else if err := c.interceptor.Start(); err != nil {
 		return nil, nil, fmt.Errorf("failed to start interceptor: %w", err)
 	}
 
 	// Create executor
 	sb := executor.New(cmd, c.config.SocketPath)
 	sb.SetVerbose(c.config.Verbose)
+	sb.SetForeground(c.config.Foreground)
+	sb.SetKillGrace(c.config.InterruptGrace)
 	c.executor = sb
+	c.lines = nil
+	if c.config.OutputLineCallback != nil {
+		c.lines = newLineOutput(c.config.OutputLineCallback, os.Stdout, os.Stderr)
+		sb.SetOutput(c.lines.stdout, c.lines.stderr)
+	}
 
 	// Create wrapper binaries
 	wrapperDir, cleanup, err := c.createWrappers()
 	if err != nil {
-		c.interceptor.Stop()
+		if !persistent {
+			c.interceptor.Stop()
+		}
 		return nil, nil, fmt.Errorf("failed to create wrappers: %w", err)
 	}
 
 	sb.SetWrapperPath(wrapperDir)
+	env := c.wrapperEnv()
+	if c.config.PreRunBatchEvaluation {
+		env = append(env, batchEnv(wrapperDir)...)
+	}
+	if c.config.ResultEnvExport {
+		env = append(env, wrapper.EnvResultFile+"="+filepath.Join(wrapperDir, resultFileName))
+	}
+	sb.SetEnv(env)
 
 	// Return cleanup function that handles both interceptor and wrappers
 	fullCleanup := func() {
 		cleanup()
-		c.interceptor.Stop()
+		if !persistent {
+			c.interceptor.Stop()
+		}
+	}
+
+	if c.config.SelfTest {
+		if err := c.runSelfTest(sb, wrapperDir); err != nil {
+			fullCleanup()
+			return nil, nil, err
+		}
 	}
 
 	return sb, fullCleanup, nil
 }
 
-// execute handles concurrent execution with exit signal monitoring
-func (c *CmdHooks) execute(sb *executor.Executor) error {
+// wrapperEnv returns the environment entries that carry wrapper-side
+// configuration to the wrapper processes spawned by the script
+func (c *CmdHooks) wrapperEnv() []string {
+	var env []string
+	if c.config.WorkingDir != "" {
+		env = append(env, wrapper.EnvWorkingDir+"="+c.config.WorkingDir)
+	}
+	if c.config.ChildUmask != nil {
+		env = append(env, fmt.Sprintf("%s=%o", wrapper.EnvChildUmask, *c.config.ChildUmask))
+	}
+	if c.config.CaseInsensitiveMatching {
+		env = append(env, wrapper.EnvCaseInsensitive+"=true")
+	}
+	if len(c.config.CommandTimeouts) > 0 {
+		env = append(env, wrapper.EnvCommandTimeouts+"="+wrapper.FormatCommandTimeouts(c.config.CommandTimeouts))
+	}
+	if c.config.DeadlineHeadroom > 0 {
+		env = append(env, wrapper.EnvDeadlineHeadroom+"="+c.config.DeadlineHeadroom.String())
+	}
+	if c.config.Listener == nil && c.config.SocketType.Network() != string(interceptor.SocketStream) {
+		env = append(env, wrapper.EnvSocketNetwork+"="+c.config.SocketType.Network())
+	}
+	if c.config.TLSConfig != nil {
+		files := c.config.WrapperTLS
+		if files.ServerName == "" {
+			files.ServerName = wrapper.DefaultTLSServerName
+		}
+		env = append(env, wrapper.EnvTLSServerName+"="+files.ServerName)
+		if files.CAFile != "" {
+			env = append(env, wrapper.EnvTLSCAFile+"="+files.CAFile)
+		}
+		if files.CertFile != "" {
+			env = append(env, wrapper.EnvTLSCertFile+"="+files.CertFile, wrapper.EnvTLSKeyFile+"="+files.KeyFile)
+		}
+	}
+	if c.config.ScratchDirs {
+		env = append(env, wrapper.EnvScratchIsolation+"=true")
+	}
+	if c.config.SharedConnection {
+		env = append(env, wrapper.EnvSharedConnection+"=true")
+	}
+	if c.config.ChaosRate > 0 {
+		env = append(env, wrapper.EnvChaos+"=true")
+	}
+	if c.config.RequestCompression {
+		env = append(env, wrapper.EnvRequestCompression+"=true")
+	}
+	if c.config.TimingCallback != nil {
+		env = append(env, wrapper.EnvTimingBreakdown+"=true")
+	}
+	if c.config.OutputHashing {
+		env = append(env, wrapper.EnvOutputHashing+"=true")
+	}
+	if c.config.OutputTranscript {
+		env = append(env, wrapper.EnvOutputTranscript+"=true")
+	}
+	if c.config.StreamOutput {
+		env = append(env, wrapper.EnvStreamOutput+"=true")
+	}
+	if c.config.SignalExitStatus {
+		env = append(env, wrapper.EnvSignalExitStatus+"=true")
+	}
+	if c.config.PTY {
+		env = append(env, wrapper.EnvPTY+"=true")
+	}
+	if c.config.FailureMode != wrapper.FailUnset {
+		env = append(env, wrapper.EnvFailureMode+"="+c.config.FailureMode.String())
+	}
+	if len(c.config.EnvAllowlist) > 0 {
+		env = append(env, wrapper.EnvEnvAllowlist+"="+strings.Join(c.config.EnvAllowlist, ","))
+	}
+	if c.config.EvaluationAttribution {
+		env = append(env, wrapper.EnvAttribution+"=true")
+	}
+	if c.config.Transcript != nil {
+		env = append(env, wrapper.EnvTranscript+"=true")
+	}
+	if c.config.Argv0Check {
+		env = append(env, wrapper.EnvArgv0Check+"=true")
+	}
+	if f, ok := c.GetHook().(hook.PostRunFilter); ok && c.config.PostRunGating {
+		env = append(env, wrapper.EnvPostRunExitCodes+"="+wrapper.FormatPostRunExitCodes(f.WantsPostRun))
+	}
+	if interpreters := c.interpreters(); len(interpreters) > 0 {
+		env = append(env, wrapper.EnvInterpretedScripts+"="+wrapper.FormatInterpreters(interpreters))
+	}
+	if c.config.RequestFieldAllowlist != nil {
+		env = append(env, wrapper.EnvRequestFields+"="+strings.Join(c.config.RequestFieldAllowlist, ","))
+	}
+	return env
+}
+
+// blockedError describes the most recent blocked command
+func (c *CmdHooks) blockedError() *BlockedError {
+	history := c.interceptor.History()
+	if len(history) == 0 {
+		return &BlockedError{}
+	}
+	last := history[len(history)-1]
+	return &BlockedError{Command: last.Command, Reason: last.Reason}
+}
+
+// execute handles concurrent execution with exit signal and cancellation
+// monitoring
+func (c *CmdHooks) execute(ctx context.Context, sb *executor.Executor) error {
 	// Execute command or script concurrently while monitoring for exit signals
 	execDone := make(chan error, 1)
+	var lineStop <-chan error
+	lines := c.lines
+	if lines != nil {
+		lineStop = lines.stopped
+	}
 	go func() {
-		execDone <- sb.Execute()
+		err := sb.Execute()
+		if lines != nil {
+			lines.flush()
+		}
+		execDone <- err
 	}()
 
 	// Monitor for completion or exit signal
 	select {
 	case err := <-execDone:
-		// Normal completion
+		// Normal completion, unless the callback rejected the last lines
+		select {
+		case lineErr := <-lineStop:
+			return lineErr
+		default:
+		}
 		if err != nil {
 			return fmt.Errorf("execution failed: %w", err)
 		}
-		if c.config.Verbose {
-			log.Printf("[INFO] Execution completed")
-		}
+		c.logf("[INFO] Execution completed")
 		return nil
 
 	case <-c.interceptor.ExitSignal():
 		// Exit signal received - kill process tree
-		log.Printf("[INFO] Exit signal received - terminating process tree")
+		c.printf("[INFO] Exit signal received - terminating process tree")
 
-		if err := sb.KillProcessTree(); err != nil {
-			log.Printf("[ERROR] Failed to kill process tree: %v", err)
-		}
+		blocked := c.blockedError()
+		c.terminate(sb, execDone, blocked)
+		return blocked
 
-		// Wait for execution to finish (should be quick after kill)
-		select {
-		case <-execDone:
-			// Execution finished after kill
-		case <-time.After(5 * time.Second):
-			// Timeout waiting for execution to finish
-			log.Printf("[ERROR] Timeout waiting for process termination")
-		}
+	case err := <-lineStop:
+		c.printf("[INFO] Output line callback requested termination - terminating process tree")
+		c.terminate(sb, execDone, nil)
+		return err
 
-		return fmt.Errorf("execution terminated by user request")
+	case <-ctx.Done():
+		c.printf("[INFO] Execution cancelled - terminating process tree")
+		c.terminate(sb, execDone, nil)
+		return fmt.Errorf("execution cancelled: %w", ctx.Err())
 	}
 }
@@ -1,15 +1,30 @@
+//go:build !windows
+
 package cmdhooks
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/codysoyland/cmdhooks/pkg/executor"
 	"github.com/codysoyland/cmdhooks/pkg/hook"
+	"github.com/codysoyland/cmdhooks/pkg/interceptor"
+	"github.com/codysoyland/cmdhooks/pkg/policy"
+	"github.com/codysoyland/cmdhooks/pkg/wrapper"
 )
 
 // testHook is a configurable hook for E2E testing
@@ -286,3 +301,1137 @@ echo "wrapper path test"
 	err = ch.Execute([]string{"bash", scriptPath})
 	assert.NoError(t, err, "Script should execute successfully with custom wrapper path")
 }
+
+// ipcOnlyHook exposes only the IPC stage of a testHook, so blocks reach the
+// interceptor and trigger its exit signal
+type ipcOnlyHook struct {
+	h *testHook
+}
+
+func (o *ipcOnlyHook) Name() string       { return o.h.Name() }
+func (o *ipcOnlyHook) Commands() []string { return o.h.Commands() }
+func (o *ipcOnlyHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	return o.h.EvaluateIPC(ctx, req)
+}
+
+// TestE2E_InterceptorReuseAcrossExecute runs sequential executions against
+// one long-lived interceptor, including one that is terminated by a block
+func TestE2E_InterceptorReuseAcrossExecute(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	// Use external commands (not shell builtins) so they go through wrappers
+	testHook := newTestHook("test-reuse", []string{"sleep", "cat"})
+	testHook.blockCommand("cat")
+
+	allowedScript := createTestScript(t, `#!/usr/bin/env bash
+sleep 0
+`)
+	blockedScript := createTestScript(t, `#!/usr/bin/env bash
+cat /dev/null
+sleep 5
+`)
+
+	ch, err := New(
+		WithHook(&ipcOnlyHook{h: testHook}),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+		WithInterceptorReuseAcrossExecute(),
+	)
+	require.NoError(t, err)
+	socketPath := ch.config.SocketPath
+
+	// The interceptor is listening before the first Execute
+	_, err = os.Stat(socketPath)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, ch.Execute([]string{"bash", allowedScript}), "execution %d", i)
+		_, err = os.Stat(socketPath)
+		require.NoError(t, err, "socket should survive execution %d", i)
+	}
+
+	// A blocked execution fires the exit signal...
+	assert.Error(t, ch.Execute([]string{"bash", blockedScript}))
+
+	// ...which is re-armed so later executions are not torn down
+	assert.NoError(t, ch.Execute([]string{"bash", allowedScript}))
+	assert.Equal(t, 1, ch.Stats().ExitRequests)
+
+	require.NoError(t, ch.Close())
+	_, err = os.Stat(socketPath)
+	assert.True(t, os.IsNotExist(err), "Close should remove the socket")
+}
+
+// TestE2E_ExecuteErrorTypes checks that blocks, non-zero exits and setup
+// failures are distinguishable by error type
+func TestE2E_ExecuteErrorTypes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	wrapperPath := []string{"go", "run", "../../cmd/cmdhooks", "run"}
+
+	t.Run("block", func(t *testing.T) {
+		testHook := newTestHook("test-block", []string{"cat"})
+		testHook.blockCommand("cat")
+		scriptPath := createTestScript(t, `#!/usr/bin/env bash
+cat /dev/null
+sleep 5
+`)
+
+		ch, err := New(WithHook(&ipcOnlyHook{h: testHook}), WithWrapperPath(wrapperPath))
+		require.NoError(t, err)
+		defer ch.Close()
+
+		err = ch.Execute([]string{"bash", scriptPath})
+		var blocked *BlockedError
+		require.ErrorAs(t, err, &blocked)
+		assert.Equal(t, []string{"cat", "/dev/null"}, blocked.Command)
+
+		var exitErr *ExitError
+		assert.False(t, errors.As(err, &exitErr))
+	})
+
+	t.Run("non-zero exit", func(t *testing.T) {
+		scriptPath := createTestScript(t, `#!/usr/bin/env bash
+exit 3
+`)
+
+		ch, err := New(WithHook(newTestHook("test-exit", []string{"cat"})), WithWrapperPath(wrapperPath))
+		require.NoError(t, err)
+		defer ch.Close()
+
+		err = ch.Execute([]string{"bash", scriptPath})
+		var exitErr *ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 3, exitErr.Code)
+
+		var blocked *BlockedError
+		assert.False(t, errors.As(err, &blocked))
+	})
+
+	t.Run("setup failure", func(t *testing.T) {
+		// Wrapping bash is rejected while generating wrappers
+		ch, err := New(WithHook(newTestHook("test-setup", []string{"bash"})), WithWrapperPath(wrapperPath))
+		require.NoError(t, err)
+		defer ch.Close()
+
+		err = ch.Execute([]string{"true"})
+		require.Error(t, err)
+
+		var blocked *BlockedError
+		var exitErr *ExitError
+		assert.False(t, errors.As(err, &blocked))
+		assert.False(t, errors.As(err, &exitErr))
+	})
+}
+
+// TestE2E_ProgressReporter checks that the reporter fires with increasing
+// counts during a multi-command run
+func TestE2E_ProgressReporter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	scriptPath := createTestScript(t, `#!/usr/bin/env bash
+sleep 0.2
+sleep 0.2
+sleep 0.2
+`)
+
+	var mu sync.Mutex
+	var reports []interceptor.Progress
+	ch, err := New(
+		WithHook(&ipcOnlyHook{h: newTestHook("test-progress", []string{"sleep"})}),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+		WithProgressReporter(func(p interceptor.Progress) {
+			mu.Lock()
+			defer mu.Unlock()
+			reports = append(reports, p)
+		}),
+		WithProgressInterval(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	require.NoError(t, ch.Execute([]string{"bash", scriptPath}))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Greater(t, len(reports), 2, "reporter should fire repeatedly")
+
+	sawRunning := false
+	for i := 1; i < len(reports); i++ {
+		assert.GreaterOrEqual(t, reports[i].Evaluated, reports[i-1].Evaluated)
+		assert.GreaterOrEqual(t, reports[i].Completed, reports[i-1].Completed)
+		if len(reports[i].Running) > 0 {
+			sawRunning = true
+			assert.Equal(t, "sleep", reports[i].Running[0].Command[0])
+		}
+	}
+	assert.True(t, sawRunning, "a running command should be reported")
+
+	final := reports[len(reports)-1]
+	assert.Equal(t, 3, final.Started)
+	assert.Equal(t, 3, final.Completed)
+	assert.Equal(t, 6, final.Evaluated)
+	assert.Empty(t, final.Running)
+
+	// The reporter stops with the execution
+	count := len(reports)
+	mu.Unlock()
+	time.Sleep(60 * time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, count, len(reports))
+}
+
+// TestE2E_RequestFieldAllowlist checks that the interceptor still enforces
+// policy when wrappers send minimized requests
+func TestE2E_RequestFieldAllowlist(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	scriptPath := createTestScript(t, `#!/usr/bin/env bash
+sleep 0
+cat /etc/hostname
+`)
+
+	testHook := newTestHook("test-allowlist", []string{"sleep", "cat"})
+	testHook.blockCommand("cat")
+	ch, err := New(
+		WithHook(&ipcOnlyHook{h: testHook}),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+		WithRequestFieldAllowlist("command_name"),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	err = ch.Execute([]string{"bash", scriptPath})
+	var blocked *BlockedError
+	require.ErrorAs(t, err, &blocked)
+	assert.Equal(t, []string{"cat"}, blocked.Command)
+
+	history := ch.interceptor.History()
+	require.NotEmpty(t, history)
+	for _, rec := range history {
+		assert.Len(t, rec.Command, 1, "arguments should not reach the interceptor")
+		// The PID is withheld too, but the interceptor reads the wrapper's
+		// from the socket's peer credentials
+		assert.NotZero(t, rec.PID)
+	}
+}
+
+// TestE2E_Transcript checks that every executed command is transcribed with
+// its environment, exit code and output digests
+func TestE2E_Transcript(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	require.NoError(t, os.WriteFile(input, []byte("hello\n"), 0600))
+
+	scriptPath := createTestScript(t, `#!/usr/bin/env bash
+export TRANSCRIPT_MARKER=run-1
+export API_TOKEN=s3cr3t
+cat `+input+`
+ls `+filepath.Join(dir, "missing")+` || true
+`)
+
+	var buf bytes.Buffer
+	ch, err := New(
+		WithHook(&ipcOnlyHook{h: newTestHook("test-transcript", []string{"cat", "ls"})}),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+		WithTranscript(&buf),
+		WithMetadataRedactor(interceptor.RedactKeys("API_TOKEN")),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	require.NoError(t, ch.Execute([]string{"bash", scriptPath}))
+
+	var entries []interceptor.TranscriptEntry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry interceptor.TranscriptEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2)
+
+	cat, ls := entries[0], entries[1]
+	assert.Equal(t, []string{"cat", input}, cat.Command)
+	assert.Zero(t, cat.ExitCode)
+	assert.Equal(t, hook.OutputDigest{
+		SHA256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		Bytes:  6,
+	}, cat.Stdout)
+	assert.Zero(t, cat.Stderr.Bytes)
+	assert.Contains(t, cat.Env, "TRANSCRIPT_MARKER=run-1")
+	assert.Contains(t, cat.Env, "API_TOKEN="+interceptor.RedactedValue)
+	assert.NotEmpty(t, cat.WorkingDir)
+
+	assert.Equal(t, "ls", ls.Command[0])
+	assert.NotZero(t, ls.ExitCode)
+	assert.Positive(t, ls.Stderr.Bytes)
+}
+
+// TestE2E_SeqpacketSocket runs wrappers against a seqpacket interceptor
+func TestE2E_SeqpacketSocket(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+	if err := interceptor.SocketSeqpacket.Validate(); err != nil {
+		t.Skip(err)
+	}
+
+	scriptPath := createTestScript(t, `#!/usr/bin/env bash
+sleep 0
+cat /etc/hostname
+`)
+
+	testHook := newTestHook("test-seqpacket", []string{"sleep", "cat"})
+	testHook.blockCommand("cat")
+	ch, err := New(
+		WithHook(&ipcOnlyHook{h: testHook}),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+		WithSocketType(interceptor.SocketSeqpacket),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	err = ch.Execute([]string{"bash", scriptPath})
+	var blocked *BlockedError
+	require.ErrorAs(t, err, &blocked)
+	assert.Equal(t, []string{"cat", "/etc/hostname"}, blocked.Command)
+	assert.Equal(t, 1, ch.Stats().ExitRequests)
+}
+
+// batchRecordingHook records every request and blocks batches that contain
+// one of the blocked commands
+type batchRecordingHook struct {
+	mu       sync.Mutex
+	requests []hook.Request
+	blocked  map[string]bool
+}
+
+func (h *batchRecordingHook) Name() string       { return "test-batch" }
+func (h *batchRecordingHook) Commands() []string { return []string{"sleep", "cat"} }
+func (h *batchRecordingHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.requests = append(h.requests, *req)
+	for _, command := range req.Batch {
+		if h.blocked[command[0]] {
+			return &hook.Response{Exit: true, Metadata: map[string]interface{}{"reason": "batch contains " + command[0]}}, nil
+		}
+	}
+	return &hook.Response{}, nil
+}
+
+// TestE2E_PreRunBatchEvaluation checks that a command list reaches the hook
+// as one batch request before any of its commands run
+func TestE2E_PreRunBatchEvaluation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	scriptPath := createTestScript(t, `#!/usr/bin/env bash
+sleep 0 && cat /etc/hostname | wc -l
+sleep 0
+`)
+
+	tests := []struct {
+		name    string
+		blocked map[string]bool
+		wantErr bool
+		// wantHooks lists the hook types the hook sees, in order
+		wantHooks []hook.HookType
+	}{
+		{
+			name: "allowed",
+			wantHooks: []hook.HookType{
+				hook.HookPreRunBatch,
+				hook.HookPreRun, hook.HookPostRun, // sleep
+				hook.HookPreRun, hook.HookPostRun, // cat
+				hook.HookPreRun, hook.HookPostRun, // sleep on its own line
+			},
+		},
+		{
+			name:      "blocked batch runs none of its commands",
+			blocked:   map[string]bool{"wc": true},
+			wantErr:   true,
+			wantHooks: []hook.HookType{hook.HookPreRunBatch},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &batchRecordingHook{blocked: tt.blocked}
+			ch, err := New(
+				WithHook(h),
+				WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+				WithPreRunBatchEvaluation(),
+			)
+			require.NoError(t, err)
+			defer ch.Close()
+
+			err = ch.Execute([]string{"bash", scriptPath})
+			if tt.wantErr {
+				var blocked *BlockedError
+				require.ErrorAs(t, err, &blocked)
+				assert.Equal(t, "batch contains wc", blocked.Reason)
+			} else {
+				require.NoError(t, err)
+			}
+
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			var hooks []hook.HookType
+			for _, req := range h.requests {
+				hooks = append(hooks, req.Hook)
+			}
+			assert.Equal(t, tt.wantHooks, hooks)
+
+			batch := h.requests[0]
+			assert.Equal(t, [][]string{{"sleep", "0"}, {"cat", "/etc/hostname"}, {"wc", "-l"}}, batch.Batch)
+			assert.Equal(t, []string{"sleep", "0"}, batch.Command)
+		})
+	}
+}
+
+// TestE2E_PreRunBatchEvaluationUserDebugTrap checks that a script's own
+// DEBUG trap runs alongside batch evaluation instead of disabling it
+func TestE2E_PreRunBatchEvaluationUserDebugTrap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	traceFile := filepath.Join(t.TempDir(), "trace")
+	scriptPath := createTestScript(t, `#!/usr/bin/env bash
+trap 'echo "$BASH_COMMAND" >> `+traceFile+`' DEBUG
+sleep 0 && cat /etc/hostname | wc -l
+trap - DEBUG
+sleep 0
+`)
+
+	h := &batchRecordingHook{}
+	ch, err := New(
+		WithHook(h),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+		WithPreRunBatchEvaluation(),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	require.NoError(t, ch.Execute([]string{"bash", scriptPath}))
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	require.NotEmpty(t, h.requests)
+	assert.Equal(t, hook.HookPreRunBatch, h.requests[0].Hook, "the script's trap does not replace the batch hook")
+	assert.Equal(t, [][]string{{"sleep", "0"}, {"cat", "/etc/hostname"}, {"wc", "-l"}}, h.requests[0].Batch)
+
+	trace, err := os.ReadFile(traceFile)
+	require.NoError(t, err)
+	assert.Equal(t, "sleep 0\ncat /etc/hostname\nwc -l\ntrap - DEBUG\n", string(trace), "the script's trap runs until it is reset")
+}
+
+// TestE2E_BlockSignal checks that a block delivers the configured signal to
+// the script, which can then shut down on its own
+func TestE2E_BlockSignal(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	marker := filepath.Join(t.TempDir(), "marker")
+	scriptPath := createTestScript(t, `#!/usr/bin/env bash
+trap 'echo blocked > `+marker+`; exit 0' USR1
+cat /etc/hostname
+sleep 10
+`)
+
+	testHook := newTestHook("test-block-signal", []string{"cat"})
+	testHook.blockCommand("cat")
+	ch, err := New(
+		WithHook(&ipcOnlyHook{h: testHook}),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+		WithBlockSignal(syscall.SIGUSR1),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	start := time.Now()
+	err = ch.Execute([]string{"bash", scriptPath})
+	var blocked *BlockedError
+	require.ErrorAs(t, err, &blocked)
+	assert.Less(t, time.Since(start), DefaultBlockSignalGrace, "the script exits on the signal without being killed")
+
+	data, err := os.ReadFile(marker)
+	require.NoError(t, err, "the script's USR1 trap should have run")
+	assert.Equal(t, "blocked\n", string(data))
+}
+
+// TestE2E_ExecuteInterruptGrace checks the grace period and the order of
+// interrupt events when a block terminates the script
+func TestE2E_ExecuteInterruptGrace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	const grace = 300 * time.Millisecond
+	tests := []struct {
+		name       string
+		trap       string
+		wantStages []InterruptStage
+	}{
+		{
+			name:       "script exits on SIGTERM",
+			trap:       "trap 'exit 0' TERM",
+			wantStages: []InterruptStage{InterruptTerminating, InterruptExited},
+		},
+		{
+			name:       "script ignores SIGTERM",
+			trap:       "trap '' TERM",
+			wantStages: []InterruptStage{InterruptTerminating, InterruptKilling, InterruptExited},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scriptPath := createTestScript(t, `#!/usr/bin/env bash
+`+tt.trap+`
+cat /etc/hostname
+while :; do sleep 0.05; done
+`)
+
+			testHook := newTestHook("test-interrupt-grace", []string{"cat"})
+			testHook.blockCommand("cat")
+
+			var (
+				mu     sync.Mutex
+				events []InterruptEvent
+				times  []time.Time
+			)
+			ch, err := New(
+				WithHook(&ipcOnlyHook{h: testHook}),
+				WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+				WithExecuteInterruptGrace(grace, func(e InterruptEvent) {
+					mu.Lock()
+					defer mu.Unlock()
+					events = append(events, e)
+					times = append(times, time.Now())
+				}),
+			)
+			require.NoError(t, err)
+			defer ch.Close()
+
+			err = ch.Execute([]string{"bash", scriptPath})
+			var blocked *BlockedError
+			require.ErrorAs(t, err, &blocked)
+
+			mu.Lock()
+			defer mu.Unlock()
+			var stages []InterruptStage
+			for _, e := range events {
+				stages = append(stages, e.Stage)
+				assert.Equal(t, grace, e.Grace)
+				assert.Same(t, blocked, e.Blocked)
+			}
+			assert.Equal(t, tt.wantStages, stages)
+			assert.Equal(t, []string{"cat", "/etc/hostname"}, blocked.Command)
+
+			if len(stages) == 3 {
+				assert.GreaterOrEqual(t, times[1].Sub(times[0]), grace, "SIGKILL waits for the grace period")
+				assert.Less(t, times[1].Sub(times[0]), executor.DefaultKillGrace)
+			}
+		})
+	}
+}
+
+// TestE2E_ExecuteContextCancel checks that cancelling the context stops a
+// running script promptly, through the same termination sequence as a
+// block, and still cleans up
+func TestE2E_ExecuteContextCancel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	var (
+		mu     sync.Mutex
+		events []InterruptEvent
+	)
+	ch, err := New(
+		WithHook(&ipcOnlyHook{h: newTestHook("test-cancel", []string{"cat"})}),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+		WithExecuteInterruptGrace(time.Second, func(e InterruptEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+		}),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	start := time.Now()
+	err = ch.ExecuteContext(ctx, []string{"sleep", "30"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second, "the script should be terminated promptly")
+
+	var blocked *BlockedError
+	assert.False(t, errors.As(err, &blocked))
+	mu.Lock()
+	var stages []InterruptStage
+	for _, e := range events {
+		stages = append(stages, e.Stage)
+		assert.Nil(t, e.Blocked)
+		assert.Equal(t, time.Second, e.Grace)
+	}
+	mu.Unlock()
+	assert.Equal(t, []InterruptStage{InterruptTerminating, InterruptExited}, stages)
+	_, statErr := os.Stat(ch.config.SocketPath)
+	assert.True(t, os.IsNotExist(statErr), "the socket should be removed")
+
+	// Cancelled before it starts, nothing runs
+	err = ch.ExecuteContext(ctx, []string{"sleep", "30"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// TestE2E_OutputLineCallback checks that the output line callback sees the
+// script's lines in order as they are written, and can stop the script
+func TestE2E_OutputLineCallback(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	t.Run("lines in order", func(t *testing.T) {
+		scriptPath := createTestScript(t, `#!/usr/bin/env bash
+echo one
+sleep 0.2
+echo two >&2
+sleep 0.2
+echo three
+`)
+
+		type line struct {
+			stream, text string
+			at           time.Time
+		}
+		var (
+			mu    sync.Mutex
+			lines []line
+		)
+		ch, err := New(
+			WithHook(&ipcOnlyHook{h: newTestHook("test-lines", []string{"cat"})}),
+			WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+			WithExecuteOutputLineCallback(func(stream string, l []byte) error {
+				mu.Lock()
+				defer mu.Unlock()
+				lines = append(lines, line{stream, string(l), time.Now()})
+				return nil
+			}),
+		)
+		require.NoError(t, err)
+		defer ch.Close()
+
+		start := time.Now()
+		require.NoError(t, ch.Execute([]string{"bash", scriptPath}))
+		end := time.Now()
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, lines, 3)
+		assert.Equal(t, []string{StreamStdout, StreamStderr, StreamStdout}, []string{lines[0].stream, lines[1].stream, lines[2].stream})
+		assert.Equal(t, []string{"one", "two", "three"}, []string{lines[0].text, lines[1].text, lines[2].text})
+		assert.Less(t, lines[0].at.Sub(start), end.Sub(start)-300*time.Millisecond, "lines arrive while the script runs")
+	})
+
+	t.Run("callback terminates script", func(t *testing.T) {
+		scriptPath := createTestScript(t, `#!/usr/bin/env bash
+echo starting
+echo "TOKEN=hunter2"
+while :; do sleep 0.05; done
+`)
+
+		errLeak := errors.New("secret printed")
+		ch, err := New(
+			WithHook(&ipcOnlyHook{h: newTestHook("test-lines", []string{"cat"})}),
+			WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+			WithExecuteOutputLineCallback(func(stream string, line []byte) error {
+				if strings.HasPrefix(string(line), "TOKEN=") {
+					return errLeak
+				}
+				return nil
+			}),
+		)
+		require.NoError(t, err)
+		defer ch.Close()
+
+		done := make(chan error, 1)
+		go func() { done <- ch.Execute([]string{"bash", scriptPath}) }()
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, errLeak)
+		case <-time.After(10 * time.Second):
+			t.Fatal("script was not terminated")
+		}
+	})
+}
+
+// exitCodeIPCHook denies its commands with a custom exit code
+type exitCodeIPCHook struct {
+	code int
+}
+
+func (e *exitCodeIPCHook) Name() string       { return "test-exit-code" }
+func (e *exitCodeIPCHook) Commands() []string { return []string{"cat"} }
+func (e *exitCodeIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	if req.Hook != hook.HookPreRun {
+		return &hook.Response{}, nil
+	}
+	return &hook.Response{ExitCode: &e.code, Reason: "cat is not allowed in CI"}, nil
+}
+
+// TestE2E_DenyWithExitCode checks that a command denied with an exit code
+// fails with that code while the script keeps running
+func TestE2E_DenyWithExitCode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	scriptPath := createTestScript(t, `#!/usr/bin/env bash
+cat /etc/hostname
+echo "status=$?"
+`)
+
+	// `go run` reports every failure as exit status 1, so build the wrapper
+	binary := filepath.Join(t.TempDir(), "cmdhooks")
+	out, err := exec.Command("go", "build", "-o", binary, "../../cmd/cmdhooks").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+	ch, err := New(
+		WithHook(&exitCodeIPCHook{code: 77}),
+		WithWrapperPath([]string{binary, "run"}),
+		WithExecuteOutputLineCallback(func(stream string, line []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			lines = append(lines, stream+": "+string(line))
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	require.NoError(t, ch.Execute([]string{"bash", scriptPath}))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, lines, "stderr: Denied cat /etc/hostname: cat is not allowed in CI")
+	assert.Contains(t, lines, "stdout: status=77")
+}
+
+// durationIPCHook measures each command from its pre-run to its post-run
+// evaluation using connection state
+type durationIPCHook struct {
+	mu       sync.Mutex
+	measured []string
+}
+
+func (d *durationIPCHook) Name() string       { return "test-duration" }
+func (d *durationIPCHook) Commands() []string { return []string{"sleep"} }
+func (d *durationIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	state := hook.ConnectionStateFromContext(ctx)
+	switch req.Hook {
+	case hook.HookPreRun:
+		state.Set("request_id", req.RequestID)
+	case hook.HookPostRun:
+		if id, ok := state.Get("request_id"); ok && id == req.RequestID {
+			d.mu.Lock()
+			d.measured = append(d.measured, req.RequestID)
+			d.mu.Unlock()
+		}
+	}
+	return &hook.Response{}, nil
+}
+
+// TestE2E_SharedConnection checks that a hook sees a command's pre-run state
+// at post-run when wrappers share their connection
+func TestE2E_SharedConnection(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	scriptPath := createTestScript(t, `#!/usr/bin/env bash
+sleep 0
+sleep 0
+`)
+
+	h := &durationIPCHook{}
+	ch, err := New(
+		WithHook(h),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+		WithEvaluateHookForPreAndPostSharingConnection(),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	require.NoError(t, ch.Execute([]string{"bash", scriptPath}))
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	require.Len(t, h.measured, 2)
+	assert.NotEqual(t, h.measured[0], h.measured[1], "each command has its own request ID")
+}
+
+func TestE2E_StartupSelfTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	tests := []struct {
+		name        string
+		wrapperPath []string
+		block       bool
+		wantErr     bool
+	}{
+		{
+			name:        "working pipeline",
+			wrapperPath: []string{"go", "run", "../../cmd/cmdhooks", "run"},
+		},
+		{
+			name:        "blocking hook does not fail the self-test",
+			wrapperPath: []string{"go", "run", "../../cmd/cmdhooks", "run"},
+			block:       true,
+		},
+		{
+			name:        "broken wrapper path",
+			wrapperPath: []string{"/nonexistent/cmdhooks", "run"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marker := filepath.Join(t.TempDir(), "ran")
+			scriptPath := createTestScript(t, fmt.Sprintf("#!/usr/bin/env bash\ntouch %q\n", marker))
+
+			h := newTestHook("selftest", []string{"sleep"})
+			if tt.block {
+				h.blockCommand("sleep")
+			}
+			ch, err := New(
+				WithHook(&ipcOnlyHook{h: h}),
+				WithWrapperPath(tt.wrapperPath),
+				WithStartupSelfTest(),
+			)
+			require.NoError(t, err)
+			defer ch.Close()
+
+			err = ch.Execute([]string{"bash", scriptPath})
+			if tt.wantErr {
+				var selfTestErr *SelfTestError
+				require.ErrorAs(t, err, &selfTestErr)
+				assert.Equal(t, "sleep", selfTestErr.Command)
+				assert.Contains(t, selfTestErr.Output, "/nonexistent/cmdhooks")
+				assert.NoFileExists(t, marker, "script must not start when the self-test fails")
+				return
+			}
+			require.NoError(t, err)
+			assert.FileExists(t, marker)
+		})
+	}
+}
+
+func TestE2E_CommandResultEnvExport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	outFile := filepath.Join(t.TempDir(), "out")
+	scriptPath := createTestScript(t, fmt.Sprintf(`#!/usr/bin/env bash
+sleep 0
+. "$CMDHOOKS_RESULT_FILE"
+echo "$CMDHOOKS_RESULT_COMMAND|$CMDHOOKS_RESULT_STAGE|$CMDHOOKS_RESULT_BLOCKED|$CMDHOOKS_RESULT_EXIT_CODE" > %q
+`, outFile))
+
+	ch, err := New(
+		WithHook(&ipcOnlyHook{h: newTestHook("result", []string{"sleep"})}),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+		WithCommandResultEnvExport(),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	require.NoError(t, ch.Execute([]string{"bash", scriptPath}))
+
+	out, err := os.ReadFile(outFile)
+	require.NoError(t, err)
+	assert.Equal(t, "sleep 0|post_run|false|0\n", string(out))
+}
+
+// failureOnlyHook is an ipcOnlyHook that only wants post-run evaluation of
+// failed commands
+type failureOnlyHook struct {
+	ipcOnlyHook
+}
+
+func (f *failureOnlyHook) WantsPostRun(exitCode int) bool { return exitCode != 0 }
+
+// TestE2E_PostRunGating checks that wrappers skip the post-run request of a
+// successful command when the hook only wants failures
+func TestE2E_PostRunGating(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	tests := []struct {
+		name          string
+		gating        bool
+		wantPostRunOK bool
+	}{
+		{name: "gated", gating: true},
+		{name: "not gated", gating: false, wantPostRunOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scriptPath := createTestScript(t, `#!/usr/bin/env bash
+ls / > /dev/null
+ls /nonexistent-cmdhooks-path 2> /dev/null
+exit 0
+`)
+
+			var (
+				mu       sync.Mutex
+				postRuns []string
+			)
+			opts := []Option{
+				WithHook(&failureOnlyHook{ipcOnlyHook{h: newTestHook("test-post-run-gating", []string{"ls"})}}),
+				WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+				WithOnDecision(func(cmd []string, stage hook.HookType, exit bool, dur time.Duration) {
+					if stage == hook.HookPostRun {
+						mu.Lock()
+						postRuns = append(postRuns, strings.Join(cmd, " "))
+						mu.Unlock()
+					}
+				}),
+			}
+			if tt.gating {
+				opts = append(opts, WithEvaluationForExitCodePostRunGating())
+			}
+			ch, err := New(opts...)
+			require.NoError(t, err)
+			defer ch.Close()
+
+			require.NoError(t, ch.Execute([]string{"bash", scriptPath}))
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Contains(t, postRuns, "ls /nonexistent-cmdhooks-path", "failures always get post-run")
+			if tt.wantPostRunOK {
+				assert.Contains(t, postRuns, "ls /")
+			} else {
+				assert.NotContains(t, postRuns, "ls /", "successful post-run is not sent")
+			}
+		})
+	}
+}
+
+// TestE2E_ScriptExtensionPolicy checks that scripts run through an
+// interpreter the hook does not list are decided by their extension
+func TestE2E_ScriptExtensionPolicy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not available")
+	}
+
+	pyScript := filepath.Join(t.TempDir(), "hello.py")
+	require.NoError(t, os.WriteFile(pyScript, []byte("print('hello')\n"), 0644))
+
+	newCmdHooks := func(t *testing.T) *CmdHooks {
+		ch, err := New(
+			WithHook(&ipcOnlyHook{h: newTestHook("test-script-policy", []string{"cat"})}),
+			WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+			WithScriptExtensionPolicy(map[string]interceptor.ScriptAction{".py": interceptor.ScriptBlock}),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { ch.Close() })
+		return ch
+	}
+
+	t.Run("matching script is blocked", func(t *testing.T) {
+		scriptPath := createTestScript(t, "#!/usr/bin/env bash\npython3 "+wrapper.ShellQuote(pyScript)+"\n")
+		err := newCmdHooks(t).Execute([]string{"bash", scriptPath})
+		var blocked *BlockedError
+		require.ErrorAs(t, err, &blocked)
+		assert.Equal(t, []string{"python3", pyScript}, blocked.Command)
+	})
+
+	t.Run("interpreter without script runs", func(t *testing.T) {
+		scriptPath := createTestScript(t, "#!/usr/bin/env bash\npython3 -c 'print(1)'\n")
+		assert.NoError(t, newCmdHooks(t).Execute([]string{"bash", scriptPath}))
+	})
+}
+
+// auditHook records the pre-run requests of its commands and tags them with
+// metadata for the hooks evaluated after it
+type auditHook struct {
+	mu       sync.Mutex
+	requests []hook.Request
+}
+
+func (h *auditHook) Name() string       { return "test-audit" }
+func (h *auditHook) Commands() []string { return []string{"ls", "cat"} }
+func (h *auditHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if req.Hook == hook.HookPreRun {
+		h.requests = append(h.requests, *req)
+	}
+	return &hook.Response{Metadata: map[string]interface{}{"audited": true}}, nil
+}
+
+// TestE2E_MultipleHooks checks that hooks given to WithHooks are evaluated
+// in order, with wrappers for the union of their commands
+func TestE2E_MultipleHooks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	scriptPath := createTestScript(t, `#!/usr/bin/env bash
+ls / > /dev/null
+sleep 0
+cat /etc/hostname
+`)
+
+	audit := &auditHook{}
+	policy := &batchRecordingHook{}
+	blocker := newTestHook("test-blocker", []string{"cat"})
+	blocker.blockCommand("cat")
+	ch, err := New(
+		WithHooks(audit, policy, &ipcOnlyHook{h: blocker}),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	assert.Equal(t, []string{"ls", "cat", "sleep"}, ch.GetHook().Commands())
+
+	err = ch.Execute([]string{"bash", scriptPath})
+	var blocked *BlockedError
+	require.ErrorAs(t, err, &blocked)
+	assert.Equal(t, []string{"cat", "/etc/hostname"}, blocked.Command)
+
+	audit.mu.Lock()
+	var audited []string
+	for _, req := range audit.requests {
+		audited = append(audited, req.Command[0])
+	}
+	audit.mu.Unlock()
+	assert.Equal(t, []string{"ls", "cat"}, audited, "the first hook sees every command it lists")
+
+	policy.mu.Lock()
+	defer policy.mu.Unlock()
+	var seen []string
+	for _, req := range policy.requests {
+		if req.Hook != hook.HookPreRun {
+			continue
+		}
+		seen = append(seen, req.Command[0])
+		if req.Command[0] == "cat" {
+			assert.Equal(t, true, req.Metadata["audited"], "metadata of earlier hooks is passed on")
+		}
+	}
+	assert.Equal(t, []string{"sleep", "cat"}, seen)
+}
+
+// TestE2E_TimingBreakdown checks that every command's phase latencies are
+// reported once its post-run evaluation is done
+func TestE2E_TimingBreakdown(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	scriptPath := createTestScript(t, `#!/usr/bin/env bash
+sleep 0.3
+`)
+
+	var (
+		mu         sync.Mutex
+		breakdowns []interceptor.TimingBreakdown
+	)
+	ch, err := New(
+		WithHook(&ipcOnlyHook{h: newTestHook("test-timing", []string{"sleep"})}),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+		WithExecuteTimingBreakdown(func(b interceptor.TimingBreakdown) {
+			mu.Lock()
+			defer mu.Unlock()
+			breakdowns = append(breakdowns, b)
+		}),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	start := time.Now()
+	require.NoError(t, ch.Execute([]string{"bash", scriptPath}))
+	elapsed := time.Since(start)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, breakdowns, 1)
+	b := breakdowns[0]
+	assert.Equal(t, []string{"sleep", "0.3"}, b.Command)
+	assert.Positive(t, b.PreRun)
+	assert.GreaterOrEqual(t, b.Execution, 300*time.Millisecond)
+	assert.Positive(t, b.PostRun)
+	assert.Equal(t, b.PreRun+b.Execution+b.PostRun, b.Total())
+	assert.Less(t, b.Total(), elapsed, "phases fit within the script's run time")
+}
+
+func TestE2E_LockfileAllowlist(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping E2E test in short mode")
+	}
+
+	bin := t.TempDir()
+	for _, name := range []string{"deploy", "build"} {
+		require.NoError(t, os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\necho "+name+"\n"), 0755))
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// Generate the lockfile with the CLI, then replace one of the binaries
+	lockPath := filepath.Join(t.TempDir(), "cmdhooks.lock")
+	out, err := exec.Command("go", "run", "../../cmd/cmdhooks", "lockfile", "generate", "-o", lockPath, "deploy", "build").CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "build"), []byte("#!/bin/sh\necho tampered\n"), 0755))
+
+	allowlist, err := policy.NewLockfileAllowlist(lockPath)
+	require.NoError(t, err)
+
+	scriptPath := createTestScript(t, `#!/usr/bin/env bash
+deploy
+build
+`)
+	ch, err := New(
+		WithHook(allowlist),
+		WithWrapperPath([]string{"go", "run", "../../cmd/cmdhooks", "run"}),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	err = ch.Execute([]string{"bash", scriptPath})
+	var blocked *BlockedError
+	require.ErrorAs(t, err, &blocked)
+	assert.Equal(t, []string{"build"}, blocked.Command, "the untouched binary runs, the replaced one is blocked")
+}
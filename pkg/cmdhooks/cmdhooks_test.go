@@ -1,16 +1,31 @@
 package cmdhooks
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/codysoyland/cmdhooks/pkg/hook"
+	"github.com/codysoyland/cmdhooks/pkg/interceptor"
+	"github.com/codysoyland/cmdhooks/pkg/policy"
+	"github.com/codysoyland/cmdhooks/pkg/wrapper"
 )
 
 // mockHook is a test double for hook.Hook
@@ -148,7 +163,7 @@ func TestNew(t *testing.T) {
 
 				// Verify configuration was applied
 				assert.NotNil(t, ch.config)
-				assert.NotNil(t, ch.hook)
+				assert.NotNil(t, ch.GetHook())
 				// Interceptor is always created for consistent behavior
 				assert.NotNil(t, ch.interceptor)
 			}
@@ -157,6 +172,122 @@ func TestNew(t *testing.T) {
 }
 
 func TestOptions(t *testing.T) {
+	t.Run("WithDecisionExplainMode", func(t *testing.T) {
+		config := &Config{}
+		require.NoError(t, WithDecisionExplainMode()(config))
+		assert.True(t, config.ExplainDecisions)
+	})
+
+	t.Run("WithInterceptorPauseResume", func(t *testing.T) {
+		config := &Config{}
+		require.NoError(t, WithInterceptorPauseResume(time.Minute, interceptor.TimeoutAllow)(config))
+		assert.Equal(t, time.Minute, config.PauseTimeout)
+		assert.Equal(t, interceptor.TimeoutAllow, config.PauseDecision)
+
+		assert.Error(t, WithInterceptorPauseResume(0, interceptor.TimeoutBlock)(&Config{}))
+		assert.Error(t, WithInterceptorPauseResume(time.Minute, interceptor.TimeoutDecision(7))(&Config{}))
+	})
+
+	t.Run("WithLogger", func(t *testing.T) {
+		config := &Config{}
+		logger := log.New(io.Discard, "", 0)
+		require.NoError(t, WithLogger(logger)(config))
+		assert.Same(t, logger, config.Logger)
+		assert.Error(t, WithLogger(nil)(&Config{}))
+	})
+
+	t.Run("WithArgAllowlist", func(t *testing.T) {
+		config := &Config{}
+		allowlist := map[string][]string{"git": {"status", "log --oneline"}}
+		require.NoError(t, WithArgAllowlist(allowlist)(config))
+		assert.Equal(t, allowlist, config.ArgAllowlist)
+		allowlist["git"][0] = "push"
+		assert.Equal(t, "status", config.ArgAllowlist["git"][0], "allowlist is copied")
+
+		assert.Error(t, WithArgAllowlist(nil)(&Config{}))
+		assert.Error(t, WithArgAllowlist(map[string][]string{"git": nil})(&Config{}))
+		assert.Error(t, WithArgAllowlist(map[string][]string{"git": {" "}})(&Config{}))
+		assert.Error(t, WithArgAllowlist(map[string][]string{"/usr/bin/git": {"status"}})(&Config{}))
+	})
+
+	t.Run("WithTLS", func(t *testing.T) {
+		config := &Config{}
+		server := &tls.Config{Certificates: []tls.Certificate{{}}}
+		require.NoError(t, WithTLS(server, wrapper.TLSClientFiles{ServerName: "interceptor.internal"})(config))
+		assert.Same(t, server, config.TLSConfig)
+		assert.Equal(t, "interceptor.internal", config.WrapperTLS.ServerName)
+
+		assert.ErrorContains(t, WithTLS(nil, wrapper.TLSClientFiles{})(config), "server config cannot be nil")
+		assert.ErrorContains(t, WithTLS(&tls.Config{}, wrapper.TLSClientFiles{})(config), "no certificate")
+		assert.ErrorContains(t, WithTLS(server, wrapper.TLSClientFiles{CAFile: filepath.Join(t.TempDir(), "missing.pem")})(config), "failed to read CA file")
+	})
+
+	t.Run("WithSyslog", func(t *testing.T) {
+		config := &Config{}
+		require.NoError(t, WithSyslog("")(config))
+		assert.Equal(t, "cmdhooks", config.SyslogTag)
+		require.NoError(t, WithSyslog("ci-runner")(config))
+		assert.Equal(t, "ci-runner", config.SyslogTag)
+	})
+
+	t.Run("WithSchedule", func(t *testing.T) {
+		config := &Config{}
+		require.NoError(t, WithSchedule(interceptor.BusinessHours(time.UTC))(config))
+		require.Len(t, config.Enrichers, 1)
+
+		req := &hook.Request{Metadata: map[string]interface{}{}}
+		config.Enrichers[0](req)
+		assert.Contains(t, []interface{}{interceptor.BucketBusinessHours, interceptor.BucketAfterHours}, req.Metadata[interceptor.MetadataTimeBucket])
+
+		assert.Error(t, WithSchedule(nil)(config))
+		assert.ErrorContains(t, WithSchedule(&interceptor.Schedule{})(config), "WithSchedule: schedule has no default bucket")
+	})
+
+	t.Run("WithSocketGroupAccess", func(t *testing.T) {
+		config := &Config{}
+		err := WithSocketGroupAccess(1000)(config)
+		assert.NoError(t, err)
+		require.NotNil(t, config.SocketGroup)
+		assert.Equal(t, 1000, *config.SocketGroup)
+
+		assert.Error(t, WithSocketGroupAccess(-1)(&Config{}))
+	})
+
+	t.Run("WithExecuteTimingBreakdown", func(t *testing.T) {
+		config := &Config{}
+		err := WithExecuteTimingBreakdown(func(interceptor.TimingBreakdown) {})(config)
+		assert.NoError(t, err)
+		assert.NotNil(t, config.TimingCallback)
+
+		assert.Error(t, WithExecuteTimingBreakdown(nil)(&Config{}))
+	})
+
+	t.Run("WithHooks", func(t *testing.T) {
+		first := newMockHook("first", []string{"curl"})
+		second := newMockHook("second", []string{"wget", "curl"})
+		config := &Config{}
+
+		err := WithHooks(first, second)(config)
+		assert.NoError(t, err)
+		require.IsType(t, &hook.Multi{}, config.Hook)
+		assert.Equal(t, []hook.Hook{first, second}, config.Hook.(*hook.Multi).Hooks())
+		assert.Equal(t, []string{"curl", "wget"}, config.Hook.Commands())
+
+		assert.Error(t, WithHooks()(&Config{}))
+		assert.Error(t, WithHooks(first, nil)(&Config{}))
+	})
+
+	t.Run("WithScriptExtensionPolicy", func(t *testing.T) {
+		config := &Config{}
+		err := WithScriptExtensionPolicy(map[string]interceptor.ScriptAction{".py": interceptor.ScriptBlock})(config)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interceptor.ScriptAction{".py": interceptor.ScriptBlock}, config.ScriptExtensions)
+
+		assert.Error(t, WithScriptExtensionPolicy(nil)(&Config{}))
+		assert.Error(t, WithScriptExtensionPolicy(map[string]interceptor.ScriptAction{".": interceptor.ScriptAllow})(&Config{}))
+		assert.Error(t, WithScriptExtensionPolicy(map[string]interceptor.ScriptAction{".sh": interceptor.ScriptAction(7)})(&Config{}))
+	})
+
 	t.Run("WithHook", func(t *testing.T) {
 		hook := newMockHook("test", []string{"curl"})
 		config := &Config{}
@@ -220,6 +351,383 @@ func TestOptions(t *testing.T) {
 		assert.Contains(t, err.Error(), "argument 0 is empty")
 	})
 
+	t.Run("WithCommandRewriteLog", func(t *testing.T) {
+		var buf bytes.Buffer
+		config := &Config{}
+		err := WithCommandRewriteLog(&buf)(config)
+		assert.NoError(t, err)
+		assert.Equal(t, &buf, config.RewriteLog)
+
+		err = WithCommandRewriteLog(nil)(config)
+		assert.Error(t, err)
+
+		ch, err := New(WithHook(newMockHook("test", []string{"curl"})), WithCommandRewriteLog(&buf))
+		require.NoError(t, err)
+		defer ch.Close()
+		assert.NotNil(t, ch.interceptor.RewriteLog())
+	})
+
+	t.Run("WithMaxPendingExits", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithMaxPendingExits(10)(config))
+		assert.Equal(t, 10, config.MaxPendingExits)
+
+		err := WithMaxPendingExits(0)(config)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "must be positive")
+	})
+
+	t.Run("WithWorkingDir", func(t *testing.T) {
+		dir := t.TempDir()
+		config := &Config{}
+		assert.NoError(t, WithWorkingDir(dir)(config))
+		assert.Equal(t, dir, config.WorkingDir)
+
+		err := WithWorkingDir(filepath.Join(dir, "missing"))(config)
+		assert.Error(t, err)
+
+		file := filepath.Join(dir, "file")
+		require.NoError(t, os.WriteFile(file, nil, 0600))
+		err = WithWorkingDir(file)(config)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "is not a directory")
+	})
+
+	t.Run("WithCommandDeduplication", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithCommandDeduplication(time.Second)(config))
+		assert.Equal(t, time.Second, config.DedupWindow)
+
+		assert.Error(t, WithCommandDeduplication(-time.Second)(config))
+	})
+
+	t.Run("WithChildUmask", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithChildUmask(0o077)(config))
+		require.NotNil(t, config.ChildUmask)
+		assert.Equal(t, 0o077, *config.ChildUmask)
+
+		assert.Error(t, WithChildUmask(0o1000)(config))
+		assert.Error(t, WithChildUmask(-1)(config))
+	})
+
+	t.Run("WithRequestEnricher", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithRequestEnricher(func(req *hook.Request) {})(config))
+		assert.Len(t, config.Enrichers, 1)
+
+		assert.Error(t, WithRequestEnricher(nil)(config))
+	})
+
+	t.Run("WithTimeQuota", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithTimeQuota(time.Hour)(config))
+		assert.Equal(t, time.Hour, config.TimeQuota)
+
+		assert.Error(t, WithTimeQuota(0)(config))
+	})
+
+	t.Run("WithExecutePreservingControllingTerminal", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithExecutePreservingControllingTerminal()(config))
+		assert.True(t, config.Foreground)
+	})
+
+	t.Run("WithEvaluateUsingUnixCredentialsForRateLimit", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithEvaluateUsingUnixCredentialsForRateLimit()(config))
+		assert.True(t, config.QuotaPerUser)
+	})
+
+	t.Run("WithPerCommandTimeQuota", func(t *testing.T) {
+		config := &Config{}
+		quotas := map[string]time.Duration{"git": 5 * time.Minute, "curl": time.Minute}
+		assert.NoError(t, WithPerCommandTimeQuota(quotas)(config))
+		assert.Equal(t, quotas, config.PerCommandTimeQuotas)
+
+		assert.Error(t, WithPerCommandTimeQuota(nil)(config))
+		assert.Error(t, WithPerCommandTimeQuota(map[string]time.Duration{"git": 0})(config))
+		assert.Error(t, WithPerCommandTimeQuota(map[string]time.Duration{"": time.Minute})(config))
+	})
+
+	t.Run("WithResponseTransformer", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithResponseTransformer(func(req *hook.Request, resp *hook.Response) *hook.Response {
+			return resp
+		})(config))
+		assert.Len(t, config.ResponseTransformers, 1)
+
+		assert.Error(t, WithResponseTransformer(nil)(config))
+	})
+
+	t.Run("WithCommandNormalizationForAudit", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithCommandNormalizationForAudit(interceptor.NormalizeCommand)(config))
+		assert.NotNil(t, config.AuditNormalizer)
+
+		assert.Error(t, WithCommandNormalizationForAudit(nil)(config))
+	})
+
+	t.Run("WithDecisionLog", func(t *testing.T) {
+		config := &Config{}
+		var buf bytes.Buffer
+		assert.NoError(t, WithDecisionLog(&buf)(config))
+		assert.Equal(t, &buf, config.DecisionLog)
+		assert.Error(t, WithDecisionLog(nil)(config))
+
+		assert.NoError(t, WithMetadataRedactor(interceptor.RedactKeys("token"))(config))
+		assert.NotNil(t, config.MetadataRedactor)
+		assert.Error(t, WithMetadataRedactor(nil)(config))
+	})
+
+	t.Run("WithPreRunConfirmationCache", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithPreRunConfirmationCache()(config))
+		assert.True(t, config.RememberDecisions)
+	})
+
+	t.Run("WithSocketType", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithSocketType(interceptor.SocketStream)(config))
+		assert.Equal(t, interceptor.SocketStream, config.SocketType)
+		assert.Error(t, WithSocketType("tcp")(config))
+	})
+
+	t.Run("WithTimeoutDecision", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithTimeoutDecision(interceptor.TimeoutAllow)(config))
+		assert.Equal(t, interceptor.TimeoutAllow, config.TimeoutDecision)
+		assert.Error(t, WithTimeoutDecision(interceptor.TimeoutDecision(7))(config))
+	})
+
+	t.Run("WithCommandPathShadowWarning", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithCommandPathShadowWarning()(config))
+		assert.True(t, config.ShadowWarning)
+	})
+
+	t.Run("WithCommandResultEnvExport", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithCommandResultEnvExport()(config))
+		assert.True(t, config.ResultEnvExport)
+	})
+
+	t.Run("WithStartupSelfTest", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithStartupSelfTest()(config))
+		assert.True(t, config.SelfTest)
+	})
+
+	t.Run("WithInterceptorBindRetry", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithInterceptorBindRetry(3, 10*time.Millisecond)(config))
+		assert.Equal(t, 3, config.BindRetries)
+		assert.Equal(t, 10*time.Millisecond, config.BindBackoff)
+		assert.Error(t, WithInterceptorBindRetry(0, time.Millisecond)(&Config{}))
+		assert.Error(t, WithInterceptorBindRetry(3, 0)(&Config{}))
+	})
+
+	t.Run("WithExecuteOutputLineCallback", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithExecuteOutputLineCallback(func(string, []byte) error { return nil })(config))
+		assert.NotNil(t, config.OutputLineCallback)
+		assert.Error(t, WithExecuteOutputLineCallback(nil)(&Config{}))
+	})
+
+	t.Run("WithChaos", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithChaos(0.25, []string{"curl"})(config))
+		assert.Equal(t, 0.25, config.ChaosRate)
+		assert.Equal(t, []string{"curl"}, config.ChaosCommands)
+		assert.Error(t, WithChaos(0, []string{"curl"})(&Config{}))
+		assert.Error(t, WithChaos(1.5, []string{"curl"})(&Config{}))
+		assert.Error(t, WithChaos(0.5, nil)(&Config{}))
+	})
+
+	t.Run("WithRequestCompressionNegotiation", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithRequestCompressionNegotiation()(config))
+		assert.True(t, config.RequestCompression)
+	})
+
+	t.Run("WithEvaluationForExitCodePostRunGating", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithEvaluationForExitCodePostRunGating()(config))
+		assert.True(t, config.PostRunGating)
+	})
+
+	t.Run("WithExecuteInterruptGrace", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithExecuteInterruptGrace(time.Second, nil)(config))
+		assert.Equal(t, time.Second, config.InterruptGrace)
+		assert.Error(t, WithExecuteInterruptGrace(0, nil)(config))
+	})
+
+	t.Run("WithBlockSignal", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithBlockSignal(syscall.SIGINT)(config))
+		assert.Equal(t, syscall.SIGINT, config.BlockSignal)
+		assert.Error(t, WithBlockSignal(0)(config))
+	})
+
+	t.Run("WithRequestFieldAllowlist", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithRequestFieldAllowlist("command_name", "metadata.timed_out")(config))
+		assert.Equal(t, []string{"command_name", "metadata.timed_out"}, config.RequestFieldAllowlist)
+
+		// An empty allowlist still minimizes requests
+		assert.NoError(t, WithRequestFieldAllowlist()(config))
+		assert.NotNil(t, config.RequestFieldAllowlist)
+		assert.Empty(t, config.RequestFieldAllowlist)
+
+		assert.Error(t, WithRequestFieldAllowlist("hostname")(config))
+	})
+
+	t.Run("WithProgressReporter", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithProgressReporter(func(interceptor.Progress) {})(config))
+		assert.NotNil(t, config.ProgressReporter)
+		assert.Error(t, WithProgressReporter(nil)(config))
+
+		assert.NoError(t, WithProgressInterval(time.Minute)(config))
+		assert.Equal(t, time.Minute, config.ProgressInterval)
+		assert.Error(t, WithProgressInterval(0)(config))
+	})
+
+	t.Run("WithCommandTimeouts", func(t *testing.T) {
+		config := &Config{}
+		timeouts := map[string]time.Duration{"make": time.Hour, "*": time.Minute}
+		assert.NoError(t, WithCommandTimeouts(timeouts)(config))
+		assert.Equal(t, timeouts, config.CommandTimeouts)
+
+		assert.Error(t, WithCommandTimeouts(map[string]time.Duration{"curl": 0})(config))
+		assert.Error(t, WithCommandTimeouts(map[string]time.Duration{"a,b": time.Second})(config))
+		assert.Error(t, WithCommandTimeouts(map[string]time.Duration{"[": time.Second})(config))
+	})
+
+	t.Run("WithConnectionTimeout", func(t *testing.T) {
+		config := &Config{}
+		assert.NoError(t, WithConnectionTimeout(5*time.Second)(config))
+		assert.Equal(t, 5*time.Second, config.ConnectionTimeout)
+
+		assert.Error(t, WithConnectionTimeout(-time.Second)(config))
+	})
+}
+
+func TestCmdHooks_WrapperEnv(t *testing.T) {
+	dir := t.TempDir()
+	ch, err := New(WithHook(newMockHook("test", []string{"curl"})), WithWorkingDir(dir))
+	require.NoError(t, err)
+	defer ch.Close()
+
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_WORKING_DIR="+dir)
+
+	ch, err = New(WithHook(newMockHook("test", []string{"curl"})), WithChildUmask(0o027))
+	require.NoError(t, err)
+	defer ch.Close()
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_CHILD_UMASK=27")
+
+	ch, err = New(WithHook(newMockHook("test", []string{"curl"})),
+		WithCommandTimeouts(map[string]time.Duration{"make": time.Hour, "curl": 30 * time.Second}))
+	require.NoError(t, err)
+	defer ch.Close()
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_COMMAND_TIMEOUTS=curl=30s,make=1h0m0s")
+
+	ch.config.RequestFieldAllowlist = []string{"command_name", "pid"}
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_REQUEST_FIELDS=command_name,pid")
+
+	assert.NoError(t, WithArgv0SpoofingDetection()(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_ARGV0_CHECK=true")
+
+	assert.NoError(t, WithTranscript(&bytes.Buffer{})(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_TRANSCRIPT=true")
+
+	assert.NotContains(t, strings.Join(ch.wrapperEnv(), " "), "CMDHOOKS_SOCKET_NETWORK")
+	ch.config.SocketType = interceptor.SocketSeqpacket
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_SOCKET_NETWORK=unixpacket")
+
+	assert.NoError(t, WithEvaluationAttribution()(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_ATTRIBUTION=true")
+
+	ch.config.TLSConfig = &tls.Config{}
+	ch.config.WrapperTLS = wrapper.TLSClientFiles{CAFile: "/etc/cmdhooks/ca.pem"}
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_TLS_SERVER_NAME=localhost")
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_TLS_CA_FILE=/etc/cmdhooks/ca.pem")
+	assert.NotContains(t, strings.Join(ch.wrapperEnv(), " "), "CMDHOOKS_TLS_CERT_FILE")
+
+	assert.NoError(t, WithCommandScratchDirs()(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_SCRATCH_ISOLATION=true")
+
+	assert.NoError(t, WithExecuteOutputHashing()(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_OUTPUT_HASHING=true")
+
+	assert.NoError(t, WithExecuteCapturingCombinedTranscriptOrder()(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_OUTPUT_TRANSCRIPT=true")
+
+	assert.NoError(t, WithStreamingOutput()(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_STREAM_OUTPUT=true")
+
+	assert.NoError(t, WithExecuteForwardingExitStatusForSignaledChildren()(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_SIGNAL_EXIT_STATUS=true")
+
+	assert.NoError(t, WithPTY()(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_PTY=true")
+
+	assert.NotContains(t, strings.Join(ch.wrapperEnv(), " "), "CMDHOOKS_FAILURE_MODE")
+	assert.NoError(t, WithFailureMode(wrapper.FailClosed)(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_FAILURE_MODE=closed")
+	assert.Error(t, WithFailureMode(wrapper.FailUnset)(&Config{}))
+
+	assert.NotContains(t, strings.Join(ch.wrapperEnv(), " "), "CMDHOOKS_ENV_ALLOWLIST")
+	assert.NoError(t, WithEnvAllowlist("HTTP_PROXY", "NO_PROXY")(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_ENV_ALLOWLIST=HTTP_PROXY,NO_PROXY")
+	assert.Error(t, WithEnvAllowlist("HTTP_PROXY=x")(&Config{}))
+
+	assert.NoError(t, WithEvaluateHookForPreAndPostSharingConnection()(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_SHARED_CONNECTION=true")
+
+	assert.NotContains(t, ch.wrapperEnv(), "CMDHOOKS_CHAOS=true", "chaos testing is off by default")
+	assert.NoError(t, WithChaos(0.5, []string{"*"})(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_CHAOS=true")
+
+	assert.NoError(t, WithRequestCompressionNegotiation()(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_REQUEST_COMPRESSION=true")
+
+	assert.NoError(t, WithExecuteTimingBreakdown(func(interceptor.TimingBreakdown) {})(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_TIMING_BREAKDOWN=true")
+
+	assert.NoError(t, WithExecuteDeadlineHeadroom(1500*time.Millisecond)(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_DEADLINE_HEADROOM=1.5s")
+	assert.Error(t, WithExecuteDeadlineHeadroom(0)(&Config{}))
+
+	assert.NoError(t, WithCommandWrapperForInterpretedScripts(map[string]int{"python3": 1, "deno": 2})(ch.config))
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_INTERPRETED_SCRIPTS=deno=2,python3=1")
+	assert.Error(t, WithCommandWrapperForInterpretedScripts(map[string]int{"python": 0})(&Config{}))
+	assert.Error(t, WithCommandWrapperForInterpretedScripts(map[string]int{})(&Config{}))
+	config := &Config{}
+	assert.NoError(t, WithCommandWrapperForInterpretedScripts(nil)(config))
+	assert.Equal(t, wrapper.DefaultInterpreters, config.Interpreters)
+	assert.Error(t, WithTranscript(nil)(&Config{}))
+}
+
+func TestCmdHooks_ScriptPolicyCommands(t *testing.T) {
+	ch, err := New(WithHook(newMockHook("test", []string{"curl", "python3"})))
+	require.NoError(t, err)
+	defer ch.Close()
+
+	assert.Empty(t, ch.scriptPolicyCommands(), "no policy, no extra wrappers")
+	assert.NotContains(t, strings.Join(ch.wrapperEnv(), "\n"), "CMDHOOKS_INTERPRETED_SCRIPTS")
+
+	require.NoError(t, WithScriptExtensionPolicy(map[string]interceptor.ScriptAction{".sh": interceptor.ScriptEvaluate})(ch.config))
+	commands := ch.scriptPolicyCommands()
+	assert.Contains(t, commands, "python")
+	assert.Contains(t, commands, "sh")
+	assert.NotContains(t, commands, "python3", "listed by the hook")
+	assert.NotContains(t, commands, "bash", "wrapper scripts run under bash")
+	assert.Contains(t, strings.Join(ch.wrapperEnv(), "\n"), "CMDHOOKS_INTERPRETED_SCRIPTS=", "default interpreters are inspected")
+
+	require.NoError(t, WithCommandWrapperForInterpretedScripts(map[string]int{"node": 1})(ch.config))
+	assert.Equal(t, []string{"node"}, ch.scriptPolicyCommands())
 }
 
 func TestCmdHooks_SetHook(t *testing.T) {
@@ -238,6 +746,34 @@ func TestCmdHooks_SetHook(t *testing.T) {
 	assert.Equal(t, hook2, ch.config.Hook)
 }
 
+func TestCmdHooks_SetHookDuringEvaluation(t *testing.T) {
+	ch, err := New(WithHook(allowAllIPCHook{}))
+	require.NoError(t, err)
+	defer ch.Close()
+	require.NoError(t, ch.interceptor.Start())
+
+	var wg sync.WaitGroup
+	for c := 0; c < 4; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := 0; r < 20; r++ {
+				_, err := roundTrip(ch.config.SocketPath, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	for n := 0; n < 100; n++ {
+		if n%2 == 0 {
+			ch.SetHook(blockCurlIPCHook{})
+		} else {
+			ch.SetHook(allowAllIPCHook{})
+		}
+		_ = ch.GetHook()
+	}
+	wg.Wait()
+}
+
 func TestCmdHooks_Close(t *testing.T) {
 	tmpDir := t.TempDir()
 	socketPath := filepath.Join(tmpDir, "test.sock")
@@ -262,6 +798,43 @@ func TestCmdHooks_Close(t *testing.T) {
 	assert.True(t, os.IsNotExist(err))
 }
 
+func TestCmdHooks_CloseRemovesSocketDir(t *testing.T) {
+	t.Run("created socket dir is removed", func(t *testing.T) {
+		ch, err := New(WithHook(newMockHook("test", []string{"curl"})))
+		require.NoError(t, err)
+
+		socketDir := ch.socketDir
+		require.NotEmpty(t, socketDir)
+		assert.Equal(t, socketDir, filepath.Dir(ch.config.SocketPath))
+		assert.DirExists(t, socketDir)
+
+		require.NoError(t, ch.interceptor.Start())
+		require.NoError(t, ch.Close())
+
+		_, err = os.Stat(socketDir)
+		assert.True(t, os.IsNotExist(err), "socket dir should be removed: %s", socketDir)
+
+		// Closing again is harmless
+		assert.NoError(t, ch.Close())
+	})
+
+	t.Run("user-provided socket dir is left alone", func(t *testing.T) {
+		userDir := t.TempDir()
+		sibling := filepath.Join(userDir, "keep.txt")
+		require.NoError(t, os.WriteFile(sibling, []byte("keep"), 0o600))
+
+		ch, err := New(WithHook(newMockHook("test", []string{"curl"})), WithSocketPath(filepath.Join(userDir, "hook.sock")))
+		require.NoError(t, err)
+		assert.Empty(t, ch.socketDir)
+
+		require.NoError(t, ch.interceptor.Start())
+		require.NoError(t, ch.Close())
+
+		assert.DirExists(t, userDir)
+		assert.FileExists(t, sibling)
+	})
+}
+
 func TestCmdHooks_CreateWrappers(t *testing.T) {
 	hook := newMockHook("test", []string{"curl", "wget"})
 
@@ -298,6 +871,110 @@ func TestCmdHooks_CreateWrappers(t *testing.T) {
 	}
 }
 
+func TestCmdHooks_CreateWrappersCaseInsensitive(t *testing.T) {
+	exePath, err := os.Executable()
+	require.NoError(t, err)
+
+	ch, err := New(
+		WithHook(newMockHook("test", []string{"curl", "Curl", "wget"})),
+		WithWrapperPath([]string{exePath, "run"}),
+		WithCaseInsensitiveMatching(true),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	wrapperDir, cleanup, err := ch.createWrappers()
+	require.NoError(t, err)
+	defer cleanup()
+
+	// Names differing only in case share a single wrapper
+	entries, err := os.ReadDir(wrapperDir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.ElementsMatch(t, []string{"curl", "wget"}, names)
+
+	assert.Contains(t, ch.wrapperEnv(), "CMDHOOKS_CASE_INSENSITIVE=true")
+
+	// Any casing of bash is rejected when matching ignores case
+	ch.SetHook(newMockHook("test", []string{"BASH"}))
+	_, _, err = ch.createWrappers()
+	assert.Error(t, err)
+}
+
+func TestCmdHooks_CaseInsensitiveMulti(t *testing.T) {
+	exePath, err := os.Executable()
+	require.NoError(t, err)
+
+	multi := hook.NewMulti(newMockHook("test", []string{"curl"}))
+	ch, err := New(
+		WithHook(multi),
+		WithWrapperPath([]string{exePath, "run"}),
+		WithCaseInsensitiveMatching(true),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	assert.False(t, multi.CaseInsensitive, "the caller's Multi is not modified")
+	folded, ok := ch.GetHook().(*hook.Multi)
+	require.True(t, ok)
+	assert.True(t, folded.CaseInsensitive)
+	assert.Equal(t, multi.Hooks(), folded.Hooks())
+
+	replacement := hook.NewMulti(newMockHook("test", []string{"wget"}))
+	ch.SetHook(replacement)
+	assert.False(t, replacement.CaseInsensitive, "the caller's Multi is not modified")
+	folded, ok = ch.GetHook().(*hook.Multi)
+	require.True(t, ok)
+	assert.True(t, folded.CaseInsensitive)
+}
+
+func TestCmdHooks_CreateWrappersGlob(t *testing.T) {
+	binDir := t.TempDir()
+	for name, mode := range map[string]os.FileMode{
+		"git-lfs":   0755,
+		"git-crypt": 0755,
+		"git-notes": 0644, // not executable
+		"kubectl":   0755,
+		"bash":      0755,
+		"gitk":      0755,
+		// Would inject a line into the wrapper's header comment
+		"git-x\ntouch injected": 0755,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\n"), mode))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(binDir, "git-dir"), 0755))
+	t.Setenv("PATH", binDir)
+
+	exePath, err := os.Executable()
+	require.NoError(t, err)
+	ch, err := New(
+		WithHook(newMockHook("test", []string{"curl", "git-*", "ba*", "*ctl", "*"})),
+		WithWrapperPath([]string{exePath, "run"}),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	wrapperDir, cleanup, err := ch.createWrappers()
+	require.NoError(t, err)
+	defer cleanup()
+
+	entries, err := os.ReadDir(wrapperDir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	// Literal names are always wrapped, patterns wrap the matching
+	// executables on PATH except bash, and "*" wraps nothing itself
+	assert.ElementsMatch(t, []string{"curl", "git-crypt", "git-lfs", "kubectl"}, names)
+
+	_, err = New(WithHook(newMockHook("test", []string{"git-["})))
+	assert.Error(t, err, "malformed patterns are rejected")
+}
+
 func TestCmdHooks_CreateWrappersNoCommands(t *testing.T) {
 	// Hook with no commands should create empty wrapper dir
 	hook := newMockHook("test", []string{})
@@ -330,7 +1007,7 @@ func TestInterceptorAlwaysCreated(t *testing.T) {
 
 		// Interceptor is always created for consistent behavior
 		assert.NotNil(t, ch.interceptor)
-		assert.NotNil(t, ch.hook)
+		assert.NotNil(t, ch.GetHook())
 		assert.NotNil(t, ch.config)
 	})
 
@@ -342,7 +1019,7 @@ func TestInterceptorAlwaysCreated(t *testing.T) {
 
 		// IPCHook creates interceptor as before
 		assert.NotNil(t, ch.interceptor)
-		assert.NotNil(t, ch.hook)
+		assert.NotNil(t, ch.GetHook())
 		assert.NotNil(t, ch.config)
 	})
 
@@ -354,7 +1031,7 @@ func TestInterceptorAlwaysCreated(t *testing.T) {
 
 		// Interceptor is always created for consistent behavior
 		assert.NotNil(t, ch.interceptor)
-		assert.NotNil(t, ch.hook)
+		assert.NotNil(t, ch.GetHook())
 		assert.NotNil(t, ch.config)
 	})
 }
@@ -387,3 +1064,501 @@ func TestCmdHooks_CreateWrappersCustomPath(t *testing.T) {
 	assert.Contains(t, contentStr, "curl")
 	assert.Contains(t, contentStr, "#!/usr/bin/env bash")
 }
+
+func TestCmdHooks_SyslogUnavailable(t *testing.T) {
+	if _, err := interceptor.NewSyslog("cmdhooks-test"); err == nil {
+		t.Skip("a system log is available")
+	}
+
+	// An unreachable system log must not prevent execution
+	ch, err := New(
+		WithHook(newMockHook("test", []string{"curl"})),
+		WithSyslog("cmdhooks-test"),
+		WithInterceptorReuseAcrossExecute(),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+	assert.Nil(t, ch.syslog)
+
+	resp, err := roundTrip(ch.config.SocketPath, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.False(t, resp.Exit)
+}
+
+func TestCmdHooks_WithListener(t *testing.T) {
+	socketPath := fmt.Sprintf("/tmp/cmdhooks_test_%d.sock", time.Now().UnixNano())
+	defer os.Remove(socketPath)
+
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	ch, err := New(WithHook(newMockHook("test", []string{"curl"})), WithListener(listener))
+	require.NoError(t, err)
+	assert.Equal(t, socketPath, ch.config.SocketPath)
+
+	require.NoError(t, ch.interceptor.Start())
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	conn.Close()
+
+	require.NoError(t, ch.Close())
+	_, err = os.Stat(socketPath)
+	assert.NoError(t, err, "adopted socket file should not be removed")
+
+	t.Run("rejects non-unix listeners", func(t *testing.T) {
+		tcp, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer tcp.Close()
+		assert.Error(t, WithListener(tcp)(&Config{}))
+		assert.Error(t, WithListener(nil)(&Config{}))
+	})
+}
+
+func TestBlockedError(t *testing.T) {
+	assert.Equal(t, "execution terminated by user request", (&BlockedError{}).Error())
+	assert.Equal(t,
+		"execution terminated by user request: blocked curl https://example.com (time quota exceeded)",
+		(&BlockedError{Command: []string{"curl", "https://example.com"}, Reason: "time quota exceeded"}).Error())
+}
+
+func TestNew_ValidatesHookCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		wantErr  bool
+	}{
+		{"plain names", []string{"curl", "git-lfs", "python3.12"}, false},
+		{"no commands", []string{}, false},
+		{"slash", []string{"curl", "bin/curl"}, true},
+		{"absolute path", []string{"/etc/cron.d/x"}, true},
+		{"parent traversal", []string{"../evil"}, true},
+		{"dot dot", []string{".."}, true},
+		{"empty name", []string{""}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch, err := New(WithHook(newMockIPCHook("validate", tt.commands)))
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, ch)
+				return
+			}
+			require.NoError(t, err)
+			ch.Close()
+		})
+	}
+}
+
+func TestCmdHooks_CreateWrappersRejectsPathTraversal(t *testing.T) {
+	exePath, err := os.Executable()
+	require.NoError(t, err)
+
+	// Keep wrapper dirs under a private temp dir so escapes are observable
+	tmpRoot := t.TempDir()
+	t.Setenv("TMPDIR", tmpRoot)
+
+	ch, err := New(WithHook(newMockIPCHook("test", []string{"curl"})), WithWrapperPath([]string{exePath, "run"}))
+	require.NoError(t, err)
+	defer ch.Close()
+
+	for _, command := range []string{"../evil", "../../evil", "/etc/cron.d/x", "sub/dir", "..", "."} {
+		t.Run(command, func(t *testing.T) {
+			// SetHook bypasses the validation done by New
+			ch.SetHook(newMockIPCHook("malicious", []string{"curl", command}))
+
+			wrapperDir, cleanup, err := ch.createWrappers()
+			assert.Error(t, err)
+			assert.Empty(t, wrapperDir)
+			assert.Nil(t, cleanup)
+
+			// Nothing may be written outside the (removed) wrapper directory
+			_, statErr := os.Stat(filepath.Join(tmpRoot, "evil"))
+			assert.True(t, os.IsNotExist(statErr))
+			_, statErr = os.Stat(filepath.Join(filepath.Dir(tmpRoot), "evil"))
+			assert.True(t, os.IsNotExist(statErr))
+
+			entries, err := os.ReadDir(tmpRoot)
+			require.NoError(t, err)
+			assert.Empty(t, entries, "wrapper directory should be cleaned up")
+		})
+	}
+}
+
+// peakIPCHook allows every command after a delay, recording the peak number
+// of evaluations running at once
+type peakIPCHook struct {
+	delay   time.Duration
+	current atomic.Int32
+	peak    atomic.Int32
+}
+
+func (h *peakIPCHook) Name() string       { return "peak" }
+func (h *peakIPCHook) Commands() []string { return []string{"curl"} }
+func (h *peakIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	n := h.current.Add(1)
+	defer h.current.Add(-1)
+	for {
+		peak := h.peak.Load()
+		if n <= peak || h.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(h.delay)
+	return &hook.Response{}, nil
+}
+
+// roundTrip sends req to an interceptor socket and returns its response
+func roundTrip(socketPath string, req hook.Request) (hook.Response, error) {
+	var resp hook.Response
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return resp, err
+	}
+	defer conn.Close()
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+	if _, err := fmt.Fprintf(conn, "%s\n", data); err != nil {
+		return resp, err
+	}
+	scanner := bufio.NewScanner(conn)
+	if !scanner.Scan() {
+		return resp, fmt.Errorf("no response: %v", scanner.Err())
+	}
+	err = json.Unmarshal(scanner.Bytes(), &resp)
+	return resp, err
+}
+
+func TestCmdHooks_NoopHook(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		decisions []string
+	)
+	ch, err := New(
+		WithHook(policy.NewNoop()),
+		WithOnDecision(func(cmd []string, stage hook.HookType, exit bool, dur time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			decisions = append(decisions, fmt.Sprintf("%s %v exit=%t", stage, cmd, exit))
+		}),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+	require.NoError(t, ch.interceptor.Start())
+
+	for _, command := range [][]string{{"rm", "-rf", "/"}, {"curl", "example.com"}} {
+		resp, err := roundTrip(ch.config.SocketPath, hook.Request{Command: command, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+		assert.False(t, resp.Denied(), "%v", command)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"pre_run [rm -rf /] exit=false",
+		"pre_run [curl example.com] exit=false",
+	}, decisions, "requests still pass through the interceptor")
+	assert.Empty(t, ch.History())
+}
+
+func TestCmdHooks_SharedEvaluationLimiter(t *testing.T) {
+	h := &peakIPCHook{delay: 20 * time.Millisecond}
+	limiter := interceptor.NewLimiter(2)
+
+	var instances []*CmdHooks
+	for range 2 {
+		ch, err := New(WithHook(h), WithSharedEvaluationLimiter(limiter))
+		require.NoError(t, err)
+		defer ch.Close()
+		require.NoError(t, ch.interceptor.Start())
+		instances = append(instances, ch)
+	}
+
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func(ch *CmdHooks) {
+			defer wg.Done()
+			resp, err := roundTrip(ch.config.SocketPath, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+			assert.NoError(t, err)
+			assert.False(t, resp.Exit)
+		}(instances[n%2])
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), h.peak.Load(), "combined evaluations should not exceed the shared limit")
+
+	assert.Error(t, WithSharedEvaluationLimiter(nil)(&Config{}))
+}
+
+func TestCmdHooks_Stats(t *testing.T) {
+	ch, err := New(WithHook(newMockHook("test", []string{"curl"})))
+	require.NoError(t, err)
+	defer ch.Close()
+	require.NoError(t, ch.interceptor.Start())
+
+	var wg sync.WaitGroup
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := roundTrip(ch.config.SocketPath, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	stats := ch.Stats()
+	assert.Equal(t, 4, stats.Total)
+	assert.Zero(t, stats.Blocked)
+	assert.Zero(t, stats.Errors)
+	assert.Equal(t, 4, stats.Latency.Count)
+}
+
+func TestCmdHooks_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	ch, err := New(WithHook(newMockHook("test", []string{"curl"})), WithLogger(log.New(&buf, "", 0)))
+	require.NoError(t, err)
+	defer ch.Close()
+	require.NoError(t, ch.interceptor.Start())
+
+	_, err = roundTrip(ch.config.SocketPath, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Request CONTINUING: [curl]")
+
+	// Warnings from New go to the logger too
+	buf.Reset()
+	ch2, err := New(WithHook(newMockHook("empty", nil)), WithLogger(log.New(&buf, "", 0)))
+	require.NoError(t, err)
+	defer ch2.Close()
+	assert.Contains(t, buf.String(), "Warning: hook empty handles no commands; nothing will be intercepted\n")
+}
+
+func TestCmdHooks_PauseResume(t *testing.T) {
+	ch, err := New(
+		WithHook(newMockHook("test", []string{"curl"})),
+		WithInterceptorPauseResume(50*time.Millisecond, interceptor.TimeoutBlock),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+	require.NoError(t, ch.interceptor.Start())
+
+	// Held past the timeout: blocked without evaluation
+	ch.Pause()
+	resp, err := roundTrip(ch.config.SocketPath, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.True(t, resp.Exit)
+	assert.Equal(t, true, resp.Metadata[interceptor.MetadataPaused])
+
+	// Released by Resume: evaluated as usual
+	done := make(chan hook.Response, 1)
+	go func() {
+		resp, err := roundTrip(ch.config.SocketPath, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+		assert.NoError(t, err)
+		done <- resp
+	}()
+	time.Sleep(10 * time.Millisecond)
+	ch.Resume()
+	resp = <-done
+	assert.False(t, resp.Exit)
+}
+
+// deadlineIPCHook never decides before the evaluation deadline
+type deadlineIPCHook struct{}
+
+func (deadlineIPCHook) Name() string       { return "deadline" }
+func (deadlineIPCHook) Commands() []string { return []string{"curl"} }
+func (deadlineIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCmdHooks_TimeoutDecision(t *testing.T) {
+	for _, tt := range []struct {
+		decision interceptor.TimeoutDecision
+		wantExit bool
+	}{
+		{interceptor.TimeoutBlock, true},
+		{interceptor.TimeoutAllow, false},
+	} {
+		ch, err := New(
+			WithHook(deadlineIPCHook{}),
+			WithInterceptorTimeout(20*time.Millisecond),
+			WithTimeoutDecision(tt.decision),
+		)
+		require.NoError(t, err)
+		defer ch.Close()
+		require.NoError(t, ch.interceptor.Start())
+
+		resp, err := roundTrip(ch.config.SocketPath, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+		assert.Equal(t, tt.wantExit, resp.Exit)
+	}
+}
+
+// blockCurlIPCHook blocks curl and allows everything else
+type blockCurlIPCHook struct{}
+
+func (blockCurlIPCHook) Name() string       { return "block-curl" }
+func (blockCurlIPCHook) Commands() []string { return []string{"curl", "ls"} }
+func (blockCurlIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	return &hook.Response{Exit: req.Command[0] == "curl"}, nil
+}
+
+func TestCmdHooks_OnDecision(t *testing.T) {
+	type decision struct {
+		cmd   []string
+		stage hook.HookType
+		exit  bool
+	}
+	var (
+		mu        sync.Mutex
+		decisions []decision
+	)
+
+	ch, err := New(
+		WithHook(blockCurlIPCHook{}),
+		WithOnDecision(func(cmd []string, stage hook.HookType, exit bool, dur time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			decisions = append(decisions, decision{cmd, stage, exit})
+		}),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+	require.NoError(t, ch.interceptor.Start())
+
+	for _, req := range []hook.Request{
+		{Command: []string{"ls"}, Hook: hook.HookPreRun},
+		{Command: []string{"ls"}, Hook: hook.HookPostRun},
+		{Command: []string{"curl", "https://example.com"}, Hook: hook.HookPreRun},
+	} {
+		_, err := roundTrip(ch.config.SocketPath, req)
+		require.NoError(t, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []decision{
+		{[]string{"ls"}, hook.HookPreRun, false},
+		{[]string{"ls"}, hook.HookPostRun, false},
+		{[]string{"curl", "https://example.com"}, hook.HookPreRun, true},
+	}, decisions)
+
+	assert.Error(t, WithOnDecision(nil)(&Config{}))
+}
+
+// allowAllIPCHook allows every command
+type allowAllIPCHook struct{}
+
+func (allowAllIPCHook) Name() string       { return "allow-all" }
+func (allowAllIPCHook) Commands() []string { return []string{"curl", "ls"} }
+func (allowAllIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	return &hook.Response{}, nil
+}
+
+func TestCmdHooks_HookReloadEndpoint(t *testing.T) {
+	var next hook.Hook = allowAllIPCHook{}
+	ch, err := New(
+		WithHook(blockCurlIPCHook{}),
+		WithHookReloadEndpoint("s3cret", func() (hook.Hook, error) { return next, nil }),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+	require.NoError(t, ch.interceptor.Start())
+
+	curlBlocked := func() bool {
+		resp, err := roundTrip(ch.config.SocketPath, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+		return resp.Exit
+	}
+	require.True(t, curlBlocked())
+
+	_, err = wrapper.RequestReload("", ch.config.SocketPath, "guess")
+	assert.ErrorContains(t, err, "not authorized")
+	assert.True(t, curlBlocked())
+
+	name, err := wrapper.RequestReload("", ch.config.SocketPath, "s3cret")
+	require.NoError(t, err)
+	assert.Equal(t, "allow-all", name)
+	assert.False(t, curlBlocked(), "the reloaded policy takes effect")
+	assert.Equal(t, "allow-all", ch.GetHook().Name())
+
+	// Reloaded hooks are validated like the one given to New
+	next = newMockHook("bad", []string{"../curl"})
+	_, err = wrapper.RequestReload("", ch.config.SocketPath, "s3cret")
+	assert.ErrorContains(t, err, "must not contain path separators")
+	assert.Equal(t, "allow-all", ch.GetHook().Name())
+
+	assert.Error(t, WithHookReloadEndpoint("", func() (hook.Hook, error) { return next, nil })(&Config{}))
+	assert.Error(t, WithHookReloadEndpoint("s3cret", nil)(&Config{}))
+}
+
+func TestCmdHooks_Explain(t *testing.T) {
+	ch, err := New(
+		WithHooks(allowAllIPCHook{}, blockCurlIPCHook{}),
+		WithArgAllowlist(map[string][]string{"ls": {"-l"}}),
+		WithDecisionExplainMode(),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+	require.NoError(t, ch.interceptor.Start())
+
+	resp, steps, err := wrapper.RequestExplanation("", ch.config.SocketPath, []string{"curl", "example.com"})
+	require.NoError(t, err)
+	assert.True(t, resp.Exit)
+	assert.Equal(t, []hook.TraceStep{
+		{Stage: hook.TraceStageHook, Hook: "allow-all", Decision: hook.DecisionAllow},
+		{Stage: hook.TraceStageHook, Hook: "block-curl", Decision: hook.DecisionBlock},
+	}, steps)
+	assert.Zero(t, ch.Stats().Total, "explaining does not count as a request")
+
+	_, steps, err = wrapper.RequestExplanation("", ch.config.SocketPath, []string{"ls", "-l"})
+	require.NoError(t, err)
+	assert.Equal(t, []hook.TraceStep{{Stage: interceptor.TraceStageArgAllowlist, Decision: hook.DecisionAllow}}, steps)
+
+	// With the explain mode, live requests carry their trace too
+	live, err := roundTrip(ch.config.SocketPath, hook.Request{Command: []string{"ls", "-a"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	steps, err = hook.DecodeTrace(live.Metadata[hook.MetadataExplain])
+	require.NoError(t, err)
+	assert.Equal(t, []hook.TraceStep{
+		{Stage: hook.TraceStageHook, Hook: "allow-all", Decision: hook.DecisionAllow},
+		{Stage: hook.TraceStageHook, Hook: "block-curl", Decision: hook.DecisionAllow},
+	}, steps)
+}
+
+// workingDirIPCHook records the working directory of each request by hook
+// type
+type workingDirIPCHook struct {
+	mu   sync.Mutex
+	dirs map[hook.HookType]string
+}
+
+func (h *workingDirIPCHook) Name() string       { return "working-dir" }
+func (h *workingDirIPCHook) Commands() []string { return []string{"true"} }
+func (h *workingDirIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dirs[req.Hook] = req.WorkingDir
+	return &hook.Response{}, nil
+}
+
+func TestCmdHooks_RequestWorkingDir(t *testing.T) {
+	h := &workingDirIPCHook{dirs: make(map[hook.HookType]string)}
+	ch, err := New(WithHook(h))
+	require.NoError(t, err)
+	defer ch.Close()
+	require.NoError(t, ch.interceptor.Start())
+
+	dir := t.TempDir()
+	w := wrapper.NewWrapperCommand(nil, wrapper.WithSocketPath(ch.config.SocketPath), wrapper.WithWorkingDir(dir))
+	require.NoError(t, w.Run([]string{"true"}))
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	assert.Equal(t, map[hook.HookType]string{hook.HookPreRun: dir, hook.HookPostRun: dir}, h.dirs)
+}
@@ -0,0 +1,32 @@
+package cmdhooks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/executor"
+)
+
+// BlockedError is returned by Execute when a hook blocked a command and the
+// process tree was terminated
+type BlockedError struct {
+	// Command is the blocked command, if known
+	Command []string
+	// Reason is the reason given by the hook, if any
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	msg := "execution terminated by user request"
+	if len(e.Command) > 0 {
+		msg += ": blocked " + strings.Join(e.Command, " ")
+	}
+	if e.Reason != "" {
+		msg += fmt.Sprintf(" (%s)", e.Reason)
+	}
+	return msg
+}
+
+// ExitError is returned (wrapped) by Execute when the script ran to
+// completion but exited with a non-zero status. Use errors.As to retrieve it.
+type ExitError = executor.ExitError
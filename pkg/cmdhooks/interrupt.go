@@ -0,0 +1,84 @@
+package cmdhooks
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/executor"
+)
+
+// InterruptStage identifies a step in terminating the script after a block
+type InterruptStage string
+
+const (
+	// InterruptTerminating is reported once a hook blocked a command, before
+	// any signal is sent to the script
+	InterruptTerminating InterruptStage = "terminating"
+	// InterruptKilling is reported when the script outlived the grace
+	// period and is about to receive SIGKILL
+	InterruptKilling InterruptStage = "killing"
+	// InterruptExited is reported once the script has exited, or was given
+	// up on
+	InterruptExited InterruptStage = "exited"
+)
+
+// InterruptEvent describes a step in terminating the script after a block
+type InterruptEvent struct {
+	Stage InterruptStage
+	// Blocked describes the command that caused the termination; nil when
+	// the output line callback requested it
+	Blocked *BlockedError
+	// Grace is how long the script has after SIGTERM before it is killed
+	Grace time.Duration
+}
+
+// InterruptCallback receives the steps of terminating the script after a
+// block, in the order terminating, killing (only if the grace period ran
+// out) and exited. It is called synchronously, so the termination waits
+// for it to return.
+type InterruptCallback func(InterruptEvent)
+
+// terminate stops the script after a hook requested exit and waits for
+// Execute to return on execDone, reporting each step to the interrupt
+// callback
+func (c *CmdHooks) terminate(sb *executor.Executor, execDone <-chan error, blocked *BlockedError) {
+	grace := sb.KillGrace()
+	notify := func(stage InterruptStage) {
+		if c.config.OnInterrupt != nil {
+			c.config.OnInterrupt(InterruptEvent{Stage: stage, Blocked: blocked, Grace: grace})
+		}
+	}
+	notify(InterruptTerminating)
+
+	// Give a cooperating script the chance to shut down on its own
+	exited := false
+	if c.config.BlockSignal != 0 {
+		var err error
+		if exited, err = sb.SignalProcessTree(c.config.BlockSignal, DefaultBlockSignalGrace); err != nil {
+			c.printf("[ERROR] Failed to signal process tree: %v", err)
+		}
+	}
+
+	if !exited {
+		var err error
+		if exited, err = sb.SignalProcessTree(syscall.SIGTERM, grace); err != nil {
+			c.printf("[ERROR] Failed to signal process tree: %v", err)
+		}
+	}
+	if !exited {
+		notify(InterruptKilling)
+		if err := sb.ForceKillProcessTree(); err != nil {
+			c.printf("[ERROR] Failed to kill process tree: %v", err)
+		}
+	}
+
+	// Wait for execution to finish (should be quick after kill)
+	select {
+	case <-execDone:
+		// Execution finished after kill
+	case <-time.After(5 * time.Second):
+		// Timeout waiting for execution to finish
+		c.printf("[ERROR] Timeout waiting for process termination")
+	}
+	notify(InterruptExited)
+}
@@ -0,0 +1,94 @@
+package cmdhooks
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Output streams passed to an OutputLineCallback
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// OutputLineCallback receives each line the script writes to stream
+// (StreamStdout or StreamStderr), without its trailing newline. line is
+// only valid during the call. Returning an error terminates the script
+// like a blocked command, and Execute returns that error.
+type OutputLineCallback func(stream string, line []byte) error
+
+// lineOutput splits the script's output into lines for an
+// OutputLineCallback, passing the output through unchanged
+type lineOutput struct {
+	fn OutputLineCallback
+	// mu serializes callback calls across streams
+	mu sync.Mutex
+	// stopped receives the first callback error
+	stopped  chan error
+	stopOnce sync.Once
+
+	stdout, stderr *lineWriter
+}
+
+// newLineOutput creates the line splitter for a single execution, writing
+// through to stdout and stderr
+func newLineOutput(fn OutputLineCallback, stdout, stderr io.Writer) *lineOutput {
+	o := &lineOutput{fn: fn, stopped: make(chan error, 1)}
+	o.stdout = &lineWriter{out: o, stream: StreamStdout, dst: stdout}
+	o.stderr = &lineWriter{out: o, stream: StreamStderr, dst: stderr}
+	return o
+}
+
+// emit passes a line to the callback, reporting its first error on stopped
+func (o *lineOutput) emit(stream string, line []byte) {
+	o.mu.Lock()
+	err := o.fn(stream, line)
+	o.mu.Unlock()
+	if err != nil {
+		o.stopOnce.Do(func() { o.stopped <- err })
+	}
+}
+
+// flush emits unterminated last lines, once the script has exited
+func (o *lineOutput) flush() {
+	o.stdout.flush()
+	o.stderr.flush()
+}
+
+// lineWriter is the io.Writer of one stream
+type lineWriter struct {
+	out    *lineOutput
+	stream string
+	dst    io.Writer
+	// partial holds output after the last newline
+	partial []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	n, err := w.dst.Write(p)
+
+	data := p
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		line := data[:i]
+		if len(w.partial) > 0 {
+			line = append(w.partial, line...)
+			w.partial = w.partial[:0]
+		}
+		w.out.emit(w.stream, line)
+		data = data[i+1:]
+	}
+	w.partial = append(w.partial, data...)
+	return n, err
+}
+
+func (w *lineWriter) flush() {
+	if len(w.partial) > 0 {
+		w.out.emit(w.stream, w.partial)
+		w.partial = nil
+	}
+}
@@ -0,0 +1,72 @@
+package cmdhooks
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineOutput(t *testing.T) {
+	type line struct{ stream, text string }
+	var got []line
+	var stdout, stderr strings.Builder
+	o := newLineOutput(func(stream string, l []byte) error {
+		got = append(got, line{stream, string(l)})
+		return nil
+	}, &stdout, &stderr)
+
+	writes := []struct {
+		w    *lineWriter
+		data string
+	}{
+		{o.stdout, "first\nsec"},
+		{o.stderr, "oops\n"},
+		{o.stdout, "ond\n\nthird"},
+		{o.stderr, "partial"},
+	}
+	for _, wr := range writes {
+		n, err := wr.w.Write([]byte(wr.data))
+		require.NoError(t, err)
+		assert.Equal(t, len(wr.data), n)
+	}
+	o.flush()
+
+	assert.Equal(t, []line{
+		{StreamStdout, "first"},
+		{StreamStderr, "oops"},
+		{StreamStdout, "second"},
+		{StreamStdout, ""},
+		{StreamStdout, "third"},
+		{StreamStderr, "partial"},
+	}, got)
+	assert.Equal(t, "first\nsecond\n\nthird", stdout.String(), "output passes through unchanged")
+	assert.Equal(t, "oops\npartial", stderr.String())
+}
+
+func TestLineOutput_Stop(t *testing.T) {
+	errSecret := errors.New("secret printed")
+	var stdout strings.Builder
+	o := newLineOutput(func(stream string, line []byte) error {
+		if strings.Contains(string(line), "SECRET") {
+			return errSecret
+		}
+		return nil
+	}, &stdout, &stdout)
+
+	_, err := o.stdout.Write([]byte("hello\nSECRET=1\nSECRET=2\n"))
+	require.NoError(t, err)
+	select {
+	case err := <-o.stopped:
+		assert.Equal(t, errSecret, err)
+	default:
+		t.Fatal("callback error was not reported")
+	}
+	select {
+	case <-o.stopped:
+		t.Fatal("only the first callback error is reported")
+	default:
+	}
+}
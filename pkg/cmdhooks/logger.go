@@ -0,0 +1,24 @@
+package cmdhooks
+
+import "log"
+
+// logf writes verbose output to the configured logger, or to the standard
+// logger when verbose
+func (c *CmdHooks) logf(format string, v ...any) {
+	switch {
+	case c.config.Logger != nil:
+		c.config.Logger.Printf(format, v...)
+	case c.config.Verbose:
+		log.Printf(format, v...)
+	}
+}
+
+// printf writes output that is reported even when not verbose, to the
+// configured logger or the standard logger
+func (c *CmdHooks) printf(format string, v ...any) {
+	if c.config.Logger != nil {
+		c.config.Logger.Printf(format, v...)
+		return
+	}
+	log.Printf(format, v...)
+}
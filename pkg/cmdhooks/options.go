@@ -1,9 +1,18 @@
 package cmdhooks
 
 import (
+	"crypto/tls"
 	"fmt"
 	"github.com/codysoyland/cmdhooks/pkg/hook"
+	"github.com/codysoyland/cmdhooks/pkg/interceptor"
+	"github.com/codysoyland/cmdhooks/pkg/wrapper"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -15,6 +24,25 @@ func WithHook(h hook.Hook) Option {
 	}
 }
 
+// WithHooks sets several hooks for request evaluation, composed with
+// hook.NewMulti: each command is evaluated by the hooks that list it, in
+// the given order, stopping at the first that denies it. Wrappers are
+// created for the union of their commands.
+func WithHooks(hooks ...hook.Hook) Option {
+	return func(c *Config) error {
+		if len(hooks) == 0 {
+			return fmt.Errorf("WithHooks: no hooks given")
+		}
+		for i, h := range hooks {
+			if h == nil {
+				return fmt.Errorf("WithHooks: hook %d is nil", i)
+			}
+		}
+		c.Hook = hook.NewMulti(hooks...)
+		return nil
+	}
+}
+
 // WithVerbose enables or disables verbose output
 func WithVerbose(v bool) Option {
 	return func(c *Config) error {
@@ -59,3 +87,1088 @@ func WithInterceptorTimeout(d time.Duration) Option {
 		return nil
 	}
 }
+
+// WithTimeoutDecision sets what happens when a hook evaluation exceeds the
+// interceptor timeout: interceptor.TimeoutBlock (the default) blocks the
+// command, interceptor.TimeoutAllow lets it run. Use TimeoutAllow in
+// latency-sensitive deployments where a slow policy service should not stall
+// scripts. Only deadline failures are affected; other hook errors still
+// block.
+func WithTimeoutDecision(d interceptor.TimeoutDecision) Option {
+	return func(c *Config) error {
+		if d != interceptor.TimeoutBlock && d != interceptor.TimeoutAllow {
+			return fmt.Errorf("WithTimeoutDecision: unknown decision %d", d)
+		}
+		c.TimeoutDecision = d
+		return nil
+	}
+}
+
+// WithCommandRewriteLog records every command substitution made by a hook
+// (original and effective command, reason, and deciding hook) to w as
+// newline-delimited JSON. Writes are serialized and flushed per entry.
+func WithCommandRewriteLog(w io.Writer) Option {
+	return func(c *Config) error {
+		if w == nil {
+			return fmt.Errorf("WithCommandRewriteLog: writer cannot be nil")
+		}
+		c.RewriteLog = w
+		return nil
+	}
+}
+
+// WithMaxPendingExits bounds the number of exit requests retained in the
+// history returned by CmdHooks.History. Every exit request is still counted
+// in CmdHooks.Stats, even once older records have been dropped.
+func WithMaxPendingExits(n int) Option {
+	return func(c *Config) error {
+		if n <= 0 {
+			return fmt.Errorf("WithMaxPendingExits: limit must be positive, got %d", n)
+		}
+		c.MaxPendingExits = n
+		return nil
+	}
+}
+
+// WithWorkingDir runs every wrapped command in dir, regardless of where the
+// script has changed to. The directory must exist.
+func WithWorkingDir(dir string) Option {
+	return func(c *Config) error {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("WithWorkingDir: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("WithWorkingDir: %s is not a directory", dir)
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("WithWorkingDir: %w", err)
+		}
+		c.WorkingDir = abs
+		return nil
+	}
+}
+
+// WithCommandDeduplication reuses the previous decision for identical
+// requests (same stage and exact argv) arriving within window, protecting
+// expensive hooks from tight loops that invoke the same command repeatedly.
+func WithCommandDeduplication(window time.Duration) Option {
+	return func(c *Config) error {
+		if window < 0 {
+			return fmt.Errorf("WithCommandDeduplication: window cannot be negative")
+		}
+		c.DedupWindow = window
+		return nil
+	}
+}
+
+// WithChildUmask sets the file-creation mask (e.g. 0o077) applied to every
+// wrapped command, so files they create are not group- or world-readable.
+func WithChildUmask(mask int) Option {
+	return func(c *Config) error {
+		if mask < 0 || mask > 0o777 {
+			return fmt.Errorf("WithChildUmask: mask %#o out of range (0 to 0777)", mask)
+		}
+		c.ChildUmask = &mask
+		return nil
+	}
+}
+
+// WithCommandTimeouts sets how long wrapped commands may run, keyed by
+// command name, e.g. {"make": time.Hour, "curl": 30 * time.Second}. Keys may
+// be glob patterns ("terraform-*"); an exact name wins over a pattern, and
+// the "*" entry applies to commands matching nothing else. Commands with no
+// applicable entry run without a timeout.
+//
+// A command that exceeds its timeout receives SIGTERM, then SIGKILL after a
+// short grace period. It exits with code 124 and the post-run request carries
+// "timed_out" metadata.
+func WithCommandTimeouts(timeouts map[string]time.Duration) Option {
+	return func(c *Config) error {
+		if err := wrapper.ValidateCommandTimeouts(timeouts); err != nil {
+			return fmt.Errorf("WithCommandTimeouts: %w", err)
+		}
+		c.CommandTimeouts = make(map[string]time.Duration, len(timeouts))
+		for name, d := range timeouts {
+			c.CommandTimeouts[name] = d
+		}
+		return nil
+	}
+}
+
+// WithSocketType selects the kind of Unix socket used between wrappers and
+// the interceptor. interceptor.SocketStream (the default) frames messages
+// with newlines; interceptor.SocketSeqpacket (Linux only) sends each message
+// as one packet, preserving message boundaries without framing. It does not
+// apply to a listener given with WithListener.
+func WithSocketType(t interceptor.SocketType) Option {
+	return func(c *Config) error {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("WithSocketType: %w", err)
+		}
+		c.SocketType = t
+		return nil
+	}
+}
+
+// WithListener makes the interceptor serve on an already-bound Unix socket
+// listener instead of binding its own, enabling systemd socket activation or
+// binding before dropping privileges. A listener inherited as a file
+// descriptor can be adopted with net.FileListener(os.NewFile(fd, name)).
+//
+// Wrappers connect to the listener's address unless WithSocketPath is also
+// given. cmdhooks never removes the socket file of an adopted listener.
+func WithListener(l net.Listener) Option {
+	return func(c *Config) error {
+		if l == nil {
+			return fmt.Errorf("WithListener: listener cannot be nil")
+		}
+		if l.Addr().Network() != "unix" {
+			return fmt.Errorf("WithListener: listener must be a Unix socket, got %s", l.Addr().Network())
+		}
+		c.Listener = l
+		return nil
+	}
+}
+
+// WithTimeQuota caps the cumulative wall-clock time consumed by all wrapped
+// commands in a run. Each command's duration is counted when its post-run
+// hook is evaluated; once the quota is used up, further commands are blocked
+// at pre-run with a "time quota exceeded" reason. A command that is already
+// running is never interrupted, so the total may overshoot the quota by the
+// duration of the last command.
+func WithTimeQuota(d time.Duration) Option {
+	return func(c *Config) error {
+		if d <= 0 {
+			return fmt.Errorf("WithTimeQuota: quota must be positive")
+		}
+		c.TimeQuota = d
+		return nil
+	}
+}
+
+// WithResponseTransformer registers a function applied to every hook
+// response in the interceptor before it is sent back to the wrapper, for
+// example to add a warning or strip metadata. Returning nil keeps the
+// response unchanged. A transformer can turn an allow into a block only
+// through interceptor.BlockResponse, and can never turn a block into an
+// allow.
+func WithResponseTransformer(t interceptor.ResponseTransformer) Option {
+	return func(c *Config) error {
+		if t == nil {
+			return fmt.Errorf("WithResponseTransformer: transformer cannot be nil")
+		}
+		c.ResponseTransformers = append(c.ResponseTransformers, t)
+		return nil
+	}
+}
+
+// WithCommandNormalizationForAudit records commands in audit records (such
+// as History) in the canonical form produced by n, so the same command with
+// different transient arguments aggregates cleanly. Hooks still evaluate the
+// raw command. interceptor.NormalizeCommand is a ready-made normalizer that
+// keeps the command name and sorted flags and redacts all values.
+func WithCommandNormalizationForAudit(n interceptor.CommandNormalizer) Option {
+	return func(c *Config) error {
+		if n == nil {
+			return fmt.Errorf("WithCommandNormalizationForAudit: normalizer cannot be nil")
+		}
+		c.AuditNormalizer = n
+		return nil
+	}
+}
+
+// WithInterceptorReuseAcrossExecute starts the interceptor once in New and
+// keeps it running across Execute calls, stopping it only on Close. This
+// saves the socket setup and teardown for embedders that run many scripts.
+// The exit signal, progress and time quota usage are reset at the start of
+// every Execute and wrappers are still generated per execution. Execute calls must not overlap.
+func WithInterceptorReuseAcrossExecute() Option {
+	return func(c *Config) error {
+		c.PersistentInterceptor = true
+		return nil
+	}
+}
+
+// WithCaseInsensitiveMatching matches invoked command names against the
+// hook's commands ignoring case, so a policy listing "curl" also covers
+// "Curl". Enable it on case-insensitive filesystems (the macOS and Windows
+// defaults), where both names run the same binary. Matching is
+// case-sensitive by default, which is correct on most Unix filesystems.
+func WithCaseInsensitiveMatching(enabled bool) Option {
+	return func(c *Config) error {
+		c.CaseInsensitiveMatching = enabled
+		return nil
+	}
+}
+
+// WithDecisionLog records every hook decision to w as newline-delimited
+// JSON, including the metadata the hook attached to its response (a risk
+// score, a matched rule id, ...) so the reasoning behind each allow or block
+// is preserved. Use WithMetadataRedactor to mask sensitive values.
+func WithDecisionLog(w io.Writer) Option {
+	return func(c *Config) error {
+		if w == nil {
+			return fmt.Errorf("WithDecisionLog: writer cannot be nil")
+		}
+		c.DecisionLog = w
+		return nil
+	}
+}
+
+// WithMetadataRedactor masks response metadata before it is written to audit
+// records (the decision log and History). The response delivered to the
+// wrapper is unaffected. interceptor.RedactKeys covers the common case.
+func WithMetadataRedactor(r interceptor.MetadataRedactor) Option {
+	return func(c *Config) error {
+		if r == nil {
+			return fmt.Errorf("WithMetadataRedactor: redactor cannot be nil")
+		}
+		c.MetadataRedactor = r
+		return nil
+	}
+}
+
+// WithPreRunConfirmationCache lets interactive hooks approve a command for
+// the rest of a run: when a pre-run allow carries
+// hook.MetadataRememberForRun set to true, later invocations of the same
+// command name skip the hook and are allowed until the current Execute
+// finishes. Blocks are never remembered.
+func WithPreRunConfirmationCache() Option {
+	return func(c *Config) error {
+		c.RememberDecisions = true
+		return nil
+	}
+}
+
+// WithProgressReporter calls fn periodically while a script executes with
+// the number of commands evaluated, started and completed and the commands
+// currently running, as a heartbeat for long-running scripts. fn runs on its
+// own goroutine and never delays command evaluation. A final report is
+// delivered when execution ends.
+func WithProgressReporter(fn func(interceptor.Progress)) Option {
+	return func(c *Config) error {
+		if fn == nil {
+			return fmt.Errorf("WithProgressReporter: reporter cannot be nil")
+		}
+		c.ProgressReporter = fn
+		return nil
+	}
+}
+
+// WithProgressInterval sets how often the progress reporter is called
+// (default DefaultProgressInterval)
+func WithProgressInterval(d time.Duration) Option {
+	return func(c *Config) error {
+		if d <= 0 {
+			return fmt.Errorf("WithProgressInterval: interval must be positive")
+		}
+		c.ProgressInterval = d
+		return nil
+	}
+}
+
+// WithRequestEnricher registers a function that adds context to every
+// request (usually via req.Metadata) before the hook evaluates it.
+// Enrichers run in the interceptor process in registration order.
+func WithRequestEnricher(e interceptor.Enricher) Option {
+	return func(c *Config) error {
+		if e == nil {
+			return fmt.Errorf("WithRequestEnricher: enricher cannot be nil")
+		}
+		c.Enrichers = append(c.Enrichers, e)
+		return nil
+	}
+}
+
+// WithProcfsEnricher attaches process-tree context read from /proc to every
+// request: the ancestor command chain ("ancestors") and cgroup ("cgroup").
+// This lets a hook allow a command only under a particular parent, such as a
+// CI runner. Only supported on Linux.
+func WithProcfsEnricher() Option {
+	return func(c *Config) error {
+		e, err := interceptor.ProcfsEnricher()
+		if err != nil {
+			return fmt.Errorf("WithProcfsEnricher: %w", err)
+		}
+		c.Enrichers = append(c.Enrichers, e)
+		return nil
+	}
+}
+
+// WithRequestFieldAllowlist limits the request fields that wrappers send to
+// the interceptor, for privacy when an IPC hook forwards requests to a
+// remote service. Fields are named as in wrapper.AllowRequestFields, e.g.
+// "command_name" to send argv[0] without arguments, or "metadata.timed_out"
+// for a single metadata key. The hook type is always sent. Unlike
+// WithMetadataRedactor, which masks audit records, this controls what
+// crosses the IPC boundary, so the hook never sees the omitted fields.
+func WithRequestFieldAllowlist(fields ...string) Option {
+	return func(c *Config) error {
+		if err := wrapper.ValidateRequestFields(fields); err != nil {
+			return fmt.Errorf("WithRequestFieldAllowlist: %w", err)
+		}
+		c.RequestFieldAllowlist = append([]string{}, fields...)
+		return nil
+	}
+}
+
+// WithArgv0SpoofingDetection resolves every wrapped command to the binary it
+// will actually run and attaches the result to the request metadata:
+// hook.MetadataResolvedPath always, and hook.MetadataArgv0Mismatch (true)
+// when argv[0]'s basename differs from the resolved binary's, for example a
+// "git" on PATH that is a symlink to another program. This is a lightweight
+// anti-masquerading signal; hooks decide whether to block or alert on it.
+func WithArgv0SpoofingDetection() Option {
+	return func(c *Config) error {
+		c.Argv0Check = true
+		return nil
+	}
+}
+
+// WithSharedEvaluationLimiter bounds concurrent hook evaluations with l.
+// Pass the same Limiter to several CmdHooks instances to cap the total
+// number of evaluations across all of them, e.g. in a host running many
+// scripts at once. Requests wait for a free slot within the interceptor
+// timeout and are blocked if none frees up in time.
+func WithSharedEvaluationLimiter(l *interceptor.Limiter) Option {
+	return func(c *Config) error {
+		if l == nil {
+			return fmt.Errorf("WithSharedEvaluationLimiter: limiter cannot be nil")
+		}
+		c.EvaluationLimiter = l
+		return nil
+	}
+}
+
+// WithTranscript records every command the script executes to w as
+// newline-delimited JSON (see interceptor.TranscriptEntry): the full argv,
+// working directory, environment snapshot, exit code, duration and SHA-256
+// digests of stdout and stderr, so a run can be replayed or diffed against
+// another. It is heavier than WithDecisionLog and meant for reproducibility
+// and debugging. Environment variables are passed through the
+// WithMetadataRedactor redactor by name, and the snapshot is capped at
+// wrapper.MaxTranscriptEnvBytes per command.
+func WithTranscript(w io.Writer) Option {
+	return func(c *Config) error {
+		if w == nil {
+			return fmt.Errorf("WithTranscript: writer cannot be nil")
+		}
+		c.Transcript = w
+		return nil
+	}
+}
+
+// WithEvaluationAttribution records the provenance of every decision under
+// the hook.MetadataAttribution response metadata key: which stage decided
+// (the wrapper's LocalHook, the interceptor's IPCHook, or the default allow)
+// and what each stage returned. Decisions that reach the interceptor carry
+// the attribution into the decision log and exit history; commands blocked
+// by a LocalHook never reach the interceptor and are only attributed in the
+// wrapper's verbose output.
+func WithEvaluationAttribution() Option {
+	return func(c *Config) error {
+		c.EvaluationAttribution = true
+		return nil
+	}
+}
+
+// WithCommandScratchDirs gives every wrapped command a private, freshly
+// created temp directory, exported to the command as CMDHOOKS_SCRATCH and
+// passed to hooks as the "scratch_dir" request metadata. The directory is
+// removed once the command and its post-run hook have finished, on every
+// exit path, so post-run hooks can inspect what the command left there.
+func WithCommandScratchDirs() Option {
+	return func(c *Config) error {
+		c.ScratchDirs = true
+		return nil
+	}
+}
+
+// WithPreRunBatchEvaluation lets hooks reason about a shell command list as
+// a whole. Before bash runs a line holding several commands, such as
+// `a && b | c`, the line is sent as a single hook.HookPreRunBatch request
+// whose Batch field holds every command; blocking it terminates the script
+// before any of them runs. Each command is still evaluated individually
+// when it runs, so hooks that ignore batch requests are unaffected.
+//
+// Batching works through a BASH_ENV startup file, so it covers bash scripts
+// and `bash -c` strings, but not other shells. The file installs a DEBUG
+// trap with functrace enabled and shadows the trap builtin, so a DEBUG trap
+// the script sets runs after the batch hook rather than replacing it; `trap
+// -p DEBUG` shows only the shim's trap. A BASH_ENV already set in the
+// environment is still sourced.
+func WithPreRunBatchEvaluation() Option {
+	return func(c *Config) error {
+		c.PreRunBatchEvaluation = true
+		return nil
+	}
+}
+
+// DefaultBlockSignalGrace is how long a script signalled by WithBlockSignal
+// has to exit before its process tree is killed
+const DefaultBlockSignalGrace = 5 * time.Second
+
+// WithBlockSignal sends sig to the script's process group when a hook
+// requests exit, instead of starting with SIGTERM. This lets a cooperating
+// script or supervisor, for example one trapping SIGUSR1, handle the block
+// gracefully. If the script has not exited DefaultBlockSignalGrace after the
+// signal, the usual SIGTERM/SIGKILL sequence follows.
+func WithBlockSignal(sig syscall.Signal) Option {
+	return func(c *Config) error {
+		if sig <= 0 {
+			return fmt.Errorf("WithBlockSignal: invalid signal %d", sig)
+		}
+		c.BlockSignal = sig
+		return nil
+	}
+}
+
+// WithOnDecision calls fn after every decision the interceptor makes, with
+// the command (normalized by WithCommandNormalizationForAudit, if set), the hook stage,
+// whether the request was blocked and how long the decision took. It is a
+// lightweight hook point for metrics and telemetry that needs no hook or
+// collector. fn runs on the request path before the wrapper receives the
+// decision, so it must return quickly; it may be called concurrently.
+// Commands blocked by a LocalHook never reach the interceptor.
+func WithOnDecision(fn interceptor.DecisionCallback) Option {
+	return func(c *Config) error {
+		if fn == nil {
+			return fmt.Errorf("WithOnDecision: callback cannot be nil")
+		}
+		c.OnDecision = fn
+		return nil
+	}
+}
+
+// WithCommandPathShadowWarning warns on stderr when New is called with a hook
+// whose commands include common shell builtins, such as echo, test or [.
+// Scripts run builtins without a PATH lookup, so their wrappers only see
+// invocations that go through a binary (env echo, find -exec test, ...), and
+// the hook's coverage is weaker than its command list suggests.
+func WithCommandPathShadowWarning() Option {
+	return func(c *Config) error {
+		c.ShadowWarning = true
+		return nil
+	}
+}
+
+// WithExecuteOutputHashing attaches the SHA-256 digests of every wrapped
+// command's stdout and stderr to its post-run request metadata, under
+// hook.MetadataStdoutSHA256 and hook.MetadataStderrSHA256, so a policy can
+// compare output against expected hashes without the output itself
+// crossing the IPC boundary. The digests are computed while the output is
+// captured and cover all of it, whatever its size.
+func WithExecuteOutputHashing() Option {
+	return func(c *Config) error {
+		c.OutputHashing = true
+		return nil
+	}
+}
+
+// WithEvaluateHookForPreAndPostSharingConnection makes each wrapper keep its
+// IPC connection open while its command runs, so the command's pre-run and
+// post-run requests are evaluated on the same connection. IPC hooks can
+// then stash state at pre-run and read it back at post-run through
+// hook.ConnectionStateFromContext, without external storage. Both requests
+// also carry the same Request.RequestID, which is set with or without this
+// option.
+func WithEvaluateHookForPreAndPostSharingConnection() Option {
+	return func(c *Config) error {
+		c.SharedConnection = true
+		return nil
+	}
+}
+
+// WithStartupSelfTest checks the wrapper→interceptor→hook pipeline before
+// each run. One wrapper is run in self-test mode with the script's
+// environment; it sends a canary request that the interceptor evaluates with
+// the hook and confirms. The hook's decision is not acted on. If the wrapper
+// cannot be started, cannot reach the interceptor, or gets no confirmation,
+// Execute returns a *SelfTestError and the script is not started, so a
+// misconfigured WithWrapperPath fails loudly instead of letting commands run
+// unchecked.
+func WithStartupSelfTest() Option {
+	return func(c *Config) error {
+		c.SelfTest = true
+		return nil
+	}
+}
+
+// WithPerCommandTimeQuota caps the cumulative wall-clock time each listed
+// command may consume in a run, e.g. {"git": 5 * time.Minute, "curl":
+// time.Minute}. Quotas are keyed by command name and accounted like
+// WithTimeQuota: once a command has used up its quota, further invocations
+// of that command are blocked at pre-run with a "time quota for <name>
+// exceeded" reason, while other commands keep running. Both options can be
+// combined.
+func WithPerCommandTimeQuota(quotas map[string]time.Duration) Option {
+	return func(c *Config) error {
+		if len(quotas) == 0 {
+			return fmt.Errorf("WithPerCommandTimeQuota: no quotas given")
+		}
+		for name, d := range quotas {
+			if name == "" {
+				return fmt.Errorf("WithPerCommandTimeQuota: empty command name")
+			}
+			if d <= 0 {
+				return fmt.Errorf("WithPerCommandTimeQuota: quota for %q must be positive", name)
+			}
+		}
+		c.PerCommandTimeQuotas = make(map[string]time.Duration, len(quotas))
+		for name, d := range quotas {
+			c.PerCommandTimeQuotas[name] = d
+		}
+		return nil
+	}
+}
+
+// WithExecuteDeadlineHeadroom reserves d of each command timeout set with
+// WithCommandTimeouts for post-run evaluation. A command that runs up to its
+// timeout is asked to stop d before the deadline instead of at it, so
+// post-run hooks still get to evaluate the result within the timeout rather
+// than being starved by a command that used all of it. The headroom is
+// capped at half of each timeout, and has no effect on commands without one.
+func WithExecuteDeadlineHeadroom(d time.Duration) Option {
+	return func(c *Config) error {
+		if d <= 0 {
+			return fmt.Errorf("WithExecuteDeadlineHeadroom: headroom must be positive")
+		}
+		c.DeadlineHeadroom = d
+		return nil
+	}
+}
+
+// WithCommandResultEnvExport gives the script a way to read hook decisions
+// beyond a command's exit code. The script's environment gets
+// CMDHOOKS_RESULT_FILE, naming a file that each wrapper rewrites after
+// every hook evaluation with KEY='value' lines the script can source:
+//
+//	CMDHOOKS_RESULT_COMMAND   the wrapped command line
+//	CMDHOOKS_RESULT_STAGE     pre_run, or post_run once the command ran
+//	CMDHOOKS_RESULT_BLOCKED   true if the hook requested termination
+//	CMDHOOKS_RESULT_REASON    the hook's reason
+//	CMDHOOKS_RESULT_WARNINGS  the hook's "warnings" metadata, joined by "; "
+//	CMDHOOKS_RESULT_EXIT_CODE the command's exit code (post_run only)
+//
+// The file describes the last wrapped command. A script may point
+// CMDHOOKS_RESULT_FILE elsewhere for a single command, e.g.
+// CMDHOOKS_RESULT_FILE=./git.env git pull.
+func WithCommandResultEnvExport() Option {
+	return func(c *Config) error {
+		c.ResultEnvExport = true
+		return nil
+	}
+}
+
+// WithEvaluateUsingUnixCredentialsForRateLimit charges WithTimeQuota and
+// WithPerCommandTimeQuota budgets per user instead of per interceptor, so a
+// shared interceptor serving several users enforces each user's limits
+// independently. Users are identified by the UID the kernel reports for the
+// connecting wrapper (SO_PEERCRED, Linux only), which is exposed to hooks as
+// hook.Request.PeerUID; requests without verified credentials share one
+// budget. For rate limits on command starts, compose the hook with
+// policy.NewPerUserRateLimiter.
+func WithEvaluateUsingUnixCredentialsForRateLimit() Option {
+	return func(c *Config) error {
+		c.QuotaPerUser = true
+		return nil
+	}
+}
+
+// WithHookReloadEndpoint lets operators swap the policy of a running
+// interceptor from outside the process. A reload control message on the
+// socket (see wrapper.RequestReload and `cmdhooks reload`) carrying token
+// makes the interceptor call load, e.g. to re-read a policy file, and
+// evaluate later requests with the returned hook. The new hook is validated
+// like the one given to WithHook; if loading or validation fails, the
+// current hook stays in place and the error is returned to the client.
+// Messages with any other token are refused.
+//
+// Wrapper scripts are created from the hook's commands when Execute
+// starts, so a reload changes decisions immediately but the set of
+// intercepted commands only from the next Execute. Use a long random token;
+// anyone who can reach the socket and knows it controls the policy.
+func WithHookReloadEndpoint(token string, load interceptor.HookLoader) Option {
+	return func(c *Config) error {
+		if token == "" {
+			return fmt.Errorf("WithHookReloadEndpoint: token must not be empty")
+		}
+		if load == nil {
+			return fmt.Errorf("WithHookReloadEndpoint: loader cannot be nil")
+		}
+		c.ReloadToken = token
+		c.HookLoader = load
+		return nil
+	}
+}
+
+// WithExecutePreservingControllingTerminal runs the command as the
+// foreground process group of the controlling terminal when stdout is a
+// TTY, so interactive job control (shells, editors, pagers, Ctrl-Z) works
+// as it would outside cmdhooks. The command still gets its own process
+// group, and the terminal is handed back when it exits.
+//
+// The trade-off: keystrokes such as Ctrl-C and Ctrl-Z reach only the
+// command, not this process, and a command that moves its children into
+// process groups of their own puts them out of reach of the process-tree
+// kill used on exit signals and timeouts. Without a TTY on stdout the
+// option has no effect.
+func WithExecutePreservingControllingTerminal() Option {
+	return func(c *Config) error {
+		c.Foreground = true
+		return nil
+	}
+}
+
+// WithCommandWrapperForInterpretedScripts lets hooks see the script behind
+// an interpreter command: for `python foo.py`, pre-run and post-run
+// requests carry hook.MetadataScriptPath (the absolute path of foo.py) and
+// hook.MetadataScriptSHA256 (the digest of its contents), so policies can
+// judge the script and not just the interpreter. Only commands the hook
+// intercepts are inspected.
+//
+// interpreters maps interpreter names to the position of the script among
+// their non-option arguments (see wrapper.WithInterpretedScripts). Pass nil
+// to use wrapper.DefaultInterpreters, which covers python, node, ruby and
+// bash.
+func WithCommandWrapperForInterpretedScripts(interpreters map[string]int) Option {
+	return func(c *Config) error {
+		if interpreters == nil {
+			interpreters = wrapper.DefaultInterpreters
+		}
+		if len(interpreters) == 0 {
+			return fmt.Errorf("WithCommandWrapperForInterpretedScripts: no interpreters given")
+		}
+		if err := wrapper.ValidateInterpreters(interpreters); err != nil {
+			return fmt.Errorf("WithCommandWrapperForInterpretedScripts: %w", err)
+		}
+		c.Interpreters = make(map[string]int, len(interpreters))
+		for name, pos := range interpreters {
+			c.Interpreters[name] = pos
+		}
+		return nil
+	}
+}
+
+// WithExecuteInterruptGrace sets how long the script has to exit after
+// SIGTERM when a hook blocks a command, before its process tree receives
+// SIGKILL (default executor.DefaultKillGrace), and reports the termination
+// to fn, if not nil: first InterruptTerminating before any signal is sent,
+// then InterruptKilling if the grace period runs out, and finally
+// InterruptExited. fn runs synchronously, so an embedder can flush its UI
+// or state before the script is signalled.
+func WithExecuteInterruptGrace(grace time.Duration, fn InterruptCallback) Option {
+	return func(c *Config) error {
+		if grace <= 0 {
+			return fmt.Errorf("WithExecuteInterruptGrace: grace must be positive")
+		}
+		c.InterruptGrace = grace
+		c.OnInterrupt = fn
+		return nil
+	}
+}
+
+// WithEvaluationForExitCodePostRunGating lets wrappers skip the post-run
+// request of a command entirely when the hook implements hook.PostRunFilter
+// and does not want that exit code, saving a round trip, e.g. on the
+// success path of a hook that only analyses failures. The hook's answers for
+// exit codes 0 to 255 are captured when Execute starts.
+//
+// Skipped requests never reach the interceptor, so they are missing from
+// decision callbacks, transcripts and history, and do not count towards
+// time quotas. Without this option the interceptor still answers unwanted
+// post-run requests without calling the hook, but wrappers send them.
+func WithEvaluationForExitCodePostRunGating() Option {
+	return func(c *Config) error {
+		c.PostRunGating = true
+		return nil
+	}
+}
+
+// WithRequestCompressionNegotiation lets wrappers and the interceptor agree
+// to gzip the IPC messages of a connection, for hooks exchanging large
+// requests or responses. Compression is negotiated in the first exchange of
+// each connection and applies to the later ones only, so it pays off
+// together with WithEvaluateHookForPreAndPostSharingConnection, where the
+// post-run request, which carries the captured output metadata, travels
+// compressed. Wrappers that do not offer compression keep talking plain
+// JSON.
+func WithRequestCompressionNegotiation() Option {
+	return func(c *Config) error {
+		c.RequestCompression = true
+		return nil
+	}
+}
+
+// WithChaos enables chaos testing, for checking that the policies and
+// scripts around wrapped commands cope with blocks and failures. It is
+// meant for test environments only, never for production. Each allowed
+// pre-run evaluation of one of commands ("*" for all) has probability rate
+// of being replaced by an injected fault: either a block, surfaced like any
+// hook denial, or a failure, where the command is skipped and reports exit
+// code 1. Injected decisions carry hook.MetadataChaos. Chaos testing is off
+// unless this option is given, and New prints a warning when it is on.
+func WithChaos(rate float64, commands []string) Option {
+	return func(c *Config) error {
+		if rate <= 0 || rate > 1 {
+			return fmt.Errorf("WithChaos: rate must be in (0, 1], got %v", rate)
+		}
+		if len(commands) == 0 {
+			return fmt.Errorf("WithChaos: no commands selected; use \"*\" for all commands")
+		}
+		c.ChaosRate = rate
+		c.ChaosCommands = commands
+		return nil
+	}
+}
+
+// WithExecuteOutputLineCallback passes each line of the script's stdout and
+// stderr to fn as it is written, for live analysis such as spotting a
+// secret being printed. The output still reaches os.Stdout and os.Stderr.
+// An error returned by fn terminates the script, as if a hook had blocked
+// a command, and Execute returns it. Wrapped commands buffer their output
+// until they exit, so their lines arrive when each command finishes. The
+// script's stdout is no longer the terminal, which disables
+// WithExecutePreservingControllingTerminal.
+func WithExecuteOutputLineCallback(fn OutputLineCallback) Option {
+	return func(c *Config) error {
+		if fn == nil {
+			return fmt.Errorf("WithExecuteOutputLineCallback: callback cannot be nil")
+		}
+		c.OutputLineCallback = fn
+		return nil
+	}
+}
+
+// WithInterceptorBindRetry retries binding the interceptor socket up to
+// retries more times, waiting backoff and doubling it after each attempt,
+// while the OS reports the address in use or unavailable. This smooths
+// quick successive runs that reuse a fixed WithSocketPath, as in tests and
+// short-lived invocations. Conflicts that outlast the retries still fail.
+func WithInterceptorBindRetry(retries int, backoff time.Duration) Option {
+	return func(c *Config) error {
+		if retries <= 0 {
+			return fmt.Errorf("WithInterceptorBindRetry: retries must be positive, got %d", retries)
+		}
+		if backoff <= 0 {
+			return fmt.Errorf("WithInterceptorBindRetry: backoff must be positive, got %v", backoff)
+		}
+		c.BindRetries = retries
+		c.BindBackoff = backoff
+		return nil
+	}
+}
+
+// WithScriptExtensionPolicy decides commands by the extension of the script
+// they run, e.g. {".sh": interceptor.ScriptEvaluate} sends every shell
+// script to the hook for approval, and {".py": interceptor.ScriptBlock}
+// blocks Python scripts outright. Scripts are found behind the interpreters
+// of WithCommandWrapperForInterpretedScripts (wrapper.DefaultInterpreters
+// if that option is not given), which are wrapped even if the hook does not
+// list them, and in commands invoked by a path such as ./deploy.sh. bash
+// itself cannot be wrapped, so `bash script.sh` run by the script is only
+// seen when the hook wraps a command that starts it.
+//
+// At pre-run, ScriptAllow and ScriptBlock decide without the hook, and
+// ScriptEvaluate defers to it. Commands the hook does not list that run no
+// matching script are allowed without consulting the hook; commands it
+// lists are evaluated as usual.
+func WithScriptExtensionPolicy(actions map[string]interceptor.ScriptAction) Option {
+	return func(c *Config) error {
+		if len(actions) == 0 {
+			return fmt.Errorf("WithScriptExtensionPolicy: no extensions given")
+		}
+		c.ScriptExtensions = make(map[string]interceptor.ScriptAction, len(actions))
+		for ext, action := range actions {
+			if strings.Trim(ext, ".") == "" {
+				return fmt.Errorf("WithScriptExtensionPolicy: empty extension")
+			}
+			switch action {
+			case interceptor.ScriptEvaluate, interceptor.ScriptAllow, interceptor.ScriptBlock:
+			default:
+				return fmt.Errorf("WithScriptExtensionPolicy: unknown action %d for %s", action, ext)
+			}
+			c.ScriptExtensions[ext] = action
+		}
+		return nil
+	}
+}
+
+// WithExecuteTimingBreakdown calls fn once every wrapped command has
+// completed its post-run evaluation, with the time spent in each phase:
+// pre-run evaluation (including IPC), command execution and post-run
+// evaluation, for telling policy latency from command latency. Replaying
+// the command's output comes after the last request and is only logged,
+// with WithVerbose. Measuring adds a few clock reads per command. fn runs on
+// the request path, so it must return quickly; it may be called
+// concurrently. Commands blocked at pre-run, or with post-run evaluation
+// skipped, are not reported.
+func WithExecuteTimingBreakdown(fn interceptor.TimingCallback) Option {
+	return func(c *Config) error {
+		if fn == nil {
+			return fmt.Errorf("WithExecuteTimingBreakdown: callback cannot be nil")
+		}
+		c.TimingCallback = fn
+		return nil
+	}
+}
+
+// WithSocketGroupAccess gives the interceptor socket to group gid with mode
+// 0660, instead of the default owner-only 0600, so commands running as a
+// different user that shares the group can reach it, such as a sidecar
+// container in the same pod. When cmdhooks creates the socket directory, it
+// is given to the group too, with search-only access. Every member of the
+// group can then request decisions, so New warns about the wider access.
+// It does not apply to a listener given with WithListener.
+func WithSocketGroupAccess(gid int) Option {
+	return func(c *Config) error {
+		if gid < 0 {
+			return fmt.Errorf("WithSocketGroupAccess: invalid group ID %d", gid)
+		}
+		c.SocketGroup = &gid
+		return nil
+	}
+}
+
+// WithSchedule tags every request with the bucket of s its evaluation time
+// falls in, under the "time_bucket" metadata key, so a hook can, for
+// example, deny deployments outside business hours (see
+// interceptor.BusinessHours) without time logic of its own. The tag is
+// added by an enricher, after those registered before it.
+func WithSchedule(s *interceptor.Schedule) Option {
+	return func(c *Config) error {
+		if s == nil {
+			return fmt.Errorf("WithSchedule: schedule cannot be nil")
+		}
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("WithSchedule: %w", err)
+		}
+		c.Enrichers = append(c.Enrichers, interceptor.ScheduleEnricher(s))
+		return nil
+	}
+}
+
+// WithSyslog sends every decision to the local system log, tagged with tag
+// (default "cmdhooks"), so blocks land alongside other security events.
+// Blocked commands are logged at warning severity and allowed ones at info.
+// If the system log is unreachable, a warning is printed and execution
+// continues without it. Syslog is not supported on Windows.
+func WithSyslog(tag string) Option {
+	return func(c *Config) error {
+		if tag == "" {
+			tag = "cmdhooks"
+		}
+		c.SyslogTag = tag
+		return nil
+	}
+}
+
+// WithTLS encrypts and authenticates the IPC channel. The interceptor
+// serves TLS configured by server, which must provide its certificate; set
+// server.ClientCAs to require wrappers to present a certificate signed by
+// one of them. Wrappers connect with the certificates in client, which are
+// checked here so mistakes surface before any command runs. TLS requires
+// the stream socket type.
+func WithTLS(server *tls.Config, client wrapper.TLSClientFiles) Option {
+	return func(c *Config) error {
+		if server == nil {
+			return fmt.Errorf("WithTLS: server config cannot be nil")
+		}
+		if len(server.Certificates) == 0 && server.GetCertificate == nil && server.GetConfigForClient == nil {
+			return fmt.Errorf("WithTLS: server config has no certificate")
+		}
+		// Wrappers may run in another working directory
+		for _, path := range []*string{&client.CAFile, &client.CertFile, &client.KeyFile} {
+			if *path == "" {
+				continue
+			}
+			abs, err := filepath.Abs(*path)
+			if err != nil {
+				return fmt.Errorf("WithTLS: %w", err)
+			}
+			*path = abs
+		}
+		if _, err := client.Load(); err != nil {
+			return fmt.Errorf("WithTLS: %w", err)
+		}
+		c.TLSConfig = server
+		c.WrapperTLS = client
+		return nil
+	}
+}
+
+// WithArgAllowlist allows commands whose arguments begin with one of the
+// prefixes listed for them without evaluating the hook, e.g.
+// {"git": {"status", "diff"}} lets `git status -s` run without a round trip
+// to the hook while `git push` is evaluated as usual. Prefixes are compared
+// word by word and commands by basename. Only pre-run evaluation is skipped;
+// the hook still sees post-run requests for allowlisted commands.
+func WithArgAllowlist(allowlist map[string][]string) Option {
+	return func(c *Config) error {
+		if len(allowlist) == 0 {
+			return fmt.Errorf("WithArgAllowlist: no commands given")
+		}
+		c.ArgAllowlist = make(map[string][]string, len(allowlist))
+		for command, prefixes := range allowlist {
+			if command == "" || strings.ContainsRune(command, '/') {
+				return fmt.Errorf("WithArgAllowlist: invalid command name %q", command)
+			}
+			if len(prefixes) == 0 {
+				return fmt.Errorf("WithArgAllowlist: no prefixes given for %s", command)
+			}
+			for _, prefix := range prefixes {
+				if strings.TrimSpace(prefix) == "" {
+					return fmt.Errorf("WithArgAllowlist: empty prefix for %s", command)
+				}
+			}
+			c.ArgAllowlist[command] = slices.Clone(prefixes)
+		}
+		return nil
+	}
+}
+
+// WithExecuteCapturingCombinedTranscriptOrder captures every wrapped
+// command's stdout and stderr in a single transcript that tags each chunk
+// with its stream, instead of in separate files. Output is replayed to the
+// matching stream in the order it was written, and post-run hooks receive
+// the transcript path under hook.MetadataOutputTranscript, from which
+// hook.ReadOutputTranscript recovers the combined output or either stream.
+func WithExecuteCapturingCombinedTranscriptOrder() Option {
+	return func(c *Config) error {
+		c.OutputTranscript = true
+		return nil
+	}
+}
+
+// WithLogger sends the diagnostic output of cmdhooks and its interceptor to
+// l instead of the standard logger, so it can be captured or silenced per
+// instance. Verbose output is written to l even without WithVerbose.
+// Wrappers run in their own processes and keep logging to their stderr
+// (see wrapper.WithLogger for running a wrapper in process).
+func WithLogger(l hook.Logger) Option {
+	return func(c *Config) error {
+		if l == nil {
+			return fmt.Errorf("WithLogger: logger cannot be nil")
+		}
+		c.Logger = l
+		return nil
+	}
+}
+
+// WithInterceptorPauseResume configures how requests are held while the
+// interceptor is paused with CmdHooks.Pause: each request waits up to
+// timeout for CmdHooks.Resume, after which decision applies to it,
+// interceptor.TimeoutBlock blocking it and interceptor.TimeoutAllow letting
+// it run without evaluation. Without this option, requests wait up to
+// interceptor.DefaultPauseTimeout and are then blocked.
+func WithInterceptorPauseResume(timeout time.Duration, decision interceptor.TimeoutDecision) Option {
+	return func(c *Config) error {
+		if timeout <= 0 {
+			return fmt.Errorf("WithInterceptorPauseResume: timeout must be positive")
+		}
+		if decision != interceptor.TimeoutBlock && decision != interceptor.TimeoutAllow {
+			return fmt.Errorf("WithInterceptorPauseResume: unknown decision %d", decision)
+		}
+		c.PauseTimeout = timeout
+		c.PauseDecision = decision
+		return nil
+	}
+}
+
+// WithDecisionExplainMode returns, with every decision, the trace of the
+// rules and hooks consulted to reach it and what each decided, under
+// hook.MetadataExplain in the response metadata (decode it with
+// hook.DecodeTrace). Without this option, only requests setting
+// hook.MetadataExplain to true are traced; `cmdhooks explain` asks for a
+// trace without running the command.
+func WithDecisionExplainMode() Option {
+	return func(c *Config) error {
+		c.ExplainDecisions = true
+		return nil
+	}
+}
+
+// WithStreamingOutput shows each wrapped command's output as it is written,
+// instead of buffering it to temp files and replaying it once the command
+// has finished, so long-running commands are not silent until they exit.
+// Post-run hooks still receive the captured output files.
+func WithStreamingOutput() Option {
+	return func(c *Config) error {
+		c.StreamOutput = true
+		return nil
+	}
+}
+
+// WithExecuteForwardingExitStatusForSignaledChildren makes a wrapped
+// command killed by a signal exit with the shell-style status 128 plus the
+// signal number, e.g. 137 for SIGKILL, so the calling script can tell what
+// happened. Without it such commands exit with status 255.
+//
+// It is opt-in because post-run hooks see the same status: by default they
+// receive -1 for a signal kill, which no command can exit with, and
+// post-run exit code gating always lets it through. With this option a
+// signal kill looks like an ordinary exit status such as 137.
+func WithExecuteForwardingExitStatusForSignaledChildren() Option {
+	return func(c *Config) error {
+		c.SignalExitStatus = true
+		return nil
+	}
+}
+
+// WithPTY runs each wrapped command under a pseudo-terminal when the
+// script's stdin is a terminal, so interactive programs such as ssh and git
+// keep their prompts and colors (see wrapper.WithPTY). Post-run hooks
+// receive everything the command wrote as its stdout.
+func WithPTY() Option {
+	return func(c *Config) error {
+		c.PTY = true
+		return nil
+	}
+}
+
+// WithFailureMode sets what happens to a wrapped command when its wrapper
+// cannot reach the interceptor, e.g. because the socket was removed or the
+// interceptor stopped: wrapper.FailOpen runs it unchecked and
+// wrapper.FailClosed blocks it. Without this option the wrapper fails with
+// an error and the command does not run.
+func WithFailureMode(mode wrapper.FailureMode) Option {
+	return func(c *Config) error {
+		if mode != wrapper.FailOpen && mode != wrapper.FailClosed {
+			return fmt.Errorf("WithFailureMode: unknown mode %v", mode)
+		}
+		c.FailureMode = mode
+		return nil
+	}
+}
+
+// WithEnvAllowlist forwards the named environment variables, as each
+// wrapped command inherits them, to hooks in Request.Env (see
+// wrapper.WithEnvAllowlist). No environment is sent without it, so secrets
+// stay out of requests unless listed.
+func WithEnvAllowlist(names ...string) Option {
+	return func(c *Config) error {
+		for _, name := range names {
+			if name == "" || strings.ContainsAny(name, "=,") {
+				return fmt.Errorf("WithEnvAllowlist: invalid variable name %q", name)
+			}
+		}
+		c.EnvAllowlist = append([]string{}, names...)
+		return nil
+	}
+}
+
+// WithConnectionTimeout closes IPC connections that take longer than d to
+// send their request or to accept the response (see
+// interceptor.SetConnectionTimeout), so a hung or malicious client cannot
+// hold a connection open indefinitely. Zero disables the timeout.
+func WithConnectionTimeout(d time.Duration) Option {
+	return func(c *Config) error {
+		if d < 0 {
+			return fmt.Errorf("WithConnectionTimeout: timeout cannot be negative")
+		}
+		c.ConnectionTimeout = d
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package cmdhooks
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+	"github.com/codysoyland/cmdhooks/pkg/wrapper"
+)
+
+// wrappedCommands returns the commands to write wrappers for: the literal
+// names the hook lists, the executables on PATH matching its glob patterns,
+// and the interpreters the script extension policy inspects. "*" is never
+// expanded, since wrapping every executable would also wrap the shell and
+// the tools wrappers rely on; it matches whatever the other entries wrap.
+func (c *CmdHooks) wrappedCommands() []string {
+	var commands, patterns []string
+	for _, command := range c.GetHook().Commands() {
+		switch {
+		case command == "*":
+		case hook.IsCommandPattern(command):
+			patterns = append(patterns, command)
+		default:
+			commands = append(commands, command)
+		}
+	}
+	commands = append(commands, c.scriptPolicyCommands()...)
+	return append(commands, pathCommands(patterns, os.Getenv("PATH"), c.config.CaseInsensitiveMatching)...)
+}
+
+// pathCommands returns the names of the executables in the directories of
+// pathList that match one of patterns, sorted. bash is left out, since
+// wrapper scripts run under it, as are names unsafe for a wrapper file.
+func pathCommands(patterns []string, pathList string, caseInsensitive bool) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	found := make(map[string]bool)
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if found[name] || name == "bash" || wrapper.ValidateCommandName(name) != nil || !hook.HandlesCommand(patterns, name, caseInsensitive) {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(dir, name)); err != nil || !info.Mode().IsRegular() || info.Mode()&0111 == 0 {
+				continue
+			}
+			found[name] = true
+		}
+	}
+
+	commands := make([]string, 0, len(found))
+	for name := range found {
+		commands = append(commands, name)
+	}
+	sort.Strings(commands)
+	return commands
+}
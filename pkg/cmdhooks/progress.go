@@ -0,0 +1,71 @@
+package cmdhooks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/interceptor"
+)
+
+// DefaultProgressInterval is how often the progress reporter is called
+// unless configured otherwise
+const DefaultProgressInterval = time.Second
+
+// Progress returns a snapshot of the current execution's progress
+func (c *CmdHooks) Progress() interceptor.Progress {
+	return c.interceptor.Progress()
+}
+
+// startProgressReporter calls the configured progress reporter periodically
+// until stopProgressReporter is called, which also delivers a final report.
+// The reporter runs on its own goroutine, so a slow reporter never delays
+// command evaluation; ticks that arrive while it is busy are dropped.
+func (c *CmdHooks) startProgressReporter() {
+	report := c.config.ProgressReporter
+	if report == nil {
+		return
+	}
+	interval := c.config.ProgressInterval
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				report(c.interceptor.Progress())
+			case <-done:
+				report(c.interceptor.Progress())
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+		})
+	}
+	c.mu.Lock()
+	c.stopProgress = stop
+	c.mu.Unlock()
+}
+
+// stopProgressReporter stops a running progress reporter, if any
+func (c *CmdHooks) stopProgressReporter() {
+	c.mu.Lock()
+	stop := c.stopProgress
+	c.stopProgress = nil
+	c.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
@@ -0,0 +1,27 @@
+package cmdhooks
+
+import (
+	"fmt"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+	"github.com/codysoyland/cmdhooks/pkg/interceptor"
+)
+
+// validatedLoader checks hooks loaded for a reload like the hook passed to
+// New, so a bad policy push leaves the current hook in place. Warnings go
+// to printf.
+func validatedLoader(load interceptor.HookLoader, printf func(format string, v ...any)) interceptor.HookLoader {
+	return func() (hook.Hook, error) {
+		h, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if h == nil {
+			return nil, fmt.Errorf("loader returned no hook")
+		}
+		if err := validateHook(h, printf); err != nil {
+			return nil, err
+		}
+		return h, nil
+	}
+}
@@ -0,0 +1,44 @@
+package cmdhooks
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/wrapper"
+)
+
+// interpreters returns the interpreters wrappers inspect for scripts: those
+// configured, or the defaults when only the script extension policy needs
+// them
+func (c *CmdHooks) interpreters() map[string]int {
+	if len(c.config.Interpreters) == 0 && len(c.config.ScriptExtensions) > 0 {
+		return wrapper.DefaultInterpreters
+	}
+	return c.config.Interpreters
+}
+
+// scriptPolicyCommands returns the interpreters to wrap for the script
+// extension policy that the hook does not already list. bash is left out,
+// since wrapper scripts run under it.
+func (c *CmdHooks) scriptPolicyCommands() []string {
+	if len(c.config.ScriptExtensions) == 0 {
+		return nil
+	}
+	listed := make(map[string]bool)
+	for _, command := range c.GetHook().Commands() {
+		listed[command] = true
+		if c.config.CaseInsensitiveMatching {
+			listed[strings.ToLower(command)] = true
+		}
+	}
+
+	var commands []string
+	for name := range c.interpreters() {
+		if name == "bash" || listed[name] || (c.config.CaseInsensitiveMatching && listed[strings.ToLower(name)]) {
+			continue
+		}
+		commands = append(commands, name)
+	}
+	sort.Strings(commands)
+	return commands
+}
@@ -0,0 +1,72 @@
+package cmdhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/executor"
+	"github.com/codysoyland/cmdhooks/pkg/wrapper"
+)
+
+// DefaultSelfTestTimeout bounds the startup self-test. It is generous since
+// a wrapper run through `go run` compiles on first use.
+const DefaultSelfTestTimeout = 30 * time.Second
+
+// SelfTestError is returned by Execute when the startup self-test enabled by
+// WithStartupSelfTest fails. The script is not started.
+type SelfTestError struct {
+	// Command is the canary command whose wrapper was run
+	Command string
+	// Output is the wrapper's combined stdout and stderr
+	Output string
+	// Err is the underlying failure
+	Err error
+}
+
+func (e *SelfTestError) Error() string {
+	msg := fmt.Sprintf("startup self-test failed for wrapper %q: %v", e.Command, e.Err)
+	if e.Output != "" {
+		msg += fmt.Sprintf("\nwrapper output:\n%s", e.Output)
+	}
+	return msg
+}
+
+func (e *SelfTestError) Unwrap() error {
+	return e.Err
+}
+
+// runSelfTest runs the wrapper for the hook's first wrapped command in
+// self-test mode, with the environment the script will get. The wrapper
+// sends a canary request to the interceptor, which evaluates it with the
+// hook and confirms it without acting on the decision. Hooks without
+// wrapped commands have no wrappers, so there is nothing to test.
+func (c *CmdHooks) runSelfTest(sb *executor.Executor, wrapperDir string) error {
+	commands := c.wrappedCommands()
+	if len(commands) == 0 {
+		return nil
+	}
+	command := filepath.Base(commands[0])
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultSelfTestTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, filepath.Join(wrapperDir, command))
+	cmd.Env = append(sb.Environ(), wrapper.EnvSelfTest+"=true")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %v", DefaultSelfTestTimeout)
+		}
+		return &SelfTestError{
+			Command: command,
+			Output:  strings.TrimSpace(string(output)),
+			Err:     err,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package cmdhooks
+
+import "os"
+
+// shareSocketDir gives dir to group gid with search-only access, so group
+// members can open the socket inside without listing or changing the
+// directory
+func shareSocketDir(dir string, gid int) error {
+	if err := os.Chown(dir, -1, gid); err != nil {
+		return err
+	}
+	return os.Chmod(dir, 0710)
+}
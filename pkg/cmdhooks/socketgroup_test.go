@@ -0,0 +1,38 @@
+//go:build !windows
+
+package cmdhooks
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdHooks_SocketGroupAccess(t *testing.T) {
+	gid := os.Getgid()
+	if os.Geteuid() == 0 {
+		gid = 4242
+	}
+
+	ch, err := New(
+		WithHook(newMockHook("test", []string{"curl"})),
+		WithSocketGroupAccess(gid),
+		WithInterceptorReuseAcrossExecute(),
+	)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	for path, mode := range map[string]os.FileMode{
+		ch.config.SocketPath:               0660,
+		filepath.Dir(ch.config.SocketPath): 0710,
+	} {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, mode, info.Mode().Perm(), path)
+		assert.Equal(t, uint32(gid), info.Sys().(*syscall.Stat_t).Gid, path)
+	}
+}
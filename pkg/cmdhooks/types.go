@@ -1,9 +1,15 @@
 package cmdhooks
 
 import (
+	"crypto/tls"
 	"github.com/codysoyland/cmdhooks/pkg/executor"
 	"github.com/codysoyland/cmdhooks/pkg/hook"
 	"github.com/codysoyland/cmdhooks/pkg/interceptor"
+	"github.com/codysoyland/cmdhooks/pkg/wrapper"
+	"io"
+	"net"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -12,11 +18,21 @@ type CmdHooks struct {
     config      *Config
     interceptor *interceptor.Interceptor
     executor    *executor.Executor
-    hook        hook.Hook
     // socketDir holds the temporary directory created to host the
     // Unix domain socket (to keep path length short). Empty if user
     // provided a custom SocketPath.
     socketDir   string
+
+	// mu guards stopProgress, and config.Hook after New
+	mu sync.Mutex
+	// stopProgress stops the progress reporter of the running execution
+	stopProgress func()
+	// lines splits the output of the running execution for the output
+	// line callback, if one is set
+	lines *lineOutput
+	// syslog receives every decision if WithSyslog is set and the system
+	// log was reachable
+	syslog *interceptor.Syslog
 }
 
 // Config holds all configuration options
@@ -32,6 +48,188 @@ type Config struct {
     // InterceptorTimeout bounds IPC evaluation inside the interceptor process.
     // If zero or negative, no timeout is applied (default behavior).
     InterceptorTimeout time.Duration
+	// RewriteLog receives one JSON line per command substitution made by a
+	// hook. Nil disables rewrite auditing.
+	RewriteLog io.Writer
+	// MaxPendingExits bounds how many exit requests are retained in the
+	// interceptor history. Zero uses interceptor.DefaultMaxExitHistory.
+	MaxPendingExits int
+	// WorkingDir, if set, is the directory every wrapped command runs in
+	WorkingDir string
+	// DedupWindow, if positive, reuses the decision for identical requests
+	// (same stage and exact argv) arriving within the window.
+	DedupWindow time.Duration
+	// ChildUmask, if set, is the file-creation mask for wrapped commands
+	ChildUmask *int
+	// Enrichers add context to each request before the hook evaluates it
+	Enrichers []interceptor.Enricher
+	// CommandTimeouts limits how long wrapped commands may run, keyed by
+	// command name or glob pattern, with "*" as the default
+	CommandTimeouts map[string]time.Duration
+	// Listener, if set, is an already-bound Unix socket listener the
+	// interceptor serves on instead of creating its own socket
+	Listener net.Listener
+	// TimeQuota, if positive, caps the cumulative wall-clock time of all
+	// wrapped commands in a run
+	TimeQuota time.Duration
+	// PerCommandTimeQuotas caps the cumulative wall-clock time of each
+	// listed command in a run, keyed by command name
+	PerCommandTimeQuotas map[string]time.Duration
+	// ResponseTransformers adjust every hook response before it is sent
+	// back to the wrapper
+	ResponseTransformers []interceptor.ResponseTransformer
+	// AuditNormalizer, if set, canonicalizes commands recorded in audit
+	// records such as History; hooks still see the raw command
+	AuditNormalizer interceptor.CommandNormalizer
+	// PersistentInterceptor starts the interceptor once in New and keeps it
+	// running across Execute calls until Close
+	PersistentInterceptor bool
+	// CaseInsensitiveMatching matches command names ignoring case
+	CaseInsensitiveMatching bool
+	// DecisionLog receives one JSON line per hook decision, including the
+	// response metadata. Nil disables decision logging.
+	DecisionLog io.Writer
+	// MetadataRedactor masks sensitive response metadata in audit records
+	MetadataRedactor interceptor.MetadataRedactor
+	// RememberDecisions lets hooks approve a command for the rest of a run
+	// via hook.MetadataRememberForRun
+	RememberDecisions bool
+	// ProgressReporter, if set, is called periodically during execution
+	// with a snapshot of the run's progress
+	ProgressReporter func(interceptor.Progress)
+	// ProgressInterval is how often ProgressReporter is called. Zero uses
+	// DefaultProgressInterval.
+	ProgressInterval time.Duration
+	// RequestFieldAllowlist, if non-nil, limits IPC requests sent by the
+	// wrappers to these fields (see wrapper.AllowRequestFields)
+	RequestFieldAllowlist []string
+	// Argv0Check flags commands whose argv[0] does not match the binary
+	// they resolve to
+	Argv0Check bool
+	// EvaluationLimiter bounds concurrent hook evaluations; it may be shared
+	// across instances
+	EvaluationLimiter *interceptor.Limiter
+	// TimeoutDecision is applied when a hook evaluation exceeds
+	// InterceptorTimeout. The zero value blocks.
+	TimeoutDecision interceptor.TimeoutDecision
+	// Transcript receives one JSON line per executed command, with its
+	// environment, exit code and output digests. Nil disables transcripts.
+	Transcript io.Writer
+	// SocketType selects stream (default) or seqpacket IPC
+	SocketType interceptor.SocketType
+	// EvaluationAttribution records which evaluation stage decided each
+	// request
+	EvaluationAttribution bool
+	// ScratchDirs gives each wrapped command a private temp directory
+	ScratchDirs bool
+	// PreRunBatchEvaluation sends each bash command list to the hooks as
+	// one pre-run batch request before its commands run
+	PreRunBatchEvaluation bool
+	// BlockSignal, if non-zero, is sent to the script's process group when
+	// a hook requests exit, before falling back to the kill sequence
+	BlockSignal syscall.Signal
+	// OnDecision is called by the interceptor after every decision
+	OnDecision interceptor.DecisionCallback
+	// ShadowWarning warns at setup about hook commands that are commonly
+	// shell builtins
+	ShadowWarning bool
+	// OutputHashing attaches SHA-256 digests of each command's stdout and
+	// stderr to post-run requests
+	OutputHashing bool
+	// SharedConnection sends each command's pre-run and post-run requests
+	// over one IPC connection
+	SharedConnection bool
+	// SelfTest sends a canary request through a wrapper before each run
+	SelfTest bool
+	// DeadlineHeadroom is the part of each command timeout reserved for
+	// post-run evaluation
+	DeadlineHeadroom time.Duration
+	// ResultEnvExport has wrappers write each hook decision to a file the
+	// script can source, named by CMDHOOKS_RESULT_FILE
+	ResultEnvExport bool
+	// QuotaPerUser applies the time quotas to each verified peer UID
+	// separately
+	QuotaPerUser bool
+	// ReloadToken and HookLoader enable hook reloads requested over the
+	// socket
+	ReloadToken string
+	HookLoader  interceptor.HookLoader
+	// Foreground runs the command in the terminal's foreground process
+	// group when stdout is the controlling terminal
+	Foreground bool
+	// Interpreters enables script detection for the listed interpreters,
+	// mapped to the position of the script argument
+	Interpreters map[string]int
+	// InterruptGrace is how long the script has to exit after SIGTERM when
+	// a hook blocks a command; zero uses executor.DefaultKillGrace
+	InterruptGrace time.Duration
+	// OnInterrupt is told about each step of terminating the script after
+	// a block
+	OnInterrupt InterruptCallback
+	// PostRunGating lets wrappers skip post-run requests for exit codes the
+	// hook does not want (see hook.PostRunFilter)
+	PostRunGating bool
+	// RequestCompression lets wrappers and the interceptor compress the
+	// later messages of each IPC connection
+	RequestCompression bool
+	// ChaosRate and ChaosCommands enable fault injection for chaos testing
+	// (see interceptor.SetChaos)
+	ChaosRate     float64
+	ChaosCommands []string
+	// OutputLineCallback receives each line of the script's output as it
+	// is written
+	OutputLineCallback OutputLineCallback
+	// BindRetries and BindBackoff retry transient socket bind failures
+	// (see interceptor.SetBindRetry)
+	BindRetries int
+	BindBackoff time.Duration
+	// ScriptExtensions decides commands by the extension of the script
+	// they run (see interceptor.SetScriptExtensionPolicy)
+	ScriptExtensions map[string]interceptor.ScriptAction
+	// TimingCallback receives the per-phase latencies of every command
+	TimingCallback interceptor.TimingCallback
+	// SocketGroup, if set, is the group granted access to the socket
+	SocketGroup *int
+	// SyslogTag, if set, sends every decision to the system log tagged
+	// with it
+	SyslogTag string
+	// TLSConfig, if set, makes the interceptor serve TLS, and WrapperTLS
+	// locates the files wrappers connect with
+	TLSConfig  *tls.Config
+	WrapperTLS wrapper.TLSClientFiles
+	// ArgAllowlist lists argument prefixes allowed without consulting the
+	// hook, by command (see interceptor.SetArgAllowlist)
+	ArgAllowlist map[string][]string
+	// OutputTranscript captures each command's stdout and stderr in one
+	// ordered, stream-tagged transcript
+	OutputTranscript bool
+	// Logger, if set, receives the diagnostic output of cmdhooks and the
+	// interceptor
+	Logger hook.Logger
+	// PauseTimeout and PauseDecision bound how long requests are held
+	// while the interceptor is paused (see interceptor.SetPauseTimeout)
+	PauseTimeout  time.Duration
+	PauseDecision interceptor.TimeoutDecision
+	// ExplainDecisions attaches the decision trace to every response (see
+	// interceptor.SetExplain)
+	ExplainDecisions bool
+	// StreamOutput shows each command's output as it is written instead of
+	// once the command has finished
+	StreamOutput bool
+	// SignalExitStatus reports wrapped commands killed by a signal with
+	// exit status 128 plus the signal number
+	SignalExitStatus bool
+	// PTY runs wrapped commands under a pseudo-terminal when the script's
+	// stdin is a terminal
+	PTY bool
+	// FailureMode decides what happens to wrapped commands when the
+	// interceptor cannot be reached
+	FailureMode wrapper.FailureMode
+	// EnvAllowlist names the environment variables wrappers forward to
+	// hooks in each request
+	EnvAllowlist []string
+	// ConnectionTimeout bounds each read and write on an IPC connection
+	ConnectionTimeout time.Duration
 }
 
 // Option represents a functional option for configuration
@@ -0,0 +1,48 @@
+package cmdhooks
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+	"github.com/codysoyland/cmdhooks/pkg/wrapper"
+)
+
+// validateHook checks a hook before anything runs. Command names that cannot
+// safely be used as wrapper filenames are an error; an empty command list or
+// a hook that implements neither LocalHook nor IPCHook is legal but almost
+// certainly a mistake, so it only produces a warning through printf.
+func validateHook(h hook.Hook, printf func(format string, v ...any)) error {
+	// A composite is checked hook by hook, since it implements both
+	// interfaces whatever its members do
+	if m, ok := h.(*hook.Multi); ok {
+		for _, member := range m.Hooks() {
+			if err := validateHook(member, printf); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	commands := h.Commands()
+	for _, command := range commands {
+		if err := wrapper.ValidateCommandName(command); err != nil {
+			return fmt.Errorf("hook %s: %w", h.Name(), err)
+		}
+		if _, err := path.Match(command, ""); err != nil {
+			return fmt.Errorf("hook %s: invalid command pattern %q: %w", h.Name(), command, err)
+		}
+	}
+
+	if len(commands) == 0 {
+		printf("Warning: hook %s handles no commands; nothing will be intercepted", h.Name())
+	}
+
+	_, isLocal := h.(hook.LocalHook)
+	_, isIPC := h.(hook.IPCHook)
+	if !isLocal && !isIPC {
+		printf("Warning: hook %s implements neither LocalHook nor IPCHook; all commands will be allowed", h.Name())
+	}
+
+	return nil
+}
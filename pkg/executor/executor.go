@@ -4,6 +4,7 @@ package executor
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -12,14 +13,31 @@ import (
 	"time"
 )
 
+// ExitError reports that the executed command ran but exited with a
+// non-zero status
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("execution exited with code %d", e.Code)
+}
+
 // Executor manages script execution with network interception
 type Executor struct {
 	command     []string
 	socketPath  string
 	wrapperPath string
-	verbose     bool         // Verbose mode flag
-	process     *exec.Cmd    // The running process
-	mu          sync.RWMutex // Protects process access
+	verbose     bool          // Verbose mode flag
+	env         []string      // Additional environment entries for the command
+	foreground  bool          // Run in the terminal's foreground process group
+	killGrace   time.Duration // Wait between SIGTERM and SIGKILL
+	stdout      io.Writer     // Destination of the command's stdout, if not os.Stdout
+	stderr      io.Writer     // Destination of the command's stderr, if not os.Stderr
+	process     *exec.Cmd     // The running process
+	tree        processTree   // The running process and its descendants
+	done        chan struct{} // Closed once the running process has been waited on
+	mu          sync.RWMutex  // Protects process and done access
 }
 
 // New creates a new executor instance
@@ -40,6 +58,19 @@ func (s *Executor) SetVerbose(verbose bool) {
 	s.verbose = verbose
 }
 
+// SetEnv sets additional KEY=VALUE environment entries passed to the command
+func (s *Executor) SetEnv(env []string) {
+	s.env = env
+}
+
+// SetOutput sets where the command's stdout and stderr are written; nil
+// keeps os.Stdout or os.Stderr. Redirecting stdout disables SetForeground,
+// since the command no longer writes to the terminal.
+func (s *Executor) SetOutput(stdout, stderr io.Writer) {
+	s.stdout = stdout
+	s.stderr = stderr
+}
+
 // Execute runs the command in the executor environment
 func (s *Executor) Execute() error {
 	if s.wrapperPath == "" {
@@ -51,45 +82,68 @@ func (s *Executor) Execute() error {
 	}
 
 	cmd := exec.Command(s.command[0], s.command[1:]...)
+	cmd.Env = s.Environ()
 
-	// Build environment
-	env := os.Environ()
-	env = s.modifyPath(env)
-	env = append(env, fmt.Sprintf("CMDHOOKS_SOCKET=%s", s.socketPath))
-	// Provide exact wrapper directory marker so wrappers can clean PATH precisely
-	if s.wrapperPath != "" {
-		env = append(env, fmt.Sprintf("CMDHOOKS_WRAPPER_DIR=%s", s.wrapperPath))
-	}
-	if s.verbose {
-		env = append(env, "CMDHOOKS_VERBOSE=true")
+	ttyFd, foreground := -1, false
+	if s.foreground && s.stdout == nil {
+		ttyFd, foreground = controllingTerminal()
 	}
-	cmd.Env = env
-
 	// Set up process group for proper tree killing
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true, // Create new process group
-	}
+	cmd.SysProcAttr = processGroupAttr(foreground, ttyFd)
 
 	// Connect standard streams
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	if s.stdout != nil {
+		cmd.Stdout = s.stdout
+	}
+	if s.stderr != nil {
+		cmd.Stderr = s.stderr
+	}
 
-	// Store process reference for termination
+	if err := cmd.Start(); err != nil {
+		if foreground {
+			// The child may have taken the terminal before exec failed
+			restoreForeground(ttyFd)
+		}
+		return fmt.Errorf("failed to execute: %w", err)
+	}
+
+	tree, treeErr := trackProcessTree(cmd.Process)
+	if treeErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: only the main process will be terminated: %v\n", treeErr)
+	}
+	defer tree.close()
+
+	// Store process reference for termination once it has started
+	done := make(chan struct{})
 	s.mu.Lock()
 	s.process = cmd
+	s.tree = tree
+	s.done = done
 	s.mu.Unlock()
 
-	err := cmd.Run()
+	err := cmd.Wait()
+	close(done)
+
+	if foreground {
+		// Take the terminal back from the command's process group
+		if tcErr := restoreForeground(ttyFd); tcErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to restore terminal foreground process group: %v\n", tcErr)
+		}
+	}
 
 	// Clear process reference after execution
 	s.mu.Lock()
 	s.process = nil
+	s.tree = processTree{}
+	s.done = nil
 	s.mu.Unlock()
 
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return fmt.Errorf("execution exited with code %d", exitErr.ExitCode())
+			return &ExitError{Code: exitErr.ExitCode()}
 		}
 		return fmt.Errorf("failed to execute: %w", err)
 	}
@@ -97,12 +151,29 @@ func (s *Executor) Execute() error {
 	return nil
 }
 
+// Environ returns the environment the command runs with: the current
+// environment with the wrapper directory on PATH, the CMDHOOKS_* settings
+// wrappers read, and the entries added with SetEnv
+func (s *Executor) Environ() []string {
+	env := os.Environ()
+	env = s.modifyPath(env)
+	env = append(env, fmt.Sprintf("CMDHOOKS_SOCKET=%s", s.socketPath))
+	// Provide exact wrapper directory marker so wrappers can clean PATH precisely
+	if s.wrapperPath != "" {
+		env = append(env, fmt.Sprintf("CMDHOOKS_WRAPPER_DIR=%s", s.wrapperPath))
+	}
+	if s.verbose {
+		env = append(env, "CMDHOOKS_VERBOSE=true")
+	}
+	return append(env, s.env...)
+}
+
 // modifyPath prepends the wrapper directory to PATH
 func (s *Executor) modifyPath(env []string) []string {
 	for i, e := range env {
 		if strings.HasPrefix(e, "PATH=") {
 			currentPath := strings.TrimPrefix(e, "PATH=")
-			newPath := s.wrapperPath + ":" + currentPath
+			newPath := s.wrapperPath + string(os.PathListSeparator) + currentPath
 			env[i] = "PATH=" + newPath
 			return env
 		}
@@ -126,10 +197,30 @@ func (s *Executor) Cleanup() error {
 	return nil
 }
 
+// DefaultKillGrace is how long KillProcessTree waits after SIGTERM before
+// sending SIGKILL, unless changed with SetKillGrace
+const DefaultKillGrace = 5 * time.Second
+
+// SetKillGrace sets how long KillProcessTree waits for the process group to
+// exit after SIGTERM. Values of zero or less restore DefaultKillGrace.
+func (s *Executor) SetKillGrace(grace time.Duration) {
+	s.killGrace = grace
+}
+
+// KillGrace returns the grace period used by KillProcessTree
+func (s *Executor) KillGrace() time.Duration {
+	if s.killGrace <= 0 {
+		return DefaultKillGrace
+	}
+	return s.killGrace
+}
+
 // KillProcessTree terminates the executor process and all its children
 func (s *Executor) KillProcessTree() error {
 	s.mu.RLock()
 	process := s.process
+	tree := s.tree
+	done := s.done
 	s.mu.RUnlock()
 
 	if process == nil || process.Process == nil {
@@ -140,7 +231,7 @@ func (s *Executor) KillProcessTree() error {
 	pid := process.Process.Pid
 
 	// First try graceful termination (SIGTERM) to the entire process group
-	if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil {
+	if err := tree.signal(syscall.SIGTERM); err != nil {
 		// If we can't kill the group, try killing just the main process
 		if killErr := process.Process.Kill(); killErr != nil {
 			return fmt.Errorf("failed to kill process %d: %w", pid, killErr)
@@ -148,30 +239,75 @@ func (s *Executor) KillProcessTree() error {
 		return nil
 	}
 
-	// Give the process group 5 seconds to terminate gracefully
-	done := make(chan error, 1)
-	go func() {
-		done <- process.Wait()
-	}()
-
+	// Give the process group the grace period to terminate gracefully.
+	// Execute waits on the process and closes done once it has exited.
 	select {
 	case <-done:
 		// Process terminated gracefully
 		return nil
-	case <-time.After(5 * time.Second):
+	case <-time.After(s.KillGrace()):
 		// Timeout - force kill the entire process group
-		if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil {
-			// If group kill fails, force kill the main process
-			return process.Process.Kill()
-		}
-		// Wait a bit more for forced termination
-		select {
-		case <-done:
-			return nil
-		case <-time.After(2 * time.Second):
-			return fmt.Errorf("process %d failed to terminate after SIGKILL", pid)
+		return s.ForceKillProcessTree()
+	}
+}
+
+// ForceKillProcessTree sends SIGKILL to the executor process group and waits
+// briefly for the process to exit
+func (s *Executor) ForceKillProcessTree() error {
+	s.mu.RLock()
+	process := s.process
+	tree := s.tree
+	done := s.done
+	s.mu.RUnlock()
+
+	if process == nil || process.Process == nil {
+		// Process not started or already finished
+		return nil
+	}
+
+	pid := process.Process.Pid
+	if err := tree.signal(syscall.SIGKILL); err != nil {
+		// If group kill fails, force kill the main process
+		return process.Process.Kill()
+	}
+	// Wait a bit more for forced termination
+	select {
+	case <-done:
+		return nil
+	case <-time.After(2 * time.Second):
+		return fmt.Errorf("process %d failed to terminate after SIGKILL", pid)
+	}
+}
+
+// SignalProcessTree sends sig to the executor process group and waits up to
+// grace for the process to exit on its own. It reports whether the process
+// has exited, so callers can fall back to KillProcessTree.
+func (s *Executor) SignalProcessTree(sig syscall.Signal, grace time.Duration) (bool, error) {
+	s.mu.RLock()
+	process := s.process
+	tree := s.tree
+	done := s.done
+	s.mu.RUnlock()
+
+	if process == nil || process.Process == nil {
+		// Process not started or already finished
+		return true, nil
+	}
+
+	pid := process.Process.Pid
+	if err := tree.signal(sig); err != nil {
+		// If we can't signal the group, try just the main process
+		if sigErr := process.Process.Signal(sig); sigErr != nil {
+			return false, fmt.Errorf("failed to signal process %d: %w", pid, sigErr)
 		}
 	}
+
+	select {
+	case <-done:
+		return true, nil
+	case <-time.After(grace):
+		return false, nil
+	}
 }
 
 // IsRunning returns true if the executor process is currently running
@@ -184,6 +320,5 @@ func (s *Executor) IsRunning() bool {
 	}
 
 	// Check if process is still alive
-	err := s.process.Process.Signal(syscall.Signal(0))
-	return err == nil
+	return processAlive(s.process.Process)
 }
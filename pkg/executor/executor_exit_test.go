@@ -1,10 +1,16 @@
+//go:build !windows
+
 package executor
 
 import (
+	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExecutorProcessTreeKill(t *testing.T) {
@@ -56,3 +62,79 @@ func TestExitSignalIntegration(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestSignalProcessTree(t *testing.T) {
+	t.Run("no process", func(t *testing.T) {
+		exited, err := (&Executor{}).SignalProcessTree(syscall.SIGUSR1, time.Second)
+		assert.NoError(t, err)
+		assert.True(t, exited)
+	})
+
+	t.Run("delivers the signal to the process group", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		marker := filepath.Join(tmpDir, "marker")
+		ex := New([]string{"bash", "-c", `trap 'echo usr1 > "$0"; exit 7' USR1; while :; do sleep 0.01; done`, marker}, filepath.Join(tmpDir, "test.sock"))
+		ex.SetWrapperPath(tmpDir)
+
+		execDone := make(chan error, 1)
+		go func() { execDone <- ex.Execute() }()
+		require.Eventually(t, ex.IsRunning, 5*time.Second, 10*time.Millisecond)
+		// Give bash time to install its trap
+		time.Sleep(100 * time.Millisecond)
+
+		exited, err := ex.SignalProcessTree(syscall.SIGUSR1, 5*time.Second)
+		require.NoError(t, err)
+		assert.True(t, exited)
+
+		var exitErr *ExitError
+		require.ErrorAs(t, <-execDone, &exitErr)
+		assert.Equal(t, 7, exitErr.Code)
+		data, err := os.ReadFile(marker)
+		require.NoError(t, err)
+		assert.Equal(t, "usr1\n", string(data))
+	})
+
+	t.Run("reports a process that ignores the signal", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		ex := New([]string{"bash", "-c", `trap '' USR1; while :; do sleep 0.01; done`}, filepath.Join(tmpDir, "test.sock"))
+		ex.SetWrapperPath(tmpDir)
+
+		execDone := make(chan error, 1)
+		go func() { execDone <- ex.Execute() }()
+		require.Eventually(t, ex.IsRunning, 5*time.Second, 10*time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+
+		exited, err := ex.SignalProcessTree(syscall.SIGUSR1, 200*time.Millisecond)
+		require.NoError(t, err)
+		assert.False(t, exited)
+
+		require.NoError(t, ex.KillProcessTree())
+		<-execDone
+	})
+}
+
+func TestKillGrace(t *testing.T) {
+	ex := New([]string{"true"}, "")
+	assert.Equal(t, DefaultKillGrace, ex.KillGrace())
+	ex.SetKillGrace(-time.Second)
+	assert.Equal(t, DefaultKillGrace, ex.KillGrace())
+
+	tmpDir := t.TempDir()
+	ex = New([]string{"bash", "-c", `trap '' TERM; while :; do sleep 0.01; done`}, filepath.Join(tmpDir, "test.sock"))
+	ex.SetWrapperPath(tmpDir)
+	ex.SetKillGrace(200 * time.Millisecond)
+
+	execDone := make(chan error, 1)
+	go func() { execDone <- ex.Execute() }()
+	require.Eventually(t, ex.IsRunning, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, ex.KillProcessTree())
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 200*time.Millisecond, "SIGTERM is given the grace period")
+	assert.Less(t, elapsed, DefaultKillGrace, "SIGKILL follows once the grace period ends")
+	assert.Error(t, <-execDone)
+
+	assert.NoError(t, ex.ForceKillProcessTree(), "no process left to kill")
+}
@@ -58,6 +58,21 @@ func TestSetWrapperPath(t *testing.T) {
 	assert.Equal(t, wrapperPath, executor.wrapperPath)
 }
 
+func TestSetEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	outFile := filepath.Join(tmpDir, "env.txt")
+
+	executor := New([]string{"sh", "-c", "echo $CMDHOOKS_TEST_VALUE > " + outFile}, filepath.Join(tmpDir, "test.sock"))
+	executor.SetWrapperPath(tmpDir)
+	executor.SetEnv([]string{"CMDHOOKS_TEST_VALUE=from-executor"})
+
+	require.NoError(t, executor.Execute())
+
+	data, err := os.ReadFile(outFile)
+	require.NoError(t, err)
+	assert.Equal(t, "from-executor", strings.TrimSpace(string(data)))
+}
+
 func TestExecuteNoWrapperPath(t *testing.T) {
 	tmpDir := t.TempDir()
 	testSocketPath := filepath.Join(tmpDir, "test.sock")
@@ -236,6 +251,19 @@ func TestExecuteCommand(t *testing.T) {
 	}
 }
 
+func TestSetOutput(t *testing.T) {
+	wrapperDir := t.TempDir()
+
+	var stdout, stderr strings.Builder
+	executor := New([]string{"sh", "-c", "echo out; echo err >&2"}, filepath.Join(wrapperDir, "test.sock"))
+	executor.SetWrapperPath(wrapperDir)
+	executor.SetOutput(&stdout, &stderr)
+
+	require.NoError(t, executor.Execute())
+	assert.Equal(t, "out\n", stdout.String())
+	assert.Equal(t, "err\n", stderr.String())
+}
+
 func TestExecuteNonExistentScript(t *testing.T) {
 	tmpDir := t.TempDir()
 	wrapperDir := filepath.Join(tmpDir, "wrappers")
@@ -0,0 +1,38 @@
+package executor
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKillProcessTreeJobObject(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("job objects are only used on Windows")
+	}
+
+	// cmd starts ping as a child, which must be terminated with it
+	tmpDir := t.TempDir()
+	ex := New([]string{"cmd", "/c", "ping -n 60 127.0.0.1 > nul"}, filepath.Join(tmpDir, "test.sock"))
+	ex.SetWrapperPath(tmpDir)
+
+	execDone := make(chan error, 1)
+	go func() { execDone <- ex.Execute() }()
+	require.Eventually(t, ex.IsRunning, 5*time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, ex.KillProcessTree())
+	select {
+	case err := <-execDone:
+		var exitErr *ExitError
+		assert.ErrorAs(t, err, &exitErr)
+	case <-time.After(10 * time.Second):
+		t.Fatal("process tree was not terminated")
+	}
+	assert.Less(t, time.Since(start), ex.KillGrace(), "terminating a job does not wait for the grace period")
+	assert.False(t, ex.IsRunning())
+}
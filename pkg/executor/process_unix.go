@@ -0,0 +1,48 @@
+//go:build !windows
+
+package executor
+
+import (
+	"os"
+	"syscall"
+)
+
+// processTree addresses the process group the command leads
+type processTree struct {
+	pid int
+}
+
+// processGroupAttr starts the command as the leader of a new process group,
+// moved to the foreground of the terminal open on ttyFd if foreground
+func processGroupAttr(foreground bool, ttyFd int) *syscall.SysProcAttr {
+	attr := &syscall.SysProcAttr{
+		Setpgid: true, // Create new process group
+	}
+	if foreground {
+		attr.Foreground = true
+		attr.Ctty = ttyFd
+	}
+	return attr
+}
+
+// trackProcessTree returns the process group of p, which it created when
+// it started
+func trackProcessTree(p *os.Process) (processTree, error) {
+	return processTree{pid: p.Pid}, nil
+}
+
+// signal sends sig to every process in the group
+func (t processTree) signal(sig syscall.Signal) error {
+	if t.pid == 0 {
+		return syscall.ESRCH
+	}
+	return syscall.Kill(-t.pid, sig)
+}
+
+// close releases the tree's resources
+func (t processTree) close() {}
+
+// processAlive reports whether p is still running
+func processAlive(p *os.Process) bool {
+	return p.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,93 @@
+//go:build windows
+
+package executor
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject       = kernel32.NewProc("TerminateJobObject")
+)
+
+// stillActive is the exit code GetExitCodeProcess reports for a running
+// process
+const stillActive = 259
+
+// processTree is a job object holding the command and every process it
+// starts, since Windows has no process groups to signal
+type processTree struct {
+	job syscall.Handle
+}
+
+// processGroupAttr starts the command in a new console process group.
+// Windows has no terminal foreground to hand over.
+func processGroupAttr(foreground bool, ttyFd int) *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// trackProcessTree puts p in a new job object. Children p starts from then
+// on join the job too; any started before it was assigned are not tracked.
+func trackProcessTree(p *os.Process) (processTree, error) {
+	job, _, err := procCreateJobObjectW.Call(0, 0)
+	if job == 0 {
+		return processTree{}, fmt.Errorf("failed to create job object: %w", err)
+	}
+	h, err := syscall.OpenProcess(syscall.PROCESS_TERMINATE|processSetQuota, false, uint32(p.Pid))
+	if err != nil {
+		syscall.CloseHandle(syscall.Handle(job))
+		return processTree{}, fmt.Errorf("failed to open process %d: %w", p.Pid, err)
+	}
+	defer syscall.CloseHandle(h)
+	if ok, _, err := procAssignProcessToJobObject.Call(job, uintptr(h)); ok == 0 {
+		syscall.CloseHandle(syscall.Handle(job))
+		return processTree{}, fmt.Errorf("failed to assign process %d to job object: %w", p.Pid, err)
+	}
+	return processTree{job: syscall.Handle(job)}, nil
+}
+
+// processSetQuota is the PROCESS_SET_QUOTA access right, required to assign
+// a process to a job object
+const processSetQuota = 0x0100
+
+// signal terminates every process in the job. Windows cannot deliver other
+// signals, and offers no graceful termination to wait for, so SIGTERM
+// terminates immediately like SIGKILL.
+func (t processTree) signal(sig syscall.Signal) error {
+	if t.job == 0 {
+		return fmt.Errorf("no job object")
+	}
+	if sig != syscall.SIGTERM && sig != syscall.SIGKILL {
+		return fmt.Errorf("signal %v is not supported on windows", sig)
+	}
+	if ok, _, err := procTerminateJobObject.Call(uintptr(t.job), 1); ok == 0 {
+		return fmt.Errorf("failed to terminate job object: %w", err)
+	}
+	return nil
+}
+
+// close releases the job object
+func (t processTree) close() {
+	if t.job != 0 {
+		syscall.CloseHandle(t.job)
+	}
+}
+
+// processAlive reports whether p is still running
+func processAlive(p *os.Process) bool {
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(p.Pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
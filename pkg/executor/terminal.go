@@ -0,0 +1,15 @@
+package executor
+
+// SetForeground makes Execute hand the controlling terminal to the
+// command's process group while it runs, when stdout is that terminal.
+// Interactive job control in the command (e.g. a shell running `fg`, or a
+// pager reading the terminal) then works as it would when started by a
+// shell. Otherwise the command runs in a background process group and is
+// stopped when it reads from, or changes the modes of, the terminal.
+//
+// In exchange, keystrokes such as Ctrl-C and Ctrl-Z signal only the command,
+// not the calling process, and a command that moves its own children into
+// new process groups takes them out of reach of KillProcessTree.
+func (s *Executor) SetForeground(foreground bool) {
+	s.foreground = foreground
+}
@@ -0,0 +1,72 @@
+//go:build !windows
+
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// procStat returns the pgrp, tty_nr and tpgid fields of a /proc/<pid>/stat
+// line
+func procStat(t *testing.T, stat string) (pgrp, tty, tpgid string) {
+	t.Helper()
+	// The fields after the command name are: state ppid pgrp session tty_nr tpgid
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	require.GreaterOrEqual(t, len(fields), 6)
+	return fields[2], fields[4], fields[5]
+}
+
+func TestSetForeground(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("reads terminal state from /proc")
+	}
+	if _, ok := controllingTerminal(); !ok {
+		t.Skip("stdout is not the controlling terminal")
+	}
+
+	self, err := os.ReadFile("/proc/self/stat")
+	require.NoError(t, err)
+	_, parentTTY, _ := procStat(t, string(self))
+
+	tmpDir := t.TempDir()
+	outFile := filepath.Join(tmpDir, "stat.txt")
+
+	executor := New([]string{"sh", "-c", "cat /proc/self/stat > " + outFile}, filepath.Join(tmpDir, "test.sock"))
+	executor.SetWrapperPath(tmpDir)
+	executor.SetForeground(true)
+	require.NoError(t, executor.Execute())
+
+	data, err := os.ReadFile(outFile)
+	require.NoError(t, err)
+	pgrp, tty, tpgid := procStat(t, string(data))
+	assert.Equal(t, parentTTY, tty, "command should share the controlling terminal")
+	assert.Equal(t, pgrp, tpgid, "command should be the terminal's foreground process group")
+
+	// The terminal is handed back once the command exits
+	fg, err := tcgetpgrp(int(os.Stdout.Fd()))
+	require.NoError(t, err)
+	assert.Equal(t, syscall.Getpgrp(), fg)
+}
+
+func TestSetForegroundWithoutTerminal(t *testing.T) {
+	tmpDir := t.TempDir()
+	outFile := filepath.Join(tmpDir, "out.txt")
+
+	// Without a terminal on stdout the option has no effect
+	executor := New([]string{"sh", "-c", "echo ran > " + outFile}, filepath.Join(tmpDir, "test.sock"))
+	executor.SetWrapperPath(tmpDir)
+	executor.SetForeground(true)
+	require.NoError(t, executor.Execute())
+
+	data, err := os.ReadFile(outFile)
+	require.NoError(t, err)
+	assert.Equal(t, "ran", strings.TrimSpace(string(data)))
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+
+package executor
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// controllingTerminal returns the descriptor of stdout if it is the
+// controlling terminal of this process
+func controllingTerminal() (int, bool) {
+	fd := int(os.Stdout.Fd())
+	_, err := tcgetpgrp(fd)
+	return fd, err == nil
+}
+
+// tcgetpgrp returns the foreground process group of the terminal open on fd
+func tcgetpgrp(fd int) (int, error) {
+	var pgrp int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp))); errno != 0 {
+		return 0, errno
+	}
+	return int(pgrp), nil
+}
+
+// tcsetpgrp makes pgrp the foreground process group of the terminal open on
+// fd. SIGTTOU is ignored meanwhile, since the caller is usually in the
+// background once a command took the terminal.
+func tcsetpgrp(fd int, pgrp int) error {
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+
+	p := int32(pgrp)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TIOCSPGRP), uintptr(unsafe.Pointer(&p))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// restoreForeground makes this process's group the foreground process
+// group of the terminal open on fd again
+func restoreForeground(fd int) error {
+	return tcsetpgrp(fd, syscall.Getpgrp())
+}
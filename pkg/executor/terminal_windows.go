@@ -0,0 +1,14 @@
+//go:build windows
+
+package executor
+
+// controllingTerminal reports no terminal: Windows consoles have no
+// foreground process group to hand over
+func controllingTerminal() (int, bool) {
+	return -1, false
+}
+
+// restoreForeground does nothing on Windows
+func restoreForeground(fd int) error {
+	return nil
+}
@@ -0,0 +1,36 @@
+package hook
+
+// MetadataAttribution is the metadata key carrying decision provenance when
+// evaluation attribution is enabled. Its value is a map with "decided_by"
+// (one of the Stage constants) and the decision of each stage under "local"
+// and "ipc" (see Response.Decision, plus "skipped" for a stage that was not
+// consulted).
+const MetadataAttribution = "attribution"
+
+// Evaluation stages that can decide a request
+const (
+	StageLocal   = "local"   // the wrapper's LocalHook
+	StageIPC     = "ipc"     // the interceptor's IPCHook
+	StageDefault = "default" // no hook handled the command
+)
+
+// Decisions recorded in attribution metadata
+const (
+	DecisionAllow   = "allow"
+	DecisionBlock   = "block"
+	DecisionNone    = "none"    // the stage had no hook for the command
+	DecisionSkipped = "skipped" // an earlier stage already decided
+)
+
+// Decision summarizes a response as DecisionAllow or DecisionBlock, or
+// DecisionNone for a nil response
+func (r *Response) Decision() string {
+	switch {
+	case r == nil:
+		return DecisionNone
+	case r.Denied():
+		return DecisionBlock
+	default:
+		return DecisionAllow
+	}
+}
@@ -0,0 +1,38 @@
+package hook
+
+import (
+	"context"
+	"time"
+)
+
+// budgetKey is the context key for the evaluation budget
+type budgetKey struct{}
+
+// WithEvaluationBudget returns a copy of ctx carrying the total time budget
+// allotted to a hook evaluation. The interceptor sets it alongside the
+// context deadline whenever an evaluation timeout is configured.
+func WithEvaluationBudget(ctx context.Context, budget time.Duration) context.Context {
+	return context.WithValue(ctx, budgetKey{}, budget)
+}
+
+// EvaluationBudget reports the total budget allotted to the current
+// evaluation and how much of it remains. It lets hooks that call into
+// libraries which ignore context deadlines bound their own sub-operations
+// (for example, by setting an HTTP client timeout to remaining).
+//
+// ok is false when no budget is configured, in which case the evaluation is
+// unbounded and ctx has no deadline.
+func EvaluationBudget(ctx context.Context) (total, remaining time.Duration, ok bool) {
+	total, ok = ctx.Value(budgetKey{}).(time.Duration)
+	if !ok {
+		return 0, 0, false
+	}
+	remaining = total
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+		remaining = time.Until(deadline)
+		if remaining < 0 {
+			remaining = 0
+		}
+	}
+	return total, remaining, true
+}
@@ -0,0 +1,18 @@
+package hook
+
+// MetadataChaos marks a pre-run response into which chaos testing injected
+// a fault, and the post-run request of a command failed that way. The value
+// is ChaosBlock or ChaosFail.
+const MetadataChaos = "chaos"
+
+// MetadataChaosExitCode is the exit code an injected ChaosFail reports
+const MetadataChaosExitCode = "chaos_exit_code"
+
+// Faults injected by chaos testing
+const (
+	// ChaosBlock blocks the command as if the hook had denied it
+	ChaosBlock = "block"
+	// ChaosFail skips the command and reports MetadataChaosExitCode as its
+	// exit code
+	ChaosFail = "fail"
+)
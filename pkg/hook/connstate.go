@@ -0,0 +1,60 @@
+package hook
+
+import (
+	"context"
+	"sync"
+)
+
+// connectionStateKey is the context key for the connection state
+type connectionStateKey struct{}
+
+// ConnectionState holds values a hook stashes for later requests on the
+// same IPC connection. A wrapper that keeps its connection open sends the
+// pre-run and post-run requests of one command over it, so a hook can
+// remember what it saw at pre-run (a start time, a snapshot, a decision)
+// and use it at post-run without external storage. It is safe for
+// concurrent use.
+type ConnectionState struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+// NewConnectionState creates an empty connection state
+func NewConnectionState() *ConnectionState {
+	return &ConnectionState{values: make(map[string]interface{})}
+}
+
+// Get returns the value stored under key
+func (s *ConnectionState) Get(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Set stores value under key
+func (s *ConnectionState) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+// Delete removes the value stored under key
+func (s *ConnectionState) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+// WithConnectionState returns a copy of ctx carrying s. The interceptor
+// sets it for every evaluation.
+func WithConnectionState(ctx context.Context, s *ConnectionState) context.Context {
+	return context.WithValue(ctx, connectionStateKey{}, s)
+}
+
+// ConnectionStateFromContext returns the state of the connection the
+// current request arrived on, or nil if there is none
+func ConnectionStateFromContext(ctx context.Context) *ConnectionState {
+	s, _ := ctx.Value(connectionStateKey{}).(*ConnectionState)
+	return s
+}
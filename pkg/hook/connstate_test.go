@@ -0,0 +1,25 @@
+package hook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionState(t *testing.T) {
+	assert.Nil(t, ConnectionStateFromContext(context.Background()))
+
+	state := NewConnectionState()
+	ctx := WithConnectionState(context.Background(), state)
+	assert.Same(t, state, ConnectionStateFromContext(ctx))
+
+	state.Set("start", 42)
+	v, ok := ConnectionStateFromContext(ctx).Get("start")
+	assert.True(t, ok)
+	assert.Equal(t, 42, v)
+
+	state.Delete("start")
+	_, ok = state.Get("start")
+	assert.False(t, ok)
+}
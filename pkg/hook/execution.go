@@ -0,0 +1,32 @@
+package hook
+
+// MetadataExecution is the post-run request metadata key under which the
+// wrapper sends ExecutionDetails when transcripts are enabled. The
+// interceptor removes it before IPC hooks see the request.
+const MetadataExecution = "execution"
+
+// Post-run request metadata keys set by the wrapper when output hashing is
+// enabled: the hex-encoded SHA-256 digests of the command's stdout and
+// stderr, computed over the full output as it was captured.
+const (
+	MetadataStdoutSHA256 = "stdout_sha256"
+	MetadataStderrSHA256 = "stderr_sha256"
+)
+
+// ExecutionDetails describes how a wrapped command ran, for replayable
+// transcripts
+type ExecutionDetails struct {
+	WorkingDir string `json:"working_dir,omitempty"`
+	// Env is the command's environment, sorted. It is cut short when it
+	// exceeds the wrapper's size limit, and EnvTruncated is set.
+	Env          []string     `json:"env,omitempty"`
+	EnvTruncated bool         `json:"env_truncated,omitempty"`
+	Stdout       OutputDigest `json:"stdout"`
+	Stderr       OutputDigest `json:"stderr"`
+}
+
+// OutputDigest identifies captured output without including it
+type OutputDigest struct {
+	SHA256 string `json:"sha256"`
+	Bytes  int64  `json:"bytes"`
+}
@@ -0,0 +1,90 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// MetadataExplain asks for the trace of an evaluation. A request setting it
+// to true gets back a response carrying, under the same key, the []TraceStep
+// of every rule and hook consulted to decide it.
+const MetadataExplain = "explain"
+
+// TraceStageHook is the Stage of trace steps recorded for hooks
+const TraceStageHook = "hook"
+
+// TraceStep records one rule or hook consulted during an evaluation and what
+// it decided: one of the Decision constants, or DecisionError when the hook
+// failed
+type TraceStep struct {
+	Stage    string `json:"stage"`
+	Hook     string `json:"hook,omitempty"`
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// DecisionError is the decision of a trace step whose hook returned an error
+const DecisionError = "error"
+
+// Trace collects the steps of an evaluation. It is safe for concurrent use,
+// and a nil Trace ignores every step.
+type Trace struct {
+	mu    sync.Mutex
+	steps []TraceStep
+}
+
+// Add appends a step to the trace
+func (t *Trace) Add(step TraceStep) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.steps = append(t.steps, step)
+}
+
+// Steps returns the steps recorded so far, in order
+func (t *Trace) Steps() []TraceStep {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TraceStep(nil), t.steps...)
+}
+
+// traceKey is the context key for the evaluation trace
+type traceKey struct{}
+
+// WithTrace returns a copy of ctx carrying t, so hooks composing others,
+// such as Multi, can record the steps of their members
+func WithTrace(ctx context.Context, t *Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+// TraceFrom returns the trace carried by ctx, or nil if the evaluation is
+// not traced
+func TraceFrom(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceKey{}).(*Trace)
+	return t
+}
+
+// DecodeTrace converts the MetadataExplain value of a response, which is a
+// []TraceStep in process and a generic JSON array once sent over the
+// socket, back to trace steps
+func DecodeTrace(v interface{}) ([]TraceStep, error) {
+	if steps, ok := v.([]TraceStep); ok {
+		return steps, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode trace: %w", err)
+	}
+	var steps []TraceStep
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return nil, fmt.Errorf("invalid trace: %w", err)
+	}
+	return steps, nil
+}
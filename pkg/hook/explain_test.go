@@ -0,0 +1,45 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	var nilTrace *Trace
+	nilTrace.Add(TraceStep{Stage: TraceStageHook})
+	assert.Nil(t, nilTrace.Steps())
+	assert.Nil(t, TraceFrom(context.Background()))
+
+	trace := &Trace{}
+	trace.Add(TraceStep{Stage: TraceStageHook, Hook: "a", Decision: DecisionAllow})
+	assert.Same(t, trace, TraceFrom(WithTrace(context.Background(), trace)))
+	assert.Len(t, trace.Steps(), 1)
+}
+
+func TestDecodeTrace(t *testing.T) {
+	steps := []TraceStep{
+		{Stage: "arg_allowlist", Decision: DecisionAllow},
+		{Stage: TraceStageHook, Hook: "deny", Decision: DecisionBlock, Reason: "no"},
+	}
+
+	decoded, err := DecodeTrace(steps)
+	require.NoError(t, err)
+	assert.Equal(t, steps, decoded)
+
+	// Over the socket the trace arrives as a generic JSON array
+	data, err := json.Marshal(map[string]interface{}{MetadataExplain: steps})
+	require.NoError(t, err)
+	var metadata map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &metadata))
+	decoded, err = DecodeTrace(metadata[MetadataExplain])
+	require.NoError(t, err)
+	assert.Equal(t, steps, decoded)
+
+	_, err = DecodeTrace("not a trace")
+	assert.Error(t, err)
+}
@@ -4,6 +4,7 @@ package hook
 
 import (
 	"context"
+	"fmt"
 )
 
 // Hook defines the base interface with common functionality
@@ -11,7 +12,9 @@ type Hook interface {
 	// Name returns a human-readable name for this hook
 	Name() string
 
-	// Commands returns the list of commands this hook handles
+	// Commands returns the list of commands this hook handles. Entries are
+	// command names, glob patterns such as "git-*", or "*" for every
+	// command; see HandlesCommand for how they are matched.
 	Commands() []string
 }
 
@@ -25,6 +28,41 @@ type LocalHook interface {
 // IPCHook embeds Hook and adds IPC-based evaluation capability
 type IPCHook interface {
 	Hook
-	// EvaluateIPC runs in the host process and communicates over IPC
+	// EvaluateIPC runs in the host process and communicates over IPC.
+	// When an evaluation timeout is configured, ctx has a deadline and
+	// carries the budget (see EvaluationBudget); otherwise it has neither.
 	EvaluateIPC(ctx context.Context, req *Request) (*Response, error)
 }
+
+// PostRunFilter is an optional interface for hooks that only need post-run
+// evaluation for some exit codes, such as a hook that analyses failures.
+// Post-run requests for other exit codes are allowed without calling the
+// hook.
+type PostRunFilter interface {
+	// WantsPostRun reports whether the hook wants to evaluate the post-run
+	// request of a command that exited with exitCode
+	WantsPostRun(exitCode int) bool
+}
+
+// WantsPostRun reports whether h wants post-run evaluation for exitCode.
+// Hooks that do not implement PostRunFilter want every post-run request.
+func WantsPostRun(h Hook, exitCode int) bool {
+	if f, ok := h.(PostRunFilter); ok {
+		return f.WantsPostRun(exitCode)
+	}
+	return true
+}
+
+// PanicError is the error reported in place of a response when a hook
+// panics during evaluation, so a faulty hook denies the command instead of
+// crashing the process evaluating it
+type PanicError struct {
+	// Value is the value passed to panic
+	Value any
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
@@ -0,0 +1,8 @@
+package hook
+
+// Logger receives the diagnostic output of the interceptor, the wrapper
+// and cmdhooks itself, for redirecting it per instance instead of writing
+// to the standard logger. *log.Logger implements it.
+type Logger interface {
+	Printf(format string, v ...any)
+}
@@ -0,0 +1,52 @@
+package hook
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IsCommandPattern reports whether an entry of Commands() is a glob pattern,
+// such as "git-*" or "*ctl", rather than a literal command name. "*" is a
+// pattern matching every command.
+func IsCommandPattern(command string) bool {
+	return strings.ContainsAny(command, "*?[")
+}
+
+// HandlesCommand reports whether the invoked command name matches any entry
+// of commands, as returned by Hook.Commands(). The name is matched by its
+// basename, so "/usr/bin/curl" and "./curl" match "curl". Entries are
+// literal names, or shell globs evaluated with path.Match. With
+// caseInsensitive, both sides are compared ignoring case.
+//
+// Exact names take precedence over glob patterns, which take precedence
+// over "*": name is compared with every literal entry first, then with each
+// pattern, and "*" matches whatever is left. The precedence only decides
+// which entry matches, so a hook handles a command when any entry matches.
+func HandlesCommand(commands []string, name string, caseInsensitive bool) bool {
+	base := filepath.Base(name)
+	for _, command := range commands {
+		if command == base || (caseInsensitive && strings.EqualFold(command, base)) {
+			return true
+		}
+	}
+
+	if caseInsensitive {
+		base = strings.ToLower(base)
+	}
+	wildcard := false
+	for _, command := range commands {
+		switch {
+		case command == "*":
+			wildcard = true
+		case IsCommandPattern(command):
+			if caseInsensitive {
+				command = strings.ToLower(command)
+			}
+			if matched, _ := path.Match(command, base); matched {
+				return true
+			}
+		}
+	}
+	return wildcard
+}
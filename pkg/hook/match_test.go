@@ -0,0 +1,50 @@
+package hook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlesCommand(t *testing.T) {
+	tests := []struct {
+		name            string
+		commands        []string
+		command         string
+		caseInsensitive bool
+		want            bool
+	}{
+		{name: "literal", commands: []string{"curl"}, command: "curl", want: true},
+		{name: "literal mismatch", commands: []string{"curl"}, command: "curl2"},
+		{name: "wildcard", commands: []string{"*"}, command: "anything", want: true},
+		{name: "prefix glob", commands: []string{"git-*"}, command: "git-lfs", want: true},
+		{name: "prefix glob needs suffix", commands: []string{"git-*"}, command: "git"},
+		{name: "suffix glob", commands: []string{"*ctl"}, command: "kubectl", want: true},
+		{name: "glob against basename", commands: []string{"git-*"}, command: "/usr/lib/git-core/git-upload-pack", want: true},
+		{name: "character class", commands: []string{"python[23]"}, command: "python3", want: true},
+		{name: "glob is case-sensitive", commands: []string{"git-*"}, command: "GIT-lfs"},
+		{name: "case-insensitive glob", commands: []string{"git-*"}, command: "GIT-lfs", caseInsensitive: true, want: true},
+		{name: "case-insensitive literal", commands: []string{"curl"}, command: "CURL", caseInsensitive: true, want: true},
+		{name: "absolute path", commands: []string{"curl"}, command: "/usr/bin/curl", want: true},
+		{name: "relative path", commands: []string{"curl"}, command: "./bin/curl", want: true},
+		{name: "path to other command", commands: []string{"curl"}, command: "/opt/curl/bin/wget"},
+		{name: "mixed case is case-sensitive", commands: []string{"curl"}, command: "/usr/bin/Curl"},
+		{name: "mixed case path", commands: []string{"cURL"}, command: "/usr/bin/Curl", caseInsensitive: true, want: true},
+		{name: "mixed entries", commands: []string{"curl", "git-*", "*"}, command: "wget", want: true},
+		{name: "no entries", command: "curl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HandlesCommand(tt.commands, tt.command, tt.caseInsensitive))
+		})
+	}
+}
+
+func TestIsCommandPattern(t *testing.T) {
+	assert.True(t, IsCommandPattern("*"))
+	assert.True(t, IsCommandPattern("git-*"))
+	assert.True(t, IsCommandPattern("python?"))
+	assert.True(t, IsCommandPattern("[a-z]ctl"))
+	assert.False(t, IsCommandPattern("git-lfs"))
+}
@@ -0,0 +1,181 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Multi composes several hooks into one. Each request is evaluated by the
+// hooks that handle its command, in order, stopping at the first that
+// denies it. Metadata returned by a hook is merged into the request passed
+// to later hooks, and a command rewritten by a hook is what later hooks
+// see. Multi implements both LocalHook and IPCHook: EvaluateLocal runs the
+// LocalHook members and EvaluateIPC the IPCHook members.
+type Multi struct {
+	hooks []Hook
+
+	// CaseInsensitive matches request commands against each hook's
+	// commands ignoring case
+	CaseInsensitive bool
+}
+
+// NewMulti creates a hook evaluating hooks in the given order
+func NewMulti(hooks ...Hook) *Multi {
+	return &Multi{hooks: hooks}
+}
+
+// Hooks returns the composed hooks, in evaluation order
+func (m *Multi) Hooks() []Hook {
+	return m.hooks
+}
+
+// Name returns the names of the composed hooks joined by "+"
+func (m *Multi) Name() string {
+	names := make([]string, len(m.hooks))
+	for i, h := range m.hooks {
+		names[i] = h.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+// Commands returns the union of the commands of the composed hooks, in
+// order of first appearance
+func (m *Multi) Commands() []string {
+	var commands []string
+	seen := make(map[string]bool)
+	for _, h := range m.hooks {
+		for _, command := range h.Commands() {
+			if !seen[command] {
+				seen[command] = true
+				commands = append(commands, command)
+			}
+		}
+	}
+	return commands
+}
+
+// WantsPostRun reports whether any composed hook wants post-run evaluation
+// for exitCode
+func (m *Multi) WantsPostRun(exitCode int) bool {
+	for _, h := range m.hooks {
+		if WantsPostRun(h, exitCode) {
+			return true
+		}
+	}
+	return false
+}
+
+// EvaluateLocal evaluates the request with the LocalHook members. It
+// returns a nil response when none of them handles the request.
+func (m *Multi) EvaluateLocal(ctx context.Context, req *Request) (*Response, error) {
+	return m.evaluate(ctx, req, func(h Hook) func(context.Context, *Request) (*Response, error) {
+		if local, ok := h.(LocalHook); ok {
+			return local.EvaluateLocal
+		}
+		return nil
+	})
+}
+
+// EvaluateIPC evaluates the request with the IPCHook members. A request
+// none of them handles is allowed.
+func (m *Multi) EvaluateIPC(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := m.evaluate(ctx, req, func(h Hook) func(context.Context, *Request) (*Response, error) {
+		if ipc, ok := h.(IPCHook); ok {
+			return ipc.EvaluateIPC
+		}
+		return nil
+	})
+	if err == nil && resp == nil {
+		resp = &Response{}
+	}
+	return resp, err
+}
+
+// evaluate runs the evaluation function that stage returns for each member,
+// skipping members it returns nil for. Each member consulted is recorded in
+// the trace of ctx, if any.
+func (m *Multi) evaluate(ctx context.Context, req *Request, stage func(Hook) func(context.Context, *Request) (*Response, error)) (*Response, error) {
+	trace := TraceFrom(ctx)
+	var combined *Response
+	current := *req
+	for _, h := range m.hooks {
+		eval := stage(h)
+		if eval == nil || !m.handles(h, &current) {
+			continue
+		}
+		if current.Hook == HookPostRun && !WantsPostRun(h, current.ExitCode) {
+			continue
+		}
+
+		resp, err := eval(ctx, &current)
+		if err != nil {
+			trace.Add(TraceStep{Stage: TraceStageHook, Hook: h.Name(), Decision: DecisionError, Reason: err.Error()})
+			return nil, fmt.Errorf("hook %s: %w", h.Name(), err)
+		}
+		trace.Add(TraceStep{Stage: TraceStageHook, Hook: h.Name(), Decision: resp.Decision(), Reason: resp.BlockReason()})
+		if resp == nil {
+			continue
+		}
+		combined = combine(combined, resp)
+		if resp.Denied() {
+			return combined, nil
+		}
+
+		// Later hooks see the metadata and rewrite of earlier ones
+		if len(resp.Metadata) > 0 {
+			metadata := make(map[string]interface{}, len(current.Metadata)+len(resp.Metadata))
+			maps.Copy(metadata, current.Metadata)
+			maps.Copy(metadata, resp.Metadata)
+			current.Metadata = metadata
+		}
+		if len(resp.ModifiedCommand) > 0 {
+			current.Command = resp.ModifiedCommand
+		}
+	}
+	return combined, nil
+}
+
+// combine folds resp into the response of the hooks evaluated before it.
+// Metadata is merged with resp taking precedence; its decision, reason and
+// rewrite replace earlier ones, and environment changes accumulate.
+func combine(prev, resp *Response) *Response {
+	out := *resp
+	if prev == nil {
+		return &out
+	}
+
+	out.Metadata = make(map[string]interface{}, len(prev.Metadata)+len(resp.Metadata))
+	maps.Copy(out.Metadata, prev.Metadata)
+	maps.Copy(out.Metadata, resp.Metadata)
+	if out.ModifiedCommand == nil {
+		out.ModifiedCommand = prev.ModifiedCommand
+	}
+	out.EnvUnset = append(slices.Clone(prev.EnvUnset), resp.EnvUnset...)
+	if len(prev.EnvOverride) > 0 {
+		out.EnvOverride = maps.Clone(prev.EnvOverride)
+		maps.Copy(out.EnvOverride, resp.EnvOverride)
+	}
+	return &out
+}
+
+// handles reports whether h handles the command of req, or any command of a
+// batch request
+func (m *Multi) handles(h Hook, req *Request) bool {
+	if req.Hook != HookPreRunBatch {
+		return len(req.Command) > 0 && m.handlesCommand(h, req.Command[0])
+	}
+	for _, command := range req.Batch {
+		if len(command) > 0 && m.handlesCommand(h, command[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// handlesCommand reports whether an entry of h's commands matches name
+func (m *Multi) handlesCommand(h Hook, name string) bool {
+	return HandlesCommand(h.Commands(), name, m.CaseInsensitive)
+}
@@ -0,0 +1,198 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHook is an IPCHook that records the requests it sees and returns
+// a fixed response
+type recordingHook struct {
+	name     string
+	commands []string
+	resp     *Response
+	err      error
+	calls    *[]string
+	seen     []*Request
+}
+
+func (h *recordingHook) Name() string       { return h.name }
+func (h *recordingHook) Commands() []string { return h.commands }
+func (h *recordingHook) EvaluateIPC(ctx context.Context, req *Request) (*Response, error) {
+	*h.calls = append(*h.calls, h.name)
+	reqCopy := *req
+	h.seen = append(h.seen, &reqCopy)
+	return h.resp, h.err
+}
+
+// localRecordingHook is the LocalHook counterpart of recordingHook
+type localRecordingHook struct {
+	name     string
+	commands []string
+	calls    *[]string
+}
+
+func (h *localRecordingHook) Name() string       { return h.name }
+func (h *localRecordingHook) Commands() []string { return h.commands }
+func (h *localRecordingHook) EvaluateLocal(ctx context.Context, req *Request) (*Response, error) {
+	*h.calls = append(*h.calls, h.name)
+	return &Response{}, nil
+}
+
+func TestMultiCommands(t *testing.T) {
+	var calls []string
+	m := NewMulti(
+		&recordingHook{name: "a", commands: []string{"curl", "wget"}, calls: &calls},
+		&recordingHook{name: "b", commands: []string{"wget", "git"}, calls: &calls},
+	)
+	assert.Equal(t, []string{"curl", "wget", "git"}, m.Commands())
+	assert.Equal(t, "a+b", m.Name())
+}
+
+func TestMultiEvaluateIPC(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []*Response
+		command   string
+		wantCalls []string
+		wantExit  bool
+	}{
+		{
+			name:      "all allow in order",
+			responses: []*Response{{}, {}, {}},
+			command:   "curl",
+			wantCalls: []string{"first", "second", "third"},
+		},
+		{
+			name:      "deny stops evaluation",
+			responses: []*Response{{}, {Exit: true, Reason: "no"}, {}},
+			command:   "curl",
+			wantCalls: []string{"first", "second"},
+			wantExit:  true,
+		},
+		{
+			name:      "hooks not listing the command are skipped",
+			responses: []*Response{{}, {}, {}},
+			command:   "git",
+			wantCalls: []string{"third"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			m := NewMulti(
+				&recordingHook{name: "first", commands: []string{"curl"}, resp: tt.responses[0], calls: &calls},
+				&recordingHook{name: "second", commands: []string{"curl"}, resp: tt.responses[1], calls: &calls},
+				&recordingHook{name: "third", commands: []string{"*"}, resp: tt.responses[2], calls: &calls},
+			)
+
+			resp, err := m.EvaluateIPC(context.Background(), &Request{Command: []string{tt.command}, Hook: HookPreRun})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.wantExit, resp.Exit)
+		})
+	}
+}
+
+func TestMultiMetadataPropagation(t *testing.T) {
+	var calls []string
+	first := &recordingHook{name: "first", commands: []string{"curl"}, calls: &calls, resp: &Response{
+		Metadata:        map[string]interface{}{"risk": "low", "by": "first"},
+		ModifiedCommand: []string{"curl", "--proto", "=https", "example.com"},
+	}}
+	second := &recordingHook{name: "second", commands: []string{"curl"}, calls: &calls, resp: &Response{
+		Metadata: map[string]interface{}{"by": "second"},
+	}}
+	m := NewMulti(first, second)
+
+	req := &Request{Command: []string{"curl", "example.com"}, Hook: HookPreRun, Metadata: map[string]interface{}{"cwd": "/work"}}
+	resp, err := m.EvaluateIPC(context.Background(), req)
+	require.NoError(t, err)
+
+	require.Len(t, second.seen, 1)
+	assert.Equal(t, map[string]interface{}{"cwd": "/work", "risk": "low", "by": "first"}, second.seen[0].Metadata)
+	assert.Equal(t, []string{"curl", "--proto", "=https", "example.com"}, second.seen[0].Command, "later hooks see the rewrite")
+	assert.Equal(t, map[string]interface{}{"cwd": "/work"}, req.Metadata, "caller's request is not modified")
+
+	assert.Equal(t, map[string]interface{}{"risk": "low", "by": "second"}, resp.Metadata)
+	assert.Equal(t, []string{"curl", "--proto", "=https", "example.com"}, resp.ModifiedCommand)
+}
+
+func TestMultiStages(t *testing.T) {
+	var calls []string
+	local := &localRecordingHook{name: "local", commands: []string{"curl"}, calls: &calls}
+	ipc := &recordingHook{name: "ipc", commands: []string{"curl"}, resp: &Response{}, calls: &calls}
+	m := NewMulti(local, ipc)
+	req := &Request{Command: []string{"curl"}, Hook: HookPreRun}
+
+	_, err := m.EvaluateLocal(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"local"}, calls)
+
+	calls = nil
+	_, err = m.EvaluateIPC(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ipc"}, calls)
+
+	resp, err := NewMulti(ipc).EvaluateLocal(context.Background(), req)
+	require.NoError(t, err)
+	assert.Nil(t, resp, "no local hook handles the request")
+}
+
+func TestMultiError(t *testing.T) {
+	var calls []string
+	m := NewMulti(
+		&recordingHook{name: "broken", commands: []string{"*"}, err: errors.New("unreachable"), calls: &calls},
+		&recordingHook{name: "after", commands: []string{"*"}, resp: &Response{}, calls: &calls},
+	)
+	_, err := m.EvaluateIPC(context.Background(), &Request{Command: []string{"curl"}, Hook: HookPreRun})
+	assert.ErrorContains(t, err, "hook broken: unreachable")
+	assert.Equal(t, []string{"broken"}, calls)
+}
+
+func TestMultiCaseInsensitive(t *testing.T) {
+	var calls []string
+	m := NewMulti(&recordingHook{name: "a", commands: []string{"curl"}, resp: &Response{}, calls: &calls})
+	req := &Request{Command: []string{"CURL"}, Hook: HookPreRun}
+
+	_, err := m.EvaluateIPC(context.Background(), req)
+	require.NoError(t, err)
+	assert.Empty(t, calls)
+
+	m.CaseInsensitive = true
+	_, err = m.EvaluateIPC(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, calls)
+}
+
+func TestMultiTrace(t *testing.T) {
+	var calls []string
+	m := NewMulti(
+		&recordingHook{name: "allow", commands: []string{"curl"}, resp: &Response{}, calls: &calls},
+		&recordingHook{name: "other", commands: []string{"git"}, resp: &Response{}, calls: &calls},
+		&recordingHook{name: "deny", commands: []string{"*"}, resp: &Response{Exit: true, Reason: "no network"}, calls: &calls},
+		&recordingHook{name: "never", commands: []string{"*"}, resp: &Response{}, calls: &calls},
+	)
+
+	trace := &Trace{}
+	ctx := WithTrace(context.Background(), trace)
+	_, err := m.EvaluateIPC(ctx, &Request{Command: []string{"curl"}, Hook: HookPreRun})
+	require.NoError(t, err)
+	assert.Equal(t, []TraceStep{
+		{Stage: TraceStageHook, Hook: "allow", Decision: DecisionAllow},
+		{Stage: TraceStageHook, Hook: "deny", Decision: DecisionBlock, Reason: "no network"},
+	}, trace.Steps())
+
+	t.Run("error", func(t *testing.T) {
+		m := NewMulti(&recordingHook{name: "broken", commands: []string{"*"}, err: errors.New("boom"), calls: &calls})
+		trace := &Trace{}
+		_, err := m.EvaluateIPC(WithTrace(context.Background(), trace), &Request{Command: []string{"curl"}, Hook: HookPreRun})
+		require.Error(t, err)
+		assert.Equal(t, []TraceStep{{Stage: TraceStageHook, Hook: "broken", Decision: DecisionError, Reason: "boom"}}, trace.Steps())
+	})
+}
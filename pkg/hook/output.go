@@ -0,0 +1,183 @@
+package hook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// MetadataOutputTranscript is the post-run request metadata key holding the
+// path of the command's output transcript, when the wrapper captures output
+// in one ordered file instead of separate stdout and stderr files
+const MetadataOutputTranscript = "output_transcript"
+
+// OutputStream identifies the stream a chunk of output was written to
+type OutputStream byte
+
+// Streams recorded in an output transcript
+const (
+	OutputStdout OutputStream = 1
+	OutputStderr OutputStream = 2
+)
+
+// String returns "stdout" or "stderr"
+func (s OutputStream) String() string {
+	switch s {
+	case OutputStdout:
+		return "stdout"
+	case OutputStderr:
+		return "stderr"
+	default:
+		return fmt.Sprintf("stream(%d)", byte(s))
+	}
+}
+
+// MaxOutputChunkBytes caps the data of a single transcript record. Larger
+// writes are split across records.
+const MaxOutputChunkBytes = 64 * 1024
+
+// outputHeaderBytes is the size of a record header: a 4-byte big-endian
+// data length followed by the stream
+const outputHeaderBytes = 5
+
+// OutputChunk is one record of an output transcript
+type OutputChunk struct {
+	Stream OutputStream
+	Data   []byte
+}
+
+// OutputTranscriptWriter records the output of several streams in one
+// transcript, in the order it is written. Each write becomes a record made
+// of the data length, the stream it was written to and the data, so
+// readers can recover both the interleaving and each stream on its own.
+type OutputTranscriptWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewOutputTranscriptWriter creates a transcript writing records to w
+func NewOutputTranscriptWriter(w io.Writer) *OutputTranscriptWriter {
+	return &OutputTranscriptWriter{w: w}
+}
+
+// Stream returns a writer recording everything written to it as output of
+// stream. Writers of different streams may be used concurrently.
+func (t *OutputTranscriptWriter) Stream(stream OutputStream) io.Writer {
+	return &outputStreamWriter{t: t, stream: stream}
+}
+
+// writeChunk writes p as records of stream, holding the lock so records of
+// concurrent writes are not interleaved
+func (t *OutputTranscriptWriter) writeChunk(stream OutputStream, p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	written := 0
+	for len(p) > 0 {
+		data := p[:min(len(p), MaxOutputChunkBytes)]
+		var header [outputHeaderBytes]byte
+		binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+		header[4] = byte(stream)
+		if _, err := t.w.Write(header[:]); err != nil {
+			return written, err
+		}
+		if _, err := t.w.Write(data); err != nil {
+			return written, err
+		}
+		written += len(data)
+		p = p[len(data):]
+	}
+	return written, nil
+}
+
+// outputStreamWriter is the writer of one stream of a transcript
+type outputStreamWriter struct {
+	t      *OutputTranscriptWriter
+	stream OutputStream
+}
+
+func (w *outputStreamWriter) Write(p []byte) (int, error) {
+	return w.t.writeChunk(w.stream, p)
+}
+
+// OutputTranscriptReader reads the records of an output transcript
+type OutputTranscriptReader struct {
+	r *bufio.Reader
+}
+
+// NewOutputTranscriptReader creates a reader of the transcript in r
+func NewOutputTranscriptReader(r io.Reader) *OutputTranscriptReader {
+	return &OutputTranscriptReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next record. It returns io.EOF at the end of the
+// transcript, and io.ErrUnexpectedEOF if the transcript ends mid-record.
+func (r *OutputTranscriptReader) Next() (OutputChunk, error) {
+	var header [outputHeaderBytes]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		return OutputChunk{}, err
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size > MaxOutputChunkBytes {
+		return OutputChunk{}, fmt.Errorf("output transcript record of %d bytes exceeds limit", size)
+	}
+	stream := OutputStream(header[4])
+	if stream != OutputStdout && stream != OutputStderr {
+		return OutputChunk{}, fmt.Errorf("output transcript record has unknown %v", stream)
+	}
+	chunk := OutputChunk{Stream: stream, Data: make([]byte, size)}
+	if _, err := io.ReadFull(r.r, chunk.Data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return OutputChunk{}, err
+	}
+	return chunk, nil
+}
+
+// ReadOutputTranscript reads every record of the transcript file at path
+func ReadOutputTranscript(path string) ([]OutputChunk, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var chunks []OutputChunk
+	r := NewOutputTranscriptReader(f)
+	for {
+		chunk, err := r.Next()
+		if err == io.EOF {
+			return chunks, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read output transcript %s: %w", path, err)
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+// CombinedOutput returns the output of every stream, in the order it was
+// written
+func CombinedOutput(chunks []OutputChunk) []byte {
+	var buf bytes.Buffer
+	for _, chunk := range chunks {
+		buf.Write(chunk.Data)
+	}
+	return buf.Bytes()
+}
+
+// StreamOutput returns the output written to stream
+func StreamOutput(chunks []OutputChunk, stream OutputStream) []byte {
+	var buf bytes.Buffer
+	for _, chunk := range chunks {
+		if chunk.Stream == stream {
+			buf.Write(chunk.Data)
+		}
+	}
+	return buf.Bytes()
+}
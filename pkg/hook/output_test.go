@@ -0,0 +1,126 @@
+package hook
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputTranscriptRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	transcript := NewOutputTranscriptWriter(&buf)
+	stdout := transcript.Stream(OutputStdout)
+	stderr := transcript.Stream(OutputStderr)
+
+	for _, w := range []struct {
+		w    io.Writer
+		data string
+	}{
+		{stdout, "building\n"},
+		{stderr, "warning: deprecated\n"},
+		{stdout, "done\n"},
+		{stderr, "error: failed\n"},
+	} {
+		n, err := io.WriteString(w.w, w.data)
+		require.NoError(t, err)
+		assert.Equal(t, len(w.data), n)
+	}
+
+	path := filepath.Join(t.TempDir(), "transcript")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+	chunks, err := ReadOutputTranscript(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, []OutputChunk{
+		{Stream: OutputStdout, Data: []byte("building\n")},
+		{Stream: OutputStderr, Data: []byte("warning: deprecated\n")},
+		{Stream: OutputStdout, Data: []byte("done\n")},
+		{Stream: OutputStderr, Data: []byte("error: failed\n")},
+	}, chunks)
+	assert.Equal(t, "building\nwarning: deprecated\ndone\nerror: failed\n", string(CombinedOutput(chunks)))
+	assert.Equal(t, "building\ndone\n", string(StreamOutput(chunks, OutputStdout)))
+	assert.Equal(t, "warning: deprecated\nerror: failed\n", string(StreamOutput(chunks, OutputStderr)))
+}
+
+func TestOutputTranscriptLargeWrite(t *testing.T) {
+	var buf bytes.Buffer
+	data := bytes.Repeat([]byte("x"), 2*MaxOutputChunkBytes+10)
+	n, err := NewOutputTranscriptWriter(&buf).Stream(OutputStdout).Write(data)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), n)
+
+	r := NewOutputTranscriptReader(&buf)
+	var sizes []int
+	var out []byte
+	for {
+		chunk, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		sizes = append(sizes, len(chunk.Data))
+		out = append(out, chunk.Data...)
+	}
+	assert.Equal(t, []int{MaxOutputChunkBytes, MaxOutputChunkBytes, 10}, sizes)
+	assert.Equal(t, data, out)
+}
+
+func TestOutputTranscriptConcurrentStreams(t *testing.T) {
+	var buf bytes.Buffer
+	transcript := NewOutputTranscriptWriter(&buf)
+
+	var wg sync.WaitGroup
+	for _, stream := range []OutputStream{OutputStdout, OutputStderr} {
+		wg.Add(1)
+		go func(stream OutputStream) {
+			defer wg.Done()
+			w := transcript.Stream(stream)
+			for range 100 {
+				_, _ = io.WriteString(w, stream.String()+"\n")
+			}
+		}(stream)
+	}
+	wg.Wait()
+
+	// Records of concurrent writes never interleave
+	r := NewOutputTranscriptReader(&buf)
+	counts := make(map[OutputStream]int)
+	for {
+		chunk, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, chunk.Stream.String()+"\n", string(chunk.Data))
+		counts[chunk.Stream]++
+	}
+	assert.Equal(t, map[OutputStream]int{OutputStdout: 100, OutputStderr: 100}, counts)
+}
+
+func TestOutputTranscriptReaderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"truncated header", []byte{0, 0, 0}, "unexpected EOF"},
+		{"truncated data", []byte{0, 0, 0, 5, 1, 'a', 'b'}, "unexpected EOF"},
+		{"unknown stream", []byte{0, 0, 0, 1, 3, 'a'}, "unknown stream(3)"},
+		{"oversized record", []byte{0xff, 0xff, 0xff, 0xff, 1}, "exceeds limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewOutputTranscriptReader(bytes.NewReader(tt.data)).Next()
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	_, err := NewOutputTranscriptReader(bytes.NewReader(nil)).Next()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,23 @@
+package hook
+
+import "strconv"
+
+// MetadataCredentialsVerified is set to true in the metadata of requests
+// whose UID and GID the interceptor read from the socket's peer
+// credentials, replacing those the wrapper reported. It is removed from
+// requests without verified credentials, so a wrapper cannot claim it.
+const MetadataCredentialsVerified = "credentials_verified"
+
+// UnverifiedPeerKey is the PeerUIDKey of requests without verified peer
+// credentials
+const UnverifiedPeerKey = "unverified"
+
+// PeerUIDKey keys a request by the verified UID of its sender, as
+// "uid:<n>", for per-user rate limits and quotas. Requests without peer
+// credentials share UnverifiedPeerKey.
+func PeerUIDKey(req *Request) string {
+	if req.PeerUID == nil {
+		return UnverifiedPeerKey
+	}
+	return "uid:" + strconv.Itoa(*req.PeerUID)
+}
@@ -0,0 +1,26 @@
+package hook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerUIDKey(t *testing.T) {
+	uid := 1000
+	assert.Equal(t, "uid:1000", PeerUIDKey(&Request{PeerUID: &uid}))
+	assert.Equal(t, UnverifiedPeerKey, PeerUIDKey(&Request{}))
+}
+
+func TestPeerUIDNotOnWire(t *testing.T) {
+	var req Request
+	require.NoError(t, json.Unmarshal([]byte(`{"command":["ls"],"PeerUID":0,"peer_uid":0}`), &req))
+	assert.Nil(t, req.PeerUID, "peer credentials cannot be claimed by the sender")
+
+	uid := 0
+	data, err := json.Marshal(&Request{Command: []string{"ls"}, PeerUID: &uid})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"command":["ls"],"pid":0,"hook":"","started_at":"0001-01-01T00:00:00Z"}`, string(data))
+}
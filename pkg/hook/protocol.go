@@ -0,0 +1,146 @@
+package hook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// Wire protocol
+//
+// Wrappers and the interceptor exchange JSON messages over a Unix socket: a
+// Request from the wrapper, answered by a Response. Stream sockets frame
+// each message with a trailing newline; seqpacket sockets carry one message
+// per packet. A connection may carry several exchanges, typically the
+// pre-run and post-run requests of one command.
+//
+// Optional features are negotiated per connection, so wrappers and
+// interceptors of different versions keep interoperating: the wrapper lists
+// what it supports in the metadata of the first request, and the
+// interceptor answers in the metadata of the first response with what it
+// will use. A peer that does not know a feature ignores the offer or never
+// answers it, and both sides carry on with plain JSON.
+//
+// Compression is negotiated this way. The wrapper sets
+// MetadataAcceptEncoding to the encodings it can use, e.g. ["gzip"]; an
+// interceptor willing to compress sets MetadataEncoding to the chosen one.
+// Every later message on the connection, in both directions, is then
+// encoded with EncodeMessage. Encoded messages never start with '{', so
+// DecodeMessage tells them apart from plain JSON.
+//
+// Keep-alive is negotiated the same way. The wrapper sets
+// MetadataProtocolVersion to the version it speaks, and an interceptor
+// serving several requests per connection answers with its own. A wrapper
+// that gets no version back is talking to a version 1 interceptor, which
+// answers one request and hangs up, so it dials again for each later
+// request.
+
+// Handshake metadata keys. They are consumed by the transport and are not
+// passed to hooks.
+const (
+	// MetadataAcceptEncoding lists the message encodings a wrapper
+	// supports, in the first request on a connection
+	MetadataAcceptEncoding = "accept_encoding"
+	// MetadataEncoding is the encoding the interceptor chose for the rest
+	// of the connection, in the first response
+	MetadataEncoding = "encoding"
+	// MetadataProtocolVersion is the protocol version of the sender, in the
+	// first request and response on a connection
+	MetadataProtocolVersion = "protocol_version"
+)
+
+// ProtocolVersion is the version of the wire protocol spoken by this
+// module. Version 2 added keep-alive connections; version 1 peers never
+// announce a version.
+const ProtocolVersion = 2
+
+// ProtocolVersionOf returns the protocol version announced in a message's
+// metadata, or 1 if it announces none
+func ProtocolVersionOf(metadata map[string]interface{}) int {
+	switch v := metadata[MetadataProtocolVersion].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 1
+}
+
+// EncodingGzip compresses a message with gzip and encodes the result as
+// standard base64, so it never contains the newline that frames messages
+const EncodingGzip = "gzip"
+
+// AcceptsEncoding reports whether the encodings offered in a request's
+// MetadataAcceptEncoding include encoding
+func AcceptsEncoding(metadata map[string]interface{}, encoding string) bool {
+	switch v := metadata[MetadataAcceptEncoding].(type) {
+	case []string:
+		for _, e := range v {
+			if e == encoding {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if e == encoding {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EncodeMessage encodes a marshaled message for the wire. The empty
+// encoding leaves it unchanged.
+func EncodeMessage(data []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return data, nil
+	case EncodingGzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		out := make([]byte, base64.StdEncoding.EncodedLen(buf.Len()))
+		base64.StdEncoding.Encode(out, buf.Bytes())
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported message encoding %q", encoding)
+	}
+}
+
+// DecodeMessage returns the JSON message held by data, which is either
+// plain JSON or encoded by EncodeMessage. Decoded messages larger than
+// maxBytes are rejected.
+func DecodeMessage(data []byte, maxBytes int) ([]byte, error) {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] == '{' {
+		return data, nil
+	}
+
+	compressed := make([]byte, base64.StdEncoding.DecodedLen(len(trimmed)))
+	n, err := base64.StdEncoding.Decode(compressed, bytes.TrimRight(trimmed, " \t\r\n"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode message: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed[:n]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress message: %w", err)
+	}
+	defer zr.Close()
+
+	out, err := io.ReadAll(io.LimitReader(zr, int64(maxBytes)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress message: %w", err)
+	}
+	if len(out) > maxBytes {
+		return nil, fmt.Errorf("message exceeds %d bytes", maxBytes)
+	}
+	return out, nil
+}
@@ -0,0 +1,60 @@
+package hook
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeMessage(t *testing.T) {
+	msg := []byte(`{"command":["make","` + strings.Repeat("x", 1000) + `"],"hook":"pre_run"}`)
+
+	plain, err := EncodeMessage(msg, "")
+	require.NoError(t, err)
+	assert.Equal(t, msg, plain)
+
+	encoded, err := EncodeMessage(msg, EncodingGzip)
+	require.NoError(t, err)
+	assert.Less(t, len(encoded), len(msg))
+	assert.NotEqual(t, byte('{'), encoded[0])
+	assert.False(t, bytes.ContainsRune(encoded, '\n'), "encoded messages never contain the framing newline")
+
+	for _, data := range [][]byte{plain, encoded} {
+		decoded, err := DecodeMessage(data, 1<<20)
+		require.NoError(t, err)
+		assert.Equal(t, msg, decoded)
+	}
+
+	_, err = EncodeMessage(msg, "br")
+	assert.Error(t, err)
+}
+
+func TestDecodeMessage_Errors(t *testing.T) {
+	encoded, err := EncodeMessage([]byte(`{"hook":"`+strings.Repeat("a", 4096)+`"}`), EncodingGzip)
+	require.NoError(t, err)
+	_, err = DecodeMessage(encoded, 1024)
+	assert.ErrorContains(t, err, "exceeds 1024 bytes")
+
+	_, err = DecodeMessage([]byte("not base64!"), 1024)
+	assert.Error(t, err)
+
+	_, err = DecodeMessage([]byte("aGVsbG8="), 1024)
+	assert.Error(t, err, "valid base64 holding no gzip stream")
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	assert.True(t, AcceptsEncoding(map[string]interface{}{MetadataAcceptEncoding: []string{"br", EncodingGzip}}, EncodingGzip))
+	assert.True(t, AcceptsEncoding(map[string]interface{}{MetadataAcceptEncoding: []interface{}{EncodingGzip}}, EncodingGzip))
+	assert.False(t, AcceptsEncoding(map[string]interface{}{MetadataAcceptEncoding: EncodingGzip}, EncodingGzip))
+	assert.False(t, AcceptsEncoding(nil, EncodingGzip))
+}
+
+func TestProtocolVersionOf(t *testing.T) {
+	assert.Equal(t, 2, ProtocolVersionOf(map[string]interface{}{MetadataProtocolVersion: 2}))
+	assert.Equal(t, 2, ProtocolVersionOf(map[string]interface{}{MetadataProtocolVersion: float64(2)}), "decoded from JSON")
+	assert.Equal(t, 1, ProtocolVersionOf(map[string]interface{}{MetadataProtocolVersion: "2"}))
+	assert.Equal(t, 1, ProtocolVersionOf(nil))
+}
@@ -0,0 +1,30 @@
+package hook
+
+import (
+	"context"
+	"sync"
+)
+
+// serializedHook guards an IPCHook with a mutex
+type serializedHook struct {
+	IPCHook
+	mu sync.Mutex
+}
+
+// Serialize wraps h so that its evaluations never overlap. The interceptor
+// evaluates requests from concurrent commands in parallel, which is unsafe
+// for hooks with unsynchronized state; Serialize makes such hooks safe
+// without changes to the hook itself.
+//
+// The tradeoff is throughput: every request waits for the one before it, so
+// a slow evaluation delays all concurrently running commands.
+func Serialize(h IPCHook) IPCHook {
+	return &serializedHook{IPCHook: h}
+}
+
+// EvaluateIPC evaluates the request while holding the hook's lock
+func (s *serializedHook) EvaluateIPC(ctx context.Context, req *Request) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.IPCHook.EvaluateIPC(ctx, req)
+}
@@ -0,0 +1,58 @@
+package hook
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// overlapDetectingHook records whether two evaluations were ever in progress
+// at the same time
+type overlapDetectingHook struct {
+	active     atomic.Int32
+	overlapped atomic.Bool
+}
+
+func (h *overlapDetectingHook) Name() string       { return "overlap" }
+func (h *overlapDetectingHook) Commands() []string { return []string{"*"} }
+func (h *overlapDetectingHook) EvaluateIPC(ctx context.Context, req *Request) (*Response, error) {
+	if h.active.Add(1) > 1 {
+		h.overlapped.Store(true)
+	}
+	time.Sleep(2 * time.Millisecond)
+	h.active.Add(-1)
+	return &Response{}, nil
+}
+
+func evaluateConcurrently(t *testing.T, h IPCHook) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := h.EvaluateIPC(context.Background(), &Request{Command: []string{"curl"}, Hook: HookPreRun})
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSerialize(t *testing.T) {
+	// Sanity check: unwrapped evaluations do overlap
+	raw := &overlapDetectingHook{}
+	evaluateConcurrently(t, raw)
+	assert.True(t, raw.overlapped.Load())
+
+	inner := &overlapDetectingHook{}
+	serialized := Serialize(inner)
+	evaluateConcurrently(t, serialized)
+	assert.False(t, inner.overlapped.Load(), "serialized evaluations must not overlap")
+
+	assert.Equal(t, "overlap", serialized.Name())
+	assert.Equal(t, []string{"*"}, serialized.Commands())
+}
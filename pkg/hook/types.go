@@ -10,28 +10,180 @@ type HookType string
 const (
 	HookPreRun  HookType = "pre_run"  // Before execution
 	HookPostRun HookType = "post_run" // After execution
+
+	// HookPreRunBatch is sent once for a shell command list such as
+	// `a && b | c`, before any of its commands run. Request.Batch holds
+	// every command in the list and Request.Command the first of them.
+	// Each command is still evaluated individually when it runs.
+	HookPreRunBatch HookType = "pre_run_batch"
+
+	// HookReload is a control message asking the interceptor to reload its
+	// hook. It carries MetadataReloadToken and is never passed to hooks.
+	HookReload HookType = "reload"
+
+	// HookExplain is a control message asking the interceptor to evaluate
+	// a pre-run request without running anything and return the trace of
+	// the evaluation under MetadataExplain. It is never passed to hooks.
+	HookExplain HookType = "explain"
+)
+
+// MetadataReloadToken is the request metadata key holding the token that
+// authenticates a HookReload control message
+const MetadataReloadToken = "reload_token"
+
+// ExitReason explains why a command did not run, for post-run hooks
+type ExitReason string
+
+const (
+	// ExitReasonNotFound means the command does not exist (exit code 127)
+	ExitReasonNotFound ExitReason = "not_found"
+	// ExitReasonNotExecutable means the command exists but could not be
+	// executed, e.g. it lacks execute permission (exit code 126)
+	ExitReasonNotExecutable ExitReason = "not_executable"
+	// ExitReasonChaos means chaos testing failed the command without
+	// running it (see MetadataChaos)
+	ExitReasonChaos ExitReason = "chaos"
+)
+
+// MetadataRememberForRun is the response metadata key an interactive hook
+// sets to true to approve a command for the rest of the run. It only takes
+// effect on pre-run allows, and only if the confirmation cache is enabled.
+const MetadataRememberForRun = "remember_for_run"
+
+// MetadataSelfTest marks the canary pre-run request sent by the startup
+// self-test. The command does not run, and the interceptor answers with
+// the decision in its metadata instead of acting on it, so hooks may
+// evaluate it like any other request but should not prompt for it.
+const MetadataSelfTest = "self_test"
+
+// Request metadata keys set by the wrapper when argv[0] checking is enabled.
+// MetadataResolvedPath is the absolute path of the binary that will run,
+// with symlinks resolved. MetadataArgv0Mismatch is set to true when the
+// basename of argv[0] differs from the resolved binary's basename, a sign
+// the command may be masquerading as another program.
+const (
+	MetadataResolvedPath  = "resolved_path"
+	MetadataArgv0Mismatch = "argv0_mismatch"
+)
+
+// Request metadata keys set by the wrapper when interpreted script
+// detection is enabled and the command runs a script file through a known
+// interpreter, e.g. `python foo.py`. MetadataScriptPath is the absolute
+// path of the script and MetadataScriptSHA256 the hex SHA-256 digest of its
+// contents, omitted if the file cannot be read.
+const (
+	MetadataScriptPath   = "script_path"
+	MetadataScriptSHA256 = "script_sha256"
 )
 
+// MetadataPreRunDuration is the post-run request metadata key holding how
+// long the command's pre-run evaluation took in the wrapper, including IPC,
+// in nanoseconds. It is set when the wrapper reports a timing breakdown.
+const MetadataPreRunDuration = "pre_run_duration"
+
 // Request represents a complete request to be evaluated by hooks
 // This consolidates all request information in a single type
 type Request struct {
 	// Core request fields
 	Command []string `json:"command"` // [0] = command, [1:] = args
 	PID     int      `json:"pid"`
+	// ResolvedPath is the absolute path, with symlinks resolved, of the
+	// binary Command runs. It is empty if the wrapper could not find the
+	// binary, leaving hooks to decide how to treat such commands.
+	ResolvedPath string `json:"resolved_path,omitempty"`
+	// LookupPath is the absolute path the wrapper found Command at in PATH,
+	// before symlinks were resolved. Package databases may record a binary
+	// under this path rather than under ResolvedPath.
+	LookupPath string `json:"lookup_path,omitempty"`
+	// WorkingDir is the directory the command runs in
+	WorkingDir string `json:"working_dir,omitempty"`
+	// Env holds the environment variables the wrapper is configured to
+	// forward, out of those the command inherits
+	Env map[string]string `json:"env,omitempty"`
+	// UID and GID identify the user running the command. They are reported
+	// by the wrapper, unless MetadataCredentialsVerified is set in
+	// Metadata, in which case the interceptor read them from the socket.
+	// They are nil when unknown (older wrappers, platforms without user
+	// IDs, or fields filtered out by the wrapper), which hooks must not
+	// mistake for root.
+	UID *int `json:"uid,omitempty"`
+	GID *int `json:"gid,omitempty"`
 
 	// Hook context
 	Hook HookType `json:"hook"`
+	// RequestID is shared by the pre-run and post-run requests of one
+	// command, so they can be correlated. The wrapper generates it once per
+	// command and also sends it as CorrelationID.
+	RequestID string `json:"request_id,omitempty"`
+	// CorrelationID traces a command across its pre-run and post-run
+	// requests. It currently has the same value as RequestID.
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// StartedAt is when the wrapper began handling the command, also shared
+	// by its pre-run and post-run requests. It is the zero time for
+	// requests from wrappers that do not report it.
+	StartedAt time.Time `json:"started_at"`
+
+	// Batch fields (only populated for pre_run_batch hooks)
+	Batch [][]string `json:"batch,omitempty"` // every command in the list
 
 	// Post-run fields (only populated for post_run hooks)
 	ExitCode int           `json:"exit_code,omitempty"`
 	Duration time.Duration `json:"duration,omitempty"`
+	// ExitReason explains an exit code the command itself did not produce
+	ExitReason ExitReason `json:"exit_reason,omitempty"`
 
 	// Additional metadata
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// PeerUID is the UID of the process that sent the request, verified by
+	// the interceptor from the socket's peer credentials. It is nil when
+	// the platform or transport does not provide them, and is never read
+	// from the wire, so a wrapper cannot claim another user's identity.
+	PeerUID *int `json:"-"`
 }
 
 // Response represents the result of a hook evaluation
 type Response struct {
 	Exit     bool                   `json:"exit,omitempty"`     // If true, command the process tree to be killed
+	Reason   string                 `json:"reason,omitempty"`   // Why the command was blocked, shown to the user
 	Metadata map[string]interface{} `json:"metadata,omitempty"` // Metadata to be merged into subsequent requests
+	// ModifiedCommand, if set in a pre-run response that allows the
+	// command, is run instead of the requested command, e.g. to force
+	// `curl --fail` or strip a dangerous flag. It is ignored at post-run.
+	ModifiedCommand []string `json:"modified_command,omitempty"`
+	// ExitCode, if set in a pre-run response, denies the command: the
+	// wrapper skips it and exits with this code (0-255), so scripts can
+	// branch on the denial. Unless Exit is also set, the rest of the
+	// script keeps running.
+	ExitCode *int `json:"exit_code,omitempty"`
+	// EnvUnset and EnvOverride, if set in a pre-run response, adjust the
+	// environment the command runs with: the listed variables are removed,
+	// then the overrides are set. PATH and CMDHOOKS_* variables, which keep
+	// child processes intercepted, cannot be changed. Both are ignored at
+	// post-run.
+	EnvUnset    []string          `json:"env_unset,omitempty"`
+	EnvOverride map[string]string `json:"env_override,omitempty"`
+}
+
+// Denied reports whether r stops the command, by Exit or ExitCode
+func (r *Response) Denied() bool {
+	return r != nil && (r.Exit || r.ExitCode != nil)
+}
+
+// MetadataModifiedCommand is the post-run request metadata key holding the
+// command that actually ran, when a hook rewrote it at pre-run (see
+// Response.ModifiedCommand). Request.Command keeps the original command.
+const MetadataModifiedCommand = "modified_command"
+
+// BlockReason returns the explanation for a decision: Reason, or else the
+// "reason" metadata key used by hooks that predate the field
+func (r *Response) BlockReason() string {
+	if r == nil {
+		return ""
+	}
+	if r.Reason != "" {
+		return r.Reason
+	}
+	reason, _ := r.Metadata["reason"].(string)
+	return reason
 }
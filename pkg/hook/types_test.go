@@ -0,0 +1,61 @@
+package hook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseBlockReason(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *Response
+		want string
+	}{
+		{name: "nil response", resp: nil},
+		{name: "no reason", resp: &Response{Exit: true}},
+		{name: "reason field", resp: &Response{Exit: true, Reason: "not allowed"}, want: "not allowed"},
+		{name: "legacy metadata", resp: &Response{Exit: true, Metadata: map[string]interface{}{"reason": "quota exceeded"}}, want: "quota exceeded"},
+		{
+			name: "field wins over metadata",
+			resp: &Response{Exit: true, Reason: "not allowed", Metadata: map[string]interface{}{"reason": "quota exceeded"}},
+			want: "not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.resp.BlockReason())
+		})
+	}
+}
+
+func TestResponseReasonJSON(t *testing.T) {
+	data, err := json.Marshal(&Response{Exit: true, Reason: "not allowed"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"exit":true,"reason":"not allowed"}`, string(data))
+
+	data, err = json.Marshal(&Response{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(data), "empty reason is omitted")
+
+	var resp Response
+	require.NoError(t, json.Unmarshal([]byte(`{"exit":true,"reason":"blocked"}`), &resp))
+	assert.Equal(t, "blocked", resp.Reason)
+}
+
+func TestResponseDenied(t *testing.T) {
+	code := 77
+	assert.False(t, (*Response)(nil).Denied())
+	assert.False(t, (&Response{}).Denied())
+	assert.True(t, (&Response{Exit: true}).Denied())
+	assert.True(t, (&Response{ExitCode: &code}).Denied())
+	assert.Equal(t, DecisionBlock, (&Response{ExitCode: &code}).Decision())
+
+	var resp Response
+	require.NoError(t, json.Unmarshal([]byte(`{"exit_code":0}`), &resp))
+	require.NotNil(t, resp.ExitCode, "an explicit zero exit code is kept")
+	assert.Equal(t, 0, *resp.ExitCode)
+}
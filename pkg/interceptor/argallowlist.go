@@ -0,0 +1,57 @@
+package interceptor
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// MetadataArgAllowlist is the response metadata key holding the allowlisted
+// prefix that let a request skip the hook
+const MetadataArgAllowlist = "arg_allowlist"
+
+// SetArgAllowlist allows pre-run requests without consulting the hook when
+// their arguments begin with one of the prefixes listed for the command,
+// e.g. {"git": {"status", "log --oneline"}} lets `git status -s` and
+// `git log --oneline -5` through while `git push` still reaches the hook.
+// Commands are matched by basename. Prefixes are split into words on white
+// space and compared word by word, so "log" does not match `git logs`.
+// Post-run requests and batches are unaffected. Nil disables the
+// allowlist.
+func (i *Interceptor) SetArgAllowlist(allowlist map[string][]string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if len(allowlist) == 0 {
+		i.argAllowlist = nil
+		return
+	}
+	i.argAllowlist = make(map[string][][]string, len(allowlist))
+	for command, prefixes := range allowlist {
+		for _, prefix := range prefixes {
+			i.argAllowlist[command] = append(i.argAllowlist[command], strings.Fields(prefix))
+		}
+	}
+}
+
+// applyArgAllowlist returns an allowing response if req's arguments start
+// with an allowlisted prefix, or nil to evaluate the hook as usual
+func (i *Interceptor) applyArgAllowlist(req *hook.Request) *hook.Response {
+	i.mu.Lock()
+	allowlist := i.argAllowlist
+	i.mu.Unlock()
+	if allowlist == nil || req.Hook != hook.HookPreRun || len(req.Command) == 0 {
+		return nil
+	}
+
+	args := req.Command[1:]
+	for _, prefix := range allowlist[filepath.Base(req.Command[0])] {
+		if len(prefix) <= len(args) && slices.Equal(prefix, args[:len(prefix)]) {
+			return &hook.Response{Metadata: map[string]interface{}{
+				MetadataArgAllowlist: strings.Join(prefix, " "),
+			}}
+		}
+	}
+	return nil
+}
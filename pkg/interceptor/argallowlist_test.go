@@ -0,0 +1,87 @@
+package interceptor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestArgAllowlist(t *testing.T) {
+	allowlist := map[string][]string{
+		"git": {"status", "log  --oneline"},
+	}
+
+	tests := []struct {
+		name       string
+		req        hook.Request
+		wantPrefix string
+	}{
+		{
+			name:       "allowlisted subcommand",
+			req:        hook.Request{Command: []string{"git", "status"}, Hook: hook.HookPreRun},
+			wantPrefix: "status",
+		},
+		{
+			name:       "extra arguments after the prefix",
+			req:        hook.Request{Command: []string{"git", "status", "-s"}, Hook: hook.HookPreRun},
+			wantPrefix: "status",
+		},
+		{
+			name:       "multi-word prefix",
+			req:        hook.Request{Command: []string{"/usr/bin/git", "log", "--oneline", "-5"}, Hook: hook.HookPreRun},
+			wantPrefix: "log --oneline",
+		},
+		{
+			name: "other subcommand reaches the hook",
+			req:  hook.Request{Command: []string{"git", "push"}, Hook: hook.HookPreRun},
+		},
+		{
+			name: "prefix matches whole words only",
+			req:  hook.Request{Command: []string{"git", "statuses"}, Hook: hook.HookPreRun},
+		},
+		{
+			name: "partial multi-word prefix reaches the hook",
+			req:  hook.Request{Command: []string{"git", "log", "-p"}, Hook: hook.HookPreRun},
+		},
+		{
+			name: "no arguments reach the hook",
+			req:  hook.Request{Command: []string{"git"}, Hook: hook.HookPreRun},
+		},
+		{
+			name: "post-run reaches the hook",
+			req:  hook.Request{Command: []string{"git", "status"}, Hook: hook.HookPostRun},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newMockHook("test", []string{"git"})
+			i := New("/tmp/unused.sock", false, h)
+			i.SetArgAllowlist(allowlist)
+
+			resp, err := i.processRequest(&tt.req)
+			require.NoError(t, err)
+			assert.False(t, resp.Exit)
+			if tt.wantPrefix != "" {
+				assert.Equal(t, 0, h.evalCount, "hook skipped")
+				assert.Equal(t, tt.wantPrefix, resp.Metadata[MetadataArgAllowlist])
+			} else {
+				assert.Equal(t, 1, h.evalCount, "hook consulted")
+				assert.NotContains(t, resp.Metadata, MetadataArgAllowlist)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		h := newMockHook("test", []string{"git"})
+		i := New("/tmp/unused.sock", false, h)
+		i.SetArgAllowlist(allowlist)
+		i.SetArgAllowlist(nil)
+		_, err := i.processRequest(&hook.Request{Command: []string{"git", "status"}, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+		assert.Equal(t, 1, h.evalCount)
+	})
+}
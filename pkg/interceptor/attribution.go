@@ -0,0 +1,29 @@
+package interceptor
+
+import (
+	"maps"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// attribute records the IPC stage's decision in the response when the
+// wrapper asked for attribution, so audit records show which stage decided
+// and what the local stage returned. It returns a copy; resp may be shared
+// with deduplicated requests.
+func attribute(req *hook.Request, resp *hook.Response) *hook.Response {
+	requested, ok := req.Metadata[hook.MetadataAttribution].(map[string]interface{})
+	if !ok {
+		return resp
+	}
+
+	attribution := maps.Clone(requested)
+	attribution["decided_by"] = hook.StageIPC
+	attribution[hook.StageIPC] = resp.Decision()
+
+	out := copyResponse(resp)
+	if out.Metadata == nil {
+		out.Metadata = make(map[string]interface{})
+	}
+	out.Metadata[hook.MetadataAttribution] = attribution
+	return out
+}
@@ -0,0 +1,48 @@
+package interceptor
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestAttribution(t *testing.T) {
+	var buf bytes.Buffer
+	h := &mockIPCHook{response: &hook.Response{Exit: true, Metadata: map[string]interface{}{"rule": "deny-curl"}}}
+	i := New("", false, h)
+	i.SetDecisionLog(NewDecisionLog(&buf))
+
+	resp, err := i.processRequest(&hook.Request{
+		Command: []string{"curl"},
+		Hook:    hook.HookPreRun,
+		Metadata: map[string]interface{}{
+			hook.MetadataAttribution: map[string]interface{}{hook.StageLocal: hook.DecisionAllow},
+		},
+	})
+	require.NoError(t, err)
+
+	want := map[string]interface{}{
+		"decided_by":    hook.StageIPC,
+		hook.StageLocal: hook.DecisionAllow,
+		hook.StageIPC:   hook.DecisionBlock,
+	}
+	assert.Equal(t, want, resp.Metadata[hook.MetadataAttribution])
+	assert.Equal(t, "deny-curl", resp.Metadata["rule"])
+	assert.NotContains(t, h.response.Metadata, hook.MetadataAttribution, "the hook's response is not modified")
+
+	var entry DecisionEntry
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, want, entry.Metadata[hook.MetadataAttribution])
+	assert.Equal(t, []string{"curl"}, i.History()[0].Command)
+	assert.Equal(t, want, i.History()[0].Metadata[hook.MetadataAttribution])
+
+	// Attribution is only added when the wrapper asks for it
+	resp, err = i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.NotContains(t, resp.Metadata, hook.MetadataAttribution)
+}
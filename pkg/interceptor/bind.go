@@ -0,0 +1,45 @@
+package interceptor
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// listen creates socket listeners (see listenSocket); tests replace it to
+// simulate bind failures
+var listen = listenSocket
+
+// SetBindRetry makes Start retry binding the socket up to retries more
+// times when the address is reported in use or unavailable, which can
+// happen briefly when a fixed socket path is reused right after a previous
+// interceptor stopped. The wait starts at backoff and doubles after each
+// attempt. Other errors, and conflicts that outlast the retries, still fail
+// Start. Zero retries, the default, binds once.
+func (i *Interceptor) SetBindRetry(retries int, backoff time.Duration) {
+	i.bindRetries = retries
+	i.bindBackoff = backoff
+}
+
+// bind removes any stale socket file and creates the listener, retrying
+// transient failures as configured by SetBindRetry
+func (i *Interceptor) bind() (net.Listener, error) {
+	delay := i.bindBackoff
+	for attempt := 0; ; attempt++ {
+		os.Remove(i.socketPath)
+		l, err := listen(i.socketType.Network(), i.socketPath)
+		if err == nil || attempt >= i.bindRetries || !transientBindError(err) {
+			return l, err
+		}
+		i.logf("Socket %s busy, retrying in %v: %v", i.socketPath, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// transientBindError reports whether a bind failure may clear up on retry
+func transientBindError(err error) bool {
+	return errors.Is(err, syscall.EADDRINUSE) || errors.Is(err, syscall.EADDRNOTAVAIL)
+}
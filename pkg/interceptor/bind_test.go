@@ -0,0 +1,66 @@
+package interceptor
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingListen makes the first failures calls to listen fail with err
+func failingListen(t *testing.T, failures int, err error) *int {
+	calls := 0
+	oldListen := listen
+	listen = func(network, address string) (net.Listener, error) {
+		calls++
+		if calls <= failures {
+			return nil, &net.OpError{Op: "listen", Net: network, Err: err}
+		}
+		return oldListen(network, address)
+	}
+	t.Cleanup(func() { listen = oldListen })
+	return &calls
+}
+
+func TestBindRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "no retries by default", retries: 0, failures: 1, err: os.NewSyscallError("bind", syscall.EADDRINUSE), wantCalls: 1, wantErr: true},
+		{name: "transient failure", retries: 3, failures: 2, err: os.NewSyscallError("bind", syscall.EADDRINUSE), wantCalls: 3},
+		{name: "address not available", retries: 3, failures: 1, err: os.NewSyscallError("bind", syscall.EADDRNOTAVAIL), wantCalls: 2},
+		{name: "persistent conflict", retries: 2, failures: 10, err: os.NewSyscallError("bind", syscall.EADDRINUSE), wantCalls: 3, wantErr: true},
+		{name: "other errors fail at once", retries: 3, failures: 1, err: os.NewSyscallError("bind", syscall.EACCES), wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socketPath := fmt.Sprintf("/tmp/test_%d.sock", time.Now().UnixNano())
+			defer os.Remove(socketPath)
+			calls := failingListen(t, tt.failures, tt.err)
+
+			i := New(socketPath, false, &mockIPCHook{})
+			if tt.retries > 0 {
+				i.SetBindRetry(tt.retries, time.Millisecond)
+			}
+			err := i.Start()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err.(*os.SyscallError).Err)
+			} else {
+				require.NoError(t, err)
+				i.Stop()
+			}
+			assert.Equal(t, tt.wantCalls, *calls)
+		})
+	}
+}
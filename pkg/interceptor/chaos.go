@@ -0,0 +1,96 @@
+package interceptor
+
+import (
+	"maps"
+	"math/rand"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// DefaultChaosExitCode is the exit code of injected failures when
+// ChaosConfig.ExitCode is unset
+const DefaultChaosExitCode = 1
+
+// ChaosConfig describes the faults injected by SetChaos
+type ChaosConfig struct {
+	// Rate is the probability, from 0 to 1, that an allowed pre-run request
+	// for one of Commands gets a fault
+	Rate float64
+	// Commands lists the command names eligible for faults; "*" matches
+	// every command
+	Commands []string
+	// ExitCode is reported by injected failures (default
+	// DefaultChaosExitCode)
+	ExitCode int
+	// Rand returns random numbers in [0, 1) (default math/rand)
+	Rand func() float64
+}
+
+// SetChaos enables fault injection for resilience testing of the code
+// around wrapped commands; it must never be used in production. Once set,
+// a random share of the allowed pre-run requests for the selected commands
+// is answered with an injected fault instead of the hook's decision: half
+// are blocked (hook.ChaosBlock), the other half are failed without running
+// (hook.ChaosFail). Injected responses carry hook.MetadataChaos, so audit
+// records and hooks can tell them apart from real decisions. Nil, the
+// default, disables injection.
+func (i *Interceptor) SetChaos(c *ChaosConfig) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if c == nil || c.Rate <= 0 {
+		i.chaos = nil
+		return
+	}
+	chaos := *c
+	if chaos.ExitCode == 0 {
+		chaos.ExitCode = DefaultChaosExitCode
+	}
+	if chaos.Rand == nil {
+		chaos.Rand = rand.Float64
+	}
+	i.chaos = &chaos
+}
+
+// ChaosInjected returns the number of faults injected so far
+func (i *Interceptor) ChaosInjected() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.chaosInjected
+}
+
+// injectChaos replaces resp with an injected fault if chaos testing picks
+// req
+func (i *Interceptor) injectChaos(req *hook.Request, resp *hook.Response) *hook.Response {
+	if req.Hook != hook.HookPreRun || resp.Denied() || len(req.Command) == 0 {
+		return resp
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	c := i.chaos
+	if c == nil || !matchesCommand(c.Commands, req.Command[0]) || c.Rand() >= c.Rate {
+		return resp
+	}
+	i.chaosInjected++
+
+	out := &hook.Response{Metadata: maps.Clone(resp.Metadata)}
+	if out.Metadata == nil {
+		out.Metadata = make(map[string]interface{})
+	}
+	if c.Rand() < 0.5 {
+		out.Exit = true
+		out.Reason = "chaos testing: injected block"
+		out.Metadata[hook.MetadataChaos] = hook.ChaosBlock
+	} else {
+		out.Metadata[hook.MetadataChaos] = hook.ChaosFail
+		out.Metadata[hook.MetadataChaosExitCode] = c.ExitCode
+	}
+	i.logf("Chaos testing: injected %s for %v", out.Metadata[hook.MetadataChaos], req.Command)
+	return out
+}
+
+// matchesCommand reports whether an entry of commands matches name, see
+// hook.HandlesCommand
+func matchesCommand(commands []string, name string) bool {
+	return hook.HandlesCommand(commands, name, false)
+}
@@ -0,0 +1,104 @@
+package interceptor
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestChaos_OffByDefault(t *testing.T) {
+	i := New("/tmp/unused.sock", false, &mockIPCHook{response: &hook.Response{}})
+	for n := 0; n < 1000; n++ {
+		resp, err := i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+		assert.False(t, resp.Exit)
+		assert.NotContains(t, resp.Metadata, hook.MetadataChaos)
+	}
+	assert.Zero(t, i.ChaosInjected())
+
+	// A zero rate also disables injection
+	i.SetChaos(&ChaosConfig{Rate: 0, Commands: []string{"*"}})
+	resp, err := i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.NotContains(t, resp.Metadata, hook.MetadataChaos)
+}
+
+func TestChaos_Rate(t *testing.T) {
+	tests := []struct {
+		name string
+		rate float64
+	}{
+		{name: "ten percent", rate: 0.1},
+		{name: "half", rate: 0.5},
+		{name: "always", rate: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := New("/tmp/unused.sock", false, &mockIPCHook{response: &hook.Response{}})
+			i.SetChaos(&ChaosConfig{Rate: tt.rate, Commands: []string{"*"}, Rand: rand.New(rand.NewSource(1)).Float64})
+
+			const total = 10000
+			faults := map[interface{}]int{}
+			for n := 0; n < total; n++ {
+				resp, err := i.processRequest(&hook.Request{Command: []string{"make"}, Hook: hook.HookPreRun})
+				require.NoError(t, err)
+				if fault, ok := resp.Metadata[hook.MetadataChaos]; ok {
+					faults[fault]++
+				}
+			}
+
+			injected := faults[hook.ChaosBlock] + faults[hook.ChaosFail]
+			assert.Equal(t, injected, i.ChaosInjected())
+			assert.InDelta(t, tt.rate, float64(injected)/total, 0.02)
+			assert.InDelta(t, 0.5, float64(faults[hook.ChaosBlock])/float64(injected), 0.05, "blocks and failures are equally likely")
+		})
+	}
+}
+
+func TestChaos_Faults(t *testing.T) {
+	i := New("/tmp/unused.sock", false, &mockIPCHook{response: &hook.Response{Metadata: map[string]interface{}{"note": "ok"}}})
+	draws := []float64{0, 0, 0, 0.9}
+	i.SetChaos(&ChaosConfig{Rate: 0.5, Commands: []string{"curl"}, ExitCode: 7, Rand: func() float64 {
+		v := draws[0]
+		draws = draws[1:]
+		return v
+	}})
+
+	resp, err := i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.True(t, resp.Exit)
+	assert.Equal(t, "chaos testing: injected block", resp.Reason)
+	assert.Equal(t, hook.ChaosBlock, resp.Metadata[hook.MetadataChaos])
+	assert.Equal(t, "ok", resp.Metadata["note"])
+
+	resp, err = i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.False(t, resp.Exit)
+	assert.Equal(t, hook.ChaosFail, resp.Metadata[hook.MetadataChaos])
+	assert.Equal(t, 7, resp.Metadata[hook.MetadataChaosExitCode])
+	assert.Empty(t, draws)
+
+	// Unselected commands and post-run requests are never touched
+	resp, err = i.processRequest(&hook.Request{Command: []string{"make"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.NotContains(t, resp.Metadata, hook.MetadataChaos)
+	resp, err = i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPostRun})
+	require.NoError(t, err)
+	assert.NotContains(t, resp.Metadata, hook.MetadataChaos)
+	assert.Equal(t, 2, i.ChaosInjected())
+}
+
+func TestChaos_KeepsHookBlocks(t *testing.T) {
+	i := New("/tmp/unused.sock", false, &mockIPCHook{response: &hook.Response{Exit: true, Reason: "denied"}})
+	i.SetChaos(&ChaosConfig{Rate: 1, Commands: []string{"*"}})
+
+	resp, err := i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.Equal(t, "denied", resp.Reason)
+	assert.NotContains(t, resp.Metadata, hook.MetadataChaos)
+}
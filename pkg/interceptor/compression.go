@@ -0,0 +1,50 @@
+package interceptor
+
+import (
+	"maps"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// SetRequestCompression lets connections negotiate compression (see the
+// wire protocol notes in package hook): when the first request on a
+// connection offers hook.EncodingGzip, the interceptor accepts it in the
+// first response and compresses every later message on the connection.
+// Wrappers that do not offer it are answered with plain JSON.
+func (i *Interceptor) SetRequestCompression(enabled bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.compression = enabled
+}
+
+// negotiateEncoding removes the encoding offer from req and returns the
+// encoding to use for the rest of the connection, or "" for plain JSON.
+// Only the first request on a connection may offer encodings.
+func (i *Interceptor) negotiateEncoding(req *hook.Request, first bool) string {
+	if _, ok := req.Metadata[hook.MetadataAcceptEncoding]; !ok {
+		return ""
+	}
+	offered := req.Metadata
+	req.Metadata = maps.Clone(req.Metadata)
+	delete(req.Metadata, hook.MetadataAcceptEncoding)
+
+	i.mu.Lock()
+	enabled := i.compression
+	i.mu.Unlock()
+	if !first || !enabled || !hook.AcceptsEncoding(offered, hook.EncodingGzip) {
+		return ""
+	}
+	return hook.EncodingGzip
+}
+
+// announceEncoding returns a copy of resp telling the wrapper that later
+// messages use encoding
+func announceEncoding(resp *hook.Response, encoding string) *hook.Response {
+	out := *resp
+	out.Metadata = maps.Clone(resp.Metadata)
+	if out.Metadata == nil {
+		out.Metadata = make(map[string]interface{})
+	}
+	out.Metadata[hook.MetadataEncoding] = encoding
+	return &out
+}
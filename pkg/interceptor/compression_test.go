@@ -0,0 +1,87 @@
+package interceptor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestRequestCompression(t *testing.T) {
+	tests := []struct {
+		name         string
+		enabled      bool
+		offer        bool
+		wantEncoding string
+	}{
+		{name: "negotiated", enabled: true, offer: true, wantEncoding: hook.EncodingGzip},
+		{name: "not offered", enabled: true, offer: false},
+		{name: "disabled", enabled: false, offer: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socketPath := fmt.Sprintf("/tmp/test_%d.sock", time.Now().UnixNano())
+			defer os.Remove(socketPath)
+
+			mockHook := &mockIPCHook{response: &hook.Response{Metadata: map[string]interface{}{"note": "ok"}}}
+			i := New(socketPath, false, mockHook)
+			i.SetRequestCompression(tt.enabled)
+			require.NoError(t, i.Start())
+			defer i.Stop()
+
+			conn, err := net.Dial("unix", socketPath)
+			require.NoError(t, err)
+			defer conn.Close()
+			scanner := bufio.NewScanner(conn)
+
+			first := hook.Request{Command: []string{"make"}, Hook: hook.HookPreRun}
+			if tt.offer {
+				first.Metadata = map[string]interface{}{hook.MetadataAcceptEncoding: []string{hook.EncodingGzip}}
+			}
+			data, err := json.Marshal(first)
+			require.NoError(t, err)
+			_, err = conn.Write(append(data, '\n'))
+			require.NoError(t, err)
+
+			require.True(t, scanner.Scan())
+			var resp hook.Response
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &resp), "the first response is always plain JSON")
+			assert.Equal(t, "ok", resp.Metadata["note"])
+			if tt.wantEncoding == "" {
+				assert.NotContains(t, resp.Metadata, hook.MetadataEncoding)
+			} else {
+				assert.Equal(t, tt.wantEncoding, resp.Metadata[hook.MetadataEncoding])
+			}
+
+			data, err = json.Marshal(hook.Request{Command: []string{"make"}, Hook: hook.HookPostRun})
+			require.NoError(t, err)
+			data, err = hook.EncodeMessage(data, tt.wantEncoding)
+			require.NoError(t, err)
+			_, err = conn.Write(append(data, '\n'))
+			require.NoError(t, err)
+
+			require.True(t, scanner.Scan())
+			raw := scanner.Bytes()
+			if tt.wantEncoding == "" {
+				assert.Equal(t, byte('{'), raw[0])
+			} else {
+				assert.NotEqual(t, byte('{'), raw[0], "later responses are compressed")
+			}
+			decoded, err := hook.DecodeMessage(raw, MaxIPCMessageBytes)
+			require.NoError(t, err)
+			resp = hook.Response{}
+			require.NoError(t, json.Unmarshal(decoded, &resp))
+			assert.Equal(t, "ok", resp.Metadata["note"])
+			assert.NotContains(t, resp.Metadata, hook.MetadataEncoding)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package interceptor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// statefulIPCHook stashes the pre-run request ID in the connection state
+// and records what each post-run request found there
+type statefulIPCHook struct {
+	mu    sync.Mutex
+	found []interface{}
+}
+
+func (s *statefulIPCHook) Name() string       { return "stateful" }
+func (s *statefulIPCHook) Commands() []string { return []string{"*"} }
+func (s *statefulIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	state := hook.ConnectionStateFromContext(ctx)
+	if state == nil {
+		return nil, fmt.Errorf("no connection state")
+	}
+	switch req.Hook {
+	case hook.HookPreRun:
+		state.Set("pre_run_id", req.RequestID)
+	case hook.HookPostRun:
+		v, _ := state.Get("pre_run_id")
+		s.mu.Lock()
+		s.found = append(s.found, v)
+		s.mu.Unlock()
+	}
+	return &hook.Response{}, nil
+}
+
+func TestConnectionState(t *testing.T) {
+	socketPath := fmt.Sprintf("/tmp/test_%d.sock", time.Now().UnixNano())
+	defer os.Remove(socketPath)
+
+	h := &statefulIPCHook{}
+	interceptor := New(socketPath, false, h)
+	require.NoError(t, interceptor.Start())
+	defer interceptor.Stop()
+
+	exchange := func(conn net.Conn, scanner *bufio.Scanner, req hook.Request) {
+		data, err := json.Marshal(req)
+		require.NoError(t, err)
+		_, err = conn.Write(append(data, '\n'))
+		require.NoError(t, err)
+		require.True(t, scanner.Scan())
+		var resp hook.Response
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &resp))
+		assert.False(t, resp.Exit)
+	}
+
+	// Pre-run and post-run over one connection share state
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	scanner := bufio.NewScanner(conn)
+	exchange(conn, scanner, hook.Request{Command: []string{"make"}, Hook: hook.HookPreRun, RequestID: "req-1"})
+	exchange(conn, scanner, hook.Request{Command: []string{"make"}, Hook: hook.HookPostRun, RequestID: "req-1"})
+	conn.Close()
+
+	// A separate connection starts with empty state
+	conn, err = net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	exchange(conn, bufio.NewScanner(conn), hook.Request{Command: []string{"make"}, Hook: hook.HookPostRun, RequestID: "req-1"})
+	conn.Close()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	assert.Equal(t, []interface{}{"req-1", nil}, h.found)
+}
+
+func TestStopClosesIdleConnections(t *testing.T) {
+	socketPath := fmt.Sprintf("/tmp/test_%d.sock", time.Now().UnixNano())
+	defer os.Remove(socketPath)
+
+	interceptor := New(socketPath, false, &mockIPCHook{response: &hook.Response{}})
+	require.NoError(t, interceptor.Start())
+
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte(`{"command":["make"],"hook":"pre_run"}` + "\n"))
+	require.NoError(t, err)
+	scanner := bufio.NewScanner(conn)
+	require.True(t, scanner.Scan())
+
+	// The connection is now idle, waiting for a post-run request
+	stopped := make(chan struct{})
+	go func() {
+		interceptor.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop waited for an idle connection")
+	}
+	assert.False(t, scanner.Scan(), "the idle connection is closed")
+}
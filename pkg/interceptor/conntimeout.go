@@ -0,0 +1,32 @@
+package interceptor
+
+import (
+	"net"
+	"time"
+)
+
+// SetConnectionTimeout bounds how long a connection may take to deliver
+// its first request, and how long each response may take to be written.
+// Connections missing the deadline are closed without a response, so a
+// wrapper that hangs mid-write, or a client that never writes, cannot hold
+// a connection open forever. A shared connection waiting for the post-run
+// request while its command runs is not timed out. Zero or negative
+// disables the timeout, the default. Call it before Start.
+func (i *Interceptor) SetConnectionTimeout(d time.Duration) {
+	i.connTimeout = d
+}
+
+// setReadDeadline bounds the next read of conn by the connection timeout
+// when first is set, and clears the deadline for the later, untimed reads
+func (i *Interceptor) setReadDeadline(conn net.Conn, first bool) {
+	if i.connTimeout <= 0 {
+		return
+	}
+	deadline := time.Time{}
+	if first {
+		deadline = time.Now().Add(i.connTimeout)
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		i.logf("Failed to set read deadline: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package interceptor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestConnectionTimeout(t *testing.T) {
+	socketPath := fmt.Sprintf("/tmp/test_%d.sock", time.Now().UnixNano())
+	defer os.Remove(socketPath)
+
+	i := New(socketPath, false, &mockIPCHook{response: &hook.Response{}})
+	i.SetConnectionTimeout(50 * time.Millisecond)
+	require.NoError(t, i.Start())
+	defer i.Stop()
+
+	t.Run("silent client is reaped", func(t *testing.T) {
+		conn, err := net.Dial("unix", socketPath)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, err := conn.Read(make([]byte, 1))
+		assert.Equal(t, 0, n, "no response is sent")
+		assert.ErrorIs(t, err, io.EOF, "the interceptor closes the connection")
+
+		select {
+		case <-i.ExitSignal():
+			t.Fatal("a timed out connection must not signal exit")
+		default:
+		}
+		assert.Zero(t, i.Stats().Total)
+	})
+
+	t.Run("shared connection waits for post-run", func(t *testing.T) {
+		conn, err := net.Dial("unix", socketPath)
+		require.NoError(t, err)
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+
+		for _, req := range []hook.Request{
+			{Command: []string{"make"}, Hook: hook.HookPreRun},
+			{Command: []string{"make"}, Hook: hook.HookPostRun},
+		} {
+			data, err := json.Marshal(req)
+			require.NoError(t, err)
+			_, err = conn.Write(append(data, '\n'))
+			require.NoError(t, err)
+			require.True(t, scanner.Scan(), "expected a response to %s", req.Hook)
+
+			// The command runs for longer than the timeout
+			time.Sleep(100 * time.Millisecond)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package interceptor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// credentialsRecordingHook records the requests it evaluates
+type credentialsRecordingHook struct {
+	mu   sync.Mutex
+	reqs []*hook.Request
+}
+
+func (h *credentialsRecordingHook) Name() string       { return "credentials-recording" }
+func (h *credentialsRecordingHook) Commands() []string { return []string{"*"} }
+func (h *credentialsRecordingHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.reqs = append(h.reqs, req)
+	return &hook.Response{}, nil
+}
+
+// sendRequestLine writes one request line to conn and waits for the
+// response
+func sendRequestLine(t *testing.T, conn net.Conn, line string) {
+	t.Helper()
+	_, err := fmt.Fprintf(conn, "%s\n", line)
+	require.NoError(t, err)
+	require.True(t, bufio.NewScanner(conn).Scan())
+}
+
+// claimedCredentials is a request from a wrapper reporting its own identity
+// and claiming it was verified
+const claimedCredentials = `{"command":["ls"],"pid":1,"uid":12345,"gid":54321,"hook":"pre_run","metadata":{"credentials_verified":true}}`
+
+func TestSelfReportedCredentials(t *testing.T) {
+	// TCP connections carry no peer credentials
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	h := &credentialsRecordingHook{}
+	i := New("/tmp/unused.sock", false, h)
+	i.SetListener(listener)
+	require.NoError(t, i.Start())
+	defer i.Stop()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	sendRequestLine(t, conn, claimedCredentials)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	require.Len(t, h.reqs, 1)
+	req := h.reqs[0]
+	require.NotNil(t, req.UID)
+	require.NotNil(t, req.GID)
+	assert.Equal(t, 12345, *req.UID, "the wrapper's report is kept")
+	assert.Equal(t, 54321, *req.GID)
+	assert.Nil(t, req.PeerUID)
+	assert.NotContains(t, req.Metadata, hook.MetadataCredentialsVerified, "a claimed verification is dropped")
+}
+
+func TestMissingCredentials(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	h := &credentialsRecordingHook{}
+	i := New("/tmp/unused.sock", false, h)
+	i.SetListener(listener)
+	require.NoError(t, i.Start())
+	defer i.Stop()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	sendRequestLine(t, conn, `{"command":["ls"],"pid":1,"hook":"pre_run"}`)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	require.Len(t, h.reqs, 1)
+	assert.Nil(t, h.reqs[0].UID, "an unreported UID is unknown, not root")
+	assert.Nil(t, h.reqs[0].GID)
+}
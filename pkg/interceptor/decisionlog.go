@@ -0,0 +1,129 @@
+package interceptor
+
+import (
+	"io"
+	"maps"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// RedactedValue replaces metadata values removed by RedactKeys
+const RedactedValue = "[REDACTED]"
+
+// DecisionEntry records the outcome of a single hook evaluation, including
+// the metadata the hook attached to explain it (a risk score, a matched
+// rule, ...). Exit is set for every denial; ExitCode is the exit code the
+// command was denied with, if the hook chose one.
+type DecisionEntry struct {
+	Time     time.Time              `json:"time"`
+	Command  []string               `json:"command"`
+	PID      int                    `json:"pid,omitempty"`
+	Hook     hook.HookType          `json:"hook"`
+	Exit     bool                   `json:"exit"`
+	ExitCode *int                   `json:"exit_code,omitempty"`
+	Reason   string                 `json:"reason,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// DecisionLog records every hook decision as newline-delimited JSON. It is
+// safe for concurrent use.
+type DecisionLog struct {
+	out jsonLineWriter
+}
+
+// NewDecisionLog creates a decision log writing to w
+func NewDecisionLog(w io.Writer) *DecisionLog {
+	return &DecisionLog{out: jsonLineWriter{w: w}}
+}
+
+// Record appends an entry to the log. A zero Time is set to the current time.
+func (l *DecisionLog) Record(entry DecisionEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	return l.out.write(entry)
+}
+
+// MetadataRedactor removes or masks sensitive values before response
+// metadata is written to audit records. It receives a copy of the metadata
+// and returns the map to record.
+type MetadataRedactor func(metadata map[string]interface{}) map[string]interface{}
+
+// RedactKeys returns a MetadataRedactor that replaces the values of the given
+// keys with RedactedValue
+func RedactKeys(keys ...string) MetadataRedactor {
+	return func(metadata map[string]interface{}) map[string]interface{} {
+		for _, key := range keys {
+			if _, ok := metadata[key]; ok {
+				metadata[key] = RedactedValue
+			}
+		}
+		return metadata
+	}
+}
+
+// SetDecisionLog configures where hook decisions are recorded. A nil log
+// disables decision recording.
+func (i *Interceptor) SetDecisionLog(l *DecisionLog) {
+	i.decisionLog = l
+}
+
+// SetMetadataRedactor sets the redactor applied to response metadata before
+// it is written to audit records (the decision log and exit history). It
+// does not affect the response sent to the wrapper.
+func (i *Interceptor) SetMetadataRedactor(r MetadataRedactor) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.redactor = r
+}
+
+// auditMetadata returns a redacted copy of response metadata for audit
+// records. The caller must hold i.mu.
+func (i *Interceptor) auditMetadata(metadata map[string]interface{}) map[string]interface{} {
+	if len(metadata) == 0 {
+		return nil
+	}
+	metadata = maps.Clone(metadata)
+	if i.redactor != nil {
+		metadata = i.redactor(metadata)
+	}
+	return metadata
+}
+
+// SetSyslog configures sending hook decisions to the system log. A nil
+// Syslog disables it. The interceptor does not close it.
+func (i *Interceptor) SetSyslog(s *Syslog) {
+	i.syslog = s
+}
+
+// recordDecision writes the decision for req to the decision log and the
+// system log, if set
+func (i *Interceptor) recordDecision(req *hook.Request, resp *hook.Response) {
+	if i.decisionLog == nil && i.syslog == nil {
+		return
+	}
+
+	i.mu.Lock()
+	entry := DecisionEntry{
+		Command:  i.auditCommand(req.Command),
+		PID:      req.PID,
+		Hook:     req.Hook,
+		Exit:     resp.Denied(),
+		ExitCode: resp.ExitCode,
+		Reason:   resp.BlockReason(),
+		Metadata: i.auditMetadata(resp.Metadata),
+	}
+	i.mu.Unlock()
+
+	if i.decisionLog != nil {
+		if err := i.decisionLog.Record(entry); err != nil {
+			i.logf("Failed to record decision: %v", err)
+		}
+	}
+	if i.syslog != nil {
+		if err := i.syslog.Record(entry); err != nil {
+			i.logf("Failed to send decision to syslog: %v", err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package interceptor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestDecisionLogIncludesResponseMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	mockHook := &mockIPCHook{response: &hook.Response{
+		Exit: true,
+		Metadata: map[string]interface{}{
+			"risk_score": 0.9,
+			"rule_id":    "no-exfil",
+			"token":      "s3cr3t",
+		},
+	}}
+	interceptor := New("/tmp/unused.sock", false, mockHook)
+	interceptor.SetDecisionLog(NewDecisionLog(&buf))
+	interceptor.SetMetadataRedactor(RedactKeys("token"))
+
+	resp, err := interceptor.processRequest(&hook.Request{
+		Command: []string{"curl", "https://example.com"},
+		PID:     42,
+		Hook:    hook.HookPreRun,
+	})
+	require.NoError(t, err)
+
+	var entry DecisionEntry
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry))
+	assert.Equal(t, []string{"curl", "https://example.com"}, entry.Command)
+	assert.Equal(t, 42, entry.PID)
+	assert.Equal(t, hook.HookPreRun, entry.Hook)
+	assert.True(t, entry.Exit)
+	assert.Equal(t, map[string]interface{}{
+		"risk_score": 0.9,
+		"rule_id":    "no-exfil",
+		"token":      RedactedValue,
+	}, entry.Metadata)
+
+	// The exit history carries the same redacted metadata
+	history := interceptor.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, RedactedValue, history[0].Metadata["token"])
+	assert.Equal(t, "no-exfil", history[0].Metadata["rule_id"])
+
+	// Redaction applies to audit records only, not to the response or hook
+	assert.Equal(t, "s3cr3t", resp.Metadata["token"])
+	assert.Equal(t, "s3cr3t", mockHook.response.Metadata["token"])
+}
+
+func TestDecisionLogRecordsEveryDecision(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := New("/tmp/unused.sock", false, &mockIPCHook{response: &hook.Response{}})
+	interceptor.SetDecisionLog(NewDecisionLog(&buf))
+
+	for _, stage := range []hook.HookType{hook.HookPreRun, hook.HookPostRun} {
+		_, err := interceptor.processRequest(&hook.Request{Command: []string{"ls"}, Hook: stage})
+		require.NoError(t, err)
+	}
+
+	var stages []hook.HookType
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var entry DecisionEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		assert.False(t, entry.Exit)
+		assert.Nil(t, entry.Metadata)
+		stages = append(stages, entry.Hook)
+	}
+	assert.Equal(t, []hook.HookType{hook.HookPreRun, hook.HookPostRun}, stages)
+}
+
+func TestDecisionLogRecordsExitCodeDenials(t *testing.T) {
+	var buf bytes.Buffer
+	code := 3
+	interceptor := New("/tmp/unused.sock", false, &mockIPCHook{response: &hook.Response{
+		ExitCode: &code,
+		Reason:   "dry run",
+	}})
+	interceptor.SetDecisionLog(NewDecisionLog(&buf))
+	var blocked bool
+	interceptor.SetDecisionCallback(func(_ []string, _ hook.HookType, exit bool, _ time.Duration) {
+		blocked = exit
+	})
+
+	_, err := interceptor.processRequest(&hook.Request{Command: []string{"make"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+
+	var entry DecisionEntry
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry))
+	assert.True(t, entry.Exit, "denials with an exit code are recorded as blocked")
+	require.NotNil(t, entry.ExitCode)
+	assert.Equal(t, 3, *entry.ExitCode)
+	assert.Equal(t, "dry run", entry.Reason)
+	assert.True(t, blocked)
+}
+
+func TestDecisionLogRecordsMetadataReason(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := New("/tmp/unused.sock", false, &mockIPCHook{response: &hook.Response{
+		Exit:     true,
+		Metadata: map[string]interface{}{"reason": "legacy hook"},
+	}})
+	interceptor.SetDecisionLog(NewDecisionLog(&buf))
+
+	_, err := interceptor.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+
+	var entry DecisionEntry
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry))
+	assert.Equal(t, "legacy hook", entry.Reason, "hooks that predate Response.Reason keep their reason")
+}
@@ -0,0 +1,93 @@
+package interceptor
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// deduplicator collapses identical requests arriving within a short window
+// into a single hook evaluation. Concurrent identical requests wait for the
+// in-flight evaluation instead of invoking the hook again.
+type deduplicator struct {
+	window  time.Duration
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+}
+
+// dedupEntry holds an in-flight or completed decision
+type dedupEntry struct {
+	done     chan struct{}
+	response *hook.Response
+	expires  time.Time // zero while the evaluation is in flight
+}
+
+func newDeduplicator(window time.Duration) *deduplicator {
+	return &deduplicator{
+		window:  window,
+		entries: make(map[string]*dedupEntry),
+	}
+}
+
+// dedupKey identifies requests with the same stage, exact argv, exit code,
+// working directory, binary and environment, and for batch requests the
+// same commands, from the same verified user
+func dedupKey(req *hook.Request) string {
+	key := string(req.Hook) + "\x00" + strconv.Itoa(req.ExitCode) + "\x00" + strings.Join(req.Command, "\x00")
+	for _, command := range req.Batch {
+		key += "\x01" + strings.Join(command, "\x00")
+	}
+	key += "\x03" + req.WorkingDir + "\x00" + req.ResolvedPath + "\x00" + req.LookupPath
+	names := make([]string, 0, len(req.Env))
+	for name := range req.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key += "\x04" + name + "=" + req.Env[name]
+	}
+	// Users of a shared interceptor never share decisions
+	if req.PeerUID != nil {
+		key += "\x02" + hook.PeerUIDKey(req)
+	}
+	return key
+}
+
+// do returns the decision for key, calling evaluate only if there is no
+// in-flight or unexpired decision for the same key
+func (d *deduplicator) do(key string, evaluate func() *hook.Response) *hook.Response {
+	now := time.Now()
+
+	d.mu.Lock()
+	d.sweep(now)
+	if entry, ok := d.entries[key]; ok {
+		d.mu.Unlock()
+		<-entry.done
+		return entry.response
+	}
+	entry := &dedupEntry{done: make(chan struct{})}
+	d.entries[key] = entry
+	d.mu.Unlock()
+
+	entry.response = evaluate()
+
+	d.mu.Lock()
+	entry.expires = time.Now().Add(d.window)
+	d.mu.Unlock()
+	close(entry.done)
+
+	return entry.response
+}
+
+// sweep drops expired decisions. Callers must hold d.mu.
+func (d *deduplicator) sweep(now time.Time) {
+	for key, entry := range d.entries {
+		if !entry.expires.IsZero() && now.After(entry.expires) {
+			delete(d.entries, key)
+		}
+	}
+}
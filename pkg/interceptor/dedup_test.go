@@ -0,0 +1,111 @@
+package interceptor
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// countingIPCHook counts evaluations and can delay them to widen races
+type countingIPCHook struct {
+	calls atomic.Int32
+	delay time.Duration
+	exit  bool
+}
+
+func (h *countingIPCHook) Name() string       { return "counting" }
+func (h *countingIPCHook) Commands() []string { return []string{"*"} }
+
+func (h *countingIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	h.calls.Add(1)
+	time.Sleep(h.delay)
+	return &hook.Response{Exit: h.exit}, nil
+}
+
+func TestDeduplicationBurst(t *testing.T) {
+	countingHook := &countingIPCHook{delay: 20 * time.Millisecond}
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, countingHook)
+	interceptor.SetDedupWindow(time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := interceptor.processRequest(&hook.Request{
+				Command: []string{"curl", "https://example.com"},
+				Hook:    hook.HookPreRun,
+			})
+			assert.NoError(t, err)
+			assert.False(t, resp.Exit)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), countingHook.calls.Load(), "identical burst should evaluate the hook once")
+}
+
+func TestDeduplicationKeys(t *testing.T) {
+	countingHook := &countingIPCHook{}
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, countingHook)
+	interceptor.SetDedupWindow(time.Second)
+
+	uid := 1000
+	requests := []*hook.Request{
+		{Command: []string{"curl", "a"}, Hook: hook.HookPreRun},
+		{Command: []string{"curl", "a"}, Hook: hook.HookPostRun},                                            // different stage
+		{Command: []string{"curl", "a"}, Hook: hook.HookPostRun, ExitCode: 2},                               // different exit code
+		{Command: []string{"curl", "a"}, Hook: hook.HookPreRun, WorkingDir: "/srv"},                         // different directory
+		{Command: []string{"curl", "a"}, Hook: hook.HookPreRun, ResolvedPath: "/opt/curl"},                  // different binary
+		{Command: []string{"curl", "a"}, Hook: hook.HookPreRun, Env: map[string]string{"HTTPS_PROXY": "x"}}, // different environment
+		{Command: []string{"curl", "b"}, Hook: hook.HookPreRun},                                             // different args
+		{Command: []string{"curl a"}, Hook: hook.HookPreRun},                                                // different argv split
+		{Command: []string{"curl", "a"}, Hook: hook.HookPreRun, PeerUID: &uid},                              // different user
+		{Command: []string{"curl", "a"}, Hook: hook.HookPreRun},                                             // duplicate of the first
+	}
+	for _, req := range requests {
+		_, err := interceptor.processRequest(req)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(9), countingHook.calls.Load())
+}
+
+func TestDeduplicationWindowExpires(t *testing.T) {
+	countingHook := &countingIPCHook{}
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, countingHook)
+	interceptor.SetDedupWindow(20 * time.Millisecond)
+
+	req := &hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun}
+	_, err := interceptor.processRequest(req)
+	require.NoError(t, err)
+
+	time.Sleep(40 * time.Millisecond)
+
+	_, err = interceptor.processRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), countingHook.calls.Load(), "expired decisions should be re-evaluated")
+}
+
+func TestDeduplicationRecordsEveryExit(t *testing.T) {
+	countingHook := &countingIPCHook{exit: true}
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, countingHook)
+	interceptor.SetDedupWindow(time.Second)
+
+	for i := 0; i < 3; i++ {
+		resp, err := interceptor.processRequest(&hook.Request{Command: []string{"rm", "-rf", "/"}, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+		assert.True(t, resp.Exit)
+	}
+
+	assert.Equal(t, int32(1), countingHook.calls.Load())
+	assert.Equal(t, 3, interceptor.Stats().ExitRequests, "reused blocks should still be recorded")
+}
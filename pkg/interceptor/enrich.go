@@ -0,0 +1,30 @@
+package interceptor
+
+import (
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// Enricher adds context to a request before it is evaluated by the hook.
+// Enrichers run in the interceptor process and typically add entries to
+// req.Metadata, which is always non-nil when an enricher is called.
+type Enricher func(req *hook.Request)
+
+// AddEnricher registers an enricher. Enrichers run in registration order.
+func (i *Interceptor) AddEnricher(e Enricher) {
+	if e != nil {
+		i.enrichers = append(i.enrichers, e)
+	}
+}
+
+// enrich applies all registered enrichers to req
+func (i *Interceptor) enrich(req *hook.Request) {
+	if len(i.enrichers) == 0 {
+		return
+	}
+	if req.Metadata == nil {
+		req.Metadata = make(map[string]interface{})
+	}
+	for _, e := range i.enrichers {
+		e(req)
+	}
+}
@@ -0,0 +1,51 @@
+package interceptor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// recordingIPCHook captures the last request it evaluated
+type recordingIPCHook struct {
+	last *hook.Request
+}
+
+func (r *recordingIPCHook) Name() string       { return "recording-hook" }
+func (r *recordingIPCHook) Commands() []string { return []string{"*"} }
+func (r *recordingIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	r.last = req
+	return &hook.Response{Exit: false}, nil
+}
+
+func TestEnrichersRunBeforeEvaluation(t *testing.T) {
+	h := &recordingIPCHook{}
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, h)
+
+	var order []string
+	interceptor.AddEnricher(func(req *hook.Request) {
+		order = append(order, "first")
+		req.Metadata["team"] = "platform"
+	})
+	interceptor.AddEnricher(nil)
+	interceptor.AddEnricher(func(req *hook.Request) {
+		order = append(order, "second")
+		req.Metadata["team"] = req.Metadata["team"].(string) + "-ci"
+	})
+
+	_, err := interceptor.processRequest(&hook.Request{
+		Command: []string{"git", "push"},
+		PID:     1234,
+		Hook:    hook.HookPreRun,
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, h.last)
+	assert.Equal(t, []string{"first", "second"}, order)
+	assert.Equal(t, "platform-ci", h.last.Metadata["team"])
+}
@@ -0,0 +1,118 @@
+package interceptor
+
+import (
+	"context"
+	"maps"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// Stages of the decision trace recorded for the interceptor's own rules.
+// Hooks are recorded as hook.TraceStageHook.
+const (
+	TraceStagePaused       = "paused"
+	TraceStageTimeQuota    = "time_quota"
+	TraceStageScriptPolicy = "script_policy"
+	TraceStageArgAllowlist = "arg_allowlist"
+	TraceStageRemembered   = "remembered"
+	TraceStageTimeout      = "timeout"
+	TraceStageTransform    = "transform"
+)
+
+// SetExplain attaches the decision trace to the response of every request,
+// under hook.MetadataExplain. Without it, only requests setting
+// hook.MetadataExplain to true, and hook.HookExplain control messages, get
+// a trace.
+func (i *Interceptor) SetExplain(enabled bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.explain = enabled
+}
+
+// explaining reports whether the response to req carries a trace
+func (i *Interceptor) explaining(req *hook.Request) bool {
+	i.mu.Lock()
+	enabled := i.explain
+	i.mu.Unlock()
+	requested, _ := req.Metadata[hook.MetadataExplain].(bool)
+	return enabled || requested
+}
+
+// handleExplain answers an explain control message. Its command is decided
+// as a pre-run request would be, by the same rules and hook, but nothing is
+// recorded and no exit is signaled: the response only reports the decision
+// and, under hook.MetadataExplain, the trace that led to it.
+func (i *Interceptor) handleExplain(req *hook.Request, state *hook.ConnectionState) *hook.Response {
+	if len(req.Command) == 0 {
+		return &hook.Response{Metadata: map[string]interface{}{"error": "explain request has no command"}}
+	}
+	hookRequest := &hook.Request{
+		Command:       req.Command,
+		PID:           req.PID,
+		ResolvedPath:  req.ResolvedPath,
+		LookupPath:    req.LookupPath,
+		WorkingDir:    req.WorkingDir,
+		Env:           req.Env,
+		UID:           req.UID,
+		GID:           req.GID,
+		Hook:          hook.HookPreRun,
+		RequestID:     req.RequestID,
+		CorrelationID: req.CorrelationID,
+		StartedAt:     req.StartedAt,
+		Metadata:      req.Metadata,
+		PeerUID:       req.PeerUID,
+	}
+	i.enrich(hookRequest)
+
+	ctx, cancel := i.evaluationContext(state)
+	defer cancel()
+	trace := &hook.Trace{}
+	ctx = hook.WithTrace(ctx, trace)
+
+	response := i.decide(ctx, hookRequest, nil)
+	decided := response.Decision()
+	response = i.transformResponse(hookRequest, response)
+	if response.Decision() != decided {
+		trace.Add(traceStep(TraceStageTransform, response))
+	}
+	i.logf("Explained %v: %s", req.Command, response.Decision())
+
+	out := copyResponse(response)
+	out.Metadata = withTrace(out.Metadata, trace)
+	return out
+}
+
+// tracedTimeoutResponse returns the timeout decision, recording it in the
+// trace of ctx
+func (i *Interceptor) tracedTimeoutResponse(ctx context.Context, req *hook.Request) *hook.Response {
+	resp := i.timeoutResponse(req)
+	hook.TraceFrom(ctx).Add(traceStep(TraceStageTimeout, resp))
+	return resp
+}
+
+// traceStep records the decision of an interceptor rule
+func traceStep(stage string, resp *hook.Response) hook.TraceStep {
+	return hook.TraceStep{Stage: stage, Decision: resp.Decision(), Reason: resp.BlockReason()}
+}
+
+// hookTraceStep records the outcome of evaluating h
+func hookTraceStep(h hook.Hook, resp *hook.Response, err error) hook.TraceStep {
+	if err != nil {
+		return hook.TraceStep{Stage: hook.TraceStageHook, Hook: h.Name(), Decision: hook.DecisionError, Reason: err.Error()}
+	}
+	return hook.TraceStep{Stage: hook.TraceStageHook, Hook: h.Name(), Decision: resp.Decision(), Reason: resp.BlockReason()}
+}
+
+// withTrace returns a copy of metadata holding the steps of trace
+func withTrace(metadata map[string]interface{}, trace *hook.Trace) map[string]interface{} {
+	out := maps.Clone(metadata)
+	if out == nil {
+		out = make(map[string]interface{})
+	}
+	steps := trace.Steps()
+	if steps == nil {
+		steps = []hook.TraceStep{}
+	}
+	out[hook.MetadataExplain] = steps
+	return out
+}
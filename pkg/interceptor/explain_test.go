@@ -0,0 +1,131 @@
+package interceptor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestExplainTrace(t *testing.T) {
+	multi := hook.NewMulti(
+		&mockIPCHook{name: "audit", commands: []string{"*"}, response: &hook.Response{}},
+		&mockIPCHook{name: "network", commands: []string{"curl"}, response: &hook.Response{Exit: true, Reason: "no network"}},
+	)
+	explain := map[string]interface{}{hook.MetadataExplain: true}
+
+	tests := []struct {
+		name      string
+		hook      hook.Hook
+		setup     func(*Interceptor)
+		command   []string
+		metadata  map[string]interface{}
+		wantSteps []hook.TraceStep
+	}{
+		{
+			name:     "not requested",
+			hook:     multi,
+			command:  []string{"curl"},
+			metadata: map[string]interface{}{},
+		},
+		{
+			name:     "multi members",
+			hook:     multi,
+			command:  []string{"curl"},
+			metadata: explain,
+			wantSteps: []hook.TraceStep{
+				{Stage: hook.TraceStageHook, Hook: "audit", Decision: hook.DecisionAllow},
+				{Stage: hook.TraceStageHook, Hook: "network", Decision: hook.DecisionBlock, Reason: "no network"},
+			},
+		},
+		{
+			name:     "global mode",
+			hook:     multi,
+			setup:    func(i *Interceptor) { i.SetExplain(true) },
+			command:  []string{"ls"},
+			metadata: map[string]interface{}{},
+			wantSteps: []hook.TraceStep{
+				{Stage: hook.TraceStageHook, Hook: "audit", Decision: hook.DecisionAllow},
+			},
+		},
+		{
+			name:     "single hook error",
+			hook:     &mockIPCHook{name: "broken", err: errors.New("backend down")},
+			command:  []string{"curl"},
+			metadata: explain,
+			wantSteps: []hook.TraceStep{
+				{Stage: hook.TraceStageHook, Hook: "broken", Decision: hook.DecisionError, Reason: "backend down"},
+			},
+		},
+		{
+			name:     "decided by a rule",
+			hook:     multi,
+			setup:    func(i *Interceptor) { i.SetArgAllowlist(map[string][]string{"curl": {"--version"}}) },
+			command:  []string{"curl", "--version"},
+			metadata: explain,
+			wantSteps: []hook.TraceStep{
+				{Stage: TraceStageArgAllowlist, Decision: hook.DecisionAllow},
+			},
+		},
+		{
+			name: "blocked by a transformer",
+			hook: multi,
+			setup: func(i *Interceptor) {
+				i.AddResponseTransformer(func(req *hook.Request, resp *hook.Response) *hook.Response {
+					return BlockResponse(resp, "read-only")
+				})
+			},
+			command:  []string{"ls"},
+			metadata: explain,
+			wantSteps: []hook.TraceStep{
+				{Stage: hook.TraceStageHook, Hook: "audit", Decision: hook.DecisionAllow},
+				{Stage: TraceStageTransform, Decision: hook.DecisionBlock, Reason: "read-only"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := New("/tmp/unused.sock", false, tt.hook)
+			if tt.setup != nil {
+				tt.setup(i)
+			}
+			resp, err := i.processRequest(&hook.Request{Command: tt.command, Hook: hook.HookPreRun, Metadata: tt.metadata})
+			require.NoError(t, err)
+
+			value, traced := resp.Metadata[hook.MetadataExplain]
+			if tt.wantSteps == nil {
+				assert.False(t, traced)
+				return
+			}
+			steps, err := hook.DecodeTrace(value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSteps, steps)
+		})
+	}
+}
+
+func TestHandleExplain(t *testing.T) {
+	h := &mockIPCHook{name: "deny", response: &hook.Response{Exit: true, Reason: "nope"}}
+	i := New("/tmp/unused.sock", false, h)
+
+	resp := i.handleExplain(&hook.Request{Command: []string{"curl"}, Hook: hook.HookExplain}, nil)
+	assert.True(t, resp.Exit)
+	assert.Equal(t, []hook.TraceStep{
+		{Stage: hook.TraceStageHook, Hook: "deny", Decision: hook.DecisionBlock, Reason: "nope"},
+	}, resp.Metadata[hook.MetadataExplain])
+
+	// A dry run: the block is neither counted nor signaled
+	assert.Zero(t, i.Stats().Total)
+	select {
+	case <-i.ExitSignal():
+		t.Fatal("explaining a block should not signal exit")
+	default:
+	}
+
+	resp = i.handleExplain(&hook.Request{Hook: hook.HookExplain}, nil)
+	assert.Equal(t, "explain request has no command", resp.Metadata["error"])
+}
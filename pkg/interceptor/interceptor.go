@@ -5,11 +5,14 @@ package interceptor
 import (
 	"bufio"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net"
 	"os"
+	"runtime/debug"
 	"sync"
 	"time"
 
@@ -27,14 +30,124 @@ const (
 type Interceptor struct {
 	socketPath string
 	verbose    bool
-	hook       hook.Hook
+	hook       hook.Hook    // guarded by hookMu; use Hook()
+	hookMu     sync.RWMutex // lets SetHook replace hook during evaluations
 	listener   net.Listener
+	// adopted is set when the listener was provided by the caller (e.g. via
+	// socket activation); the socket file is then owned by the caller.
+	adopted bool
+	// socketType selects stream or seqpacket IPC
+	socketType SocketType
 	stop       chan struct{}
-	exitSignal chan struct{} // Channel to signal process tree termination
+	exitSignal *exitLatch // Signals process tree termination
 	wg         sync.WaitGroup
     // evaluateTimeout bounds hook evaluations inside the interceptor.
     // If zero or negative, no timeout is applied.
     evaluateTimeout time.Duration
+	// connTimeout bounds each read of a connection's first request and
+	// each response write, if positive
+	connTimeout time.Duration
+	// bindRetries and bindBackoff configure retries of transient bind
+	// failures in Start
+	bindRetries int
+	bindBackoff time.Duration
+	// socketGroup, if set, is the group granted access to the socket
+	socketGroup *int
+	// tlsConfig, if set, makes the listener serve TLS
+	tlsConfig *tls.Config
+	// rewriteLog records command substitutions made by hooks, if set.
+	rewriteLog *RewriteLog
+	// decisionLog records every hook decision, if set.
+	decisionLog *DecisionLog
+	// syslog sends every hook decision to the system log, if set.
+	syslog *Syslog
+	// dedup reuses decisions for identical requests within a window, if set.
+	dedup *deduplicator
+	// enrichers add context to requests before evaluation
+	enrichers []Enricher
+	// transformers adjust hook responses before they are sent back
+	transformers []ResponseTransformer
+	// limiter bounds concurrent hook evaluations, if set
+	limiter *Limiter
+	// timeoutDecision is applied when an evaluation exceeds evaluateTimeout
+	timeoutDecision TimeoutDecision
+	// transcript records executed commands, if set
+	transcript *Transcript
+	// logger receives diagnostic output, if set (see SetLogger)
+	logger hook.Logger
+
+	// mu protects stop's closing, exitSignal and the exit bookkeeping below
+	mu             sync.Mutex
+	exitCount      int
+	exitHistory    []ExitRecord
+	maxExitHistory int
+	// requestCount, blockCount, errorCount and latency back Stats (also
+	// under mu)
+	requestCount int
+	blockCount   int
+	errorCount   int
+	latency      LatencySummary
+	// resumed is closed by Resume to release the requests held while
+	// paused, and is nil when not paused; pauseTimeout and pauseDecision
+	// bound the wait (also under mu)
+	resumed       chan struct{}
+	pauseTimeout  time.Duration
+	pauseDecision TimeoutDecision
+	// explain attaches the decision trace to every response (also under
+	// mu, see SetExplain)
+	explain bool
+
+	// timeQuota and timeUsed track cumulative command time (also under mu)
+	timeQuota time.Duration
+	timeUsed  time.Duration
+	// commandQuotas and commandTimeUsed track per-command time by command
+	// name (also under mu)
+	commandQuotas   map[string]time.Duration
+	commandTimeUsed map[string]time.Duration
+	// quotaKey splits the quotas by requester; keyTimeUsed and
+	// bucketTimeUsed track each key's time (also under mu)
+	quotaKey       QuotaKey
+	keyTimeUsed    map[string]time.Duration
+	bucketTimeUsed map[quotaBucket]time.Duration
+	// reloadToken and reloadHook enable reload control messages (also
+	// under mu)
+	reloadToken string
+	reloadHook  HookLoader
+	// compression lets connections negotiate compressed messages (also
+	// under mu)
+	compression bool
+	// chaos configures fault injection, and chaosInjected counts the
+	// faults injected (also under mu)
+	chaos         *ChaosConfig
+	chaosInjected int
+	// scriptPolicy maps normalized script extensions to actions (also
+	// under mu)
+	scriptPolicy map[string]ScriptAction
+	// argAllowlist maps command names to argument prefixes allowed
+	// without the hook, split into words (also under mu)
+	argAllowlist map[string][][]string
+	// normalizer canonicalizes commands in audit records (also under mu)
+	normalizer CommandNormalizer
+	// redactor masks response metadata in audit records (also under mu)
+	redactor MetadataRedactor
+	// rememberEnabled and remembered implement the pre-run confirmation
+	// cache, keyed by command name (also under mu)
+	rememberEnabled bool
+	remembered      map[string]bool
+
+	// progress tracking for the current run (also under mu)
+	runStarted time.Time
+	evaluated  int
+	started    int
+	completed  int
+	running    map[int]runningCommand
+
+	// onDecision is called after every decision, if set (also under mu)
+	onDecision DecisionCallback
+	// onTiming is called after every post-run decision, if set (also under mu)
+	onTiming TimingCallback
+	// idleConns are connections waiting for their next request (also under mu)
+	idleConns map[net.Conn]struct{}
 }
 
 // New creates a new interceptor instance
@@ -48,34 +161,110 @@ func New(socketPath string, verbose bool, h hook.Hook) *Interceptor {
         verbose:         verbose,
         hook:            h,
         stop:            make(chan struct{}),
-        exitSignal:      make(chan struct{}),
+        exitSignal:      newExitLatch(),
         // Default to no timeout; callers may configure if desired.
         evaluateTimeout: 0,
+        maxExitHistory:  DefaultMaxExitHistory,
+        runStarted:      time.Now(),
     }
 }
 
 // ExitSignal returns a channel that will receive a signal when exit is requested
 func (i *Interceptor) ExitSignal() <-chan struct{} {
-	return i.exitSignal
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.exitSignal.ch
+}
+
+// ResetExitSignal re-arms the exit signal after it has fired so that a
+// long-lived interceptor can serve another execution. Channels previously
+// returned by ExitSignal stay closed.
+func (i *Interceptor) ResetExitSignal() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.exitSignal.fired() {
+		i.exitSignal = newExitLatch()
+	}
+}
+
+// signalExit fires the exit signal. Requests still finishing once Stop has
+// begun leave it alone.
+func (i *Interceptor) signalExit() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	select {
+	case <-i.stop:
+		return
+	default:
+	}
+	i.exitSignal.fire()
+}
+
+// exitLatch is one arming of the exit signal. Its channel is closed at most
+// once, however many requests decide to exit.
+type exitLatch struct {
+	ch   chan struct{}
+	once sync.Once
+}
+
+func newExitLatch() *exitLatch {
+	return &exitLatch{ch: make(chan struct{})}
+}
+
+// fire closes the channel if it is still open
+func (l *exitLatch) fire() {
+	l.once.Do(func() { close(l.ch) })
+}
+
+// fired reports whether the channel has been closed
+func (l *exitLatch) fired() bool {
+	select {
+	case <-l.ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetListener makes the interceptor serve connections on an already-bound
+// listener instead of creating its own socket, for example one inherited via
+// systemd socket activation (see net.FileListener). Start then skips binding,
+// and neither Start nor Stop touch the socket file. Must be called before
+// Start; the interceptor closes the listener on Stop.
+func (i *Interceptor) SetListener(l net.Listener) {
+	i.listener = l
+	i.adopted = l != nil
 }
 
 // Start starts the interceptor and begins listening for connections
 func (i *Interceptor) Start() error {
-	// Remove any existing socket file
-	os.Remove(i.socketPath)
+	if i.adopted {
+		// Frame messages to suit the adopted socket
+		i.socketType = SocketType(i.listener.Addr().Network())
+		if err := i.wrapTLS(); err != nil {
+			return err
+		}
+		i.wg.Add(1)
+		go i.listen()
+		return nil
+	}
 
 	// Create Unix domain socket listener
-	listener, err := net.Listen("unix", i.socketPath)
+	listener, err := i.bind()
 	if err != nil {
 		return fmt.Errorf("failed to create socket listener: %w", err)
 	}
 	i.listener = listener
 
 	// Set permissions to be restrictive
-	if err := os.Chmod(i.socketPath, 0600); err != nil {
+	if err := i.setSocketPermissions(); err != nil {
 		listener.Close()
 		return fmt.Errorf("failed to set socket permissions: %w", err)
 	}
+	if err := i.wrapTLS(); err != nil {
+		listener.Close()
+		return err
+	}
 
 	i.wg.Add(1)
 	go i.listen()
@@ -85,28 +274,46 @@ func (i *Interceptor) Start() error {
 
 // Stop stops the interceptor and cleans up resources
 func (i *Interceptor) Stop() {
+	// Closing stop under mu orders it with signalExit and setIdle
+	i.mu.Lock()
 	select {
 	case <-i.stop:
 		// Already stopped
+		i.mu.Unlock()
 		return
 	default:
 		close(i.stop)
 	}
+	i.mu.Unlock()
 
 	if i.listener != nil {
 		i.listener.Close()
 	}
+	// Connections held open between requests would otherwise keep Stop
+	// waiting until their wrappers exit
+	i.mu.Lock()
+	for conn := range i.idleConns {
+		conn.Close()
+	}
+	i.mu.Unlock()
 	i.wg.Wait()
-	os.Remove(i.socketPath)
+	if !i.adopted {
+		os.Remove(i.socketPath)
+	}
 }
 
-// SetHook changes the hook used for request evaluation
+// SetHook changes the hook used for request evaluation. Evaluations
+// already running finish with the previous hook.
 func (i *Interceptor) SetHook(h hook.Hook) {
+	i.hookMu.Lock()
+	defer i.hookMu.Unlock()
 	i.hook = h
 }
 
 // Hook returns the current hook
 func (i *Interceptor) Hook() hook.Hook {
+	i.hookMu.RLock()
+	defer i.hookMu.RUnlock()
 	return i.hook
 }
 
@@ -116,6 +323,28 @@ func (i *Interceptor) SetEvaluateTimeout(d time.Duration) {
     i.evaluateTimeout = d
 }
 
+// SetRewriteLog configures where command substitutions made by hooks are
+// recorded. A nil log disables rewrite recording.
+func (i *Interceptor) SetRewriteLog(l *RewriteLog) {
+	i.rewriteLog = l
+}
+
+// RewriteLog returns the configured rewrite log, or nil
+func (i *Interceptor) RewriteLog() *RewriteLog {
+	return i.rewriteLog
+}
+
+// SetDedupWindow enables reuse of decisions for identical requests (same
+// stage and exact argv) arriving within d of each other. Zero or negative
+// disables deduplication.
+func (i *Interceptor) SetDedupWindow(d time.Duration) {
+	if d <= 0 {
+		i.dedup = nil
+		return
+	}
+	i.dedup = newDeduplicator(d)
+}
+
 // listen accepts and handles incoming connections
 func (i *Interceptor) listen() {
 	defer i.wg.Done()
@@ -133,9 +362,7 @@ func (i *Interceptor) listen() {
 			case <-i.stop:
 				return
 			default:
-				if i.verbose {
-					log.Printf("Failed to accept connection: %v", err)
-				}
+				i.logf("Failed to accept connection: %v", err)
 				continue
 			}
 		}
@@ -151,70 +378,205 @@ func (i *Interceptor) handleConnection(conn net.Conn) {
 	defer i.wg.Done()
 	defer conn.Close()
 
-	scanner := bufio.NewScanner(conn)
-	// Guard against overly large IPC messages
-	scanner.Buffer(make([]byte, 0, 64*1024), MaxIPCMessageBytes)
-	writer := bufio.NewWriter(conn)
+	if err := handshake(conn); err != nil {
+		i.logf("Rejected TLS connection: %v", err)
+		return
+	}
 
-	// Read and parse request
-	req, err := readRequest(scanner)
-	if err != nil {
-		if i.verbose {
-			log.Printf("Request read/parse error: %v", err)
+	// encoding is negotiated by the first request (see negotiateEncoding)
+	// and applies to every later response
+	encoding := ""
+
+	// Seqpacket sockets carry one message per packet; stream sockets frame
+	// messages with newlines
+	read := func() (*hook.Request, error) { return readPacketRequest(conn) }
+	write := func(resp *hook.Response) error { return writePacketResponse(conn, resp, encoding) }
+	if i.socketType.Network() != string(SocketSeqpacket) {
+		scanner := bufio.NewScanner(conn)
+		// Guard against overly large IPC messages
+		scanner.Buffer(make([]byte, 0, 64*1024), MaxIPCMessageBytes)
+		writer := bufio.NewWriter(conn)
+		read = func() (*hook.Request, error) { return readRequest(scanner) }
+		write = func(resp *hook.Response) error { return writeResponse(writer, resp, encoding) }
+	}
+	if i.connTimeout > 0 {
+		send := write
+		write = func(resp *hook.Response) error {
+			if err := conn.SetWriteDeadline(time.Now().Add(i.connTimeout)); err != nil {
+				return err
+			}
+			return send(resp)
 		}
-		errResp := &hook.Response{
-			Exit: true,
+	}
+
+	// A wrapper may keep the connection open and send several requests,
+	// typically the pre-run and post-run requests of one command. They
+	// share the connection's state. Wrappers that announce a protocol
+	// version are told this interceptor supports it (see negotiateProtocol);
+	// one-shot wrappers simply hang up after their response.
+	state := hook.NewConnectionState()
+	// Every request on the connection comes from the same peer
+	uid, gid, pid, verified := peerCredentials(underlyingConn(conn))
+	for served := 0; ; served++ {
+		if served > 0 && !i.setIdle(conn, true) {
+			return
 		}
-		if writeErr := writeResponse(writer, errResp); writeErr != nil {
-			if i.verbose {
-				log.Printf("Failed to write error response: %v", writeErr)
+		i.setReadDeadline(conn, served == 0)
+		req, err := read()
+		if served > 0 {
+			i.setIdle(conn, false)
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				return
 			}
 		}
-		return
-	}
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			i.logf("Closing connection: no request received within %v", i.connTimeout)
+			return
+		}
+		if err != nil {
+			i.logf("Request read/parse error: %v", err)
+			errResp := &hook.Response{
+				Exit: true,
+			}
+			if writeErr := write(errResp); writeErr != nil {
+				i.logf("Failed to write error response: %v", writeErr)
+			}
+			return
+		}
 
-	// Process request
-	resp, err := i.processRequest(req)
-	if err != nil {
-		if i.verbose {
-			log.Printf("Request processing error: %v", err)
+		if verified {
+			req.PeerUID = &uid
+			verifiedUID, verifiedGID := uid, gid
+			req.UID, req.GID = &verifiedUID, &verifiedGID
+			// The kernel's PID, unlike the reported one, can be trusted
+			// for process lineage (see ProcfsEnricher)
+			if pid > 0 {
+				req.PID = pid
+			}
+			if req.Metadata == nil {
+				req.Metadata = make(map[string]interface{})
+			}
+			req.Metadata[hook.MetadataCredentialsVerified] = true
+		} else {
+			delete(req.Metadata, hook.MetadataCredentialsVerified)
 		}
-		errResp := &hook.Response{
-			Exit: true,
+		// The first request may negotiate an encoding for the rest of the
+		// connection; the response that accepts it is still plain
+		accepted := i.negotiateEncoding(req, served == 0)
+		keepAlive := negotiateProtocol(req, served == 0)
+		reply := func(resp *hook.Response) error {
+			if keepAlive {
+				resp = announceProtocol(resp)
+			}
+			if accepted == "" {
+				return write(resp)
+			}
+			if err := write(announceEncoding(resp, accepted)); err != nil {
+				return err
+			}
+			encoding = accepted
+			return nil
 		}
-		if writeErr := writeResponse(writer, errResp); writeErr != nil {
-			if i.verbose {
-				log.Printf("Failed to write error response: %v", writeErr)
+
+		// Control messages are answered by the interceptor itself
+		if req.Hook == hook.HookReload {
+			if err := reply(i.handleReload(req)); err != nil {
+				i.logf("Failed to write response: %v", err)
+				return
 			}
+			continue
+		}
+		if req.Hook == hook.HookExplain {
+			if err := reply(i.handleExplain(req, state)); err != nil {
+				i.logf("Failed to write response: %v", err)
+				return
+			}
+			continue
+		}
+
+		// Process request
+		resp, err := i.processRequestWithState(req, state)
+		if err != nil {
+			i.logf("Request processing error: %v", err)
+			errResp := &hook.Response{
+				Exit: true,
+			}
+			if writeErr := write(errResp); writeErr != nil {
+				i.logf("Failed to write error response: %v", writeErr)
+			}
+			return
 		}
-		return
-	}
 
-	// Write response
-	if err := writeResponse(writer, resp); err != nil {
-		if i.verbose {
-			log.Printf("Failed to write response: %v", err)
+		// Write response
+		if err := reply(resp); err != nil {
+			i.logf("Failed to write response: %v", err)
+			return
 		}
 	}
 }
 
+// setIdle tracks connections waiting between requests, so Stop can close
+// them instead of waiting for the wrapper to hang up. It reports false if
+// the interceptor is stopping and the connection should be dropped.
+func (i *Interceptor) setIdle(conn net.Conn, idle bool) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if !idle {
+		delete(i.idleConns, conn)
+		return true
+	}
+	select {
+	case <-i.stop:
+		return false
+	default:
+	}
+	if i.idleConns == nil {
+		i.idleConns = make(map[net.Conn]struct{})
+	}
+	i.idleConns[conn] = struct{}{}
+	return true
+}
+
 // readRequest reads and unmarshals a JSON request from the scanner
 func readRequest(scanner *bufio.Scanner) (*hook.Request, error) {
 	if !scanner.Scan() {
-		return nil, fmt.Errorf("failed to read request: %v", scanner.Err())
+		if scanner.Err() == nil {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read request: %w", scanner.Err())
+	}
+	return parseRequest(scanner.Bytes())
+}
+
+// parseRequest decodes a request message, which may be compressed (see
+// hook.DecodeMessage)
+func parseRequest(data []byte) (*hook.Request, error) {
+	data, err := hook.DecodeMessage(data, MaxIPCMessageBytes)
+	if err != nil {
+		return nil, err
 	}
 	var req hook.Request
-	if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+	if err := json.Unmarshal(data, &req); err != nil {
 		return nil, fmt.Errorf("failed to parse request: %v", err)
 	}
 	return &req, nil
 }
 
-// writeResponse marshals and writes a JSON response to the writer
-func writeResponse(writer *bufio.Writer, resp *hook.Response) error {
+// marshalResponse marshals a response and encodes it with encoding
+func marshalResponse(resp *hook.Response, encoding string) ([]byte, error) {
 	data, err := json.Marshal(resp)
 	if err != nil {
-		return fmt.Errorf("failed to marshal response: %v", err)
+		return nil, fmt.Errorf("failed to marshal response: %v", err)
+	}
+	return hook.EncodeMessage(data, encoding)
+}
+
+// writeResponse marshals and writes a JSON response to the writer, encoded
+// with encoding
+func writeResponse(writer *bufio.Writer, resp *hook.Response, encoding string) error {
+	data, err := marshalResponse(resp, encoding)
+	if err != nil {
+		return err
 	}
 	if _, err := fmt.Fprintf(writer, "%s\n", string(data)); err != nil {
 		return fmt.Errorf("failed to write response: %v", err)
@@ -224,69 +586,228 @@ func writeResponse(writer *bufio.Writer, resp *hook.Response) error {
 
 // processRequest handles the business logic of processing a request and returning a response
 func (i *Interceptor) processRequest(req *hook.Request) (*hook.Response, error) {
+	return i.processRequestWithState(req, hook.NewConnectionState())
+}
+
+// processRequestWithState processes a request that arrived on a connection
+// with the given state
+func (i *Interceptor) processRequestWithState(req *hook.Request, state *hook.ConnectionState) (*hook.Response, error) {
 	hookRequest := &hook.Request{
-		Command:  req.Command,
-		PID:      req.PID,
-		Hook:     hook.HookType(req.Hook),
-		ExitCode: req.ExitCode,
-		Duration: req.Duration,
-		Metadata: req.Metadata,
-	}
-
-    var (
-        ctx    context.Context
-        cancel context.CancelFunc = func() {}
-    )
-    if i.evaluateTimeout > 0 {
-        ctx, cancel = context.WithTimeout(context.Background(), i.evaluateTimeout)
-    } else {
-        // No timeout requested; use background context.
-        ctx = context.Background()
-    }
-    defer cancel()
+		Command:       req.Command,
+		PID:           req.PID,
+		ResolvedPath:  req.ResolvedPath,
+		LookupPath:    req.LookupPath,
+		WorkingDir:    req.WorkingDir,
+		Env:           req.Env,
+		UID:           req.UID,
+		GID:           req.GID,
+		Hook:          hook.HookType(req.Hook),
+		RequestID:     req.RequestID,
+		CorrelationID: req.CorrelationID,
+		StartedAt:     req.StartedAt,
+		Batch:         req.Batch,
+		ExitCode:      req.ExitCode,
+		Duration:      req.Duration,
+		ExitReason:    req.ExitReason,
+		Metadata:      req.Metadata,
+		PeerUID:       req.PeerUID,
+	}
+	details, hasDetails := takeExecutionDetails(hookRequest)
+	i.enrich(hookRequest)
+
+	// Held requests wait before their evaluation deadline starts. Self-test
+	// canaries are answered while paused.
+	var pauseResponse *hook.Response
+	if !isSelfTest(hookRequest) {
+		pauseResponse = i.waitWhilePaused(hookRequest)
+	}
 
-	var response *hook.Response
-	var err error
+	ctx, cancel := i.evaluationContext(state)
+	defer cancel()
 
-	// Check if hook implements IPCHook
-	switch h := i.hook.(type) {
-	case hook.IPCHook:
-		response, err = h.EvaluateIPC(ctx, hookRequest)
-		if err != nil {
-			response = &hook.Response{
-				Exit: true,
-			}
-		}
-	default:
-		// For hooks that do not implement IPCHook, default to allow.
-		// This ensures LocalHook-only setups are not blocked by IPC stage.
-		if i.verbose {
-			log.Printf("Non-IPCHook provided; default-allowing request: %v", req.Command)
-		}
-		response = &hook.Response{Exit: false}
+	if isSelfTest(hookRequest) {
+		return i.selfTestResponse(ctx, hookRequest), nil
 	}
 
+	var trace *hook.Trace
+	if i.explaining(hookRequest) {
+		trace = &hook.Trace{}
+		ctx = hook.WithTrace(ctx, trace)
+	}
+
+	decisionStart := time.Now()
+	response := i.decide(ctx, hookRequest, pauseResponse)
+	decided := response.Decision()
+	response = i.transformResponse(hookRequest, response)
+	if response.Decision() != decided {
+		trace.Add(traceStep(TraceStageTransform, response))
+	}
+	response = i.injectChaos(hookRequest, response)
+	response = attribute(hookRequest, response)
+	i.rememberDecision(hookRequest, response)
+	i.trackProgress(hookRequest, response)
+	decisionTime := time.Since(decisionStart)
+	i.recordStats(response, decisionTime)
+	i.notifyDecision(hookRequest, response, decisionTime)
+	i.notifyTiming(hookRequest, decisionTime)
+
 	resp := &hook.Response{
-		Exit: response.Exit,
+		Exit:            response.Exit,
+		Reason:          response.Reason,
+		Metadata:        response.Metadata,
+		ModifiedCommand: response.ModifiedCommand,
+		ExitCode:        response.ExitCode,
+		EnvUnset:        response.EnvUnset,
+		EnvOverride:     response.EnvOverride,
+	}
+	if trace != nil {
+		resp.Metadata = withTrace(resp.Metadata, trace)
+	}
+
+	i.recordDecision(hookRequest, response)
+	i.recordRewrite(hookRequest, response)
+	if i.transcript != nil && hookRequest.Hook == hook.HookPostRun && hasDetails {
+		i.recordTranscript(hookRequest, details)
 	}
 
 	// Signal exit if requested
 	if response.Exit {
-		select {
-		case <-i.exitSignal:
-			// Already closed
-		default:
-			close(i.exitSignal)
-		}
+		i.recordExit(hookRequest, response)
+		i.signalExit()
 	}
 
-	if i.verbose {
+	if i.logging() {
 		if response.Exit {
-			log.Printf("Request EXIT: %v", req.Command)
+			if reason := response.BlockReason(); reason != "" {
+				i.logf("Request EXIT: %v (%s)", req.Command, reason)
+			} else {
+				i.logf("Request EXIT: %v", req.Command)
+			}
 		} else {
-			log.Printf("Request CONTINUING: %v", req.Command)
+			i.logf("Request CONTINUING: %v", req.Command)
 		}
 	}
 
 	return resp, nil
 }
+
+// evaluationContext returns the context hooks evaluate a request in,
+// bounded by the evaluation timeout if one is set
+func (i *Interceptor) evaluationContext(state *hook.ConnectionState) (context.Context, context.CancelFunc) {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc = func() {}
+	)
+	if i.evaluateTimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), i.evaluateTimeout)
+		// Expose the budget for hooks whose libraries ignore deadlines
+		ctx = hook.WithEvaluationBudget(ctx, i.evaluateTimeout)
+	} else {
+		// No timeout requested; use background context.
+		ctx = context.Background()
+	}
+	return hook.WithConnectionState(ctx, state), cancel
+}
+
+// decide returns the decision for a request: pauseResponse if the request
+// was held, else that of the first rule deciding it, else the hook's. The
+// rule or hook that decided is recorded in the trace of ctx, if any.
+func (i *Interceptor) decide(ctx context.Context, req *hook.Request, pauseResponse *hook.Response) *hook.Response {
+	trace := hook.TraceFrom(ctx)
+	rules := []struct {
+		stage string
+		apply func(*hook.Request) *hook.Response
+		log   string
+	}{
+		{TraceStageTimeQuota, i.applyTimeQuota, "Time quota exhausted; blocking: %v"},
+		{TraceStageScriptPolicy, i.applyScriptPolicy, "Decided by script extension policy: %v"},
+		{TraceStageArgAllowlist, i.applyArgAllowlist, "Allowed by argument allowlist: %v"},
+		{TraceStageRemembered, i.rememberedResponse, "Command approved earlier in this run: %v"},
+	}
+
+	if pauseResponse != nil {
+		// Commands that finished during a pause still count against the
+		// time quota
+		if req.Hook == hook.HookPostRun {
+			i.applyTimeQuota(req)
+		}
+		trace.Add(traceStep(TraceStagePaused, pauseResponse))
+		return pauseResponse
+	}
+	for _, rule := range rules {
+		if response := rule.apply(req); response != nil {
+			i.logf(rule.log, req.Command)
+			trace.Add(traceStep(rule.stage, response))
+			return response
+		}
+	}
+	// Deduplicated evaluations share the trace of the first request, so
+	// traced requests are evaluated on their own
+	if i.dedup != nil && trace == nil {
+		return i.dedup.do(dedupKey(req), func() *hook.Response {
+			return i.evaluateHook(ctx, req)
+		})
+	}
+	return i.evaluateHook(ctx, req)
+}
+
+// evaluateIPC calls the hook, converting a panic into a *hook.PanicError
+// so a faulty hook blocks the request instead of crashing the interceptor
+func (i *Interceptor) evaluateIPC(ctx context.Context, h hook.IPCHook, req *hook.Request) (resp *hook.Response, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr := &hook.PanicError{Value: r, Stack: debug.Stack()}
+			i.logf("Hook %s panicked evaluating %v: %v\n%s", h.Name(), req.Command, r, panicErr.Stack)
+			resp, err = nil, panicErr
+		}
+	}()
+	return h.EvaluateIPC(ctx, req)
+}
+
+// evaluateHook runs the configured hook for a request. Hook errors are
+// converted into exit responses, except timeouts, which follow the
+// configured TimeoutDecision.
+func (i *Interceptor) evaluateHook(ctx context.Context, req *hook.Request) *hook.Response {
+	// Check if hook implements IPCHook
+	switch h := i.Hook().(type) {
+	case hook.IPCHook:
+		if req.Hook == hook.HookPostRun && !hook.WantsPostRun(h, req.ExitCode) {
+			return &hook.Response{}
+		}
+		if i.limiter != nil {
+			if err := i.limiter.Acquire(ctx); err != nil {
+				i.logf("No evaluation slot for %v: %v", req.Command, err)
+				if isTimeout(ctx, err) {
+					return i.tracedTimeoutResponse(ctx, req)
+				}
+				return &hook.Response{Exit: true}
+			}
+			defer i.limiter.Release()
+		}
+		traced := len(hook.TraceFrom(ctx).Steps())
+		response, err := i.evaluateIPC(ctx, h, req)
+		if err != nil || response == nil {
+			i.recordError()
+		}
+		if trace := hook.TraceFrom(ctx); len(trace.Steps()) == traced {
+			// The hook does not trace its own steps, as Multi does
+			trace.Add(hookTraceStep(h, response, err))
+		}
+		var panicErr *hook.PanicError
+		if err != nil && isTimeout(ctx, err) && !errors.As(err, &panicErr) {
+			return i.tracedTimeoutResponse(ctx, req)
+		}
+		if err != nil || response == nil {
+			return &hook.Response{
+				Exit: true,
+			}
+		}
+		return response
+	default:
+		// For hooks that do not implement IPCHook, default to allow.
+		// This ensures LocalHook-only setups are not blocked by IPC stage.
+		i.logf("Non-IPCHook provided; default-allowing request: %v", req.Command)
+		hook.TraceFrom(ctx).Add(hook.TraceStep{Stage: hook.StageDefault, Decision: hook.DecisionAllow})
+		return &hook.Response{Exit: false}
+	}
+}
@@ -127,14 +127,9 @@ func TestInterceptorExitSignal(t *testing.T) {
 		// Expected - channel is empty
 	}
 
-	// Test that we can signal exit by closing the channel (simulate what happens in handleConnection)
-	select {
-	case <-interceptor.exitSignal:
-		// Already closed
-		t.Fatal("Exit signal channel should not be closed yet")
-	default:
-		close(interceptor.exitSignal)
-	}
+	// Signal exit as processRequest does; signaling twice must not panic
+	interceptor.signalExit()
+	interceptor.signalExit()
 
 	// Now the channel should be closed and readable
 	select {
@@ -147,6 +142,7 @@ func TestInterceptorExitSignal(t *testing.T) {
 
 // Unit tests for readRequest function
 func TestReadRequest(t *testing.T) {
+	startedAt := time.Date(2024, 5, 1, 12, 30, 0, 500_000_000, time.UTC)
 	tests := []struct {
 		name        string
 		input       string
@@ -174,6 +170,52 @@ func TestReadRequest(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name:  "request with working directory",
+			input: `{"command":["rm","-rf","build"],"pid":789,"working_dir":"/home/user/project","hook":"pre_run"}` + "\n",
+			wantRequest: &hook.Request{
+				Command:    []string{"rm", "-rf", "build"},
+				PID:        789,
+				WorkingDir: "/home/user/project",
+				Hook:       hook.HookPreRun,
+			},
+			wantError: false,
+		},
+		{
+			name:  "request with resolved path",
+			input: `{"command":["python","app.py"],"pid":789,"resolved_path":"/usr/bin/python3.12","hook":"pre_run"}` + "\n",
+			wantRequest: &hook.Request{
+				Command:      []string{"python", "app.py"},
+				PID:          789,
+				ResolvedPath: "/usr/bin/python3.12",
+				Hook:         hook.HookPreRun,
+			},
+			wantError: false,
+		},
+		{
+			name:  "request with correlation fields",
+			input: `{"command":["make"],"pid":789,"hook":"post_run","request_id":"a1b2c3","correlation_id":"a1b2c3","started_at":"2024-05-01T12:30:00.5Z"}` + "\n",
+			wantRequest: &hook.Request{
+				Command:       []string{"make"},
+				PID:           789,
+				Hook:          hook.HookPostRun,
+				RequestID:     "a1b2c3",
+				CorrelationID: "a1b2c3",
+				StartedAt:     startedAt,
+			},
+			wantError: false,
+		},
+		{
+			name:  "request with environment",
+			input: `{"command":["curl","https://example.com"],"pid":789,"env":{"HTTP_PROXY":"http://proxy:3128"},"hook":"pre_run"}` + "\n",
+			wantRequest: &hook.Request{
+				Command: []string{"curl", "https://example.com"},
+				PID:     789,
+				Env:     map[string]string{"HTTP_PROXY": "http://proxy:3128"},
+				Hook:    hook.HookPreRun,
+			},
+			wantError: false,
+		},
 		{
 			name:        "empty input",
 			input:       "",
@@ -247,7 +289,7 @@ func TestWriteResponse(t *testing.T) {
 			var buf strings.Builder
 			writer := bufio.NewWriter(&buf)
 
-			err := writeResponse(writer, tt.response)
+			err := writeResponse(writer, tt.response, "")
 
 			if tt.wantError {
 				assert.Error(t, err)
@@ -285,6 +327,16 @@ func (m *mockIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook
 	return m.response, m.err
 }
 
+// failureOnlyIPCHook is a mockIPCHook that only wants post-run requests of
+// failed commands
+type failureOnlyIPCHook struct {
+	mockIPCHook
+}
+
+func (f *failureOnlyIPCHook) WantsPostRun(exitCode int) bool {
+	return exitCode != 0
+}
+
 type mockBasicHook struct {
 	name     string
 	commands []string
@@ -347,6 +399,32 @@ func TestProcessRequest(t *testing.T) {
 			wantExit:  true,
 			wantError: false,
 		},
+		{
+			name: "post-run the hook does not want is allowed",
+			hook: &failureOnlyIPCHook{mockIPCHook{
+				response: &hook.Response{Exit: true},
+			}},
+			request: &hook.Request{
+				Command:  []string{"make"},
+				Hook:     hook.HookPostRun,
+				ExitCode: 0,
+			},
+			wantExit:  false,
+			wantError: false,
+		},
+		{
+			name: "post-run the hook wants is evaluated",
+			hook: &failureOnlyIPCHook{mockIPCHook{
+				response: &hook.Response{Exit: true},
+			}},
+			request: &hook.Request{
+				Command:  []string{"make"},
+				Hook:     hook.HookPostRun,
+				ExitCode: 2,
+			},
+			wantExit:  true,
+			wantError: false,
+		},
 		{
 			name: "non-IPC hook defaults to allow",
 			hook: &mockBasicHook{
@@ -522,6 +600,58 @@ func TestSetHookAndHook(t *testing.T) {
 	assert.Equal(t, mockHook2, interceptor.Hook())
 }
 
+func TestSetHookDuringEvaluation(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "sethook.sock")
+	allow := &mockIPCHook{name: "allow", response: &hook.Response{}}
+	block := &mockIPCHook{name: "block", response: &hook.Response{Exit: true}}
+	interceptor := New(socketPath, false, allow)
+	require.NoError(t, interceptor.Start())
+	defer interceptor.Stop()
+
+	// Swap hooks for as long as requests are evaluated
+	stop := make(chan struct{})
+	swapped := make(chan struct{})
+	go func() {
+		defer close(swapped)
+		for n := 0; ; n++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if n%2 == 0 {
+				interceptor.SetHook(block)
+			} else {
+				interceptor.SetHook(allow)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for c := 0; c < 8; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := 0; r < 20; r++ {
+				conn, err := net.Dial("unix", socketPath)
+				if !assert.NoError(t, err) {
+					return
+				}
+				_, err = fmt.Fprintf(conn, "%s\n", `{"command":["curl"],"pid":1,"hook":"pre_run"}`)
+				assert.NoError(t, err)
+				assert.True(t, bufio.NewScanner(conn).Scan(), "every request is answered")
+				conn.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-swapped
+
+	assert.Equal(t, 160, interceptor.Stats().Total)
+	assert.Zero(t, interceptor.Stats().Errors)
+}
+
 // Integration tests with real socket communication
 func TestSocketCommunication(t *testing.T) {
 	t.Run("full request-response cycle", func(t *testing.T) {
@@ -694,3 +824,147 @@ func TestSocketCommunication(t *testing.T) {
 		assert.Equal(t, true, response["exit"])
 	})
 }
+
+func TestInterceptorAdoptedListener(t *testing.T) {
+	// Use shorter socket path for macOS Unix domain socket limits
+	socketPath := fmt.Sprintf("/tmp/test_%d.sock", time.Now().UnixNano())
+	defer os.Remove(socketPath)
+
+	// Bind the socket ourselves, as a service manager would
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	interceptor := New(socketPath, false, &mockIPCHook{response: &hook.Response{Exit: true}})
+	interceptor.SetListener(listener)
+	require.NoError(t, interceptor.Start())
+
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = fmt.Fprintf(conn, "%s\n", `{"command":["curl"],"pid":1,"hook":"pre_run"}`)
+	require.NoError(t, err)
+
+	scanner := bufio.NewScanner(conn)
+	require.True(t, scanner.Scan())
+	var resp hook.Response
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &resp))
+	assert.True(t, resp.Exit)
+
+	// The adopted socket file belongs to the caller and must survive Stop
+	interceptor.Stop()
+	_, err = os.Stat(socketPath)
+	assert.NoError(t, err)
+}
+
+// contextCapturingHook records the context passed to EvaluateIPC
+type contextCapturingHook struct {
+	hasDeadline bool
+	total       time.Duration
+	remaining   time.Duration
+	hasBudget   bool
+}
+
+func (c *contextCapturingHook) Name() string       { return "ctx-hook" }
+func (c *contextCapturingHook) Commands() []string { return []string{"*"} }
+func (c *contextCapturingHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	_, c.hasDeadline = ctx.Deadline()
+	c.total, c.remaining, c.hasBudget = hook.EvaluationBudget(ctx)
+	return &hook.Response{}, nil
+}
+
+func TestEvaluationBudgetPropagation(t *testing.T) {
+	t.Run("timeout configured", func(t *testing.T) {
+		h := &contextCapturingHook{}
+		interceptor := New("/tmp/unused.sock", false, h)
+		interceptor.SetEvaluateTimeout(2 * time.Second)
+
+		_, err := interceptor.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+
+		assert.True(t, h.hasDeadline)
+		assert.True(t, h.hasBudget)
+		assert.Equal(t, 2*time.Second, h.total)
+		assert.Greater(t, h.remaining, time.Duration(0))
+		assert.LessOrEqual(t, h.remaining, 2*time.Second)
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		h := &contextCapturingHook{}
+		interceptor := New("/tmp/unused.sock", false, h)
+
+		_, err := interceptor.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+
+		assert.False(t, h.hasDeadline)
+		assert.False(t, h.hasBudget)
+	})
+}
+
+func TestResetExitSignal(t *testing.T) {
+	interceptor := New("/tmp/unused.sock", false, &mockIPCHook{response: &hook.Response{Exit: true}})
+
+	// Resetting an unfired signal keeps the same channel
+	first := interceptor.ExitSignal()
+	interceptor.ResetExitSignal()
+	assert.Equal(t, first, interceptor.ExitSignal())
+
+	_, err := interceptor.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	<-first
+
+	interceptor.ResetExitSignal()
+	second := interceptor.ExitSignal()
+	select {
+	case <-second:
+		t.Fatal("exit signal should be re-armed")
+	default:
+	}
+
+	_, err = interceptor.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	<-second
+}
+
+func TestConcurrentExitSignals(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "exits.sock")
+	interceptor := New(socketPath, false, &mockIPCHook{name: "block", response: &hook.Response{Exit: true}})
+	require.NoError(t, interceptor.Start())
+	exitSignal := interceptor.ExitSignal()
+
+	// Blocked requests race to fire the signal
+	var wg sync.WaitGroup
+	for c := 0; c < 16; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := net.Dial("unix", socketPath)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+			_, err = fmt.Fprintf(conn, "%s\n", `{"command":["curl"],"pid":1,"hook":"pre_run"}`)
+			assert.NoError(t, err)
+			assert.True(t, bufio.NewScanner(conn).Scan(), "every request is answered")
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case <-exitSignal:
+	default:
+		t.Fatal("blocked requests should signal exit")
+	}
+	assert.Equal(t, 16, interceptor.Stats().Blocked)
+
+	// Once stopped, the re-armed signal no longer fires
+	interceptor.ResetExitSignal()
+	interceptor.Stop()
+	interceptor.signalExit()
+	select {
+	case <-interceptor.ExitSignal():
+		t.Fatal("exit must not be signaled after Stop")
+	default:
+	}
+}
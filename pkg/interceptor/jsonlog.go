@@ -0,0 +1,42 @@
+package interceptor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// jsonLineWriter writes values as newline-delimited JSON, flushing after
+// every line so audit records survive a crash. It is safe for concurrent use.
+type jsonLineWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// write appends v to the log as a single line
+func (l *jsonLineWriter) write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Write the whole line at once so concurrent entries never interleave
+	if _, err := l.w.Write(data); err != nil {
+		return fmt.Errorf("failed to write entry: %w", err)
+	}
+	if f, ok := l.w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return fmt.Errorf("failed to flush log: %w", err)
+		}
+	}
+	if s, ok := l.w.(interface{ Sync() error }); ok {
+		// Best effort: not every file supports fsync (e.g. pipes)
+		_ = s.Sync()
+	}
+	return nil
+}
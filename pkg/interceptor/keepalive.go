@@ -0,0 +1,32 @@
+package interceptor
+
+import (
+	"maps"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// negotiateProtocol removes the protocol version announcement from req and
+// reports whether the response must announce hook.ProtocolVersion in turn,
+// which it does for the first request on a connection. Wrappers that
+// announce nothing are not told, so their responses are unchanged.
+func negotiateProtocol(req *hook.Request, first bool) bool {
+	if _, ok := req.Metadata[hook.MetadataProtocolVersion]; !ok {
+		return false
+	}
+	req.Metadata = maps.Clone(req.Metadata)
+	delete(req.Metadata, hook.MetadataProtocolVersion)
+	return first
+}
+
+// announceProtocol returns a copy of resp telling the wrapper that the
+// connection stays open for further requests
+func announceProtocol(resp *hook.Response) *hook.Response {
+	out := *resp
+	out.Metadata = maps.Clone(resp.Metadata)
+	if out.Metadata == nil {
+		out.Metadata = make(map[string]interface{})
+	}
+	out.Metadata[hook.MetadataProtocolVersion] = hook.ProtocolVersion
+	return &out
+}
@@ -0,0 +1,91 @@
+package interceptor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestKeepAlive(t *testing.T) {
+	tests := []struct {
+		name          string
+		announce      bool
+		wantAnnounced bool
+	}{
+		{name: "announced", announce: true, wantAnnounced: true},
+		{name: "one-shot wrapper", announce: false, wantAnnounced: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socketPath := fmt.Sprintf("/tmp/test_%d.sock", time.Now().UnixNano())
+			defer os.Remove(socketPath)
+
+			h := newMockHook("keepalive", []string{"*"})
+			h.allowAll = false
+			h.responses["curl:pre_run"] = &hook.Response{Exit: true, Reason: "curl is blocked"}
+			h.responses["curl:post_run"] = &hook.Response{Metadata: map[string]interface{}{"note": "post-run"}}
+			i := New(socketPath, false, h)
+			require.NoError(t, i.Start())
+			defer i.Stop()
+
+			conn, err := net.Dial("unix", socketPath)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			// Both requests are written before either response is read
+			pre := hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun}
+			if tt.announce {
+				pre.Metadata = map[string]interface{}{hook.MetadataProtocolVersion: hook.ProtocolVersion}
+			}
+			post := hook.Request{Command: []string{"curl"}, Hook: hook.HookPostRun}
+			for _, req := range []hook.Request{pre, post} {
+				data, err := json.Marshal(req)
+				require.NoError(t, err)
+				_, err = conn.Write(append(data, '\n'))
+				require.NoError(t, err)
+			}
+
+			scanner := bufio.NewScanner(conn)
+			var responses []hook.Response
+			for len(responses) < 2 {
+				require.True(t, scanner.Scan(), "expected a response per request")
+				var resp hook.Response
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &resp))
+				responses = append(responses, resp)
+			}
+
+			assert.True(t, responses[0].Exit)
+			assert.Equal(t, "curl is blocked", responses[0].Reason)
+			assert.Equal(t, tt.wantAnnounced, hook.ProtocolVersionOf(responses[0].Metadata) == hook.ProtocolVersion)
+			if !tt.wantAnnounced {
+				assert.NotContains(t, responses[0].Metadata, hook.MetadataProtocolVersion)
+			}
+
+			assert.False(t, responses[1].Exit)
+			assert.Equal(t, map[string]interface{}{"note": "post-run"}, responses[1].Metadata, "only the first response announces the version")
+			assert.Equal(t, 2, i.Stats().Total)
+		})
+	}
+}
+
+func TestNegotiateProtocol(t *testing.T) {
+	req := &hook.Request{Metadata: map[string]interface{}{hook.MetadataProtocolVersion: float64(2), "other": "kept"}}
+	offered := req.Metadata
+	assert.True(t, negotiateProtocol(req, true))
+	assert.Equal(t, map[string]interface{}{"other": "kept"}, req.Metadata, "hooks do not see the announcement")
+	assert.Contains(t, offered, hook.MetadataProtocolVersion, "the wrapper's metadata is not modified")
+
+	req = &hook.Request{Metadata: map[string]interface{}{hook.MetadataProtocolVersion: float64(2)}}
+	assert.False(t, negotiateProtocol(req, false), "only the first request is answered")
+	assert.False(t, negotiateProtocol(&hook.Request{}, true))
+}
@@ -0,0 +1,55 @@
+package interceptor
+
+import (
+	"context"
+)
+
+// Limiter bounds the number of hook evaluations that run at once. A single
+// Limiter can be shared by several interceptors, so a host running many
+// scripts can cap evaluations process-wide.
+type Limiter struct {
+	slots chan struct{}
+}
+
+// NewLimiter returns a Limiter allowing n concurrent evaluations. Values of
+// n below 1 are treated as 1.
+func NewLimiter(n int) *Limiter {
+	if n < 1 {
+		n = 1
+	}
+	return &Limiter{slots: make(chan struct{}, n)}
+}
+
+// Acquire blocks until an evaluation slot is free or ctx is done. Each
+// successful Acquire must be paired with a Release.
+func (l *Limiter) Acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire
+func (l *Limiter) Release() {
+	<-l.slots
+}
+
+// InUse returns the number of slots currently held
+func (l *Limiter) InUse() int {
+	return len(l.slots)
+}
+
+// Limit returns the maximum number of concurrent evaluations
+func (l *Limiter) Limit() int {
+	return cap(l.slots)
+}
+
+// SetEvaluationLimiter bounds concurrent hook evaluations with l, which may
+// be shared with other interceptors. Requests wait for a slot within the
+// evaluation timeout and are blocked if none frees up in time. A nil limiter
+// removes the bound. Must be called before Start.
+func (i *Interceptor) SetEvaluationLimiter(l *Limiter) {
+	i.limiter = l
+}
@@ -0,0 +1,94 @@
+package interceptor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// concurrencyHook records the peak number of evaluations running at once
+type concurrencyHook struct {
+	delay   time.Duration
+	current atomic.Int32
+	peak    atomic.Int32
+}
+
+func (h *concurrencyHook) Name() string       { return "concurrency" }
+func (h *concurrencyHook) Commands() []string { return []string{"*"} }
+func (h *concurrencyHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	n := h.current.Add(1)
+	defer h.current.Add(-1)
+	for {
+		peak := h.peak.Load()
+		if n <= peak || h.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(h.delay)
+	return &hook.Response{}, nil
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(2)
+	assert.Equal(t, 2, l.Limit())
+
+	require.NoError(t, l.Acquire(context.Background()))
+	require.NoError(t, l.Acquire(context.Background()))
+	assert.Equal(t, 2, l.InUse())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Acquire(ctx), context.DeadlineExceeded)
+
+	l.Release()
+	assert.Equal(t, 1, l.InUse())
+	require.NoError(t, l.Acquire(context.Background()))
+
+	assert.Equal(t, 1, NewLimiter(0).Limit())
+}
+
+func TestSharedEvaluationLimiter(t *testing.T) {
+	h := &concurrencyHook{delay: 20 * time.Millisecond}
+	limiter := NewLimiter(2)
+
+	first := New("", false, h)
+	first.SetEvaluationLimiter(limiter)
+	second := New("", false, h)
+	second.SetEvaluationLimiter(limiter)
+
+	var wg sync.WaitGroup
+	for _, i := range []*Interceptor{first, second, first, second, first, second} {
+		wg.Add(1)
+		go func(i *Interceptor) {
+			defer wg.Done()
+			resp, err := i.processRequest(&hook.Request{Command: []string{"ls"}, Hook: hook.HookPreRun})
+			assert.NoError(t, err)
+			assert.False(t, resp.Exit)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), h.peak.Load(), "combined evaluations should not exceed the shared limit")
+	assert.Zero(t, limiter.InUse())
+}
+
+func TestSharedEvaluationLimiterTimeout(t *testing.T) {
+	limiter := NewLimiter(1)
+	require.NoError(t, limiter.Acquire(context.Background()))
+	defer limiter.Release()
+
+	i := New("", false, &concurrencyHook{})
+	i.SetEvaluationLimiter(limiter)
+	i.SetEvaluateTimeout(20 * time.Millisecond)
+
+	resp, err := i.processRequest(&hook.Request{Command: []string{"ls"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.True(t, resp.Exit, "a request that cannot get a slot in time is blocked")
+}
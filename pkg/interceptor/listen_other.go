@@ -0,0 +1,13 @@
+//go:build !windows
+
+package interceptor
+
+import "net"
+
+// namedPipes reports whether the socket path stands for a named pipe
+const namedPipes = false
+
+// listenSocket listens on the Unix socket at address
+func listenSocket(network, address string) (net.Listener, error) {
+	return net.Listen(network, address)
+}
@@ -0,0 +1,42 @@
+package interceptor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+	"github.com/codysoyland/cmdhooks/pkg/namedpipe"
+)
+
+func TestStartNamedPipe(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("named pipes are only used on Windows")
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "hook.sock")
+	i := New(socketPath, false, &mockIPCHook{response: &hook.Response{Reason: "over a pipe"}})
+	require.NoError(t, i.Start())
+	defer i.Stop()
+
+	conn, err := namedpipe.Dial(namedpipe.Name(socketPath))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	req, err := json.Marshal(hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	_, err = fmt.Fprintf(conn, "%s\n", req)
+	require.NoError(t, err)
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	require.NoError(t, err)
+	var resp hook.Response
+	require.NoError(t, json.Unmarshal(line, &resp))
+	assert.Equal(t, "over a pipe", resp.Reason)
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package interceptor
+
+import (
+	"net"
+
+	"github.com/codysoyland/cmdhooks/pkg/namedpipe"
+)
+
+// namedPipes reports whether the socket path stands for a named pipe
+const namedPipes = true
+
+// listenSocket listens on the named pipe standing in for the Unix socket
+// at address (see namedpipe.Name). Other networks are passed to net.Listen.
+func listenSocket(network, address string) (net.Listener, error) {
+	if network != string(SocketStream) {
+		return net.Listen(network, address)
+	}
+	return namedpipe.Listen(namedpipe.Name(address))
+}
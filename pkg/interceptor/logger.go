@@ -0,0 +1,29 @@
+package interceptor
+
+import (
+	"log"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// SetLogger sends the interceptor's diagnostic output to l, whether or not
+// it is verbose. Without a logger, output goes to the standard logger when
+// verbose and is discarded otherwise.
+func (i *Interceptor) SetLogger(l hook.Logger) {
+	i.logger = l
+}
+
+// logging reports whether diagnostic output is written anywhere
+func (i *Interceptor) logging() bool {
+	return i.logger != nil || i.verbose
+}
+
+// logf writes diagnostic output, if enabled
+func (i *Interceptor) logf(format string, v ...any) {
+	switch {
+	case i.logger != nil:
+		i.logger.Printf(format, v...)
+	case i.verbose:
+		log.Printf(format, v...)
+	}
+}
@@ -0,0 +1,58 @@
+package interceptor
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestLogger(t *testing.T) {
+	// Capture the standard logger to check what reaches it
+	var std bytes.Buffer
+	log.SetOutput(&std)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	tests := []struct {
+		name       string
+		verbose    bool
+		withLogger bool
+		wantLogger bool
+		wantStd    bool
+	}{
+		{name: "quiet by default"},
+		{name: "verbose without logger", verbose: true, wantStd: true},
+		{name: "logger", withLogger: true, wantLogger: true},
+		{name: "verbose with logger", verbose: true, withLogger: true, wantLogger: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			std.Reset()
+			var buf bytes.Buffer
+			i := New("/tmp/unused.sock", tt.verbose, &mockIPCHook{response: &hook.Response{}})
+			if tt.withLogger {
+				i.SetLogger(log.New(&buf, "", 0))
+			}
+
+			_, err := i.processRequest(&hook.Request{Command: []string{"curl", "example.com"}, Hook: hook.HookPreRun})
+			require.NoError(t, err)
+
+			if tt.wantLogger {
+				assert.Contains(t, buf.String(), "Request CONTINUING: [curl example.com]")
+			} else {
+				assert.Empty(t, buf.String())
+			}
+			if tt.wantStd {
+				assert.Contains(t, std.String(), "Request CONTINUING: [curl example.com]")
+			} else {
+				assert.Empty(t, std.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package interceptor
+
+import (
+	"sort"
+	"strings"
+)
+
+// CommandNormalizer maps a raw argv to the canonical form recorded in audit
+// records, so that invocations differing only in transient arguments
+// aggregate together. Hooks always see the raw argv.
+type CommandNormalizer func(argv []string) []string
+
+// NormalizeCommand is a CommandNormalizer that keeps the command name and its
+// flags, sorted, and redacts everything else: flag values given inline
+// ("--out=file") become "--out=*" and each positional argument (including
+// flag values passed as separate arguments) becomes "*". For example,
+// "curl -o out.txt --silent https://example.com" normalizes to
+// "curl --silent -o * *".
+func NormalizeCommand(argv []string) []string {
+	if len(argv) == 0 {
+		return argv
+	}
+
+	var flags []string
+	positional := 0
+	for _, arg := range argv[1:] {
+		if len(arg) < 2 || arg[0] != '-' {
+			positional++
+			continue
+		}
+		if name, _, ok := strings.Cut(arg, "="); ok {
+			arg = name + "=*"
+		}
+		flags = append(flags, arg)
+	}
+	sort.Strings(flags)
+
+	normalized := make([]string, 0, 1+len(flags)+positional)
+	normalized = append(normalized, argv[0])
+	normalized = append(normalized, flags...)
+	for j := 0; j < positional; j++ {
+		normalized = append(normalized, "*")
+	}
+	return normalized
+}
+
+// SetCommandNormalizer sets the normalizer applied to commands recorded in
+// audit records such as the exit history. Nil records raw commands.
+func (i *Interceptor) SetCommandNormalizer(n CommandNormalizer) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.normalizer = n
+}
+
+// auditCommand returns the form of argv to record in audit records. The
+// caller must hold i.mu.
+func (i *Interceptor) auditCommand(argv []string) []string {
+	if i.normalizer == nil {
+		return argv
+	}
+	return i.normalizer(append([]string(nil), argv...))
+}
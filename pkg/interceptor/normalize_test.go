@@ -0,0 +1,71 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestNormalizeCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		argv []string
+		want []string
+	}{
+		{"empty", []string{}, []string{}},
+		{"bare command", []string{"ls"}, []string{"ls"}},
+		{
+			name: "flags sorted and positionals redacted",
+			argv: []string{"curl", "-o", "out.txt", "--silent", "https://example.com"},
+			want: []string{"curl", "--silent", "-o", "*", "*"},
+		},
+		{
+			name: "inline values redacted",
+			argv: []string{"git", "commit", "--message=fix", "-a"},
+			want: []string{"git", "--message=*", "-a", "*"},
+		},
+		{
+			name: "lone dash is positional",
+			argv: []string{"cat", "-"},
+			want: []string{"cat", "*"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeCommand(tt.argv))
+		})
+	}
+}
+
+func TestAuditUsesNormalizedCommand(t *testing.T) {
+	h := &recordingIPCHook{}
+	interceptor := New("/tmp/unused.sock", false, &blockingRecorder{recordingIPCHook: h})
+	interceptor.SetCommandNormalizer(NormalizeCommand)
+
+	raw := []string{"curl", "--token=abc123", "https://internal.example"}
+	_, err := interceptor.processRequest(&hook.Request{Command: raw, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+
+	// The hook sees the raw argv...
+	require.NotNil(t, h.last)
+	assert.Equal(t, raw, h.last.Command)
+
+	// ...while the audit history records the normalized form
+	history := interceptor.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, []string{"curl", "--token=*", "*"}, history[0].Command)
+}
+
+// blockingRecorder records requests like recordingIPCHook but blocks them
+type blockingRecorder struct {
+	*recordingIPCHook
+}
+
+func (b *blockingRecorder) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	_, _ = b.recordingIPCHook.EvaluateIPC(ctx, req)
+	return &hook.Response{Exit: true}, nil
+}
@@ -0,0 +1,38 @@
+package interceptor
+
+import (
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// DecisionCallback is called once for every decision the interceptor makes.
+// cmd is the command in its audit form (see SetCommandNormalizer), stage the
+// request's hook type, exit whether the request was blocked and dur how long
+// the decision took, including waiting for an evaluation slot.
+type DecisionCallback func(cmd []string, stage hook.HookType, exit bool, dur time.Duration)
+
+// SetDecisionCallback sets a callback invoked after every decision, for
+// metrics and telemetry. It runs synchronously on the request path before
+// the response is sent, so it must be fast and must not block; hand slow
+// work off to another goroutine. A nil callback disables it.
+func (i *Interceptor) SetDecisionCallback(fn DecisionCallback) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.onDecision = fn
+}
+
+// notifyDecision invokes the decision callback, if set
+func (i *Interceptor) notifyDecision(req *hook.Request, resp *hook.Response, dur time.Duration) {
+	i.mu.Lock()
+	fn := i.onDecision
+	var cmd []string
+	if fn != nil {
+		cmd = append([]string(nil), i.auditCommand(req.Command)...)
+	}
+	i.mu.Unlock()
+
+	if fn != nil {
+		fn(cmd, req.Hook, resp.Denied(), dur)
+	}
+}
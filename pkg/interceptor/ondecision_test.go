@@ -0,0 +1,72 @@
+package interceptor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestDecisionCallback(t *testing.T) {
+	type call struct {
+		cmd   []string
+		stage hook.HookType
+		exit  bool
+		dur   time.Duration
+	}
+	var calls []call
+
+	mockHook := newMockHook("test", []string{"*"})
+	mockHook.allowAll = false
+	mockHook.responses["ls:pre_run"] = &hook.Response{}
+	mockHook.responses["ls:post_run"] = &hook.Response{}
+
+	interceptor := New("/tmp/unused.sock", false, mockHook)
+	interceptor.SetCommandNormalizer(func(argv []string) []string { return argv[:1] })
+	interceptor.SetDecisionCallback(func(cmd []string, stage hook.HookType, exit bool, dur time.Duration) {
+		calls = append(calls, call{cmd, stage, exit, dur})
+	})
+
+	requests := []*hook.Request{
+		{Command: []string{"ls", "-la"}, PID: 1, Hook: hook.HookPreRun},
+		{Command: []string{"ls", "-la"}, PID: 1, Hook: hook.HookPostRun, ExitCode: 0},
+		{Command: []string{"curl", "https://example.com"}, PID: 2, Hook: hook.HookPreRun},
+	}
+	for _, req := range requests {
+		_, err := interceptor.processRequest(req)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, calls, 3, "one invocation per decision")
+	assert.Equal(t, []string{"ls"}, calls[0].cmd, "commands are passed in audit form")
+	assert.Equal(t, hook.HookPreRun, calls[0].stage)
+	assert.False(t, calls[0].exit)
+	assert.Equal(t, hook.HookPostRun, calls[1].stage)
+	assert.Equal(t, []string{"curl"}, calls[2].cmd)
+	assert.True(t, calls[2].exit)
+	for _, c := range calls {
+		assert.Greater(t, c.dur, time.Duration(0))
+	}
+
+	interceptor.SetDecisionCallback(nil)
+	_, err := interceptor.processRequest(requests[0])
+	require.NoError(t, err)
+	assert.Len(t, calls, 3)
+}
+
+func TestDecisionCallback_Duration(t *testing.T) {
+	var got time.Duration
+	interceptor := New("/tmp/unused.sock", false, &slowIPCHook{})
+	interceptor.SetEvaluateTimeout(20 * time.Millisecond)
+	interceptor.SetDecisionCallback(func(_ []string, _ hook.HookType, exit bool, dur time.Duration) {
+		assert.True(t, exit)
+		got = dur
+	})
+
+	_, err := interceptor.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, got, 20*time.Millisecond)
+}
@@ -0,0 +1,82 @@
+package interceptor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// panickingIPCHook panics evaluating curl, after waiting for the
+// evaluation deadline if wait is set, and allows everything else
+type panickingIPCHook struct {
+	wait bool
+}
+
+func (panickingIPCHook) Name() string       { return "panicking" }
+func (panickingIPCHook) Commands() []string { return []string{"*"} }
+func (h panickingIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	if req.Command[0] == "curl" {
+		if h.wait {
+			<-ctx.Done()
+		}
+		panic("nil map in policy")
+	}
+	return &hook.Response{}, nil
+}
+
+func TestHookPanicRecovery(t *testing.T) {
+	socketPath := fmt.Sprintf("/tmp/test_%d.sock", time.Now().UnixNano())
+	defer os.Remove(socketPath)
+
+	var logs bytes.Buffer
+	interceptor := New(socketPath, false, panickingIPCHook{})
+	interceptor.SetLogger(log.New(&logs, "", 0))
+	require.NoError(t, interceptor.Start())
+	defer interceptor.Stop()
+
+	exchange := func(command string) hook.Response {
+		conn, err := net.Dial("unix", socketPath)
+		require.NoError(t, err)
+		defer conn.Close()
+		data, err := json.Marshal(hook.Request{Command: []string{command}, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+		_, err = conn.Write(append(data, '\n'))
+		require.NoError(t, err)
+		scanner := bufio.NewScanner(conn)
+		require.True(t, scanner.Scan(), "the interceptor should answer")
+		var resp hook.Response
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &resp))
+		return resp
+	}
+
+	assert.True(t, exchange("curl").Exit, "a panicking hook denies the command")
+	assert.False(t, exchange("git").Exit, "the interceptor survives the panic")
+	assert.True(t, exchange("curl").Exit)
+
+	assert.Equal(t, 2, interceptor.Stats().Errors)
+	assert.Contains(t, logs.String(), "Hook panicking panicked evaluating [curl]: nil map in policy")
+	assert.Contains(t, logs.String(), "panic_test.go", "the stack is logged")
+}
+
+func TestHookPanicIgnoresTimeoutDecision(t *testing.T) {
+	// A panic after the deadline is still a panic, not a timeout
+	i := New("/tmp/unused.sock", false, panickingIPCHook{wait: true})
+	i.SetEvaluateTimeout(10 * time.Millisecond)
+	i.SetTimeoutDecision(TimeoutAllow)
+
+	resp, err := i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.True(t, resp.Exit)
+}
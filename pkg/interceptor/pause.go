@@ -0,0 +1,95 @@
+package interceptor
+
+import (
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// DefaultPauseTimeout is how long a request is held while the interceptor
+// is paused, unless configured otherwise (see SetPauseTimeout)
+const DefaultPauseTimeout = 30 * time.Second
+
+// MetadataPaused is the response metadata key set on decisions applied
+// because the interceptor was still paused when the request's wait ended
+const MetadataPaused = "paused"
+
+// SetPauseTimeout sets how long a request is held while the interceptor is
+// paused, and the decision applied to it if the interceptor is still paused
+// then. Zero or negative timeouts restore DefaultPauseTimeout.
+func (i *Interceptor) SetPauseTimeout(timeout time.Duration, decision TimeoutDecision) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.pauseTimeout = timeout
+	i.pauseDecision = decision
+}
+
+// Pause holds new requests instead of evaluating them, e.g. while the
+// backend of a hook is under maintenance. Held requests are evaluated once
+// Resume is called, or decided by the pause decision when their wait times
+// out (see SetPauseTimeout). Requests already being evaluated are
+// unaffected. Pausing a paused interceptor does nothing.
+func (i *Interceptor) Pause() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.resumed == nil {
+		i.resumed = make(chan struct{})
+		i.logf("Interceptor paused")
+	}
+}
+
+// Resume releases the requests held by Pause and evaluates new requests
+// again. Resuming an interceptor that is not paused does nothing.
+func (i *Interceptor) Resume() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.resumed != nil {
+		close(i.resumed)
+		i.resumed = nil
+		i.logf("Interceptor resumed")
+	}
+}
+
+// Paused reports whether the interceptor is paused
+func (i *Interceptor) Paused() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.resumed != nil
+}
+
+// waitWhilePaused holds req until the interceptor is resumed, returning nil
+// to evaluate it as usual, or the pause decision if the wait times out or
+// the interceptor stops first
+func (i *Interceptor) waitWhilePaused(req *hook.Request) *hook.Response {
+	i.mu.Lock()
+	resumed, timeout, decision := i.resumed, i.pauseTimeout, i.pauseDecision
+	i.mu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+	if timeout <= 0 {
+		timeout = DefaultPauseTimeout
+	}
+
+	i.logf("Interceptor paused; holding request: %v", req.Command)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-resumed:
+		return nil
+	case <-timer.C:
+	case <-i.stop:
+	}
+
+	allow := decision == TimeoutAllow
+	if allow {
+		i.logf("Interceptor still paused; allowing: %v", req.Command)
+	} else {
+		i.logf("Interceptor still paused; blocking: %v", req.Command)
+	}
+	return &hook.Response{
+		Exit:     !allow,
+		Reason:   "interceptor paused",
+		Metadata: map[string]interface{}{MetadataPaused: true},
+	}
+}
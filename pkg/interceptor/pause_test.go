@@ -0,0 +1,101 @@
+package interceptor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestPauseResume(t *testing.T) {
+	h := newMockHook("test", []string{"curl"})
+	i := New("/tmp/unused.sock", false, h)
+	assert.False(t, i.Paused())
+
+	i.Pause()
+	i.Pause()
+	assert.True(t, i.Paused())
+
+	done := make(chan *hook.Response, 1)
+	go func() {
+		resp, err := i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+		assert.NoError(t, err)
+		done <- resp
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("request should be held while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	i.Resume()
+	i.Resume()
+	assert.False(t, i.Paused())
+	select {
+	case resp := <-done:
+		assert.False(t, resp.Exit)
+		assert.NotContains(t, resp.Metadata, MetadataPaused)
+		assert.Equal(t, 1, h.evalCount, "held request is evaluated on resume")
+	case <-time.After(time.Second):
+		t.Fatal("request should be released on resume")
+	}
+
+	// Not paused: evaluated right away
+	resp, err := i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.False(t, resp.Exit)
+	assert.Equal(t, 2, h.evalCount)
+}
+
+func TestPauseTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		decision TimeoutDecision
+		wantExit bool
+	}{
+		{"block", TimeoutBlock, true},
+		{"allow", TimeoutAllow, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newMockHook("test", []string{"curl"})
+			i := New("/tmp/unused.sock", false, h)
+			i.SetPauseTimeout(20*time.Millisecond, tt.decision)
+			i.Pause()
+
+			start := time.Now()
+			resp, err := i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+			assert.Equal(t, tt.wantExit, resp.Exit)
+			assert.Equal(t, true, resp.Metadata[MetadataPaused])
+			assert.Zero(t, h.evalCount, "the hook is not consulted while paused")
+		})
+	}
+}
+
+func TestPauseStop(t *testing.T) {
+	i := New("/tmp/unused.sock", false, newMockHook("test", []string{"curl"}))
+	i.SetPauseTimeout(time.Hour, TimeoutBlock)
+	i.Pause()
+
+	done := make(chan *hook.Response, 1)
+	go func() {
+		resp, _ := i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+		done <- resp
+	}()
+	time.Sleep(20 * time.Millisecond)
+	i.Stop()
+
+	select {
+	case resp := <-done:
+		assert.True(t, resp.Exit, "held requests get the pause decision when the interceptor stops")
+	case <-time.After(time.Second):
+		t.Fatal("request should be released on stop")
+	}
+}
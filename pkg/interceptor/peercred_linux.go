@@ -0,0 +1,32 @@
+//go:build linux
+
+package interceptor
+
+import (
+	"net"
+	"syscall"
+)
+
+// peerCredentials returns the UID, GID and PID of the process on the other
+// end of a Unix socket connection, from SO_PEERCRED. Other connections, such
+// as TCP, have no peer credentials. The PID is 0 when the peer is in
+// another PID namespace.
+func peerCredentials(conn net.Conn) (uid, gid, pid int, ok bool) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return 0, 0, 0, false
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil || credErr != nil {
+		return 0, 0, 0, false
+	}
+	return int(cred.Uid), int(cred.Gid), int(cred.Pid), true
+}
@@ -0,0 +1,96 @@
+//go:build linux
+
+package interceptor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// peerRecordingHook records the PeerUID of each request
+type peerRecordingHook struct {
+	mu   sync.Mutex
+	uids []*int
+}
+
+func (h *peerRecordingHook) Name() string       { return "peer-recording" }
+func (h *peerRecordingHook) Commands() []string { return []string{"*"} }
+func (h *peerRecordingHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.uids = append(h.uids, req.PeerUID)
+	return &hook.Response{}, nil
+}
+
+func TestPeerUID(t *testing.T) {
+	for _, socketType := range []SocketType{SocketStream, SocketSeqpacket} {
+		t.Run(string(socketType), func(t *testing.T) {
+			socketPath := filepath.Join(t.TempDir(), "peer.sock")
+			h := &peerRecordingHook{}
+			i := New(socketPath, false, h)
+			i.SetSocketType(socketType)
+			require.NoError(t, i.Start())
+			defer i.Stop()
+
+			// A claimed identity in the request is ignored
+			data, err := json.Marshal(map[string]interface{}{
+				"command": []string{"ls"},
+				"hook":    hook.HookPreRun,
+				"PeerUID": 12345,
+			})
+			require.NoError(t, err)
+
+			if socketType == SocketSeqpacket {
+				packetRoundTrip(t, socketPath, data)
+			} else {
+				conn, err := net.Dial("unix", socketPath)
+				require.NoError(t, err)
+				defer conn.Close()
+				_, err = conn.Write(append(data, '\n'))
+				require.NoError(t, err)
+				_, err = bufio.NewReader(conn).ReadBytes('\n')
+				require.NoError(t, err)
+			}
+
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			require.Len(t, h.uids, 1)
+			require.NotNil(t, h.uids[0], "peer credentials are read from the socket")
+			assert.Equal(t, os.Getuid(), *h.uids[0])
+		})
+	}
+}
+
+func TestPeerCredentialsReplaceReported(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "peer.sock")
+	h := &credentialsRecordingHook{}
+	i := New(socketPath, false, h)
+	require.NoError(t, i.Start())
+	defer i.Stop()
+
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	defer conn.Close()
+	sendRequestLine(t, conn, claimedCredentials)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	require.Len(t, h.reqs, 1)
+	req := h.reqs[0]
+	require.NotNil(t, req.UID)
+	require.NotNil(t, req.GID)
+	assert.Equal(t, os.Getuid(), *req.UID, "the verified identity replaces the reported one")
+	assert.Equal(t, os.Getgid(), *req.GID)
+	assert.Equal(t, true, req.Metadata[hook.MetadataCredentialsVerified])
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package interceptor
+
+import "net"
+
+// peerCredentials is only supported on Linux; requests carry no PeerUID
+// elsewhere
+func peerCredentials(conn net.Conn) (uid, gid, pid int, ok bool) {
+	return 0, 0, 0, false
+}
@@ -0,0 +1,114 @@
+//go:build linux
+
+package interceptor
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// maxAncestorDepth bounds the ancestor walk to guard against cycles caused by
+// PID reuse while walking
+const maxAncestorDepth = 64
+
+// procRoot is the procfs mount point (overridable in tests)
+var procRoot = "/proc"
+
+// ProcfsEnricher returns an enricher that attaches process-tree context read
+// from procfs: "ancestors" holds the command names of the requesting
+// process's parent, grandparent, and so on, and "cgroup" holds its cgroup
+// path. Entries that disappear while being read (the process tree is live)
+// are skipped rather than treated as errors. Both keys are only ever set by
+// the enricher: values sent by the client are discarded. Over Unix sockets
+// the PID is the peer's, from the socket credentials, rather than the one
+// the wrapper reports.
+func ProcfsEnricher() (Enricher, error) {
+	if _, err := os.Stat(procRoot); err != nil {
+		return nil, fmt.Errorf("procfs not available: %w", err)
+	}
+	return enrichFromProcfs, nil
+}
+
+// enrichFromProcfs attaches ancestor and cgroup information for req.PID,
+// replacing any the client sent
+func enrichFromProcfs(req *hook.Request) {
+	delete(req.Metadata, "ancestors")
+	delete(req.Metadata, "cgroup")
+	if req.PID <= 0 {
+		return
+	}
+
+	var ancestors []string
+	pid := req.PID
+	for depth := 0; depth < maxAncestorDepth; depth++ {
+		_, ppid, err := readProcStat(pid)
+		if err != nil || ppid <= 0 {
+			break
+		}
+		comm, _, err := readProcStat(ppid)
+		if err != nil {
+			break
+		}
+		ancestors = append(ancestors, comm)
+		pid = ppid
+	}
+	if len(ancestors) > 0 {
+		req.Metadata["ancestors"] = ancestors
+	}
+
+	if cgroup, err := readProcCgroup(req.PID); err == nil && cgroup != "" {
+		req.Metadata["cgroup"] = cgroup
+	}
+}
+
+// readProcStat returns the command name and parent PID of pid
+func readProcStat(pid int) (string, int, error) {
+	data, err := os.ReadFile(fmt.Sprintf("%s/%d/stat", procRoot, pid))
+	if err != nil {
+		return "", 0, err
+	}
+	// Format: "pid (comm) state ppid ...". comm may itself contain spaces
+	// or parentheses, so locate it using the last closing parenthesis.
+	stat := string(data)
+	open := strings.IndexByte(stat, '(')
+	closing := strings.LastIndexByte(stat, ')')
+	if open < 0 || closing < open {
+		return "", 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	fields := strings.Fields(stat[closing+1:])
+	if len(fields) < 2 {
+		return "", 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed ppid for pid %d: %w", pid, err)
+	}
+	return stat[open+1 : closing], ppid, nil
+}
+
+// readProcCgroup returns the cgroup path of pid, preferring the unified
+// (v2) hierarchy and falling back to the first listed hierarchy
+func readProcCgroup(pid int) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("%s/%d/cgroup", procRoot, pid))
+	if err != nil {
+		return "", err
+	}
+	var first string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			return parts[2], nil
+		}
+		if first == "" {
+			first = parts[2]
+		}
+	}
+	return first, nil
+}
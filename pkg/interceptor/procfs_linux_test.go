@@ -0,0 +1,120 @@
+//go:build linux
+
+package interceptor
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestProcfsEnricher(t *testing.T) {
+	enricher, err := ProcfsEnricher()
+	require.NoError(t, err)
+
+	req := &hook.Request{PID: os.Getpid(), Metadata: map[string]interface{}{}}
+	enricher(req)
+
+	// The test binary always has a parent, so at least one ancestor is found
+	ancestors, ok := req.Metadata["ancestors"].([]string)
+	require.True(t, ok, "ancestors should be attached")
+	assert.NotEmpty(t, ancestors)
+
+	parentComm, _, err := readProcStat(os.Getppid())
+	require.NoError(t, err)
+	assert.Equal(t, parentComm, ancestors[0])
+}
+
+func TestProcfsEnricherMissingProcess(t *testing.T) {
+	req := &hook.Request{PID: 1 << 30, Metadata: map[string]interface{}{}}
+	enrichFromProcfs(req)
+	assert.Empty(t, req.Metadata, "vanished processes should be skipped quietly")
+}
+
+func TestReadProcStat(t *testing.T) {
+	root := t.TempDir()
+	oldRoot := procRoot
+	procRoot = root
+	t.Cleanup(func() { procRoot = oldRoot })
+
+	write := func(pid, name, content string) {
+		dir := filepath.Join(root, pid)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("42", "stat", "42 (my (odd) cmd) S 7 42 42 0 -1\n")
+	write("42", "cgroup", "4:memory:/legacy\n0::/ci/job-9\n")
+	write("7", "stat", "7 (runner) S 1 7 7 0 -1\n")
+	write("1", "stat", "1 (init) S 0 1 1 0 -1\n")
+
+	comm, ppid, err := readProcStat(42)
+	require.NoError(t, err)
+	assert.Equal(t, "my (odd) cmd", comm)
+	assert.Equal(t, 7, ppid)
+
+	req := &hook.Request{PID: 42, Metadata: map[string]interface{}{}}
+	enrichFromProcfs(req)
+	assert.Equal(t, []string{"runner", "init"}, req.Metadata["ancestors"])
+	assert.Equal(t, "/ci/job-9", req.Metadata["cgroup"])
+}
+
+func TestProcfsEnricherDiscardsClientLineage(t *testing.T) {
+	spoofed := func(pid int) *hook.Request {
+		return &hook.Request{PID: pid, Metadata: map[string]interface{}{
+			"ancestors": []string{"sshd"},
+			"cgroup":    "/trusted",
+			"other":     "kept",
+		}}
+	}
+
+	for name, pid := range map[string]int{"no pid": 0, "vanished process": 1 << 30} {
+		t.Run(name, func(t *testing.T) {
+			req := spoofed(pid)
+			enrichFromProcfs(req)
+			assert.Equal(t, map[string]interface{}{"other": "kept"}, req.Metadata)
+		})
+	}
+}
+
+func TestProcfsEnricherUsesPeerPID(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "procfs.sock")
+	h := &recordingIPCHook{}
+	i := New(socketPath, false, h)
+	enricher, err := ProcfsEnricher()
+	require.NoError(t, err)
+	i.AddEnricher(enricher)
+	require.NoError(t, i.Start())
+	defer i.Stop()
+
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	defer conn.Close()
+	// The client claims to be init, with a lineage of its choosing
+	data, err := json.Marshal(map[string]interface{}{
+		"command":  []string{"ls"},
+		"pid":      1,
+		"hook":     hook.HookPreRun,
+		"metadata": map[string]interface{}{"ancestors": []string{"sshd"}, "cgroup": "/trusted"},
+	})
+	require.NoError(t, err)
+	_, err = conn.Write(append(data, '\n'))
+	require.NoError(t, err)
+	require.True(t, bufio.NewScanner(conn).Scan())
+
+	require.NotNil(t, h.last)
+	assert.Equal(t, os.Getpid(), h.last.PID, "the PID comes from the socket's peer credentials")
+	parentComm, _, err := readProcStat(os.Getppid())
+	require.NoError(t, err)
+	ancestors, ok := h.last.Metadata["ancestors"].([]string)
+	require.True(t, ok)
+	assert.Equal(t, parentComm, ancestors[0])
+	assert.NotEqual(t, "/trusted", h.last.Metadata["cgroup"])
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package interceptor
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// ProcfsEnricher is only supported on Linux
+func ProcfsEnricher() (Enricher, error) {
+	return nil, fmt.Errorf("procfs enrichment is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,101 @@
+package interceptor
+
+import (
+	"sort"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// Progress is a snapshot of the commands handled during a run
+type Progress struct {
+	// Evaluated counts all evaluated requests (pre-run and post-run)
+	Evaluated int
+	// Started counts commands allowed to run
+	Started int
+	// Completed counts commands that have finished
+	Completed int
+	// Running lists the commands currently running, longest-running first
+	Running []RunningCommand
+	// Elapsed is the time since the run started
+	Elapsed time.Duration
+}
+
+// RunningCommand describes a command that has passed pre-run evaluation but
+// has not reported completion yet
+type RunningCommand struct {
+	Command []string
+	PID     int
+	// Elapsed is how long the command has been running
+	Elapsed time.Duration
+}
+
+// runningCommand is the internal record behind RunningCommand
+type runningCommand struct {
+	command []string
+	started time.Time
+}
+
+// Progress returns a snapshot of the current run's progress
+func (i *Interceptor) Progress() Progress {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := time.Now()
+	p := Progress{
+		Evaluated: i.evaluated,
+		Started:   i.started,
+		Completed: i.completed,
+	}
+	if !i.runStarted.IsZero() {
+		p.Elapsed = now.Sub(i.runStarted)
+	}
+	for pid, rc := range i.running {
+		p.Running = append(p.Running, RunningCommand{
+			Command: rc.command,
+			PID:     pid,
+			Elapsed: now.Sub(rc.started),
+		})
+	}
+	sort.Slice(p.Running, func(a, b int) bool {
+		if p.Running[a].Elapsed != p.Running[b].Elapsed {
+			return p.Running[a].Elapsed > p.Running[b].Elapsed
+		}
+		return p.Running[a].PID < p.Running[b].PID
+	})
+	return p
+}
+
+// ResetProgress clears progress tracking and restarts the run clock.
+// cmdhooks calls it at the start of every execution.
+func (i *Interceptor) ResetProgress() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.evaluated, i.started, i.completed = 0, 0, 0
+	i.running = nil
+	i.runStarted = time.Now()
+}
+
+// trackProgress updates progress tracking for an evaluated request. Wrappers
+// send pre-run and post-run requests from the same process, so the PID pairs
+// them up.
+func (i *Interceptor) trackProgress(req *hook.Request, resp *hook.Response) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.evaluated++
+	switch req.Hook {
+	case hook.HookPreRun:
+		if resp.Denied() {
+			return
+		}
+		i.started++
+		if i.running == nil {
+			i.running = make(map[int]runningCommand)
+		}
+		i.running[req.PID] = runningCommand{command: i.auditCommand(req.Command), started: time.Now()}
+	case hook.HookPostRun:
+		i.completed++
+		delete(i.running, req.PID)
+	}
+}
@@ -0,0 +1,56 @@
+package interceptor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestProgress(t *testing.T) {
+	mockHook := &mockIPCHook{response: &hook.Response{}}
+	interceptor := New("/tmp/unused.sock", false, mockHook)
+	interceptor.ResetProgress()
+
+	send := func(pid int, stage hook.HookType, argv ...string) {
+		_, err := interceptor.processRequest(&hook.Request{Command: argv, PID: pid, Hook: stage})
+		require.NoError(t, err)
+	}
+
+	send(100, hook.HookPreRun, "make", "all")
+	time.Sleep(5 * time.Millisecond)
+	send(200, hook.HookPreRun, "curl", "https://example.com")
+
+	p := interceptor.Progress()
+	assert.Equal(t, 2, p.Evaluated)
+	assert.Equal(t, 2, p.Started)
+	assert.Equal(t, 0, p.Completed)
+	require.Len(t, p.Running, 2)
+	assert.Equal(t, []string{"make", "all"}, p.Running[0].Command, "longest-running first")
+	assert.Equal(t, 100, p.Running[0].PID)
+	assert.Greater(t, p.Running[0].Elapsed, p.Running[1].Elapsed)
+	assert.Greater(t, p.Elapsed, time.Duration(0))
+
+	send(100, hook.HookPostRun, "make", "all")
+	p = interceptor.Progress()
+	assert.Equal(t, 3, p.Evaluated)
+	assert.Equal(t, 1, p.Completed)
+	require.Len(t, p.Running, 1)
+	assert.Equal(t, 200, p.Running[0].PID)
+
+	// Blocked commands never start
+	mockHook.response = &hook.Response{Exit: true}
+	send(300, hook.HookPreRun, "rm", "-rf", "/")
+	p = interceptor.Progress()
+	assert.Equal(t, 2, p.Started)
+	assert.Len(t, p.Running, 1)
+
+	interceptor.ResetProgress()
+	p = interceptor.Progress()
+	assert.Zero(t, p.Evaluated)
+	assert.Zero(t, p.Started)
+	assert.Empty(t, p.Running)
+}
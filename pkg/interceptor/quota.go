@@ -0,0 +1,140 @@
+package interceptor
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// SetTimeQuota limits the cumulative wall-clock time wrapped commands may
+// consume. Each post-run request adds its reported Duration to the running
+// total; once the total reaches d, pre-run requests are blocked without
+// consulting the hook. Zero or negative disables the quota.
+func (i *Interceptor) SetTimeQuota(d time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.timeQuota = d
+}
+
+// TimeUsed returns the cumulative command time reported so far, across all
+// quota keys
+func (i *Interceptor) TimeUsed() time.Duration {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.timeUsed
+}
+
+// SetPerCommandTimeQuota limits the cumulative wall-clock time each listed
+// command may consume, keyed by command name. It works like SetTimeQuota,
+// but per command: once a command's total reaches its quota, only that
+// command is blocked at pre-run. Commands not in quotas are unlimited, and
+// non-positive entries are ignored.
+func (i *Interceptor) SetPerCommandTimeQuota(quotas map[string]time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.commandQuotas = make(map[string]time.Duration, len(quotas))
+	for name, d := range quotas {
+		if d > 0 {
+			i.commandQuotas[name] = d
+		}
+	}
+}
+
+// CommandTimeUsed returns the cumulative time reported so far for the
+// command with the given name, across all quota keys. Only commands with a
+// per-command quota are tracked.
+func (i *Interceptor) CommandTimeUsed(name string) time.Duration {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.commandTimeUsed[name]
+}
+
+// QuotaKey maps a request to the party its time is charged to, such as
+// hook.PeerUIDKey for per-user quotas
+type QuotaKey func(req *hook.Request) string
+
+// SetQuotaKey makes the time quotas apply to each key separately: every key
+// gets the full SetTimeQuota and SetPerCommandTimeQuota budgets, and one
+// key exhausting them does not block requests with other keys. Nil, the
+// default, charges all requests to one shared budget.
+func (i *Interceptor) SetQuotaKey(fn QuotaKey) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.quotaKey = fn
+}
+
+// KeyTimeUsed returns the cumulative command time charged to key so far.
+// Without a quota key, all time is charged to "".
+func (i *Interceptor) KeyTimeUsed(key string) time.Duration {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.keyTimeUsed[key]
+}
+
+// ResetTimeUsed clears the command time charged to every quota, so the
+// budgets start afresh. cmdhooks calls it at the start of every execution.
+func (i *Interceptor) ResetTimeUsed() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.timeUsed = 0
+	i.keyTimeUsed = nil
+	i.commandTimeUsed = nil
+	i.bucketTimeUsed = nil
+}
+
+// quotaBucket identifies a per-command budget of one quota key
+type quotaBucket struct {
+	key, name string
+}
+
+// applyTimeQuota accounts for finished commands and returns a blocking
+// response for pre-run requests once the quota is exhausted, or nil
+func (i *Interceptor) applyTimeQuota(req *hook.Request) *hook.Response {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var key, name string
+	if i.quotaKey != nil {
+		key = i.quotaKey(req)
+	}
+	if len(req.Command) > 0 {
+		name = filepath.Base(req.Command[0])
+	}
+	commandQuota, hasCommandQuota := i.commandQuotas[name]
+	bucket := quotaBucket{key, name}
+
+	switch req.Hook {
+	case hook.HookPostRun:
+		if req.Duration > 0 {
+			if i.keyTimeUsed == nil {
+				i.keyTimeUsed = make(map[string]time.Duration)
+			}
+			i.timeUsed += req.Duration
+			i.keyTimeUsed[key] += req.Duration
+			if hasCommandQuota {
+				if i.commandTimeUsed == nil {
+					i.commandTimeUsed = make(map[string]time.Duration)
+					i.bucketTimeUsed = make(map[quotaBucket]time.Duration)
+				}
+				i.commandTimeUsed[name] += req.Duration
+				i.bucketTimeUsed[bucket] += req.Duration
+			}
+		}
+	case hook.HookPreRun:
+		if i.timeQuota > 0 && i.keyTimeUsed[key] >= i.timeQuota {
+			return &hook.Response{
+				Exit:   true,
+				Reason: "time quota exceeded",
+			}
+		}
+		if hasCommandQuota && i.bucketTimeUsed[bucket] >= commandQuota {
+			return &hook.Response{
+				Exit:   true,
+				Reason: fmt.Sprintf("time quota for %s exceeded", name),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package interceptor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestTimeQuota(t *testing.T) {
+	mockHook := &mockIPCHook{response: &hook.Response{Exit: false}}
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, mockHook)
+	interceptor.SetTimeQuota(time.Minute)
+
+	run := func(d time.Duration) *hook.Response {
+		resp, err := interceptor.processRequest(&hook.Request{Command: []string{"make"}, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+		if resp.Exit {
+			return resp
+		}
+		_, err = interceptor.processRequest(&hook.Request{Command: []string{"make"}, Hook: hook.HookPostRun, Duration: d})
+		require.NoError(t, err)
+		return resp
+	}
+
+	assert.False(t, run(40*time.Second).Exit)
+	assert.Equal(t, 40*time.Second, interceptor.TimeUsed())
+
+	// Under quota at pre-run, so this command runs and overshoots it
+	assert.False(t, run(30*time.Second).Exit)
+	assert.Equal(t, 70*time.Second, interceptor.TimeUsed())
+
+	assert.True(t, run(time.Second).Exit, "commands after the quota is exhausted are blocked")
+	assert.Equal(t, 70*time.Second, interceptor.TimeUsed())
+	assert.Equal(t, 1, interceptor.Stats().ExitRequests)
+}
+
+func TestTimeQuotaDisabled(t *testing.T) {
+	mockHook := &mockIPCHook{response: &hook.Response{Exit: false}}
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, mockHook)
+
+	_, err := interceptor.processRequest(&hook.Request{Command: []string{"make"}, Hook: hook.HookPostRun, Duration: time.Hour})
+	require.NoError(t, err)
+
+	resp, err := interceptor.processRequest(&hook.Request{Command: []string{"make"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.False(t, resp.Exit)
+	assert.Equal(t, time.Hour, interceptor.TimeUsed())
+}
+
+func TestPerCommandTimeQuota(t *testing.T) {
+	mockHook := &mockIPCHook{response: &hook.Response{Exit: false}}
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, mockHook)
+	interceptor.SetPerCommandTimeQuota(map[string]time.Duration{
+		"git":  5 * time.Minute,
+		"curl": time.Minute,
+	})
+
+	run := func(cmd string, d time.Duration) *hook.Response {
+		resp, err := interceptor.processRequest(&hook.Request{Command: []string{cmd}, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+		if resp.Exit {
+			return resp
+		}
+		_, err = interceptor.processRequest(&hook.Request{Command: []string{cmd}, Hook: hook.HookPostRun, Duration: d})
+		require.NoError(t, err)
+		return resp
+	}
+
+	assert.False(t, run("curl", 45*time.Second).Exit)
+	assert.False(t, run("curl", 30*time.Second).Exit, "under quota at pre-run")
+	assert.Equal(t, 75*time.Second, interceptor.CommandTimeUsed("curl"))
+
+	resp := run("curl", time.Second)
+	assert.True(t, resp.Exit, "curl has spent its budget")
+	assert.Equal(t, "time quota for curl exceeded", resp.Reason)
+	assert.Equal(t, 75*time.Second, interceptor.CommandTimeUsed("curl"))
+
+	assert.False(t, run("git", 2*time.Minute).Exit, "other commands keep their own budget")
+	assert.False(t, run("/usr/bin/git", 2*time.Minute).Exit, "commands are keyed by name")
+	assert.Equal(t, 4*time.Minute, interceptor.CommandTimeUsed("git"))
+	assert.False(t, run("make", time.Hour).Exit, "commands without a quota are unlimited")
+	assert.Zero(t, interceptor.CommandTimeUsed("make"))
+	assert.Equal(t, 1, interceptor.Stats().ExitRequests)
+
+	// Every budget starts afresh on the next run
+	interceptor.ResetTimeUsed()
+	assert.Zero(t, interceptor.TimeUsed())
+	assert.Zero(t, interceptor.CommandTimeUsed("curl"))
+	assert.False(t, run("curl", time.Second).Exit)
+}
+
+func TestTimeQuotaPerKey(t *testing.T) {
+	mockHook := &mockIPCHook{response: &hook.Response{Exit: false}}
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, mockHook)
+	interceptor.SetTimeQuota(time.Minute)
+	interceptor.SetPerCommandTimeQuota(map[string]time.Duration{"curl": 10 * time.Second})
+	interceptor.SetQuotaKey(hook.PeerUIDKey)
+
+	alice, bob := 1000, 1001
+	run := func(uid *int, cmd string, d time.Duration) *hook.Response {
+		resp, err := interceptor.processRequest(&hook.Request{Command: []string{cmd}, Hook: hook.HookPreRun, PeerUID: uid})
+		require.NoError(t, err)
+		if resp.Exit {
+			return resp
+		}
+		_, err = interceptor.processRequest(&hook.Request{Command: []string{cmd}, Hook: hook.HookPostRun, Duration: d, PeerUID: uid})
+		require.NoError(t, err)
+		return resp
+	}
+
+	assert.False(t, run(&alice, "curl", 10*time.Second).Exit)
+	assert.False(t, run(&alice, "make", time.Minute).Exit)
+	assert.True(t, run(&alice, "make", time.Second).Exit, "alice has used her quota")
+
+	assert.False(t, run(&bob, "curl", 10*time.Second).Exit, "bob has his own budgets")
+	resp := run(&bob, "curl", time.Second)
+	assert.True(t, resp.Exit)
+	assert.Equal(t, "time quota for curl exceeded", resp.Reason)
+	assert.False(t, run(&bob, "make", time.Second).Exit)
+	assert.False(t, run(nil, "make", time.Second).Exit, "unverified requests share their own budget")
+
+	assert.Equal(t, 70*time.Second, interceptor.KeyTimeUsed("uid:1000"))
+	assert.Equal(t, 11*time.Second, interceptor.KeyTimeUsed("uid:1001"))
+	assert.Equal(t, time.Second, interceptor.KeyTimeUsed(hook.UnverifiedPeerKey))
+	assert.Equal(t, 82*time.Second, interceptor.TimeUsed())
+	assert.Equal(t, 20*time.Second, interceptor.CommandTimeUsed("curl"))
+}
+
+func TestTimeQuotaChargedWhilePaused(t *testing.T) {
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, &mockIPCHook{response: &hook.Response{}})
+	interceptor.SetTimeQuota(time.Minute)
+	interceptor.SetPauseTimeout(10*time.Millisecond, TimeoutAllow)
+	interceptor.Pause()
+
+	resp, err := interceptor.processRequest(&hook.Request{Command: []string{"make"}, Hook: hook.HookPostRun, Duration: 2 * time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, true, resp.Metadata[MetadataPaused], "the request timed out while paused")
+	assert.Equal(t, 2*time.Minute, interceptor.TimeUsed(), "the command's time is still charged")
+
+	interceptor.Resume()
+	resp, err = interceptor.processRequest(&hook.Request{Command: []string{"make"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.True(t, resp.Exit, "the quota cannot be bypassed by running commands during a pause")
+}
@@ -0,0 +1,70 @@
+package interceptor
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// HookLoader loads a replacement hook, e.g. by re-reading a policy file
+type HookLoader func() (hook.Hook, error)
+
+// SetReloadEndpoint lets clients of the socket replace the hook at runtime.
+// A hook.HookReload control message whose MetadataReloadToken matches token
+// makes the interceptor call load and switch to the hook it returns.
+// Messages with a wrong or missing token are refused, as are all reload
+// messages while the endpoint is not set. An empty token disables it.
+func (i *Interceptor) SetReloadEndpoint(token string, load HookLoader) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if token == "" || load == nil {
+		i.reloadToken, i.reloadHook = "", nil
+		return
+	}
+	i.reloadToken, i.reloadHook = token, load
+}
+
+// handleReload answers a reload control message. The response metadata
+// holds "reloaded" and, on success, the new hook's name under "hook" or, on
+// failure, the reason under "error". The current hook is kept unless the
+// reload succeeds.
+func (i *Interceptor) handleReload(req *hook.Request) *hook.Response {
+	i.mu.Lock()
+	token, load := i.reloadToken, i.reloadHook
+	i.mu.Unlock()
+
+	given, _ := req.Metadata[hook.MetadataReloadToken].(string)
+	if load == nil || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		i.logf("Refused unauthenticated hook reload")
+		return reloadFailure("reload not authorized")
+	}
+
+	h, err := load()
+	if err == nil && h == nil {
+		err = fmt.Errorf("loader returned no hook")
+	}
+	if err != nil {
+		i.logf("Hook reload failed: %v", err)
+		return reloadFailure(err.Error())
+	}
+
+	i.SetHook(h)
+	i.logf("Hook reloaded: %s", h.Name())
+	return &hook.Response{
+		Metadata: map[string]interface{}{
+			"reloaded": true,
+			"hook":     h.Name(),
+		},
+	}
+}
+
+// reloadFailure is the response to a reload that did not happen
+func reloadFailure(reason string) *hook.Response {
+	return &hook.Response{
+		Metadata: map[string]interface{}{
+			"reloaded": false,
+			"error":    reason,
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package interceptor
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestReloadEndpoint(t *testing.T) {
+	socketPath := fmt.Sprintf("/tmp/test_%d.sock", time.Now().UnixNano())
+	defer os.Remove(socketPath)
+
+	interceptor := New(socketPath, false, &mockIPCHook{response: &hook.Response{Exit: true}})
+	require.NoError(t, interceptor.Start())
+	defer interceptor.Stop()
+
+	policy := "allow"
+	loads := 0
+	interceptor.SetReloadEndpoint("s3cret", func() (hook.Hook, error) {
+		loads++
+		if policy == "broken" {
+			return nil, fmt.Errorf("policy file is invalid")
+		}
+		return &mockIPCHook{response: &hook.Response{Exit: policy == "block"}}, nil
+	})
+
+	reload := func(token string) hook.Response {
+		return sendRequest(t, socketPath, hook.Request{
+			Hook:     hook.HookReload,
+			Metadata: map[string]interface{}{hook.MetadataReloadToken: token},
+		})
+	}
+	curl := func() bool {
+		return sendRequest(t, socketPath, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun}).Exit
+	}
+
+	require.True(t, curl(), "the initial policy blocks")
+
+	resp := reload("wrong")
+	assert.Equal(t, false, resp.Metadata["reloaded"])
+	assert.Equal(t, "reload not authorized", resp.Metadata["error"])
+	assert.Zero(t, loads, "unauthenticated reloads never reach the loader")
+	assert.True(t, curl())
+
+	resp = reload("s3cret")
+	assert.Equal(t, true, resp.Metadata["reloaded"])
+	assert.Equal(t, "mock-ipc-hook", resp.Metadata["hook"])
+	assert.False(t, curl(), "the reloaded policy takes effect")
+
+	policy = "broken"
+	resp = reload("s3cret")
+	assert.Equal(t, false, resp.Metadata["reloaded"])
+	assert.Equal(t, "policy file is invalid", resp.Metadata["error"])
+	assert.False(t, curl(), "a failed reload keeps the current policy")
+
+	assert.Equal(t, 2, interceptor.Stats().ExitRequests, "refused reloads are not exit requests")
+
+	// Disabling the endpoint refuses every reload
+	interceptor.SetReloadEndpoint("", nil)
+	assert.Equal(t, false, reload("").Metadata["reloaded"])
+}
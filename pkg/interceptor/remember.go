@@ -0,0 +1,57 @@
+package interceptor
+
+import (
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// SetRememberDecisions enables the pre-run confirmation cache: when a hook
+// allows a pre-run request and sets hook.MetadataRememberForRun in the
+// response metadata, later pre-run requests for the same command name are
+// allowed without consulting the hook until ClearRememberedDecisions is
+// called. Blocks are never remembered.
+func (i *Interceptor) SetRememberDecisions(enabled bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rememberEnabled = enabled
+}
+
+// ClearRememberedDecisions forgets all remembered approvals. cmdhooks calls
+// it at the start of every execution so approvals last for a single run.
+func (i *Interceptor) ClearRememberedDecisions() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remembered = nil
+}
+
+// rememberedResponse returns an allow response if req's command was
+// previously approved for the rest of the run, or nil
+func (i *Interceptor) rememberedResponse(req *hook.Request) *hook.Response {
+	if req.Hook != hook.HookPreRun || len(req.Command) == 0 {
+		return nil
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if !i.rememberEnabled || !i.remembered[req.Command[0]] {
+		return nil
+	}
+	return &hook.Response{Metadata: map[string]interface{}{"remembered": true}}
+}
+
+// rememberDecision caches an approval the hook asked to remember
+func (i *Interceptor) rememberDecision(req *hook.Request, resp *hook.Response) {
+	if req.Hook != hook.HookPreRun || len(req.Command) == 0 || resp.Denied() {
+		return
+	}
+	if remember, _ := resp.Metadata[hook.MetadataRememberForRun].(bool); !remember {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if !i.rememberEnabled {
+		return
+	}
+	if i.remembered == nil {
+		i.remembered = make(map[string]bool)
+	}
+	i.remembered[req.Command[0]] = true
+}
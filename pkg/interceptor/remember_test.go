@@ -0,0 +1,76 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// promptingHook simulates an interactive hook that approves every command
+// and asks for the approval to be remembered
+type promptingHook struct {
+	prompts map[string]int
+}
+
+func (p *promptingHook) Name() string       { return "prompting" }
+func (p *promptingHook) Commands() []string { return []string{"*"} }
+func (p *promptingHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	if req.Hook != hook.HookPreRun {
+		return &hook.Response{}, nil
+	}
+	p.prompts[req.Command[0]]++
+	if req.Command[0] == "rm" {
+		return &hook.Response{Exit: true, Metadata: map[string]interface{}{hook.MetadataRememberForRun: true}}, nil
+	}
+	return &hook.Response{Metadata: map[string]interface{}{hook.MetadataRememberForRun: true}}, nil
+}
+
+func TestRememberDecisions(t *testing.T) {
+	preRun := func(i *Interceptor, argv ...string) *hook.Response {
+		resp, err := i.processRequest(&hook.Request{Command: argv, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("approval remembered for the run", func(t *testing.T) {
+		h := &promptingHook{prompts: map[string]int{}}
+		interceptor := New("/tmp/unused.sock", false, h)
+		interceptor.SetRememberDecisions(true)
+
+		assert.False(t, preRun(interceptor, "curl", "https://a.example").Exit)
+		assert.False(t, preRun(interceptor, "curl", "https://b.example").Exit)
+		assert.Equal(t, 1, h.prompts["curl"], "second invocation should not re-prompt")
+
+		// Scope is the command name
+		assert.False(t, preRun(interceptor, "wget", "https://a.example").Exit)
+		assert.Equal(t, 1, h.prompts["wget"])
+
+		// A new run starts with a clean cache
+		interceptor.ClearRememberedDecisions()
+		preRun(interceptor, "curl")
+		assert.Equal(t, 2, h.prompts["curl"])
+	})
+
+	t.Run("blocks are never remembered", func(t *testing.T) {
+		h := &promptingHook{prompts: map[string]int{}}
+		interceptor := New("/tmp/unused.sock", false, h)
+		interceptor.SetRememberDecisions(true)
+
+		assert.True(t, preRun(interceptor, "rm", "-rf", "/").Exit)
+		assert.True(t, preRun(interceptor, "rm", "-rf", "/").Exit)
+		assert.Equal(t, 2, h.prompts["rm"])
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		h := &promptingHook{prompts: map[string]int{}}
+		interceptor := New("/tmp/unused.sock", false, h)
+
+		preRun(interceptor, "curl")
+		preRun(interceptor, "curl")
+		assert.Equal(t, 2, h.prompts["curl"])
+	})
+}
@@ -0,0 +1,60 @@
+package interceptor
+
+import (
+	"io"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// RewriteEntry describes a single command substitution made by a hook.
+type RewriteEntry struct {
+	Time      time.Time `json:"time"`
+	Original  []string  `json:"original"`
+	Effective []string  `json:"effective"`
+	Reason    string    `json:"reason,omitempty"`
+	DecidedBy string    `json:"decided_by,omitempty"`
+	PID       int       `json:"pid,omitempty"`
+}
+
+// RewriteLog records command substitutions as newline-delimited JSON.
+// Every rewrite silently changes what a script executes, so the log is kept
+// separate from general logging and each entry is flushed as it is written.
+// It is safe for concurrent use.
+type RewriteLog struct {
+	out jsonLineWriter
+}
+
+// NewRewriteLog creates a rewrite log writing to w
+func NewRewriteLog(w io.Writer) *RewriteLog {
+	return &RewriteLog{out: jsonLineWriter{w: w}}
+}
+
+// Record appends an entry to the log. A zero Time is set to the current time.
+func (l *RewriteLog) Record(entry RewriteEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	return l.out.write(entry)
+}
+
+// recordRewrite writes the command substitution made by the response to a
+// pre-run request to the rewrite log, if set
+func (i *Interceptor) recordRewrite(req *hook.Request, resp *hook.Response) {
+	if i.rewriteLog == nil || req.Hook != hook.HookPreRun || resp.Denied() || len(resp.ModifiedCommand) == 0 {
+		return
+	}
+
+	entry := RewriteEntry{
+		Original:  req.Command,
+		Effective: resp.ModifiedCommand,
+		Reason:    resp.Reason,
+		PID:       req.PID,
+	}
+	if h := i.Hook(); h != nil {
+		entry.DecidedBy = h.Name()
+	}
+	if err := i.rewriteLog.Record(entry); err != nil {
+		i.logf("Failed to record rewrite: %v", err)
+	}
+}
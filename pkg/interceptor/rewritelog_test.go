@@ -0,0 +1,103 @@
+package interceptor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestRewriteLogRecord(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewRewriteLog(&buf)
+
+	err := log.Record(RewriteEntry{
+		Original:  []string{"curl", "https://example.com"},
+		Effective: []string{"curl", "--fail", "https://example.com"},
+		Reason:    "force --fail",
+		DecidedBy: "policy",
+		PID:       42,
+	})
+	require.NoError(t, err)
+
+	var entry RewriteEntry
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry))
+	assert.Equal(t, []string{"curl", "https://example.com"}, entry.Original)
+	assert.Equal(t, []string{"curl", "--fail", "https://example.com"}, entry.Effective)
+	assert.Equal(t, "force --fail", entry.Reason)
+	assert.Equal(t, "policy", entry.DecidedBy)
+	assert.Equal(t, 42, entry.PID)
+	assert.False(t, entry.Time.IsZero(), "time should default to now")
+}
+
+func TestRewriteLogFlushes(t *testing.T) {
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	log := NewRewriteLog(writer)
+
+	require.NoError(t, log.Record(RewriteEntry{Original: []string{"a"}, Effective: []string{"b"}}))
+
+	// Entry must reach the underlying buffer without an explicit Flush
+	assert.Contains(t, buf.String(), `"effective":["b"]`)
+}
+
+func TestRewriteLogConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewRewriteLog(&buf)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, log.Record(RewriteEntry{Original: []string{"wget"}, Effective: []string{"curl"}}))
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, n)
+	for _, line := range lines {
+		var entry RewriteEntry
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry), "each line should be a complete entry")
+	}
+}
+
+func TestRecordRewrite(t *testing.T) {
+	var buf bytes.Buffer
+	mockHook := &mockIPCHook{name: "policy", response: &hook.Response{
+		Reason:          "force --fail",
+		ModifiedCommand: []string{"curl", "--fail", "https://example.com"},
+	}}
+	i := New("/tmp/unused.sock", false, mockHook)
+	i.SetRewriteLog(NewRewriteLog(&buf))
+
+	resp, err := i.processRequest(&hook.Request{Command: []string{"curl", "https://example.com"}, Hook: hook.HookPreRun, PID: 42})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"curl", "--fail", "https://example.com"}, resp.ModifiedCommand)
+
+	var entry RewriteEntry
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry))
+	assert.Equal(t, []string{"curl", "https://example.com"}, entry.Original)
+	assert.Equal(t, []string{"curl", "--fail", "https://example.com"}, entry.Effective)
+	assert.Equal(t, "force --fail", entry.Reason)
+	assert.Equal(t, "policy", entry.DecidedBy)
+	assert.Equal(t, 42, entry.PID)
+
+	// Post-run responses and blocks are not rewrites
+	buf.Reset()
+	_, err = i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPostRun})
+	require.NoError(t, err)
+	mockHook.response.Exit = true
+	_, err = i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,135 @@
+package interceptor
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// MetadataTimeBucket is the request metadata key holding the time bucket
+// assigned by a schedule enricher (see ScheduleEnricher)
+const MetadataTimeBucket = "time_bucket"
+
+// Buckets of the BusinessHours schedule
+const (
+	BucketBusinessHours = "business-hours"
+	BucketAfterHours    = "after-hours"
+)
+
+// TimeWindow is a weekly recurring span of time of day. Start and End are
+// offsets from midnight; End is exclusive, and an End before Start spans
+// midnight, e.g. 22:00 to 06:00. The window opens on each of Days, or every
+// day if Days is empty.
+type TimeWindow struct {
+	Days  []time.Weekday
+	Start time.Duration
+	End   time.Duration
+}
+
+// ScheduleBucket names the time covered by its windows
+type ScheduleBucket struct {
+	Name    string
+	Windows []TimeWindow
+}
+
+// Schedule assigns evaluation times to named buckets, so a hook can decide
+// by time of day without time logic of its own. Buckets are checked in
+// order and the first with a window containing the time names it; times in
+// no window get Default. Times are read in Location, or the local time zone
+// if it is nil.
+type Schedule struct {
+	Buckets  []ScheduleBucket
+	Default  string
+	Location *time.Location
+}
+
+// BusinessHours returns a schedule putting Monday to Friday, 09:00 to 17:00
+// in loc in BucketBusinessHours and all other times in BucketAfterHours
+func BusinessHours(loc *time.Location) *Schedule {
+	return &Schedule{
+		Buckets: []ScheduleBucket{{
+			Name: BucketBusinessHours,
+			Windows: []TimeWindow{{
+				Days:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+				Start: 9 * time.Hour,
+				End:   17 * time.Hour,
+			}},
+		}},
+		Default:  BucketAfterHours,
+		Location: loc,
+	}
+}
+
+// Validate checks that every bucket is named and every window lies within
+// a day and is not empty
+func (s *Schedule) Validate() error {
+	if s.Default == "" {
+		return fmt.Errorf("schedule has no default bucket")
+	}
+	for _, b := range s.Buckets {
+		if b.Name == "" {
+			return fmt.Errorf("schedule bucket has no name")
+		}
+		for _, w := range b.Windows {
+			if w.Start < 0 || w.Start >= 24*time.Hour || w.End < 0 || w.End > 24*time.Hour {
+				return fmt.Errorf("bucket %s: window %v-%v is outside the day", b.Name, w.Start, w.End)
+			}
+			if w.Start == w.End {
+				return fmt.Errorf("bucket %s: window %v-%v is empty", b.Name, w.Start, w.End)
+			}
+		}
+	}
+	return nil
+}
+
+// Bucket returns the name of the bucket t falls in
+func (s *Schedule) Bucket(t time.Time) string {
+	loc := s.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	for _, b := range s.Buckets {
+		for _, w := range b.Windows {
+			if w.contains(t) {
+				return b.Name
+			}
+		}
+	}
+	return s.Default
+}
+
+// contains reports whether t is within the window. The part of a window
+// spanning midnight that falls on the next day belongs to the day it
+// opened.
+func (w TimeWindow) contains(t time.Time) bool {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	if w.Start < w.End {
+		return w.opensOn(t.Weekday()) && offset >= w.Start && offset < w.End
+	}
+	if offset >= w.Start {
+		return w.opensOn(t.Weekday())
+	}
+	return offset < w.End && w.opensOn((t.Weekday()+6)%7)
+}
+
+// opensOn reports whether the window opens on day
+func (w TimeWindow) opensOn(day time.Weekday) bool {
+	return len(w.Days) == 0 || slices.Contains(w.Days, day)
+}
+
+// ScheduleEnricher returns an enricher that tags every request with the
+// bucket of s its evaluation time falls in, under MetadataTimeBucket
+func ScheduleEnricher(s *Schedule) Enricher {
+	return scheduleEnricher(s, time.Now)
+}
+
+// scheduleEnricher is ScheduleEnricher reading the time from now
+func scheduleEnricher(s *Schedule, now func() time.Time) Enricher {
+	return func(req *hook.Request) {
+		req.Metadata[MetadataTimeBucket] = s.Bucket(now())
+	}
+}
@@ -0,0 +1,73 @@
+package interceptor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestScheduleBucket(t *testing.T) {
+	loc := time.FixedZone("test", -5*60*60)
+	night := ScheduleBucket{
+		Name:    "night",
+		Windows: []TimeWindow{{Days: []time.Weekday{time.Friday}, Start: 22 * time.Hour, End: 6 * time.Hour}},
+	}
+	schedule := BusinessHours(loc)
+	schedule.Buckets = append(schedule.Buckets, night)
+
+	// 2026-10-12 is a Monday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 10, day, hour, min, 0, 0, loc)
+	}
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{name: "weekday morning", t: at(12, 9, 0), want: BucketBusinessHours},
+		{name: "weekday afternoon", t: at(16, 16, 59), want: BucketBusinessHours},
+		{name: "end is exclusive", t: at(12, 17, 0), want: BucketAfterHours},
+		{name: "before opening", t: at(12, 8, 59), want: BucketAfterHours},
+		{name: "weekend", t: at(17, 11, 0), want: BucketAfterHours},
+		{name: "other time zone", t: time.Date(2026, 10, 12, 15, 0, 0, 0, time.UTC), want: BucketBusinessHours},
+		{name: "window opening late friday", t: at(16, 23, 0), want: "night"},
+		{name: "window spanning into saturday", t: at(17, 5, 59), want: "night"},
+		{name: "spanning window closes", t: at(17, 6, 0), want: BucketAfterHours},
+		{name: "spanning window only opens friday", t: at(13, 2, 0), want: BucketAfterHours},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, schedule.Bucket(tt.t))
+		})
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	assert.NoError(t, BusinessHours(nil).Validate())
+	assert.Error(t, (&Schedule{}).Validate(), "no default")
+	assert.Error(t, (&Schedule{Default: "x", Buckets: []ScheduleBucket{{}}}).Validate(), "unnamed bucket")
+	assert.Error(t, (&Schedule{Default: "x", Buckets: []ScheduleBucket{{Name: "a", Windows: []TimeWindow{{Start: time.Hour, End: time.Hour}}}}}).Validate())
+	assert.Error(t, (&Schedule{Default: "x", Buckets: []ScheduleBucket{{Name: "a", Windows: []TimeWindow{{Start: time.Hour, End: 25 * time.Hour}}}}}).Validate())
+}
+
+func TestScheduleEnricher(t *testing.T) {
+	clock := time.Date(2026, 10, 14, 10, 30, 0, 0, time.UTC) // a Wednesday
+	h := &recordingIPCHook{}
+	i := New("/tmp/unused.sock", false, h)
+	i.AddEnricher(scheduleEnricher(BusinessHours(time.UTC), func() time.Time { return clock }))
+
+	_, err := i.processRequest(&hook.Request{Command: []string{"deploy"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	require.NotNil(t, h.last)
+	assert.Equal(t, BucketBusinessHours, h.last.Metadata[MetadataTimeBucket])
+
+	clock = clock.Add(12 * time.Hour)
+	_, err = i.processRequest(&hook.Request{Command: []string{"deploy"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.Equal(t, BucketAfterHours, h.last.Metadata[MetadataTimeBucket])
+}
@@ -0,0 +1,115 @@
+package interceptor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// ScriptAction is what a script extension policy does with a command that
+// runs a script with a listed extension
+type ScriptAction int
+
+const (
+	// ScriptEvaluate sends the command to the hook, even when the hook does
+	// not list the interpreter, e.g. so an approving hook sees every .sh
+	ScriptEvaluate ScriptAction = iota
+	// ScriptAllow lets the command run without consulting the hook
+	ScriptAllow
+	// ScriptBlock blocks the command without consulting the hook
+	ScriptBlock
+)
+
+// String returns the action name
+func (a ScriptAction) String() string {
+	switch a {
+	case ScriptEvaluate:
+		return "evaluate"
+	case ScriptAllow:
+		return "allow"
+	case ScriptBlock:
+		return "block"
+	}
+	return fmt.Sprintf("ScriptAction(%d)", int(a))
+}
+
+// SetScriptExtensionPolicy decides commands by the extension of the script
+// they run: the script an interpreter runs (hook.MetadataScriptPath, see
+// wrapper.WithInterpretedScripts), or else the command itself when it is a
+// script path. Extensions are matched case-insensitively, with or without
+// the leading dot.
+//
+// The policy composes with the hook's command names as follows. At pre-run,
+// a matching ScriptAllow or ScriptBlock decides without the hook, and a
+// matching ScriptEvaluate defers to it. Other requests reach the hook only
+// if it lists the command, so interpreters wrapped just for the policy are
+// allowed unless a script matches. Post-run requests are never blocked by
+// the policy. Nil disables the policy.
+func (i *Interceptor) SetScriptExtensionPolicy(actions map[string]ScriptAction) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if len(actions) == 0 {
+		i.scriptPolicy = nil
+		return
+	}
+	i.scriptPolicy = make(map[string]ScriptAction, len(actions))
+	for ext, action := range actions {
+		i.scriptPolicy[NormalizeExtension(ext)] = action
+	}
+}
+
+// NormalizeExtension returns ext in lower case with a leading dot
+func NormalizeExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// scriptExtension returns the extension of the script req runs, if any
+func scriptExtension(req *hook.Request) string {
+	if script, ok := req.Metadata[hook.MetadataScriptPath].(string); ok && script != "" {
+		return strings.ToLower(filepath.Ext(script))
+	}
+	if len(req.Command) > 0 && strings.ContainsRune(req.Command[0], filepath.Separator) {
+		return strings.ToLower(filepath.Ext(req.Command[0]))
+	}
+	return ""
+}
+
+// applyScriptPolicy returns the policy's decision for req, or nil to
+// evaluate the hook as usual
+func (i *Interceptor) applyScriptPolicy(req *hook.Request) *hook.Response {
+	i.mu.Lock()
+	policy := i.scriptPolicy
+	i.mu.Unlock()
+	if policy == nil || req.Hook == hook.HookPreRunBatch || len(req.Command) == 0 {
+		return nil
+	}
+
+	ext := scriptExtension(req)
+	action, matched := policy[ext]
+	switch {
+	case matched && action == ScriptEvaluate:
+		return nil
+	case matched && req.Hook == hook.HookPreRun:
+		resp := &hook.Response{Metadata: map[string]interface{}{"script_policy": action.String()}}
+		if action == ScriptBlock {
+			resp.Exit = true
+			resp.Reason = fmt.Sprintf("%s scripts are not allowed", ext)
+		}
+		return resp
+	case hookHandles(i.Hook(), req.Command[0]):
+		return nil
+	default:
+		return &hook.Response{}
+	}
+}
+
+// hookHandles reports whether an entry of h's commands matches name
+func hookHandles(h hook.Hook, name string) bool {
+	return h != nil && hook.HandlesCommand(h.Commands(), name, true)
+}
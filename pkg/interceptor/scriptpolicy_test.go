@@ -0,0 +1,107 @@
+package interceptor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestScriptExtensionPolicy(t *testing.T) {
+	policy := map[string]ScriptAction{
+		".py": ScriptBlock,
+		"SH":  ScriptEvaluate,
+		".rb": ScriptAllow,
+	}
+	script := func(path string) map[string]interface{} {
+		return map[string]interface{}{hook.MetadataScriptPath: path}
+	}
+
+	tests := []struct {
+		name      string
+		policy    map[string]ScriptAction
+		req       hook.Request
+		wantExit  bool
+		wantHook  bool
+		wantMatch string
+	}{
+		{
+			name:      "blocked extension",
+			policy:    policy,
+			req:       hook.Request{Command: []string{"python3", "app.py"}, Hook: hook.HookPreRun, Metadata: script("/work/app.py")},
+			wantExit:  true,
+			wantMatch: "block",
+		},
+		{
+			name:      "allowed extension",
+			policy:    policy,
+			req:       hook.Request{Command: []string{"ruby", "app.rb"}, Hook: hook.HookPreRun, Metadata: script("/work/app.rb")},
+			wantMatch: "allow",
+		},
+		{
+			name:     "evaluated extension reaches the hook",
+			policy:   policy,
+			req:      hook.Request{Command: []string{"sh", "deploy.SH"}, Hook: hook.HookPreRun, Metadata: script("/work/deploy.SH")},
+			wantHook: true,
+		},
+		{
+			name:      "direct script path",
+			policy:    policy,
+			req:       hook.Request{Command: []string{"./tools/gen.py"}, Hook: hook.HookPreRun},
+			wantExit:  true,
+			wantMatch: "block",
+		},
+		{
+			name:   "interpreter without script skips the hook",
+			policy: policy,
+			req:    hook.Request{Command: []string{"python3", "-c", "print(1)"}, Hook: hook.HookPreRun},
+		},
+		{
+			name:     "listed command reaches the hook",
+			policy:   policy,
+			req:      hook.Request{Command: []string{"curl", "example.com"}, Hook: hook.HookPreRun},
+			wantHook: true,
+		},
+		{
+			name:     "listed command by path reaches the hook",
+			policy:   policy,
+			req:      hook.Request{Command: []string{"/usr/bin/CURL", "example.com"}, Hook: hook.HookPreRun},
+			wantHook: true,
+		},
+		{
+			name:   "post-run is never blocked",
+			policy: policy,
+			req:    hook.Request{Command: []string{"python3", "app.py"}, Hook: hook.HookPostRun, Metadata: script("/work/app.py")},
+		},
+		{
+			name:     "no policy",
+			req:      hook.Request{Command: []string{"python3", "app.py"}, Hook: hook.HookPreRun, Metadata: script("/work/app.py")},
+			wantHook: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockHook := &mockIPCHook{commands: []string{"curl"}, response: &hook.Response{Metadata: map[string]interface{}{"hook": true}}}
+			i := New("/tmp/unused.sock", false, mockHook)
+			i.SetScriptExtensionPolicy(tt.policy)
+
+			resp, err := i.processRequest(&tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExit, resp.Exit)
+			assert.Equal(t, tt.wantHook, resp.Metadata["hook"] == true, "hook consulted")
+			if tt.wantMatch != "" {
+				assert.Equal(t, tt.wantMatch, resp.Metadata["script_policy"])
+			} else {
+				assert.NotContains(t, resp.Metadata, "script_policy")
+			}
+		})
+	}
+}
+
+func TestNormalizeExtension(t *testing.T) {
+	assert.Equal(t, ".sh", NormalizeExtension("sh"))
+	assert.Equal(t, ".sh", NormalizeExtension(".SH"))
+}
@@ -0,0 +1,28 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// isSelfTest reports whether req is a startup self-test canary
+func isSelfTest(req *hook.Request) bool {
+	marked, _ := req.Metadata[hook.MetadataSelfTest].(bool)
+	return marked
+}
+
+// selfTestResponse evaluates a self-test canary and confirms it was
+// received. The hook's decision is reported in the "decision" metadata key
+// but never acted on: the canary is not counted, remembered, recorded or
+// allowed to signal exit, and is always allowed.
+func (i *Interceptor) selfTestResponse(ctx context.Context, req *hook.Request) *hook.Response {
+	response := i.evaluateHook(ctx, req)
+	i.logf("Self-test request for %v; hook decision: %s", req.Command, response.Decision())
+	return &hook.Response{
+		Metadata: map[string]interface{}{
+			hook.MetadataSelfTest: true,
+			"decision":            response.Decision(),
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package interceptor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestSelfTestRequest(t *testing.T) {
+	var decisions int
+	interceptor := New("/tmp/unused.sock", false, &mockIPCHook{response: &hook.Response{Exit: true}})
+	interceptor.SetDecisionCallback(func([]string, hook.HookType, bool, time.Duration) {
+		decisions++
+	})
+
+	resp, err := interceptor.processRequest(&hook.Request{
+		Command:  []string{"curl"},
+		PID:      1,
+		Hook:     hook.HookPreRun,
+		Metadata: map[string]interface{}{hook.MetadataSelfTest: true},
+	})
+	require.NoError(t, err)
+
+	assert.False(t, resp.Exit, "the canary is never blocked")
+	assert.Equal(t, true, resp.Metadata[hook.MetadataSelfTest])
+	assert.Equal(t, hook.DecisionBlock, resp.Metadata["decision"], "the hook's decision is reported")
+	assert.Zero(t, decisions, "the canary is not a decision")
+	select {
+	case <-interceptor.ExitSignal():
+		t.Fatal("the canary must not signal exit")
+	default:
+	}
+}
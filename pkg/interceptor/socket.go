@@ -0,0 +1,115 @@
+package interceptor
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"runtime"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// SocketType selects the kind of Unix socket used for IPC. Its value is the
+// network name passed to net.Listen and net.Dial.
+type SocketType string
+
+const (
+	// SocketStream is a SOCK_STREAM socket carrying newline-delimited JSON
+	// messages (the default)
+	SocketStream SocketType = "unix"
+	// SocketSeqpacket is a SOCK_SEQPACKET socket carrying one JSON message
+	// per packet, so no framing is needed. Linux only.
+	SocketSeqpacket SocketType = "unixpacket"
+)
+
+// Network returns the network name for net.Listen and net.Dial
+func (t SocketType) Network() string {
+	if t == "" {
+		return string(SocketStream)
+	}
+	return string(t)
+}
+
+// Validate reports whether the socket type is known and supported on this
+// platform
+func (t SocketType) Validate() error {
+	switch t {
+	case "", SocketStream:
+		return nil
+	case SocketSeqpacket:
+		if !seqpacketSupported {
+			return fmt.Errorf("socket type %s is not supported on %s", t, runtime.GOOS)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown socket type %q", t)
+	}
+}
+
+// SetSocketType sets the kind of socket Start listens on. Must be called
+// before Start. A listener set with SetListener keeps its own type.
+func (i *Interceptor) SetSocketType(t SocketType) {
+	i.socketType = t
+}
+
+// SetSocketGroup makes Start hand the socket to group gid with mode 0660,
+// so processes that share the group but run as another user, such as a
+// sidecar container, can connect. By default, or with a negative gid, the
+// socket is 0600 and only reachable by its owner. Must be called before
+// Start; it does not apply to a listener set with SetListener.
+func (i *Interceptor) SetSocketGroup(gid int) {
+	if gid < 0 {
+		i.socketGroup = nil
+		return
+	}
+	i.socketGroup = &gid
+}
+
+// setSocketPermissions restricts the socket to its owner, or to its owner
+// and the group set with SetSocketGroup
+func (i *Interceptor) setSocketPermissions() error {
+	if namedPipes {
+		// Named pipes are not files; they reject remote clients instead
+		return nil
+	}
+	if i.socketGroup == nil {
+		return os.Chmod(i.socketPath, 0600)
+	}
+	if err := os.Chown(i.socketPath, -1, *i.socketGroup); err != nil {
+		return err
+	}
+	return os.Chmod(i.socketPath, 0660)
+}
+
+// readPacketRequest reads a request sent as a single packet. The buffer is
+// one byte larger than the limit so oversized (truncated) packets are
+// detected instead of parsed.
+func readPacketRequest(conn net.Conn) (*hook.Request, error) {
+	buf := make([]byte, MaxIPCMessageBytes+1)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+	if n == 0 {
+		// The peer shut down the connection
+		return nil, io.EOF
+	}
+	if n > MaxIPCMessageBytes {
+		return nil, fmt.Errorf("request exceeds %d bytes", MaxIPCMessageBytes)
+	}
+	return parseRequest(buf[:n])
+}
+
+// writePacketResponse writes a response as a single packet, encoded with
+// encoding
+func writePacketResponse(conn net.Conn, resp *hook.Response, encoding string) error {
+	data, err := marshalResponse(resp, encoding)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(data); err != nil {
+		return fmt.Errorf("failed to write response: %v", err)
+	}
+	return nil
+}
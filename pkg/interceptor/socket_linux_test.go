@@ -0,0 +1,113 @@
+//go:build linux
+
+package interceptor
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// packetRoundTrip sends data as one packet and decodes the response packet
+func packetRoundTrip(t *testing.T, socketPath string, data []byte) hook.Response {
+	t.Helper()
+	conn, err := net.Dial("unixpacket", socketPath)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write(data)
+	require.NoError(t, err)
+
+	buf := make([]byte, MaxIPCMessageBytes)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	var resp hook.Response
+	require.NoError(t, json.Unmarshal(buf[:n], &resp))
+	return resp
+}
+
+func TestSeqpacketRoundTrip(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "seq.sock")
+	h := &mockIPCHook{response: &hook.Response{Metadata: map[string]interface{}{"rule": "ok"}}}
+	i := New(socketPath, false, h)
+	i.SetSocketType(SocketSeqpacket)
+	require.NoError(t, i.Start())
+	defer i.Stop()
+
+	// Messages carry no newline framing
+	data, err := json.Marshal(hook.Request{Command: []string{"ls"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	resp := packetRoundTrip(t, socketPath, data)
+	assert.False(t, resp.Exit)
+	assert.Equal(t, "ok", resp.Metadata["rule"])
+
+	// Oversized packets are rejected rather than parsed truncated
+	big := append([]byte(`{"command":["ls"],"hook":"pre_run","metadata":{"pad":"`), bytes.Repeat([]byte("x"), MaxIPCMessageBytes)...)
+	big = append(big, `"}}`...)
+	assert.True(t, packetRoundTrip(t, socketPath, big).Exit)
+
+	// A stream client cannot connect to a seqpacket socket
+	_, err = net.Dial("unix", socketPath)
+	assert.Error(t, err)
+}
+
+func TestSeqpacketAdoptedListener(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "seq.sock")
+	l, err := net.Listen("unixpacket", socketPath)
+	require.NoError(t, err)
+
+	i := New(socketPath, false, &mockIPCHook{response: &hook.Response{}})
+	i.SetListener(l)
+	require.NoError(t, i.Start())
+	defer i.Stop()
+
+	data, err := json.Marshal(hook.Request{Command: []string{"ls"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.False(t, packetRoundTrip(t, socketPath, data).Exit)
+}
+
+// testGroup returns a group the test may hand files to: any group as root,
+// the primary group otherwise
+func testGroup() int {
+	if os.Geteuid() == 0 {
+		return 4242
+	}
+	return os.Getgid()
+}
+
+func TestSocketGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		gid      int
+		wantMode os.FileMode
+	}{
+		{name: "default owner only", gid: -1, wantMode: 0600},
+		{name: "group access", gid: testGroup(), wantMode: 0660},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socketPath := filepath.Join(t.TempDir(), "group.sock")
+			i := New(socketPath, false, &mockIPCHook{})
+			i.SetSocketGroup(tt.gid)
+			require.NoError(t, i.Start())
+			defer i.Stop()
+
+			info, err := os.Stat(socketPath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMode, info.Mode().Perm())
+			if tt.gid >= 0 {
+				assert.Equal(t, uint32(tt.gid), info.Sys().(*syscall.Stat_t).Gid)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !linux
+
+package interceptor
+
+// seqpacketSupported reports whether SOCK_SEQPACKET Unix sockets are available
+const seqpacketSupported = false
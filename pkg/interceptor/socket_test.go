@@ -0,0 +1,23 @@
+package interceptor
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSocketType(t *testing.T) {
+	assert.Equal(t, "unix", SocketType("").Network())
+	assert.Equal(t, "unix", SocketStream.Network())
+	assert.Equal(t, "unixpacket", SocketSeqpacket.Network())
+
+	assert.NoError(t, SocketType("").Validate())
+	assert.NoError(t, SocketStream.Validate())
+	assert.Error(t, SocketType("tcp").Validate())
+	if runtime.GOOS == "linux" {
+		assert.NoError(t, SocketSeqpacket.Validate())
+	} else {
+		assert.Error(t, SocketSeqpacket.Validate())
+	}
+}
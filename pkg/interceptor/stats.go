@@ -0,0 +1,145 @@
+package interceptor
+
+import (
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// DefaultMaxExitHistory bounds the number of exit records retained by an
+// interceptor when no explicit limit is configured.
+const DefaultMaxExitHistory = 1000
+
+// Stats summarizes the requests handled by an interceptor
+type Stats struct {
+	// Total counts every request evaluated, whichever stage decided it
+	Total int
+	// Blocked counts the requests that were denied, by blocking or by
+	// overriding the exit code
+	Blocked int
+	// Errors counts hook evaluations that failed or returned no response,
+	// including timeouts
+	Errors int
+	// Latency summarizes the time taken to decide each request
+	Latency LatencySummary
+	// ExitRequests counts every request that asked for process termination,
+	// including those that arrived after teardown was already triggered.
+	ExitRequests int
+}
+
+// LatencySummary summarizes the decision latencies of a number of requests
+type LatencySummary struct {
+	Count int
+	Total time.Duration
+	Min   time.Duration
+	Max   time.Duration
+}
+
+// Mean returns the average latency, or zero if no request was recorded
+func (l LatencySummary) Mean() time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+	return l.Total / time.Duration(l.Count)
+}
+
+// add records one latency
+func (l *LatencySummary) add(d time.Duration) {
+	if l.Count == 0 || d < l.Min {
+		l.Min = d
+	}
+	if d > l.Max {
+		l.Max = d
+	}
+	l.Count++
+	l.Total += d
+}
+
+// ExitRecord describes a single request that asked for process termination.
+// Command is normalized if a CommandNormalizer is configured.
+type ExitRecord struct {
+	Time    time.Time
+	Command []string
+	PID     int
+	Hook    hook.HookType
+	// Reason explains the block (see hook.Response.BlockReason)
+	Reason string
+	// Metadata is the metadata the hook attached to its response, after
+	// redaction
+	Metadata map[string]interface{}
+}
+
+// Stats returns a snapshot of the interceptor counters
+func (i *Interceptor) Stats() Stats {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return Stats{
+		Total:        i.requestCount,
+		Blocked:      i.blockCount,
+		Errors:       i.errorCount,
+		Latency:      i.latency,
+		ExitRequests: i.exitCount,
+	}
+}
+
+// recordStats counts a decided request and the time taken to decide it
+func (i *Interceptor) recordStats(resp *hook.Response, latency time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.requestCount++
+	if resp.Denied() {
+		i.blockCount++
+	}
+	i.latency.add(latency)
+}
+
+// recordError counts a failed hook evaluation
+func (i *Interceptor) recordError() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.errorCount++
+}
+
+// History returns the recorded exit requests, oldest first. Only the most
+// recent records are retained once the history limit is reached; Stats still
+// counts every exit request.
+func (i *Interceptor) History() []ExitRecord {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	history := make([]ExitRecord, len(i.exitHistory))
+	copy(history, i.exitHistory)
+	return history
+}
+
+// SetMaxExitHistory bounds the number of retained exit records. Zero or
+// negative values restore DefaultMaxExitHistory.
+func (i *Interceptor) SetMaxExitHistory(n int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if n <= 0 {
+		n = DefaultMaxExitHistory
+	}
+	i.maxExitHistory = n
+	if len(i.exitHistory) > n {
+		i.exitHistory = append([]ExitRecord(nil), i.exitHistory[len(i.exitHistory)-n:]...)
+	}
+}
+
+// recordExit records an exit request. Every request is counted even though
+// only the first one triggers teardown.
+func (i *Interceptor) recordExit(req *hook.Request, resp *hook.Response) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.exitCount++
+	i.exitHistory = append(i.exitHistory, ExitRecord{
+		Time:     time.Now(),
+		Command:  i.auditCommand(req.Command),
+		PID:      req.PID,
+		Hook:     req.Hook,
+		Reason:   resp.BlockReason(),
+		Metadata: i.auditMetadata(resp.Metadata),
+	})
+	if over := len(i.exitHistory) - i.maxExitHistory; over > 0 {
+		i.exitHistory = append([]ExitRecord(nil), i.exitHistory[over:]...)
+	}
+}
@@ -0,0 +1,164 @@
+package interceptor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestStatsCounters(t *testing.T) {
+	h := hook.NewMulti(
+		&mockIPCHook{name: "block", commands: []string{"curl"}, response: &hook.Response{Exit: true}},
+		&mockIPCHook{name: "allow", commands: []string{"git"}, response: &hook.Response{}},
+		&mockIPCHook{name: "broken", commands: []string{"wget"}, err: fmt.Errorf("unreachable")},
+	)
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, h)
+
+	commands := []string{"curl", "git", "git", "wget", "curl", "git"}
+	var wg sync.WaitGroup
+	for round := 0; round < 5; round++ {
+		for n, command := range commands {
+			wg.Add(1)
+			go func(pid int, command string) {
+				defer wg.Done()
+				_, err := interceptor.processRequest(&hook.Request{
+					Command: []string{command},
+					PID:     pid,
+					Hook:    hook.HookPreRun,
+				})
+				assert.NoError(t, err)
+			}(round*len(commands)+n, command)
+		}
+	}
+	wg.Wait()
+
+	stats := interceptor.Stats()
+	assert.Equal(t, 30, stats.Total)
+	assert.Equal(t, 15, stats.Blocked, "blocked curl and failed wget")
+	assert.Equal(t, 5, stats.Errors)
+	assert.Equal(t, 15, stats.ExitRequests)
+
+	assert.Equal(t, 30, stats.Latency.Count)
+	assert.LessOrEqual(t, stats.Latency.Min, stats.Latency.Mean())
+	assert.LessOrEqual(t, stats.Latency.Mean(), stats.Latency.Max)
+}
+
+func TestLatencySummary(t *testing.T) {
+	var l LatencySummary
+	assert.Zero(t, l.Mean())
+
+	for _, d := range []time.Duration{3 * time.Millisecond, time.Millisecond, 5 * time.Millisecond} {
+		l.add(d)
+	}
+	assert.Equal(t, LatencySummary{
+		Count: 3,
+		Total: 9 * time.Millisecond,
+		Min:   time.Millisecond,
+		Max:   5 * time.Millisecond,
+	}, l)
+	assert.Equal(t, 3*time.Millisecond, l.Mean())
+}
+
+func TestExitBookkeepingConcurrentBlocks(t *testing.T) {
+	mockHook := &mockIPCHook{response: &hook.Response{Exit: true}}
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, mockHook)
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			resp, err := interceptor.processRequest(&hook.Request{
+				Command: []string{"curl", fmt.Sprintf("https://blocked%d.example", id)},
+				PID:     1000 + id,
+				Hook:    hook.HookPreRun,
+			})
+			assert.NoError(t, err)
+			assert.True(t, resp.Exit)
+		}(i)
+	}
+	wg.Wait()
+
+	// Only the first exit closes the channel, but every attempt is recorded
+	select {
+	case <-interceptor.ExitSignal():
+	default:
+		t.Fatal("exit signal should be closed")
+	}
+	assert.Equal(t, n, interceptor.Stats().ExitRequests)
+
+	history := interceptor.History()
+	require.Len(t, history, n)
+	var pids []int
+	for _, rec := range history {
+		pids = append(pids, rec.PID)
+		assert.Equal(t, hook.HookPreRun, rec.Hook)
+		assert.Equal(t, "curl", rec.Command[0])
+	}
+	sort.Ints(pids)
+	for i, pid := range pids {
+		assert.Equal(t, 1000+i, pid)
+	}
+}
+
+func TestBlockReason(t *testing.T) {
+	// Use shorter socket path for macOS Unix domain socket limits
+	socketPath := fmt.Sprintf("/tmp/test_%d.sock", time.Now().UnixNano())
+	defer os.Remove(socketPath)
+
+	mockHook := &mockIPCHook{response: &hook.Response{Exit: true, Reason: "curl is not allowed"}}
+	interceptor := New(socketPath, false, mockHook)
+	require.NoError(t, interceptor.Start())
+	defer interceptor.Stop()
+
+	resp := sendRequest(t, socketPath, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	assert.True(t, resp.Exit)
+	assert.Equal(t, "curl is not allowed", resp.Reason, "the reason survives the IPC round trip")
+
+	// The metadata key used before the field existed is recorded too
+	mockHook.response = &hook.Response{Exit: true, Metadata: map[string]interface{}{"reason": "legacy"}}
+	sendRequest(t, socketPath, hook.Request{Command: []string{"wget"}, Hook: hook.HookPreRun})
+
+	history := interceptor.History()
+	require.Len(t, history, 2)
+	assert.Equal(t, "curl is not allowed", history[0].Reason)
+	assert.Equal(t, "legacy", history[1].Reason)
+}
+
+func TestExitHistoryBound(t *testing.T) {
+	mockHook := &mockIPCHook{response: &hook.Response{Exit: true}}
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, mockHook)
+	interceptor.SetMaxExitHistory(3)
+
+	for i := 0; i < 5; i++ {
+		_, err := interceptor.processRequest(&hook.Request{Command: []string{"wget"}, PID: i, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 5, interceptor.Stats().ExitRequests)
+	history := interceptor.History()
+	require.Len(t, history, 3)
+	assert.Equal(t, 2, history[0].PID, "oldest records should be dropped first")
+	assert.Equal(t, 4, history[2].PID)
+}
+
+func TestAllowedRequestsNotRecorded(t *testing.T) {
+	mockHook := &mockIPCHook{response: &hook.Response{}}
+	interceptor := New(filepath.Join(t.TempDir(), "test.sock"), false, mockHook)
+
+	_, err := interceptor.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+
+	assert.Zero(t, interceptor.Stats().ExitRequests)
+	assert.Empty(t, interceptor.History())
+}
@@ -0,0 +1,26 @@
+//go:build windows || plan9
+
+package interceptor
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Syslog is only supported on Unix systems
+type Syslog struct{}
+
+// NewSyslog is only supported on Unix systems
+func NewSyslog(tag string) (*Syslog, error) {
+	return nil, fmt.Errorf("syslog is not supported on %s", runtime.GOOS)
+}
+
+// Record does nothing
+func (s *Syslog) Record(entry DecisionEntry) error {
+	return nil
+}
+
+// Close does nothing
+func (s *Syslog) Close() error {
+	return nil
+}
@@ -0,0 +1,64 @@
+//go:build !windows && !plan9
+
+package interceptor
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+)
+
+// Syslog sends hook decisions to the system log, so they land alongside
+// other security events. Blocked requests are logged at warning severity
+// and allowed ones at info, under the auth facility.
+type Syslog struct {
+	w *syslog.Writer
+}
+
+// NewSyslog connects to the local syslog daemon, tagging messages with tag
+func NewSyslog(tag string) (*Syslog, error) {
+	return dialSyslog("", "", tag)
+}
+
+// dialSyslog connects to the syslog daemon at raddr over network, or the
+// local one if both are empty
+func dialSyslog(network, raddr, tag string) (*Syslog, error) {
+	w, err := syslog.Dial(network, raddr, syslog.LOG_AUTH|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &Syslog{w: w}, nil
+}
+
+// Record sends a decision to the system log
+func (s *Syslog) Record(entry DecisionEntry) error {
+	msg := syslogMessage(entry)
+	if entry.Exit {
+		return s.w.Warning(msg)
+	}
+	return s.w.Info(msg)
+}
+
+// Close closes the connection to the syslog daemon
+func (s *Syslog) Close() error {
+	return s.w.Close()
+}
+
+// syslogMessage formats a decision as a single line, e.g.
+// "blocked pre_run pid=42: curl example.com (reason: network access)"
+func syslogMessage(entry DecisionEntry) string {
+	decision := "allowed"
+	if entry.Exit {
+		decision = "blocked"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", decision, entry.Hook)
+	if entry.PID != 0 {
+		fmt.Fprintf(&b, " pid=%d", entry.PID)
+	}
+	fmt.Fprintf(&b, ": %s", strings.Join(entry.Command, " "))
+	if entry.Reason != "" {
+		fmt.Fprintf(&b, " (reason: %s)", entry.Reason)
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+//go:build !windows && !plan9
+
+package interceptor
+
+import (
+	"log/syslog"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// syslogPriority matches the priority and message of a syslog datagram
+var syslogPriority = regexp.MustCompile(`^<(\d+)>.*?: (.*)\n?$`)
+
+func TestSyslog(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	s, err := dialSyslog("unixgram", addr, "cmdhooks-test")
+	require.NoError(t, err)
+	defer s.Close()
+
+	code := 3
+	tests := []struct {
+		name     string
+		response *hook.Response
+		severity syslog.Priority
+		message  string
+	}{
+		{
+			name:     "allow",
+			response: &hook.Response{},
+			severity: syslog.LOG_INFO,
+			message:  "allowed pre_run pid=42: curl example.com",
+		},
+		{
+			name:     "block",
+			response: &hook.Response{Exit: true, Reason: "network access"},
+			severity: syslog.LOG_WARNING,
+			message:  "blocked pre_run pid=42: curl example.com (reason: network access)",
+		},
+		{
+			name:     "block with exit code",
+			response: &hook.Response{ExitCode: &code, Reason: "dry run"},
+			severity: syslog.LOG_WARNING,
+			message:  "blocked pre_run pid=42: curl example.com (reason: dry run)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := New("/tmp/unused.sock", false, &mockIPCHook{response: tt.response})
+			i.SetSyslog(s)
+			_, err := i.processRequest(&hook.Request{Command: []string{"curl", "example.com"}, PID: 42, Hook: hook.HookPreRun})
+			require.NoError(t, err)
+
+			buf := make([]byte, 4096)
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+			n, err := conn.Read(buf)
+			require.NoError(t, err)
+
+			m := syslogPriority.FindStringSubmatch(string(buf[:n]))
+			require.NotNil(t, m, "unexpected datagram %q", buf[:n])
+			priority, err := strconv.Atoi(m[1])
+			require.NoError(t, err)
+			assert.Equal(t, syslog.LOG_AUTH, syslog.Priority(priority)&^7, "facility")
+			assert.Equal(t, tt.severity, syslog.Priority(priority)&7, "severity")
+			assert.Equal(t, tt.message, m[2])
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// TimeoutDecision is the decision applied when a hook evaluation exceeds the
+// evaluation timeout
+type TimeoutDecision int
+
+const (
+	// TimeoutBlock blocks the command (the default)
+	TimeoutBlock TimeoutDecision = iota
+	// TimeoutAllow lets the command run, for latency-sensitive deployments
+	// that prefer availability over enforcement
+	TimeoutAllow
+)
+
+// SetTimeoutDecision sets the decision used when an evaluation fails because
+// its deadline was exceeded. Other hook failures still block.
+func (i *Interceptor) SetTimeoutDecision(d TimeoutDecision) {
+	i.timeoutDecision = d
+}
+
+// isTimeout reports whether an evaluation failed because its deadline passed
+func isTimeout(ctx context.Context, err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+// timeoutResponse returns the configured decision for a timed-out evaluation
+func (i *Interceptor) timeoutResponse(req *hook.Request) *hook.Response {
+	allow := i.timeoutDecision == TimeoutAllow
+	if i.logging() {
+		if allow {
+			i.logf("Hook evaluation timed out; allowing: %v", req.Command)
+		} else {
+			i.logf("Hook evaluation timed out; blocking: %v", req.Command)
+		}
+	}
+	return &hook.Response{
+		Exit:   !allow,
+		Reason: "hook evaluation timed out",
+	}
+}
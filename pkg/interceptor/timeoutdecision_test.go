@@ -0,0 +1,78 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// slowIPCHook waits for its context to expire, then returns evalErr or the
+// context's error
+type slowIPCHook struct {
+	evalErr error
+}
+
+func (s *slowIPCHook) Name() string       { return "slow" }
+func (s *slowIPCHook) Commands() []string { return []string{"*"} }
+func (s *slowIPCHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	<-ctx.Done()
+	if s.evalErr != nil {
+		return nil, s.evalErr
+	}
+	return nil, ctx.Err()
+}
+
+func TestTimeoutDecision(t *testing.T) {
+	tests := []struct {
+		name     string
+		hook     hook.Hook
+		decision TimeoutDecision
+		wantExit bool
+	}{
+		{name: "default blocks", hook: &slowIPCHook{}, decision: TimeoutBlock, wantExit: true},
+		{name: "allow on timeout", hook: &slowIPCHook{}, decision: TimeoutAllow, wantExit: false},
+		{name: "allow when the hook returns another error after the deadline", hook: &slowIPCHook{evalErr: errors.New("upstream unreachable")}, decision: TimeoutAllow, wantExit: false},
+		{name: "other errors still block", hook: &mockIPCHook{err: errors.New("boom")}, decision: TimeoutAllow, wantExit: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := New("", false, tt.hook)
+			i.SetEvaluateTimeout(20 * time.Millisecond)
+			i.SetTimeoutDecision(tt.decision)
+
+			resp, err := i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExit, resp.Exit)
+
+			select {
+			case <-i.ExitSignal():
+				assert.True(t, tt.wantExit, "exit signalled for an allowed request")
+			default:
+				assert.False(t, tt.wantExit, "exit not signalled for a blocked request")
+			}
+		})
+	}
+}
+
+func TestTimeoutDecisionWaitingForLimiter(t *testing.T) {
+	limiter := NewLimiter(1)
+	require.NoError(t, limiter.Acquire(context.Background()))
+	defer limiter.Release()
+
+	i := New("", false, &mockIPCHook{response: &hook.Response{}})
+	i.SetEvaluationLimiter(limiter)
+	i.SetEvaluateTimeout(20 * time.Millisecond)
+	i.SetTimeoutDecision(TimeoutAllow)
+
+	resp, err := i.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.False(t, resp.Exit)
+	assert.Equal(t, "hook evaluation timed out", resp.Reason)
+}
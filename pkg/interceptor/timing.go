@@ -0,0 +1,84 @@
+package interceptor
+
+import (
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// TimingBreakdown is how long each phase of a wrapped command took. PreRun
+// and Execution are measured by the wrapper: PreRun spans the whole pre-run
+// evaluation including IPC, and Execution the command itself. PostRun is
+// the interceptor's post-run decision time, as passed to the decision
+// callback. Replaying the command's output follows the last request, so it
+// is not included; verbose wrappers log it.
+type TimingBreakdown struct {
+	// Command is the command in its audit form (see SetCommandNormalizer)
+	Command   []string
+	RequestID string
+	PreRun    time.Duration
+	Execution time.Duration
+	PostRun   time.Duration
+}
+
+// Total returns the time spent in all phases
+func (t TimingBreakdown) Total() time.Duration {
+	return t.PreRun + t.Execution + t.PostRun
+}
+
+// TimingCallback receives the timing breakdown of each command that
+// completed its post-run evaluation
+type TimingCallback func(TimingBreakdown)
+
+// SetTimingCallback sets a callback invoked after the post-run decision of
+// every command whose wrapper reports its pre-run time (see
+// hook.MetadataPreRunDuration). Like the decision callback, it runs on the
+// request path and must not block. A nil callback disables it.
+func (i *Interceptor) SetTimingCallback(fn TimingCallback) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.onTiming = fn
+}
+
+// notifyTiming invokes the timing callback for a post-run request that
+// took dur to decide, if the callback is set and the wrapper reported its
+// pre-run time
+func (i *Interceptor) notifyTiming(req *hook.Request, dur time.Duration) {
+	if req.Hook != hook.HookPostRun {
+		return
+	}
+	preRun, ok := metadataDuration(req.Metadata[hook.MetadataPreRunDuration])
+	if !ok {
+		return
+	}
+
+	i.mu.Lock()
+	fn := i.onTiming
+	var cmd []string
+	if fn != nil {
+		cmd = append([]string(nil), i.auditCommand(req.Command)...)
+	}
+	i.mu.Unlock()
+
+	if fn != nil {
+		fn(TimingBreakdown{
+			Command:   cmd,
+			RequestID: req.RequestID,
+			PreRun:    preRun,
+			Execution: req.Duration,
+			PostRun:   dur,
+		})
+	}
+}
+
+// metadataDuration reads a duration in nanoseconds from a metadata value
+func metadataDuration(v interface{}) (time.Duration, bool) {
+	// JSON numbers decode as float64
+	switch d := v.(type) {
+	case float64:
+		return time.Duration(d), true
+	case time.Duration:
+		return d, true
+	}
+	return 0, false
+}
@@ -0,0 +1,42 @@
+package interceptor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestTimingCallback(t *testing.T) {
+	var breakdowns []TimingBreakdown
+	interceptor := New("/tmp/unused.sock", false, newMockHook("test", []string{"*"}))
+	interceptor.SetCommandNormalizer(func(argv []string) []string { return argv[:1] })
+	interceptor.SetTimingCallback(func(b TimingBreakdown) {
+		breakdowns = append(breakdowns, b)
+	})
+
+	requests := []*hook.Request{
+		{Command: []string{"ls", "-la"}, Hook: hook.HookPreRun, RequestID: "r1"},
+		// JSON numbers arrive as float64
+		{Command: []string{"ls", "-la"}, Hook: hook.HookPostRun, RequestID: "r1", Duration: 300 * time.Millisecond,
+			Metadata: map[string]interface{}{hook.MetadataPreRunDuration: float64(20 * time.Millisecond)}},
+		// Wrappers without the breakdown do not report pre-run time
+		{Command: []string{"ls"}, Hook: hook.HookPostRun, RequestID: "r2", Duration: time.Second},
+	}
+	for _, req := range requests {
+		_, err := interceptor.processRequest(req)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, breakdowns, 1)
+	b := breakdowns[0]
+	assert.Equal(t, []string{"ls"}, b.Command, "commands are passed in audit form")
+	assert.Equal(t, "r1", b.RequestID)
+	assert.Equal(t, 20*time.Millisecond, b.PreRun)
+	assert.Equal(t, 300*time.Millisecond, b.Execution)
+	assert.Positive(t, b.PostRun)
+	assert.Equal(t, b.PreRun+b.Execution+b.PostRun, b.Total())
+}
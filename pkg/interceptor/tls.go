@@ -0,0 +1,66 @@
+package interceptor
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+)
+
+// tlsHandshakeTimeout bounds the TLS handshake of a new connection, so a
+// client that never completes it cannot hold up Stop
+const tlsHandshakeTimeout = 10 * time.Second
+
+// SetTLS makes the interceptor accept only TLS connections, configured by
+// cfg, which must provide the server certificate. If cfg has ClientCAs but
+// leaves ClientAuth unset, clients must present a certificate signed by one
+// of them and connections without one are rejected. TLS requires a stream
+// socket. Must be called before Start; a nil cfg disables TLS.
+func (i *Interceptor) SetTLS(cfg *tls.Config) {
+	if cfg == nil {
+		i.tlsConfig = nil
+		return
+	}
+	cfg = cfg.Clone()
+	if cfg.ClientCAs != nil && cfg.ClientAuth == tls.NoClientCert {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	i.tlsConfig = cfg
+}
+
+// wrapTLS wraps the listener so it serves TLS, if configured
+func (i *Interceptor) wrapTLS() error {
+	if i.tlsConfig == nil {
+		return nil
+	}
+	if i.socketType.Network() == string(SocketSeqpacket) {
+		return fmt.Errorf("TLS requires a stream socket, not %s", i.socketType)
+	}
+	i.listener = tls.NewListener(i.listener, i.tlsConfig)
+	return nil
+}
+
+// handshake completes the TLS handshake of conn, if it is a TLS connection,
+// verifying the client certificate when one is required
+func handshake(conn net.Conn) error {
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	if err := tc.SetDeadline(time.Now().Add(tlsHandshakeTimeout)); err != nil {
+		return err
+	}
+	if err := tc.Handshake(); err != nil {
+		return err
+	}
+	return tc.SetDeadline(time.Time{})
+}
+
+// underlyingConn returns the transport connection beneath a TLS connection,
+// for reading socket options such as the peer credentials
+func underlyingConn(conn net.Conn) net.Conn {
+	if tc, ok := conn.(*tls.Conn); ok {
+		return tc.NetConn()
+	}
+	return conn
+}
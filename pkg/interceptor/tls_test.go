@@ -0,0 +1,27 @@
+package interceptor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetTLS(t *testing.T) {
+	i := New("/tmp/unused.sock", false, nil)
+
+	cfg := &tls.Config{ClientCAs: x509.NewCertPool()}
+	i.SetTLS(cfg)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, i.tlsConfig.ClientAuth, "client CAs require a client certificate")
+	assert.Equal(t, tls.NoClientCert, cfg.ClientAuth, "caller's config is not modified")
+
+	i.SetTLS(&tls.Config{ClientCAs: x509.NewCertPool(), ClientAuth: tls.VerifyClientCertIfGiven})
+	assert.Equal(t, tls.VerifyClientCertIfGiven, i.tlsConfig.ClientAuth)
+
+	i.SetSocketType(SocketSeqpacket)
+	assert.ErrorContains(t, i.wrapTLS(), "TLS requires a stream socket")
+
+	i.SetTLS(nil)
+	assert.Nil(t, i.tlsConfig)
+}
@@ -0,0 +1,127 @@
+package interceptor
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// TranscriptEntry records one executed command in enough detail to replay it
+// or diff two runs: the full argv, environment, exit code and output digests
+type TranscriptEntry struct {
+	Time         time.Time         `json:"time"`
+	Command      []string          `json:"command"`
+	PID          int               `json:"pid,omitempty"`
+	ExitCode     int               `json:"exit_code"`
+	Duration     time.Duration     `json:"duration"`
+	TimedOut     bool              `json:"timed_out,omitempty"`
+	WorkingDir   string            `json:"working_dir,omitempty"`
+	Env          []string          `json:"env,omitempty"`
+	EnvTruncated bool              `json:"env_truncated,omitempty"`
+	Stdout       hook.OutputDigest `json:"stdout"`
+	Stderr       hook.OutputDigest `json:"stderr"`
+}
+
+// Transcript records every executed command as newline-delimited JSON. It
+// is safe for concurrent use.
+type Transcript struct {
+	out jsonLineWriter
+}
+
+// NewTranscript creates a transcript writing to w
+func NewTranscript(w io.Writer) *Transcript {
+	return &Transcript{out: jsonLineWriter{w: w}}
+}
+
+// Record appends an entry to the transcript. A zero Time is set to the
+// current time.
+func (t *Transcript) Record(entry TranscriptEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	return t.out.write(entry)
+}
+
+// SetTranscript configures where executed commands are recorded. Wrappers
+// must send hook.ExecutionDetails with post-run requests for entries to be
+// written. A nil transcript disables recording.
+func (i *Interceptor) SetTranscript(t *Transcript) {
+	i.transcript = t
+}
+
+// takeExecutionDetails removes the execution details from request metadata,
+// so hooks never see the environment snapshot, and decodes them
+func takeExecutionDetails(req *hook.Request) (hook.ExecutionDetails, bool) {
+	var details hook.ExecutionDetails
+	raw, ok := req.Metadata[hook.MetadataExecution]
+	if !ok {
+		return details, false
+	}
+	delete(req.Metadata, hook.MetadataExecution)
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return details, false
+	}
+	return details, json.Unmarshal(data, &details) == nil
+}
+
+// recordTranscript writes a transcript entry for a completed command
+func (i *Interceptor) recordTranscript(req *hook.Request, details hook.ExecutionDetails) {
+	timedOut, _ := req.Metadata["timed_out"].(bool)
+	entry := TranscriptEntry{
+		Command:      req.Command,
+		PID:          req.PID,
+		ExitCode:     req.ExitCode,
+		Duration:     req.Duration,
+		TimedOut:     timedOut,
+		WorkingDir:   details.WorkingDir,
+		EnvTruncated: details.EnvTruncated,
+		Stdout:       details.Stdout,
+		Stderr:       details.Stderr,
+	}
+
+	i.mu.Lock()
+	entry.Env = i.auditEnv(details.Env)
+	i.mu.Unlock()
+
+	if err := i.transcript.Record(entry); err != nil {
+		i.logf("Failed to record transcript entry: %v", err)
+	}
+}
+
+// auditEnv applies the metadata redactor to an environment snapshot, treating
+// variable names as keys. The caller must hold i.mu.
+func (i *Interceptor) auditEnv(env []string) []string {
+	if i.redactor == nil || len(env) == 0 {
+		return env
+	}
+
+	values := make(map[string]interface{}, len(env))
+	for _, e := range env {
+		name, value, _ := strings.Cut(e, "=")
+		values[name] = value
+	}
+	values = i.redactor(values)
+
+	redacted := make([]string, 0, len(env))
+	for _, e := range env {
+		name, _, _ := strings.Cut(e, "=")
+		if value, ok := values[name]; ok {
+			redacted = append(redacted, name+"="+envValue(value))
+		}
+	}
+	return redacted
+}
+
+// envValue formats a redacted value for an environment entry
+func envValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
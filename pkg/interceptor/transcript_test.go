@@ -0,0 +1,81 @@
+package interceptor
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// executionMetadata returns details as they arrive over IPC, decoded into a
+// generic map
+func executionMetadata(t *testing.T, details hook.ExecutionDetails) interface{} {
+	data, err := json.Marshal(details)
+	require.NoError(t, err)
+	var raw interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	return raw
+}
+
+func TestTranscript(t *testing.T) {
+	var buf bytes.Buffer
+	h := &recordingIPCHook{}
+	i := New("", false, h)
+	i.SetTranscript(NewTranscript(&buf))
+	i.SetMetadataRedactor(RedactKeys("API_TOKEN"))
+
+	details := hook.ExecutionDetails{
+		WorkingDir: "/work",
+		Env:        []string{"API_TOKEN=s3cr3t", "HOME=/root"},
+		Stdout:     hook.OutputDigest{SHA256: "abc", Bytes: 3},
+		Stderr:     hook.OutputDigest{SHA256: "def", Bytes: 0},
+	}
+
+	// Pre-run requests are not transcribed
+	_, err := i.processRequest(&hook.Request{Command: []string{"make", "all"}, PID: 7, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+
+	_, err = i.processRequest(&hook.Request{
+		Command:  []string{"make", "all"},
+		PID:      7,
+		Hook:     hook.HookPostRun,
+		ExitCode: 2,
+		Duration: time.Second,
+		Metadata: map[string]interface{}{
+			"timed_out":            true,
+			hook.MetadataExecution: executionMetadata(t, details),
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, h.last)
+	assert.NotContains(t, h.last.Metadata, hook.MetadataExecution, "hooks never see the environment snapshot")
+
+	// Post-run requests without details are skipped
+	_, err = i.processRequest(&hook.Request{Command: []string{"ls"}, Hook: hook.HookPostRun})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+
+	var entry TranscriptEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.False(t, entry.Time.IsZero())
+	entry.Time = time.Time{}
+	assert.Equal(t, TranscriptEntry{
+		Command:    []string{"make", "all"},
+		PID:        7,
+		ExitCode:   2,
+		Duration:   time.Second,
+		TimedOut:   true,
+		WorkingDir: "/work",
+		Env:        []string{"API_TOKEN=" + RedactedValue, "HOME=/root"},
+		Stdout:     details.Stdout,
+		Stderr:     details.Stderr,
+	}, entry)
+}
@@ -0,0 +1,95 @@
+package interceptor
+
+import (
+	"maps"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// ResponseTransformer adjusts a hook response before it is sent back to the
+// wrapper, for cross-cutting changes such as adding or stripping metadata.
+// It receives a copy of the response and may modify and return it, or return
+// a new response. Returning nil keeps the response unchanged.
+//
+// A transformer may turn an allow into a block only by returning the result
+// of BlockResponse; setting Exit or ExitCode on an allowed response any
+// other way is ignored, so a transformer that only means to edit metadata
+// cannot block commands by accident. A block is never turned back into an
+// allow.
+type ResponseTransformer func(req *hook.Request, resp *hook.Response) *hook.Response
+
+// intendedBlock marks the metadata of a response passed to BlockResponse.
+// Its type is unexported so only BlockResponse can set it.
+type intendedBlock struct{}
+
+// intendedBlockKey is the metadata key holding the intendedBlock mark
+const intendedBlockKey = "cmdhooks.intended_block"
+
+// BlockResponse turns resp into a block with the given reason, for a
+// ResponseTransformer that means to block an allowed request. To deny only
+// the command without terminating the script, set ExitCode on the result
+// and clear Exit.
+func BlockResponse(resp *hook.Response, reason string) *hook.Response {
+	resp.Exit = true
+	if reason != "" {
+		resp.Reason = reason
+	}
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]interface{})
+	}
+	resp.Metadata[intendedBlockKey] = intendedBlock{}
+	return resp
+}
+
+// takeIntendedBlock reports whether resp was passed to BlockResponse, and
+// removes the mark
+func takeIntendedBlock(resp *hook.Response) bool {
+	_, ok := resp.Metadata[intendedBlockKey].(intendedBlock)
+	delete(resp.Metadata, intendedBlockKey)
+	if len(resp.Metadata) == 0 {
+		resp.Metadata = nil
+	}
+	return ok
+}
+
+// AddResponseTransformer registers a response transformer. Transformers run
+// in registration order, each seeing the previous one's result.
+func (i *Interceptor) AddResponseTransformer(t ResponseTransformer) {
+	if t != nil {
+		i.transformers = append(i.transformers, t)
+	}
+}
+
+// transformResponse applies all registered transformers to resp
+func (i *Interceptor) transformResponse(req *hook.Request, resp *hook.Response) *hook.Response {
+	for _, t := range i.transformers {
+		// Hand out a copy: responses may be shared between deduplicated
+		// requests and must not be modified in place
+		next := t(req, copyResponse(resp))
+		if next == nil {
+			continue
+		}
+		intended := takeIntendedBlock(next)
+		switch {
+		case resp.Denied() && !next.Denied():
+			i.logf("Response transformer tried to allow a blocked request; keeping block: %v", req.Command)
+			next.Exit = resp.Exit
+			next.ExitCode = resp.ExitCode
+		case !resp.Denied() && next.Denied() && !intended:
+			i.logf("Response transformer blocked an allowed request without BlockResponse; keeping allow: %v", req.Command)
+			next.Exit = false
+			next.ExitCode = nil
+		}
+		resp = next
+	}
+	return resp
+}
+
+// copyResponse returns a copy of resp with its own metadata map
+func copyResponse(resp *hook.Response) *hook.Response {
+	c := *resp
+	if resp.Metadata != nil {
+		c.Metadata = maps.Clone(resp.Metadata)
+	}
+	return &c
+}
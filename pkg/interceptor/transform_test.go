@@ -0,0 +1,124 @@
+package interceptor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// sendRequest sends req over the interceptor socket and returns the response
+func sendRequest(t *testing.T, socketPath string, req hook.Request) hook.Response {
+	t.Helper()
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	_, err = fmt.Fprintf(conn, "%s\n", data)
+	require.NoError(t, err)
+
+	scanner := bufio.NewScanner(conn)
+	require.True(t, scanner.Scan())
+	var resp hook.Response
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &resp))
+	return resp
+}
+
+func TestResponseTransformerOverIPC(t *testing.T) {
+	// Use shorter socket path for macOS Unix domain socket limits
+	socketPath := fmt.Sprintf("/tmp/test_%d.sock", time.Now().UnixNano())
+	defer os.Remove(socketPath)
+
+	mockHook := &mockIPCHook{response: &hook.Response{
+		Metadata: map[string]interface{}{"secret": "s3cr3t", "keep": "yes"},
+	}}
+	interceptor := New(socketPath, false, mockHook)
+	interceptor.AddResponseTransformer(func(req *hook.Request, resp *hook.Response) *hook.Response {
+		delete(resp.Metadata, "secret")
+		resp.Metadata["banner"] = "audited by " + req.Command[0]
+		return resp
+	})
+	require.NoError(t, interceptor.Start())
+	defer interceptor.Stop()
+
+	resp := sendRequest(t, socketPath, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	assert.False(t, resp.Exit)
+	assert.Equal(t, map[string]interface{}{"keep": "yes", "banner": "audited by curl"}, resp.Metadata)
+
+	// The hook's own response must not be modified in place
+	assert.Contains(t, mockHook.response.Metadata, "secret")
+}
+
+func TestResponseTransformerDecisions(t *testing.T) {
+	tests := []struct {
+		name        string
+		hookExit    bool
+		transformer ResponseTransformer
+		wantExit    bool
+	}{
+		{
+			name:        "nil keeps allow",
+			transformer: func(*hook.Request, *hook.Response) *hook.Response { return nil },
+			wantExit:    false,
+		},
+		{
+			name:        "fresh response keeps allow",
+			transformer: func(*hook.Request, *hook.Response) *hook.Response { return &hook.Response{} },
+			wantExit:    false,
+		},
+		{
+			name: "explicit block",
+			transformer: func(req *hook.Request, resp *hook.Response) *hook.Response {
+				return BlockResponse(resp, "read-only")
+			},
+			wantExit: true,
+		},
+		{
+			name: "unintended block is refused",
+			transformer: func(req *hook.Request, resp *hook.Response) *hook.Response {
+				resp.Exit = true
+				return resp
+			},
+			wantExit: false,
+		},
+		{
+			name: "unintended exit code is refused",
+			transformer: func(req *hook.Request, resp *hook.Response) *hook.Response {
+				code := 1
+				resp.ExitCode = &code
+				return resp
+			},
+			wantExit: false,
+		},
+		{
+			name:        "block cannot be undone",
+			hookExit:    true,
+			transformer: func(*hook.Request, *hook.Response) *hook.Response { return &hook.Response{} },
+			wantExit:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := New("/tmp/unused.sock", false, &mockIPCHook{response: &hook.Response{Exit: tt.hookExit}})
+			interceptor.AddResponseTransformer(tt.transformer)
+
+			resp, err := interceptor.processRequest(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExit, resp.Exit)
+			assert.Equal(t, tt.wantExit, resp.Denied())
+			assert.Equal(t, tt.wantExit, interceptor.Stats().ExitRequests == 1)
+			assert.NotContains(t, resp.Metadata, intendedBlockKey, "the mark is not sent to the wrapper")
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Package namedpipe provides the Windows named pipe transport that stands in
+// for the Unix domain socket between the interceptor and wrappers. Messages
+// keep the same newline-delimited JSON framing as on a stream socket.
+package namedpipe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Prefix starts the name of every local named pipe
+const Prefix = `\\.\pipe\`
+
+// Name returns the named pipe used in place of the Unix socket at
+// socketPath. A socketPath that already names a pipe is returned
+// unchanged; any other path maps to a pipe derived from it, so the
+// interceptor and wrappers agree on the pipe given the socket path alone.
+func Name(socketPath string) string {
+	if strings.HasPrefix(socketPath, Prefix) {
+		return socketPath
+	}
+	sum := sha256.Sum256([]byte(socketPath))
+	return Prefix + "cmdhooks-" + hex.EncodeToString(sum[:8])
+}
+
+// Addr is the address of a named pipe
+type Addr string
+
+// Network returns "pipe"
+func (a Addr) Network() string { return "pipe" }
+
+// String returns the pipe name
+func (a Addr) String() string { return string(a) }
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package namedpipe
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// Listen is only supported on Windows
+func Listen(name string) (net.Listener, error) {
+	return nil, fmt.Errorf("named pipes are not supported on %s", runtime.GOOS)
+}
+
+// Dial is only supported on Windows
+func Dial(name string) (net.Conn, error) {
+	return nil, fmt.Errorf("named pipes are not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,76 @@
+package namedpipe
+
+import (
+	"bufio"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestName(t *testing.T) {
+	name := Name("/tmp/cmdhooks-123/hook.sock")
+	assert.Regexp(t, `^\\\\\.\\pipe\\cmdhooks-[0-9a-f]{16}$`, name)
+	assert.Equal(t, name, Name("/tmp/cmdhooks-123/hook.sock"), "the mapping is stable")
+	assert.NotEqual(t, name, Name("/tmp/cmdhooks-456/hook.sock"))
+	assert.Equal(t, `\\.\pipe\custom`, Name(`\\.\pipe\custom`), "pipe names are kept")
+}
+
+func TestListenDial(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		_, err := Listen(Name(t.TempDir()))
+		assert.Error(t, err)
+		t.Skip("named pipes are only supported on Windows")
+	}
+
+	name := Name(t.TempDir())
+	l, err := Listen(name)
+	require.NoError(t, err)
+	assert.Equal(t, name, l.Addr().String())
+
+	_, err = Listen(name)
+	assert.Error(t, err, "a pipe is served by one listener")
+
+	// Echo each line back with a prefix, one connection at a time
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				fmt.Fprintf(conn, "echo %s\n", scanner.Text())
+			}
+			conn.Close()
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		conn, err := Dial(name)
+		require.NoError(t, err)
+		_, err = fmt.Fprintf(conn, "{\"n\":%d}\n", i)
+		require.NoError(t, err)
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("echo {\"n\":%d}\n", i), line)
+		require.NoError(t, conn.Close())
+	}
+
+	// Close wakes the pending Accept
+	closed := make(chan struct{})
+	go func() {
+		l.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return")
+	}
+	_, err = Dial(name)
+	assert.Error(t, err, "the pipe is gone once closed")
+}
@@ -0,0 +1,186 @@
+//go:build windows
+
+package namedpipe
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procCreateNamedPipeW = kernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe = kernel32.NewProc("ConnectNamedPipe")
+	procWaitNamedPipeW   = kernel32.NewProc("WaitNamedPipeW")
+)
+
+const (
+	pipeAccessDuplex          = 0x00000003
+	fileFlagFirstPipeInstance = 0x00080000
+	pipeTypeByte              = 0x00000000
+	pipeReadmodeByte          = 0x00000000
+	pipeWait                  = 0x00000000
+	pipeRejectRemoteClients   = 0x00000008
+	pipeUnlimitedInstances    = 255
+	pipeBufferSize            = 64 * 1024
+
+	errorPipeBusy      syscall.Errno = 231
+	errorPipeConnected syscall.Errno = 535
+
+	// dialTimeout bounds how long Dial waits for a free pipe instance
+	dialTimeout = 5 * time.Second
+)
+
+// conn is a connected pipe instance. Reads and writes are synchronous, so
+// the handle is wrapped in an *os.File; deadlines are not supported.
+type conn struct {
+	*os.File
+	name Addr
+}
+
+func (c *conn) LocalAddr() net.Addr  { return c.name }
+func (c *conn) RemoteAddr() net.Addr { return c.name }
+
+// Close cancels any read or write blocked on the pipe before closing it,
+// so closing an idle connection from another goroutine does not hang
+func (c *conn) Close() error {
+	syscall.CancelIoEx(syscall.Handle(c.Fd()), nil)
+	return c.File.Close()
+}
+
+// listener accepts connections on a named pipe, one pipe instance per
+// connection. Remote clients are rejected.
+type listener struct {
+	name Addr
+
+	mu        sync.Mutex
+	closed    bool
+	pending   syscall.Handle // the instance the next Accept waits on
+	accepting bool           // an Accept is waiting on pending
+}
+
+// Listen creates the named pipe, failing if another process already
+// serves it
+func Listen(name string) (net.Listener, error) {
+	h, err := createInstance(name, true)
+	if err != nil {
+		return nil, err
+	}
+	return &listener{name: Addr(name), pending: h}, nil
+}
+
+// createInstance creates an instance of the pipe for the next client
+func createInstance(name string, first bool) (syscall.Handle, error) {
+	p, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return syscall.InvalidHandle, err
+	}
+	mode := uintptr(pipeAccessDuplex)
+	if first {
+		mode |= fileFlagFirstPipeInstance
+	}
+	h, _, err := procCreateNamedPipeW.Call(
+		uintptr(unsafe.Pointer(p)),
+		mode,
+		pipeTypeByte|pipeReadmodeByte|pipeWait|pipeRejectRemoteClients,
+		pipeUnlimitedInstances,
+		pipeBufferSize,
+		pipeBufferSize,
+		0,
+		0,
+	)
+	if syscall.Handle(h) == syscall.InvalidHandle {
+		return syscall.InvalidHandle, fmt.Errorf("failed to create named pipe %s: %w", name, err)
+	}
+	return syscall.Handle(h), nil
+}
+
+// Accept waits for a client to connect to the pending pipe instance and
+// creates the next one
+func (l *listener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+	h := l.pending
+	l.accepting = true
+	l.mu.Unlock()
+
+	ok, _, err := procConnectNamedPipe.Call(uintptr(h), 0)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.accepting = false
+	if l.closed {
+		// Close woke the wait by connecting to the pipe itself, and left
+		// the instance to be closed here
+		syscall.CloseHandle(h)
+		return nil, net.ErrClosed
+	}
+	if ok == 0 && !errors.Is(err, errorPipeConnected) {
+		return nil, fmt.Errorf("failed to accept pipe connection: %w", err)
+	}
+	next, err := createInstance(string(l.name), false)
+	if err != nil {
+		syscall.CloseHandle(h)
+		return nil, err
+	}
+	l.pending = next
+	return &conn{File: os.NewFile(uintptr(h), string(l.name)), name: l.name}, nil
+}
+
+// Close stops accepting connections. Connections already accepted stay
+// open.
+func (l *listener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	if !l.accepting {
+		syscall.CloseHandle(l.pending)
+		l.mu.Unlock()
+		return nil
+	}
+	l.mu.Unlock()
+
+	// Wake the Accept waiting for a client, which closes the instance
+	if c, err := Dial(string(l.name)); err == nil {
+		c.Close()
+	}
+	return nil
+}
+
+// Addr returns the pipe name
+func (l *listener) Addr() net.Addr {
+	return l.name
+}
+
+// Dial connects to the named pipe, waiting for a free instance if every
+// instance is busy
+func Dial(name string) (net.Conn, error) {
+	p, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(dialTimeout)
+	for {
+		h, err := syscall.CreateFile(p, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_EXISTING, 0, 0)
+		if err == nil {
+			return &conn{File: os.NewFile(uintptr(h), name), name: Addr(name)}, nil
+		}
+		if !errors.Is(err, errorPipeBusy) || time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to connect to named pipe %s: %w", name, err)
+		}
+		// Wait for the server to create the next instance
+		procWaitNamedPipeW.Call(uintptr(unsafe.Pointer(p)), uintptr(time.Until(deadline).Milliseconds()))
+	}
+}
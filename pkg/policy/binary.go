@@ -0,0 +1,30 @@
+package policy
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// resolveBinary returns the absolute, symlink-free path of the binary a
+// request will execute. The path the wrapper resolved is used when present;
+// otherwise the command is looked up in this process's PATH.
+func resolveBinary(req *hook.Request) (string, error) {
+	path := req.ResolvedPath
+	if path == "" {
+		path, _ = req.Metadata[hook.MetadataResolvedPath].(string)
+	}
+	if path == "" {
+		var err error
+		path, err = exec.LookPath(req.Command[0])
+		if err != nil {
+			return "", fmt.Errorf("cannot resolve %s: %w", req.Command[0], err)
+		}
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return filepath.Abs(path)
+}
@@ -0,0 +1,170 @@
+package policy
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// DefaultCacheMaxEntries is the number of decisions a CachingHook keeps
+// unless configured otherwise
+const DefaultCacheMaxEntries = 1024
+
+// CachingHook is an IPCHook that remembers the pre-run decisions of another
+// IPCHook for a while, so repeated invocations of the same command line skip
+// a slow evaluation such as a Remote policy server. Decisions are keyed by
+// the full argv and the verified peer (see hook.PeerUIDKey), so it suits
+// hooks whose decision depends on nothing else. Errors are never cached,
+// and post-run requests are always passed through.
+//
+// The cache holds at most a fixed number of entries; beyond that the least
+// recently used decision is evicted, bounding memory in long-lived
+// interceptors that see many distinct commands.
+type CachingHook struct {
+	inner      hook.IPCHook
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+// cacheEntry is one remembered decision
+type cacheEntry struct {
+	key     string
+	resp    *hook.Response
+	expires time.Time
+}
+
+// CachingHookOption configures a CachingHook
+type CachingHookOption func(*CachingHook)
+
+// WithCacheMaxEntries bounds how many decisions are kept (default
+// DefaultCacheMaxEntries). Values below 1 are ignored.
+func WithCacheMaxEntries(n int) CachingHookOption {
+	return func(c *CachingHook) {
+		if n > 0 {
+			c.maxEntries = n
+		}
+	}
+}
+
+// NewCachingHook creates a hook that caches the pre-run decisions of inner
+// for ttl. A ttl of zero or less keeps decisions until they are evicted.
+func NewCachingHook(inner hook.IPCHook, ttl time.Duration, opts ...CachingHookOption) *CachingHook {
+	c := &CachingHook{
+		inner:      inner,
+		ttl:        ttl,
+		maxEntries: DefaultCacheMaxEntries,
+		now:        time.Now,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Name returns the name of the wrapped hook
+func (c *CachingHook) Name() string {
+	return c.inner.Name()
+}
+
+// Commands returns the commands of the wrapped hook
+func (c *CachingHook) Commands() []string {
+	return c.inner.Commands()
+}
+
+// Len returns the number of cached decisions, including expired ones not
+// yet evicted
+func (c *CachingHook) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// EvaluateIPC answers a pre-run request from the cache when it holds a live
+// decision for the command, and evaluates and caches it otherwise
+func (c *CachingHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	if req.Hook != hook.HookPreRun {
+		return c.inner.EvaluateIPC(ctx, req)
+	}
+
+	key := hook.PeerUIDKey(req) + "\x00" + strings.Join(req.Command, "\x00")
+	if resp, ok := c.get(key); ok {
+		return resp, nil
+	}
+
+	resp, err := c.inner.EvaluateIPC(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	c.put(key, resp)
+	return copyResponse(resp), nil
+}
+
+// get returns a copy of the live decision for key, marking it most recently
+// used. An expired decision is evicted.
+func (c *CachingHook) get(key string) (*hook.Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return copyResponse(entry.resp), true
+}
+
+// put stores the decision for key, evicting the least recently used entries
+// beyond the bound
+func (c *CachingHook) put(key string, resp *hook.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, resp: copyResponse(resp)}
+	if c.ttl > 0 {
+		entry.expires = c.now().Add(c.ttl)
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove drops elem from the cache. The caller holds mu.
+func (c *CachingHook) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
+
+// copyResponse returns a copy of resp with its own metadata map, so callers
+// may modify the response without changing the cached one
+func copyResponse(resp *hook.Response) *hook.Response {
+	out := *resp
+	if resp.Metadata != nil {
+		out.Metadata = make(map[string]interface{}, len(resp.Metadata))
+		for k, v := range resp.Metadata {
+			out.Metadata[k] = v
+		}
+	}
+	return &out
+}
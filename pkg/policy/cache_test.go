@@ -0,0 +1,128 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// countingHook blocks curl and counts its evaluations per command
+type countingHook struct {
+	mu    sync.Mutex
+	calls map[string]int
+	err   error
+}
+
+func (h *countingHook) Name() string       { return "counting" }
+func (h *countingHook) Commands() []string { return []string{"*"} }
+
+func (h *countingHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.calls == nil {
+		h.calls = make(map[string]int)
+	}
+	h.calls[req.Command[0]]++
+	if h.err != nil {
+		return nil, h.err
+	}
+	return &hook.Response{Exit: req.Command[0] == "curl", Metadata: map[string]interface{}{"reason": "evaluated"}}, nil
+}
+
+func (h *countingHook) count(cmd string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.calls[cmd]
+}
+
+func evaluate(t *testing.T, c *CachingHook, stage hook.HookType, cmd ...string) *hook.Response {
+	t.Helper()
+	resp, err := c.EvaluateIPC(context.Background(), &hook.Request{Command: cmd, Hook: stage})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestCachingHook(t *testing.T) {
+	inner := &countingHook{}
+	c := NewCachingHook(inner, time.Minute)
+	now := time.Unix(1700000000, 0)
+	c.now = func() time.Time { return now }
+
+	assert.True(t, evaluate(t, c, hook.HookPreRun, "curl", "example.com").Exit)
+	assert.True(t, evaluate(t, c, hook.HookPreRun, "curl", "example.com").Exit)
+	assert.Equal(t, 1, inner.count("curl"), "second decision served from the cache")
+
+	evaluate(t, c, hook.HookPreRun, "curl", "other.example")
+	assert.Equal(t, 2, inner.count("curl"), "arguments are part of the key")
+
+	evaluate(t, c, hook.HookPostRun, "curl", "example.com")
+	assert.Equal(t, 3, inner.count("curl"), "post-run is never cached")
+
+	// Modifying a returned response leaves the cached one intact
+	resp := evaluate(t, c, hook.HookPreRun, "curl", "example.com")
+	resp.Metadata["reason"] = "changed"
+	assert.Equal(t, "evaluated", evaluate(t, c, hook.HookPreRun, "curl", "example.com").Metadata["reason"])
+
+	now = now.Add(time.Minute)
+	evaluate(t, c, hook.HookPreRun, "curl", "example.com")
+	assert.Equal(t, 4, inner.count("curl"), "expired decision is evaluated again")
+}
+
+func TestCachingHookMaxEntries(t *testing.T) {
+	inner := &countingHook{}
+	c := NewCachingHook(inner, 0, WithCacheMaxEntries(2))
+
+	evaluate(t, c, hook.HookPreRun, "git")
+	evaluate(t, c, hook.HookPreRun, "ls")
+	evaluate(t, c, hook.HookPreRun, "git") // git is now the most recently used
+	evaluate(t, c, hook.HookPreRun, "curl")
+	assert.Equal(t, 2, c.Len())
+
+	evaluate(t, c, hook.HookPreRun, "git")
+	evaluate(t, c, hook.HookPreRun, "curl")
+	assert.Equal(t, 1, inner.count("git"), "recently used entry kept")
+	assert.Equal(t, 1, inner.count("curl"))
+
+	evaluate(t, c, hook.HookPreRun, "ls")
+	assert.Equal(t, 2, inner.count("ls"), "least recently used entry evicted")
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestCachingHookErrors(t *testing.T) {
+	inner := &countingHook{err: errors.New("policy server down")}
+	c := NewCachingHook(inner, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.EvaluateIPC(context.Background(), &hook.Request{Command: []string{"git"}, Hook: hook.HookPreRun})
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 2, inner.count("git"), "errors are not cached")
+	assert.Zero(t, c.Len())
+}
+
+func TestCachingHookConcurrent(t *testing.T) {
+	inner := &countingHook{}
+	c := NewCachingHook(inner, time.Minute, WithCacheMaxEntries(4))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cmd := []string{"git", string(rune('a' + (i+j)%8))}
+				_, err := c.EvaluateIPC(context.Background(), &hook.Request{Command: cmd, Hook: hook.HookPreRun})
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, c.Len(), 4)
+}
@@ -0,0 +1,235 @@
+package policy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// LockfileVersion is the lockfile format written by GenerateLockfile
+const LockfileVersion = 1
+
+// Lockfile maps command names to the hex-encoded SHA-256 digests of the
+// binaries allowed to run as them. It is generated once in a trusted
+// environment (see GenerateLockfile) and enforced with a LockfileAllowlist.
+type Lockfile struct {
+	Version  int                 `json:"version"`
+	Commands map[string][]string `json:"commands"`
+}
+
+// GenerateLockfile resolves each command in PATH and records the digest of
+// its binary, with symlinks resolved
+func GenerateLockfile(commands []string) (*Lockfile, error) {
+	l := &Lockfile{Version: LockfileVersion, Commands: make(map[string][]string)}
+	for _, command := range commands {
+		path, err := exec.LookPath(command)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve %s: %w", command, err)
+		}
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		digest, err := hashFile(path)
+		if err != nil {
+			return nil, err
+		}
+		name := filepath.Base(command)
+		if !slices.Contains(l.Commands[name], digest) {
+			l.Commands[name] = append(l.Commands[name], digest)
+		}
+	}
+	return l, nil
+}
+
+// LoadLockfile reads and validates a lockfile
+func LoadLockfile(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	var l Lockfile
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+	}
+	if err := l.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid lockfile %s: %w", path, err)
+	}
+	return &l, nil
+}
+
+// Validate checks the lockfile version and that every digest is a
+// hex-encoded SHA-256 digest
+func (l *Lockfile) Validate() error {
+	if l.Version != LockfileVersion {
+		return fmt.Errorf("unsupported version %d", l.Version)
+	}
+	for name, digests := range l.Commands {
+		if name == "" || strings.ContainsRune(name, '/') {
+			return fmt.Errorf("invalid command name %q", name)
+		}
+		if len(digests) == 0 {
+			return fmt.Errorf("command %s has no digests", name)
+		}
+		for _, digest := range digests {
+			if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+				return fmt.Errorf("command %s: invalid SHA-256 digest %q", name, digest)
+			}
+		}
+	}
+	return nil
+}
+
+// Write writes the lockfile as indented JSON, with commands in sorted order
+func (l *Lockfile) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lockfile: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
+
+// LockfileAllowlist is an IPCHook that only allows the commands of a
+// lockfile, and only when the binary they resolve to has a digest the
+// lockfile lists for them. Digests are cached per binary path and
+// recomputed when the file's size or modification time changes.
+type LockfileAllowlist struct {
+	lockfile *Lockfile
+	commands []string
+
+	mu     sync.Mutex
+	hashes map[string]fileHash
+}
+
+// fileHash is the cached digest of a binary
+type fileHash struct {
+	size    int64
+	modTime time.Time
+	digest  string
+}
+
+// NewLockfileAllowlist creates a pre-run hook enforcing the lockfile at
+// path. It handles exactly the commands the lockfile lists.
+func NewLockfileAllowlist(path string) (*LockfileAllowlist, error) {
+	l, err := LoadLockfile(path)
+	if err != nil {
+		return nil, err
+	}
+	commands := make([]string, 0, len(l.Commands))
+	for name := range l.Commands {
+		commands = append(commands, name)
+	}
+	sort.Strings(commands)
+	return &LockfileAllowlist{
+		lockfile: l,
+		commands: commands,
+		hashes:   make(map[string]fileHash),
+	}, nil
+}
+
+// Name returns the hook name
+func (a *LockfileAllowlist) Name() string {
+	return "lockfile-allowlist"
+}
+
+// Commands returns the commands listed in the lockfile
+func (a *LockfileAllowlist) Commands() []string {
+	return a.commands
+}
+
+// EvaluateIPC checks the digest of the binary a pre-run request will
+// execute against the lockfile. A binary that cannot be resolved or hashed
+// is blocked. Post-run requests are always allowed.
+func (a *LockfileAllowlist) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	if req.Hook != hook.HookPreRun || len(req.Command) == 0 {
+		return &hook.Response{}, nil
+	}
+
+	name := filepath.Base(req.Command[0])
+	allowed, ok := a.lockfile.Commands[name]
+	if !ok {
+		return blockUnlocked(req.Command[0], "", "not in lockfile"), nil
+	}
+
+	path, err := resolveBinary(req)
+	if err != nil {
+		return blockUnlocked(req.Command[0], "", err.Error()), nil
+	}
+	digest, err := a.hash(path)
+	if err != nil {
+		return blockUnlocked(path, "", err.Error()), nil
+	}
+	if !slices.Contains(allowed, digest) {
+		return blockUnlocked(path, digest, "binary digest does not match lockfile"), nil
+	}
+	return &hook.Response{}, nil
+}
+
+// hash returns the digest of the file at path, from the cache if the file
+// is unchanged
+func (a *LockfileAllowlist) hash(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot stat %s: %w", path, err)
+	}
+
+	a.mu.Lock()
+	cached, ok := a.hashes[path]
+	a.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.digest, nil
+	}
+
+	digest, err := hashFile(path)
+	if err != nil {
+		return "", err
+	}
+	a.mu.Lock()
+	a.hashes[path] = fileHash{size: info.Size(), modTime: info.ModTime(), digest: digest}
+	a.mu.Unlock()
+	return digest, nil
+}
+
+// hashFile returns the hex-encoded SHA-256 digest of the file at path
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot hash %s: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("cannot hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// blockUnlocked returns a blocking response explaining why path was
+// rejected, with the digest that failed to match if it was computed
+func blockUnlocked(path, digest, reason string) *hook.Response {
+	resp := &hook.Response{
+		Exit:     true,
+		Reason:   fmt.Sprintf("%s: %s", path, reason),
+		Metadata: map[string]interface{}{"binary": path},
+	}
+	if digest != "" {
+		resp.Metadata["sha256"] = digest
+	}
+	return resp
+}
@@ -0,0 +1,117 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// writeLockedBinary writes an executable file with the given content into dir
+func writeLockedBinary(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0755))
+	return path
+}
+
+func TestLockfileRoundTrip(t *testing.T) {
+	bin := t.TempDir()
+	deploy := writeLockedBinary(t, bin, "deploy", "#!/bin/sh\necho deploy\n")
+	build := writeLockedBinary(t, bin, "build", "#!/bin/sh\necho build\n")
+	other := writeLockedBinary(t, bin, "other", "#!/bin/sh\necho other\n")
+	t.Setenv("PATH", bin)
+
+	lockfile, err := GenerateLockfile([]string{"deploy", "build"})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "cmdhooks.lock")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, lockfile.Write(f))
+	require.NoError(t, f.Close())
+
+	allowlist, err := NewLockfileAllowlist(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build", "deploy"}, allowlist.Commands())
+
+	evaluate := func(req *hook.Request) *hook.Response {
+		t.Helper()
+		resp, err := allowlist.EvaluateIPC(context.Background(), req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	assert.False(t, evaluate(&hook.Request{Command: []string{"deploy", "prod"}, Hook: hook.HookPreRun}).Denied())
+	assert.False(t, evaluate(&hook.Request{
+		Command:  []string{"build"},
+		Hook:     hook.HookPreRun,
+		Metadata: map[string]interface{}{hook.MetadataResolvedPath: build},
+	}).Denied(), "the wrapper's resolved path is used")
+	assert.True(t, evaluate(&hook.Request{
+		Command:      []string{"build"},
+		ResolvedPath: other,
+		Hook:         hook.HookPreRun,
+	}).Denied(), "a command resolving to another binary is blocked")
+
+	resp := evaluate(&hook.Request{Command: []string{"other"}, Hook: hook.HookPreRun})
+	assert.True(t, resp.Denied())
+	assert.Equal(t, "other: not in lockfile", resp.Reason)
+
+	// Replacing a locked binary is detected despite the cached digest
+	writeLockedBinary(t, bin, "deploy", "#!/bin/sh\necho tampered deploy\n")
+	resp = evaluate(&hook.Request{Command: []string{"deploy"}, Hook: hook.HookPreRun})
+	assert.True(t, resp.Denied())
+	assert.Equal(t, deploy, resp.Metadata["binary"])
+	assert.NotEmpty(t, resp.Metadata["sha256"])
+	assert.Contains(t, resp.Reason, "binary digest does not match lockfile")
+
+	assert.False(t, evaluate(&hook.Request{Command: []string{"deploy"}, Hook: hook.HookPostRun}).Denied(), "post-run is always allowed")
+}
+
+func TestLockfileUnresolvable(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := GenerateLockfile([]string{"missing"})
+	assert.ErrorContains(t, err, "cannot resolve missing")
+
+	path := filepath.Join(t.TempDir(), "cmdhooks.lock")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":1,"commands":{"missing":["`+
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"+`"]}}`), 0644))
+	allowlist, err := NewLockfileAllowlist(path)
+	require.NoError(t, err)
+
+	resp, err := allowlist.EvaluateIPC(context.Background(), &hook.Request{Command: []string{"missing"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.True(t, resp.Denied())
+}
+
+func TestLoadLockfileInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "not json", content: "deploy abc", wantErr: "failed to parse lockfile"},
+		{name: "unknown version", content: `{"version":2,"commands":{}}`, wantErr: "unsupported version 2"},
+		{name: "path as name", content: `{"version":1,"commands":{"/bin/sh":["00"]}}`, wantErr: "invalid command name"},
+		{name: "no digests", content: `{"version":1,"commands":{"sh":[]}}`, wantErr: "has no digests"},
+		{name: "short digest", content: `{"version":1,"commands":{"sh":["abcd"]}}`, wantErr: "invalid SHA-256 digest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cmdhooks.lock")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+			_, err := NewLockfileAllowlist(path)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	_, err := LoadLockfile(filepath.Join(t.TempDir(), "missing.lock"))
+	assert.ErrorContains(t, err, "failed to read lockfile")
+}
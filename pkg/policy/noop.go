@@ -0,0 +1,33 @@
+package policy
+
+import (
+	"context"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// Noop is an IPCHook that allows every request. It handles "*", so a
+// cmdhooks instance built around it runs the full interception pipeline
+// (wrappers, IPC, history, decision logs) while deciding nothing, which
+// suits bootstrapping a policy by first observing what scripts run.
+type Noop struct{}
+
+// NewNoop creates a hook that allows all commands
+func NewNoop() *Noop {
+	return &Noop{}
+}
+
+// Name returns the hook name
+func (n *Noop) Name() string {
+	return "noop"
+}
+
+// Commands returns "*", matching every command
+func (n *Noop) Commands() []string {
+	return []string{"*"}
+}
+
+// EvaluateIPC allows the request
+func (n *Noop) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	return &hook.Response{}, nil
+}
@@ -0,0 +1,27 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestNoop(t *testing.T) {
+	n := NewNoop()
+	assert.Equal(t, []string{"*"}, n.Commands())
+
+	requests := []*hook.Request{
+		{Command: []string{"rm", "-rf", "/"}, Hook: hook.HookPreRun},
+		{Command: []string{"curl"}, Hook: hook.HookPostRun, ExitCode: 7},
+		{Batch: [][]string{{"curl"}, {"sh"}}, Hook: hook.HookPreRunBatch},
+	}
+	for _, req := range requests {
+		resp, err := n.EvaluateIPC(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, resp.Denied(), "%s %v", req.Hook, req.Command)
+	}
+}
@@ -0,0 +1,124 @@
+// Package policy provides reusable hook implementations that can be composed
+// into CmdHooks policies.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// DefaultMaxOutputBytes is the amount of captured output inspected per stream
+// by an OutputMatcher unless configured otherwise.
+const DefaultMaxOutputBytes = 1024 * 1024 // 1 MiB
+
+// OutputMatcher is an IPCHook that inspects the captured stdout and stderr of
+// a command during post-run evaluation and requests exit when any configured
+// pattern matches.
+type OutputMatcher struct {
+	patterns map[string]*regexp.Regexp
+	names    []string // pattern names in evaluation order
+	commands []string
+	maxBytes int64
+}
+
+// OutputMatcherOption configures an OutputMatcher
+type OutputMatcherOption func(*OutputMatcher)
+
+// WithOutputCommands limits the matcher to the given commands (default "*")
+func WithOutputCommands(commands ...string) OutputMatcherOption {
+	return func(m *OutputMatcher) {
+		m.commands = commands
+	}
+}
+
+// WithMaxOutputBytes bounds how much output is read from each stream.
+// Output beyond the limit is not inspected.
+func WithMaxOutputBytes(n int64) OutputMatcherOption {
+	return func(m *OutputMatcher) {
+		if n > 0 {
+			m.maxBytes = n
+		}
+	}
+}
+
+// NewOutputMatcher creates a post-run hook that fails a command whose output
+// matches any of the named patterns. Patterns are evaluated in name order.
+func NewOutputMatcher(patterns map[string]*regexp.Regexp, opts ...OutputMatcherOption) *OutputMatcher {
+	m := &OutputMatcher{
+		patterns: patterns,
+		commands: []string{"*"},
+		maxBytes: DefaultMaxOutputBytes,
+	}
+	for name, re := range patterns {
+		if re != nil {
+			m.names = append(m.names, name)
+		}
+	}
+	sort.Strings(m.names)
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Name returns the hook name
+func (m *OutputMatcher) Name() string {
+	return "output-matcher"
+}
+
+// Commands returns the list of commands this hook handles
+func (m *OutputMatcher) Commands() []string {
+	return m.commands
+}
+
+// EvaluateIPC matches the captured output of post-run requests. Pre-run
+// requests are always allowed.
+func (m *OutputMatcher) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	if req.Hook != hook.HookPostRun {
+		return &hook.Response{}, nil
+	}
+
+	for _, stream := range []string{"stdout", "stderr"} {
+		path, _ := req.Metadata[stream+"_file"].(string)
+		if path == "" {
+			continue
+		}
+		output, err := m.readOutput(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", stream, err)
+		}
+		for _, name := range m.names {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if m.patterns[name].Match(output) {
+				return &hook.Response{
+					Exit:   true,
+					Reason: fmt.Sprintf("output matched %s", name),
+					Metadata: map[string]interface{}{
+						"matched_pattern": name,
+						"matched_stream":  stream,
+					},
+				}, nil
+			}
+		}
+	}
+
+	return &hook.Response{}, nil
+}
+
+// readOutput reads at most maxBytes of a captured output file
+func (m *OutputMatcher) readOutput(path string) ([]byte, error) {
+	f, err := os.Open(path) // #nosec G304 -- path is provided by the wrapper's capture
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, m.maxBytes))
+}
@@ -0,0 +1,130 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// writeOutput writes captured output to a temp file and returns its path
+func writeOutput(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "output")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestOutputMatcher(t *testing.T) {
+	patterns := map[string]*regexp.Regexp{
+		"fatal-error": regexp.MustCompile(`(?i)fatal:`),
+		"panic":       regexp.MustCompile(`panic\(`),
+	}
+
+	tests := []struct {
+		name        string
+		hookType    hook.HookType
+		stdout      string
+		stderr      string
+		wantExit    bool
+		wantPattern string
+		wantStream  string
+	}{
+		{
+			name:     "no match allows",
+			hookType: hook.HookPostRun,
+			stdout:   "everything is fine\n",
+			stderr:   "warning: something minor\n",
+			wantExit: false,
+		},
+		{
+			name:        "stdout match exits",
+			hookType:    hook.HookPostRun,
+			stdout:      "FATAL: repository not found\n",
+			wantExit:    true,
+			wantPattern: "fatal-error",
+			wantStream:  "stdout",
+		},
+		{
+			name:        "stderr match exits",
+			hookType:    hook.HookPostRun,
+			stdout:      "ok\n",
+			stderr:      "goroutine 1 [running]:\npanic(0x1)\n",
+			wantExit:    true,
+			wantPattern: "panic",
+			wantStream:  "stderr",
+		},
+		{
+			name:     "pre-run is ignored",
+			hookType: hook.HookPreRun,
+			stdout:   "fatal: ignored\n",
+			wantExit: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher := NewOutputMatcher(patterns)
+			req := &hook.Request{
+				Command: []string{"git", "clone"},
+				Hook:    tt.hookType,
+				Metadata: map[string]interface{}{
+					"stdout_file": writeOutput(t, tt.stdout),
+					"stderr_file": writeOutput(t, tt.stderr),
+				},
+			}
+
+			resp, err := matcher.EvaluateIPC(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExit, resp.Exit)
+			if tt.wantExit {
+				assert.Equal(t, "output matched "+tt.wantPattern, resp.Reason)
+				assert.Equal(t, tt.wantPattern, resp.Metadata["matched_pattern"])
+				assert.Equal(t, tt.wantStream, resp.Metadata["matched_stream"])
+			}
+		})
+	}
+}
+
+func TestOutputMatcherSizeLimit(t *testing.T) {
+	patterns := map[string]*regexp.Regexp{"late": regexp.MustCompile(`needle`)}
+	matcher := NewOutputMatcher(patterns, WithMaxOutputBytes(16))
+
+	req := &hook.Request{
+		Command: []string{"cat"},
+		Hook:    hook.HookPostRun,
+		Metadata: map[string]interface{}{
+			"stdout_file": writeOutput(t, "0123456789abcdefghij needle"),
+		},
+	}
+
+	resp, err := matcher.EvaluateIPC(context.Background(), req)
+	require.NoError(t, err)
+	assert.False(t, resp.Exit, "output beyond the size limit should not be inspected")
+}
+
+func TestOutputMatcherCommands(t *testing.T) {
+	matcher := NewOutputMatcher(nil)
+	assert.Equal(t, []string{"*"}, matcher.Commands())
+
+	matcher = NewOutputMatcher(nil, WithOutputCommands("make", "go"))
+	assert.Equal(t, []string{"make", "go"}, matcher.Commands())
+	assert.Equal(t, "output-matcher", matcher.Name())
+}
+
+func TestOutputMatcherMissingFile(t *testing.T) {
+	matcher := NewOutputMatcher(map[string]*regexp.Regexp{"x": regexp.MustCompile("x")})
+	req := &hook.Request{
+		Command:  []string{"cat"},
+		Hook:     hook.HookPostRun,
+		Metadata: map[string]interface{}{"stdout_file": filepath.Join(t.TempDir(), "missing")},
+	}
+
+	_, err := matcher.EvaluateIPC(context.Background(), req)
+	assert.Error(t, err)
+}
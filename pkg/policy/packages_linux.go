@@ -0,0 +1,212 @@
+//go:build linux
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// PackageQuery reports whether the file at path is owned by an installed
+// package. It returns an error if ownership could not be determined.
+type PackageQuery func(ctx context.Context, path string) (bool, error)
+
+// PackageOwnedAllowlist is an IPCHook that only allows commands whose binary
+// is owned by an installed distribution package, as reported by the package
+// manager (dpkg or rpm). Both the binary's symlink-resolved path and the
+// path the wrapper found it at in PATH (Request.LookupPath) are checked,
+// along with their merged-/usr aliases (/bin and /usr/bin, ...). Results
+// are cached per binary path.
+type PackageOwnedAllowlist struct {
+	query    PackageQuery
+	commands []string
+
+	mu    sync.Mutex
+	owned map[string]bool
+}
+
+// PackageAllowlistOption configures a PackageOwnedAllowlist
+type PackageAllowlistOption func(*PackageOwnedAllowlist)
+
+// WithPackageQuery replaces the package manager query (default: dpkg, then
+// rpm)
+func WithPackageQuery(q PackageQuery) PackageAllowlistOption {
+	return func(a *PackageOwnedAllowlist) {
+		if q != nil {
+			a.query = q
+		}
+	}
+}
+
+// WithPackageCommands limits the allowlist to the given commands (default "*")
+func WithPackageCommands(commands ...string) PackageAllowlistOption {
+	return func(a *PackageOwnedAllowlist) {
+		a.commands = commands
+	}
+}
+
+// NewPackageOwnedAllowlist creates a pre-run hook that blocks any command
+// whose binary is not owned by an installed package. If the package manager
+// cannot be queried the command is blocked (the error is not cached, so a
+// later invocation is queried again).
+func NewPackageOwnedAllowlist(opts ...PackageAllowlistOption) *PackageOwnedAllowlist {
+	a := &PackageOwnedAllowlist{
+		query:    QueryPackageManager,
+		commands: []string{"*"},
+		owned:    make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Name returns the hook name
+func (a *PackageOwnedAllowlist) Name() string {
+	return "package-owned-allowlist"
+}
+
+// Commands returns the list of commands this hook handles
+func (a *PackageOwnedAllowlist) Commands() []string {
+	return a.commands
+}
+
+// EvaluateIPC checks package ownership of the binary a pre-run request will
+// execute. Post-run requests are always allowed.
+func (a *PackageOwnedAllowlist) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	if req.Hook != hook.HookPreRun || len(req.Command) == 0 {
+		return &hook.Response{}, nil
+	}
+
+	path, err := resolveBinary(req)
+	if err != nil {
+		return blockUnowned(req.Command[0], err.Error()), nil
+	}
+
+	owned, err := a.isOwnedAny(ctx, ownershipCandidates(path, req.LookupPath))
+	if err != nil {
+		return blockUnowned(path, fmt.Sprintf("package query failed: %v", err)), nil
+	}
+	if !owned {
+		return blockUnowned(path, "not owned by an installed package"), nil
+	}
+	return &hook.Response{}, nil
+}
+
+// isOwnedAny reports whether any of paths is owned by a package. An error is
+// only returned if no path is known to be owned and a query failed.
+func (a *PackageOwnedAllowlist) isOwnedAny(ctx context.Context, paths []string) (bool, error) {
+	var firstErr error
+	for _, path := range paths {
+		owned, err := a.isOwned(ctx, path)
+		if owned {
+			return true, nil
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return false, firstErr
+}
+
+// mergedUsrDirs pairs the top-level directories that merged-/usr systems
+// link into /usr with their targets. Packages record the paths they were
+// built with, so dpkg knows /bin/ls but not /usr/bin/ls, which it resolves
+// to.
+var mergedUsrDirs = [][2]string{
+	{"/bin", "/usr/bin"},
+	{"/sbin", "/usr/sbin"},
+	{"/lib", "/usr/lib"},
+}
+
+// ownershipCandidates returns the paths a binary may be recorded under in
+// the package database: each of paths, then its merged-/usr aliases.
+// Empty paths and duplicates are dropped.
+func ownershipCandidates(paths ...string) []string {
+	var candidates []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			candidates = append(candidates, path)
+		}
+	}
+	for _, path := range paths {
+		add(path)
+	}
+	for _, path := range paths {
+		for _, dirs := range mergedUsrDirs {
+			if rest, ok := strings.CutPrefix(path, dirs[0]+"/"); ok {
+				add(dirs[1] + "/" + rest)
+			}
+			if rest, ok := strings.CutPrefix(path, dirs[1]+"/"); ok {
+				add(dirs[0] + "/" + rest)
+			}
+		}
+	}
+	return candidates
+}
+
+// isOwned returns the cached ownership of path, querying on a miss
+func (a *PackageOwnedAllowlist) isOwned(ctx context.Context, path string) (bool, error) {
+	a.mu.Lock()
+	owned, ok := a.owned[path]
+	a.mu.Unlock()
+	if ok {
+		return owned, nil
+	}
+
+	owned, err := a.query(ctx, path)
+	if err != nil {
+		return false, err
+	}
+
+	a.mu.Lock()
+	a.owned[path] = owned
+	a.mu.Unlock()
+	return owned, nil
+}
+
+// blockUnowned returns a blocking response explaining why path was rejected
+func blockUnowned(path, reason string) *hook.Response {
+	return &hook.Response{
+		Exit:     true,
+		Reason:   fmt.Sprintf("%s: %s", path, reason),
+		Metadata: map[string]interface{}{"binary": path},
+	}
+}
+
+// errNoPackageManager is returned when neither dpkg nor rpm is installed
+var errNoPackageManager = errors.New("no supported package manager (dpkg or rpm) found")
+
+// QueryPackageManager is the default PackageQuery. It asks dpkg, falling back
+// to rpm, whether path belongs to an installed package.
+func QueryPackageManager(ctx context.Context, path string) (bool, error) {
+	if dpkg, err := exec.LookPath("dpkg-query"); err == nil {
+		return queryExitStatus(exec.CommandContext(ctx, dpkg, "--search", path))
+	}
+	if rpm, err := exec.LookPath("rpm"); err == nil {
+		return queryExitStatus(exec.CommandContext(ctx, rpm, "--query", "--file", path))
+	}
+	return false, errNoPackageManager
+}
+
+// queryExitStatus runs a package manager query, treating exit status 0 as
+// owned and 1 as not owned
+func queryExitStatus(cmd *exec.Cmd) (bool, error) {
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, err
+}
@@ -0,0 +1,186 @@
+//go:build linux
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+	"github.com/codysoyland/cmdhooks/pkg/interceptor"
+	"github.com/codysoyland/cmdhooks/pkg/wrapper"
+)
+
+// fakePackageDB is a mocked package manager backed by a set of owned paths
+type fakePackageDB struct {
+	owned   map[string]bool
+	err     error
+	queries map[string]int
+}
+
+func (f *fakePackageDB) query(ctx context.Context, path string) (bool, error) {
+	f.queries[path]++
+	if f.err != nil {
+		return false, f.err
+	}
+	return f.owned[path], nil
+}
+
+// writeBinary creates an executable file and returns its resolved path
+func writeBinary(t *testing.T, dir, name string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0o755))
+	resolved, err := filepath.EvalSymlinks(path)
+	require.NoError(t, err)
+	return resolved
+}
+
+func TestPackageOwnedAllowlist(t *testing.T) {
+	dir := t.TempDir()
+	packaged := writeBinary(t, dir, "packaged")
+	dropped := writeBinary(t, dir, "dropped")
+
+	db := &fakePackageDB{owned: map[string]bool{packaged: true}, queries: map[string]int{}}
+	allowlist := NewPackageOwnedAllowlist(WithPackageQuery(db.query))
+	assert.Equal(t, "package-owned-allowlist", allowlist.Name())
+	assert.Equal(t, []string{"*"}, allowlist.Commands())
+
+	evaluate := func(path string, stage hook.HookType) *hook.Response {
+		resp, err := allowlist.EvaluateIPC(context.Background(), &hook.Request{
+			Command:  []string{filepath.Base(path)},
+			Hook:     stage,
+			Metadata: map[string]interface{}{"resolved_path": path},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	assert.False(t, evaluate(packaged, hook.HookPreRun).Exit)
+
+	resp := evaluate(dropped, hook.HookPreRun)
+	assert.True(t, resp.Exit)
+	assert.Equal(t, dropped, resp.Metadata["binary"])
+	assert.Equal(t, dropped+": not owned by an installed package", resp.Reason)
+
+	// Results are cached per binary
+	evaluate(packaged, hook.HookPreRun)
+	evaluate(dropped, hook.HookPreRun)
+	assert.Equal(t, 1, db.queries[packaged])
+	assert.Equal(t, 1, db.queries[dropped])
+
+	// Post-run is never checked
+	assert.False(t, evaluate(dropped, hook.HookPostRun).Exit)
+}
+
+func TestPackageOwnedAllowlistQueryFailure(t *testing.T) {
+	binary := writeBinary(t, t.TempDir(), "tool")
+	db := &fakePackageDB{err: errors.New("database locked"), queries: map[string]int{}}
+	allowlist := NewPackageOwnedAllowlist(WithPackageQuery(db.query))
+
+	for i := 0; i < 2; i++ {
+		resp, err := allowlist.EvaluateIPC(context.Background(), &hook.Request{
+			Command:  []string{"tool"},
+			Hook:     hook.HookPreRun,
+			Metadata: map[string]interface{}{"resolved_path": binary},
+		})
+		require.NoError(t, err)
+		assert.True(t, resp.Exit, "fails closed when the package manager cannot be queried")
+		assert.Contains(t, resp.Reason, "database locked")
+	}
+	assert.Equal(t, 2, db.queries[binary], "failures are not cached")
+}
+
+func TestPackageOwnedAllowlistUnresolvable(t *testing.T) {
+	db := &fakePackageDB{queries: map[string]int{}}
+	allowlist := NewPackageOwnedAllowlist(WithPackageQuery(db.query))
+
+	resp, err := allowlist.EvaluateIPC(context.Background(), &hook.Request{
+		Command: []string{"cmdhooks-definitely-missing-command"},
+		Hook:    hook.HookPreRun,
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Exit)
+	assert.Empty(t, db.queries)
+}
+
+func TestPackageOwnedAllowlistAliases(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		owned string
+	}{
+		// dpkg on merged-/usr systems only knows /bin/ls, which resolves
+		// to /usr/bin/ls
+		{"merged usr", "/usr/bin/cmdhooks-test-ls", "/bin/cmdhooks-test-ls"},
+		{"merged usr reverse", "/sbin/cmdhooks-test-ip", "/usr/sbin/cmdhooks-test-ip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakePackageDB{owned: map[string]bool{tt.owned: true}, queries: map[string]int{}}
+			allowlist := NewPackageOwnedAllowlist(WithPackageQuery(db.query))
+			resp, err := allowlist.EvaluateIPC(context.Background(), &hook.Request{
+				Command:      []string{filepath.Base(tt.path)},
+				Hook:         hook.HookPreRun,
+				ResolvedPath: tt.path,
+			})
+			require.NoError(t, err)
+			assert.False(t, resp.Exit)
+		})
+	}
+
+	// A binary is only unowned once every alias has been checked
+	db := &fakePackageDB{owned: map[string]bool{}, queries: map[string]int{}}
+	allowlist := NewPackageOwnedAllowlist(WithPackageQuery(db.query))
+	resp, err := allowlist.EvaluateIPC(context.Background(), &hook.Request{
+		Command:      []string{"cmdhooks-test-ls"},
+		Hook:         hook.HookPreRun,
+		ResolvedPath: "/usr/bin/cmdhooks-test-ls",
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Exit)
+	assert.Equal(t, "/usr/bin/cmdhooks-test-ls", resp.Metadata["binary"])
+	assert.Equal(t, map[string]int{"/usr/bin/cmdhooks-test-ls": 1, "/bin/cmdhooks-test-ls": 1}, db.queries)
+}
+
+// TestPackageOwnedAllowlistLookupPath runs a command through a wrapper to
+// check that a package owning the symlink found in PATH, rather than its
+// target, allows it
+func TestPackageOwnedAllowlistLookupPath(t *testing.T) {
+	dir := t.TempDir()
+	target := writeBinary(t, dir, "target")
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Symlink(target, link))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	for _, tt := range []struct {
+		name    string
+		owned   map[string]bool
+		allowed bool
+	}{
+		{"owned symlink", map[string]bool{link: true}, true},
+		{"unowned", map[string]bool{}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakePackageDB{owned: tt.owned, queries: map[string]int{}}
+			socketPath := filepath.Join(t.TempDir(), "hook.sock")
+			i := interceptor.New(socketPath, false, NewPackageOwnedAllowlist(WithPackageQuery(db.query)))
+			require.NoError(t, i.Start())
+			defer i.Stop()
+
+			err := wrapper.NewWrapperCommand(nil, wrapper.WithSocketPath(socketPath)).Run([]string{"link"})
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+			assert.Equal(t, 1, db.queries[target])
+			assert.Equal(t, 1, db.queries[link], "the path found in PATH is checked too")
+		})
+	}
+}
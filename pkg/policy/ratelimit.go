@@ -0,0 +1,145 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// RateLimitKey maps a request to the bucket it is counted against
+type RateLimitKey func(req *hook.Request) string
+
+// KeyByCommand counts each command name against its own bucket
+func KeyByCommand(req *hook.Request) string {
+	if len(req.Command) == 0 {
+		return ""
+	}
+	return req.Command[0]
+}
+
+// RateLimiter is an IPCHook that limits how often commands may start. Each
+// key gets a token bucket holding up to n invocations, refilled at n per
+// period; a pre-run request with no token left is blocked. Post-run
+// requests are always allowed.
+type RateLimiter struct {
+	n        int
+	per      time.Duration
+	key      RateLimitKey
+	commands []string
+	now      func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket holds the tokens of one key as of last
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiterOption configures a RateLimiter
+type RateLimiterOption func(*RateLimiter)
+
+// WithRateLimitCommands limits the hook to the given commands (default "*")
+func WithRateLimitCommands(commands ...string) RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.commands = commands
+	}
+}
+
+// WithRateLimitKey sets how requests are assigned to buckets (default
+// KeyByCommand)
+func WithRateLimitKey(key RateLimitKey) RateLimiterOption {
+	return func(l *RateLimiter) {
+		if key != nil {
+			l.key = key
+		}
+	}
+}
+
+// NewRateLimiter creates a hook allowing each key at most n command starts
+// per period, with bursts of up to n. Values of n below 1 are treated as 1,
+// and a period of zero never refills, capping each key at n starts.
+func NewRateLimiter(n int, per time.Duration, opts ...RateLimiterOption) *RateLimiter {
+	if n < 1 {
+		n = 1
+	}
+	l := &RateLimiter{
+		n:        n,
+		per:      per,
+		key:      KeyByCommand,
+		commands: []string{"*"},
+		now:      time.Now,
+		buckets:  make(map[string]*tokenBucket),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// NewPerUserRateLimiter creates a RateLimiter keyed by the verified UID of
+// the requesting process (see hook.PeerUIDKey), so each user of a shared
+// interceptor gets an independent budget. Requests without peer
+// credentials share a single bucket.
+func NewPerUserRateLimiter(n int, per time.Duration, opts ...RateLimiterOption) *RateLimiter {
+	return NewRateLimiter(n, per, append([]RateLimiterOption{WithRateLimitKey(hook.PeerUIDKey)}, opts...)...)
+}
+
+// Name returns the hook name
+func (l *RateLimiter) Name() string {
+	return "rate-limiter"
+}
+
+// Commands returns the list of commands this hook handles
+func (l *RateLimiter) Commands() []string {
+	return l.commands
+}
+
+// EvaluateIPC takes a token from the request's bucket at pre-run, blocking
+// the command if none is left
+func (l *RateLimiter) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	if req.Hook != hook.HookPreRun {
+		return &hook.Response{}, nil
+	}
+
+	key := l.key(req)
+	if l.take(key) {
+		return &hook.Response{}, nil
+	}
+	return &hook.Response{
+		Exit:     true,
+		Reason:   fmt.Sprintf("rate limit of %d per %s exceeded", l.n, l.per),
+		Metadata: map[string]interface{}{"rate_limit_key": key},
+	}, nil
+}
+
+// take refills key's bucket for the time elapsed and takes a token from it
+func (l *RateLimiter) take(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.n), last: now}
+		l.buckets[key] = b
+	}
+	if l.per > 0 {
+		b.tokens += float64(l.n) * float64(now.Sub(b.last)) / float64(l.per)
+		if b.tokens > float64(l.n) {
+			b.tokens = float64(l.n)
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// fakeClock returns a controllable time source for rate limiter tests
+func fakeClock(l *RateLimiter) *time.Time {
+	now := time.Unix(1700000000, 0)
+	l.now = func() time.Time { return now }
+	return &now
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := NewRateLimiter(2, time.Minute)
+	now := fakeClock(l)
+
+	start := func(cmd string) bool {
+		resp, err := l.EvaluateIPC(context.Background(), &hook.Request{Command: []string{cmd}, Hook: hook.HookPreRun})
+		require.NoError(t, err)
+		return !resp.Exit
+	}
+
+	assert.True(t, start("curl"))
+	assert.True(t, start("curl"))
+	assert.False(t, start("curl"), "burst used up")
+	assert.True(t, start("git"), "commands have separate buckets by default")
+
+	*now = now.Add(30 * time.Second)
+	assert.True(t, start("curl"), "one token refilled")
+	assert.False(t, start("curl"))
+
+	resp, err := l.EvaluateIPC(context.Background(), &hook.Request{Command: []string{"curl"}, Hook: hook.HookPostRun})
+	require.NoError(t, err)
+	assert.False(t, resp.Exit, "post-run is never limited")
+}
+
+func TestPerUserRateLimiter(t *testing.T) {
+	l := NewPerUserRateLimiter(1, time.Hour)
+	fakeClock(l)
+
+	alice, bob := 1000, 1001
+	start := func(uid *int, cmd string) *hook.Response {
+		resp, err := l.EvaluateIPC(context.Background(), &hook.Request{Command: []string{cmd}, Hook: hook.HookPreRun, PeerUID: uid})
+		require.NoError(t, err)
+		return resp
+	}
+
+	assert.False(t, start(&alice, "curl").Exit)
+	resp := start(&alice, "git")
+	assert.True(t, resp.Exit, "alice's bucket covers all her commands")
+	assert.Equal(t, "uid:1000", resp.Metadata["rate_limit_key"])
+	assert.Equal(t, "rate limit of 1 per 1h0m0s exceeded", resp.Reason)
+
+	assert.False(t, start(&bob, "curl").Exit, "bob has an independent bucket")
+	assert.True(t, start(&bob, "curl").Exit)
+
+	assert.False(t, start(nil, "curl").Exit, "unverified requests share a bucket")
+	resp = start(nil, "git")
+	assert.True(t, resp.Exit)
+	assert.Equal(t, hook.UnverifiedPeerKey, resp.Metadata["rate_limit_key"])
+}
@@ -0,0 +1,204 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// Defaults for a Remote hook
+const (
+	DefaultRemoteTimeout    = 5 * time.Second
+	DefaultRemoteRetries    = 2
+	DefaultRemoteRetryDelay = 100 * time.Millisecond
+
+	// remoteUnavailableExitCode is the exit code of commands denied because
+	// the policy server could not be reached
+	remoteUnavailableExitCode = 1
+
+	// maxRemoteResponseBytes caps policy server responses
+	maxRemoteResponseBytes = 1024 * 1024 // 1 MiB
+)
+
+// Remote is an IPCHook that forwards every request to an external policy
+// server and applies its decision, so the policy logic can live entirely
+// outside the Go program.
+//
+// Each request is POSTed as a JSON hook.Request; the server answers 200 OK
+// with a JSON hook.Response. Connection errors and 5xx responses are retried.
+// When the server cannot be reached, pre-run requests are denied with exit
+// code 1 unless the hook is configured to fail open; the command is skipped
+// but the rest of the session keeps running. Post-run requests are allowed.
+// If the evaluation context expires, its error is returned so the
+// interceptor's timeout decision applies.
+type Remote struct {
+	url        string
+	client     *http.Client
+	commands   []string
+	retries    int
+	retryDelay time.Duration
+	failOpen   bool
+}
+
+// RemoteOption configures a Remote hook
+type RemoteOption func(*Remote)
+
+// WithRemoteCommands limits the hook to the given commands (default "*")
+func WithRemoteCommands(commands ...string) RemoteOption {
+	return func(r *Remote) {
+		r.commands = commands
+	}
+}
+
+// WithRemoteTimeout bounds each attempt to reach the policy server
+func WithRemoteTimeout(d time.Duration) RemoteOption {
+	return func(r *Remote) {
+		if d > 0 {
+			r.client.Timeout = d
+		}
+	}
+}
+
+// WithRemoteRetries sets how many times a failed attempt is retried, waiting
+// delay times the attempt number between attempts
+func WithRemoteRetries(n int, delay time.Duration) RemoteOption {
+	return func(r *Remote) {
+		if n >= 0 {
+			r.retries = n
+		}
+		if delay >= 0 {
+			r.retryDelay = delay
+		}
+	}
+}
+
+// WithRemoteFailOpen allows commands when the policy server cannot be
+// reached, instead of denying them
+func WithRemoteFailOpen(failOpen bool) RemoteOption {
+	return func(r *Remote) {
+		r.failOpen = failOpen
+	}
+}
+
+// WithRemoteHTTPClient sets the HTTP client used to reach the policy server,
+// e.g. to configure TLS. The client's Timeout bounds each attempt.
+func WithRemoteHTTPClient(c *http.Client) RemoteOption {
+	return func(r *Remote) {
+		if c != nil {
+			r.client = c
+		}
+	}
+}
+
+// NewRemote creates a hook that forwards requests to the policy server at
+// addr, a URL such as "http://policy.internal:8080/evaluate". An address
+// without a scheme is treated as http.
+func NewRemote(addr string, opts ...RemoteOption) *Remote {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	r := &Remote{
+		url:        addr,
+		client:     &http.Client{Timeout: DefaultRemoteTimeout},
+		commands:   []string{"*"},
+		retries:    DefaultRemoteRetries,
+		retryDelay: DefaultRemoteRetryDelay,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Name returns the hook name
+func (r *Remote) Name() string {
+	return "remote-policy"
+}
+
+// Commands returns the list of commands this hook handles
+func (r *Remote) Commands() []string {
+	return r.commands
+}
+
+// EvaluateIPC asks the policy server for a decision on req
+func (r *Remote) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= r.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * r.retryDelay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		resp, retry, err := r.post(ctx, body)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+
+	return r.unavailable(req, lastErr), nil
+}
+
+// post makes one attempt to reach the policy server. It reports whether a
+// failed attempt is worth retrying.
+func (r *Remote) post(ctx context.Context, body []byte) (*hook.Response, bool, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := r.client.Do(httpReq)
+	if err != nil {
+		return nil, true, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(httpResp.Body, maxRemoteResponseBytes))
+		return nil, httpResp.StatusCode >= 500, fmt.Errorf("policy server returned %s", httpResp.Status)
+	}
+
+	var resp hook.Response
+	if err := json.NewDecoder(io.LimitReader(httpResp.Body, maxRemoteResponseBytes)).Decode(&resp); err != nil {
+		return nil, false, fmt.Errorf("invalid policy server response: %w", err)
+	}
+	return &resp, false, nil
+}
+
+// unavailable returns the fail-open or fail-closed decision used when the
+// policy server could not be reached. Failing closed denies only the
+// command, so an outage does not end the session. Post-run requests are
+// always allowed, since the command already ran.
+func (r *Remote) unavailable(req *hook.Request, err error) *hook.Response {
+	resp := &hook.Response{
+		Reason:   "policy server unavailable",
+		Metadata: map[string]interface{}{"remote_error": err.Error()},
+	}
+	if !r.failOpen && req.Hook != hook.HookPostRun {
+		code := remoteUnavailableExitCode
+		resp.ExitCode = &code
+	}
+	return resp
+}
@@ -0,0 +1,165 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// fakePolicyServer blocks requests for the named commands and records the
+// requests it receives
+func fakePolicyServer(t *testing.T, blocked ...string) (*httptest.Server, *[]hook.Request) {
+	var received []hook.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		var req hook.Request
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&req)) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		received = append(received, req)
+
+		resp := hook.Response{Metadata: map[string]interface{}{"rule": "default-allow"}}
+		for _, b := range blocked {
+			if req.Command[0] == b {
+				resp = hook.Response{Exit: true, Metadata: map[string]interface{}{"rule": "deny-" + b}}
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &received
+}
+
+func TestRemote(t *testing.T) {
+	srv, received := fakePolicyServer(t, "curl")
+	remote := NewRemote(srv.URL)
+
+	tests := []struct {
+		name     string
+		command  []string
+		wantExit bool
+		wantRule string
+	}{
+		{name: "allowed", command: []string{"ls", "-la"}, wantExit: false, wantRule: "default-allow"},
+		{name: "blocked", command: []string{"curl", "https://example.com"}, wantExit: true, wantRule: "deny-curl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &hook.Request{Command: tt.command, PID: 42, Hook: hook.HookPreRun}
+			resp, err := remote.EvaluateIPC(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExit, resp.Exit)
+			assert.Equal(t, tt.wantRule, resp.Metadata["rule"])
+		})
+	}
+
+	require.Len(t, *received, 2)
+	assert.Equal(t, hook.Request{Command: []string{"ls", "-la"}, PID: 42, Hook: hook.HookPreRun}, (*received)[0])
+}
+
+func TestRemoteRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	remote := NewRemote(srv.URL, WithRemoteRetries(2, time.Millisecond))
+	resp, err := remote.EvaluateIPC(context.Background(), &hook.Request{Command: []string{"ls"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.False(t, resp.Exit)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRemoteUnavailable(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	req := &hook.Request{Command: []string{"ls"}, Hook: hook.HookPreRun}
+
+	resp, err := NewRemote(srv.URL, WithRemoteRetries(3, time.Millisecond)).EvaluateIPC(context.Background(), req)
+	require.NoError(t, err)
+	assert.True(t, resp.Denied(), "fails closed by default")
+	assert.False(t, resp.Exit, "an outage does not end the session")
+	require.NotNil(t, resp.ExitCode)
+	assert.Equal(t, 1, *resp.ExitCode)
+	assert.Equal(t, "policy server unavailable", resp.Reason)
+	assert.Contains(t, resp.Metadata["remote_error"], "403")
+	assert.Equal(t, int32(1), calls.Load(), "client errors are not retried")
+
+	resp, err = NewRemote(srv.URL, WithRemoteFailOpen(true)).EvaluateIPC(context.Background(), req)
+	require.NoError(t, err)
+	assert.False(t, resp.Denied())
+
+	resp, err = NewRemote(srv.URL).EvaluateIPC(context.Background(), &hook.Request{Command: []string{"ls"}, Hook: hook.HookPostRun})
+	require.NoError(t, err)
+	assert.False(t, resp.Denied(), "commands that already ran are not blocked")
+
+	// Unreachable server
+	srv.Close()
+	resp, err = NewRemote(srv.URL, WithRemoteRetries(1, time.Millisecond)).EvaluateIPC(context.Background(), req)
+	require.NoError(t, err)
+	assert.True(t, resp.Denied())
+}
+
+func TestRemoteContextDeadline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := NewRemote(srv.URL, WithRemoteFailOpen(true)).EvaluateIPC(ctx, &hook.Request{Command: []string{"ls"}, Hook: hook.HookPreRun})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRemoteAttemptTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	remote := NewRemote(srv.URL, WithRemoteTimeout(20*time.Millisecond), WithRemoteRetries(0, 0))
+	resp, err := remote.EvaluateIPC(context.Background(), &hook.Request{Command: []string{"ls"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.True(t, resp.Denied())
+}
+
+func TestNewRemote(t *testing.T) {
+	remote := NewRemote("policy.internal:8080/evaluate")
+	assert.Equal(t, "http://policy.internal:8080/evaluate", remote.url)
+	assert.Equal(t, []string{"*"}, remote.Commands())
+	assert.Equal(t, "remote-policy", remote.Name())
+
+	remote = NewRemote("https://policy.internal", WithRemoteCommands("curl", "wget"))
+	assert.True(t, strings.HasPrefix(remote.url, "https://"))
+	assert.Equal(t, []string{"curl", "wget"}, remote.Commands())
+}
@@ -0,0 +1,67 @@
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// WithArgv0Check enables argv[0] spoofing detection: before pre-run
+// evaluation the command is resolved to its real binary, and the request
+// metadata records the resolved path and whether argv[0] matches it
+func WithArgv0Check(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.Argv0Check = enabled
+	}
+}
+
+// lookPath finds the real command using the clean PATH to avoid resolving to
+// the wrapper itself. It returns the path and the original PATH.
+func (w *WrapperCommand) lookPath(cmd string) (string, string, error) {
+	realCmd, err := lookPathIn(cmd, w.getCleanPath())
+	return realCmd, os.Getenv("PATH"), err
+}
+
+// resolveBinary returns the absolute path PATH lookup finds cmd at, and the
+// absolute, symlink-free path of the binary it runs
+func (w *WrapperCommand) resolveBinary(cmd string) (lookup, resolved string, err error) {
+	path, _, err := w.lookPath(cmd)
+	if err != nil {
+		return "", "", err
+	}
+	if lookup, err = filepath.Abs(path); err != nil {
+		return "", "", err
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return "", "", err
+	}
+	if resolved, err = filepath.Abs(path); err != nil {
+		return "", "", err
+	}
+	return lookup, resolved, nil
+}
+
+// argv0Mismatch reports whether argv0 names a different program than the
+// resolved binary
+func (w *WrapperCommand) argv0Mismatch(argv0, resolved string) bool {
+	name, binary := filepath.Base(argv0), filepath.Base(resolved)
+	if w.CaseInsensitive {
+		return !strings.EqualFold(name, binary)
+	}
+	return name != binary
+}
+
+// checkArgv0 records the resolved binary and any argv[0] mismatch in
+// metadata. Nothing is recorded if the binary was not found.
+func (w *WrapperCommand) checkArgv0(argv0, resolved string, metadata map[string]any) {
+	if resolved == "" {
+		return
+	}
+	metadata[hook.MetadataResolvedPath] = resolved
+	if w.argv0Mismatch(argv0, resolved) {
+		metadata[hook.MetadataArgv0Mismatch] = true
+		w.logf("argv[0] %s does not match resolved binary %s", argv0, resolved)
+	}
+}
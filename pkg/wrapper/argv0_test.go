@@ -0,0 +1,111 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_Argv0Check(t *testing.T) {
+	truePath, err := exec.LookPath("true")
+	require.NoError(t, err)
+	realTrue, err := filepath.EvalSymlinks(truePath)
+	require.NoError(t, err)
+
+	// "mytool" masquerades as true; "true" is a symlink that keeps its name
+	dir := t.TempDir()
+	require.NoError(t, os.Symlink(realTrue, filepath.Join(dir, "mytool")))
+	require.NoError(t, os.Symlink(realTrue, filepath.Join(dir, "true")))
+	t.Setenv("PATH", dir)
+
+	tests := []struct {
+		name         string
+		command      string
+		check        bool
+		wantMismatch bool
+		wantResolved bool
+	}{
+		{name: "symlink with a different name", command: "mytool", check: true, wantMismatch: true, wantResolved: true},
+		{name: "symlink with the same name", command: "true", check: true, wantResolved: true},
+		{name: "disabled", command: "mytool", check: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var preRun *hook.Request
+			h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+				if req.Hook == hook.HookPreRun {
+					preRun = req
+				}
+			}}
+
+			w := NewWrapperCommand(h, WithArgv0Check(tt.check))
+			require.NoError(t, w.Run([]string{tt.command}))
+			require.NotNil(t, preRun)
+
+			_, mismatch := preRun.Metadata[hook.MetadataArgv0Mismatch]
+			assert.Equal(t, tt.wantMismatch, mismatch)
+			if tt.wantResolved {
+				assert.Equal(t, realTrue, preRun.Metadata[hook.MetadataResolvedPath])
+			} else {
+				assert.NotContains(t, preRun.Metadata, hook.MetadataResolvedPath)
+			}
+		})
+	}
+}
+
+func TestWrapperCommand_Argv0MismatchCaseInsensitive(t *testing.T) {
+	w := NewWrapperCommand(nil)
+	assert.True(t, w.argv0Mismatch("Curl", "/usr/bin/curl"))
+
+	w = NewWrapperCommand(nil, WithCaseInsensitiveMatching(true))
+	assert.False(t, w.argv0Mismatch("Curl", "/usr/bin/curl"))
+	assert.True(t, w.argv0Mismatch("curl", "/usr/bin/busybox"))
+}
+
+func TestWrapperCommand_Argv0CheckUnresolvable(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	var preRun *hook.Request
+	h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+		if req.Hook == hook.HookPreRun {
+			preRun = req
+		}
+	}}
+	w := NewWrapperCommand(h, WithArgv0Check(true))
+
+	err := w.Run([]string{"does-not-exist"})
+	assert.Equal(t, ExitCodeNotFound, exitCodeOf(t, err))
+	require.NotNil(t, preRun, "the hook still decides")
+	assert.Empty(t, preRun.ResolvedPath)
+	assert.NotContains(t, preRun.Metadata, hook.MetadataResolvedPath)
+	assert.NotContains(t, preRun.Metadata, hook.MetadataArgv0Mismatch)
+}
+
+func TestWrapperCommand_ResolvedPath(t *testing.T) {
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "tool")
+	require.NoError(t, os.WriteFile(tool, []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.Symlink(tool, filepath.Join(dir, "alias")))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	want, err := filepath.EvalSymlinks(tool)
+	require.NoError(t, err)
+
+	socketPath, lines := captureSocket(t)
+	require.NoError(t, NewWrapperCommand(nil, WithSocketPath(socketPath)).Run([]string{"alias"}))
+
+	for _, hookType := range []hook.HookType{hook.HookPreRun, hook.HookPostRun} {
+		var req hook.Request
+		require.NoError(t, json.Unmarshal([]byte(<-lines), &req))
+		assert.Equal(t, hookType, req.Hook)
+		assert.Equal(t, []string{"alias"}, req.Command, "the command is sent as typed")
+		assert.Equal(t, want, req.ResolvedPath, "the binary is resolved through PATH and symlinks")
+		assert.Equal(t, filepath.Join(dir, "alias"), req.LookupPath, "the PATH entry is sent as found")
+	}
+}
@@ -0,0 +1,46 @@
+package wrapper
+
+import (
+	"maps"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// WithEvaluationAttribution records which stage decided each request, and
+// what each stage returned, under hook.MetadataAttribution
+func WithEvaluationAttribution(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.Attribution = enabled
+	}
+}
+
+// attribute returns a copy of resp whose metadata records that stage made
+// the decision, given the local and IPC stage responses. Attribution added
+// by the interceptor is kept.
+func (w *WrapperCommand) attribute(resp *hook.Response, stage string, local, ipc *hook.Response, ipcSkipped bool) *hook.Response {
+	if !w.Attribution {
+		return resp
+	}
+
+	attribution := make(map[string]interface{})
+	if existing, ok := resp.Metadata[hook.MetadataAttribution].(map[string]interface{}); ok {
+		maps.Copy(attribution, existing)
+	}
+	attribution["decided_by"] = stage
+	attribution[hook.StageLocal] = local.Decision()
+	attribution[hook.StageIPC] = ipc.Decision()
+	if ipcSkipped {
+		attribution[hook.StageIPC] = hook.DecisionSkipped
+	}
+
+	metadata := maps.Clone(resp.Metadata)
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata[hook.MetadataAttribution] = attribution
+
+	w.logf("Decision by %s (local: %v, ipc: %v)", stage, attribution[hook.StageLocal], attribution[hook.StageIPC])
+	out := *resp
+	out.Metadata = metadata
+	return &out
+}
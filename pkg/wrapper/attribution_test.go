@@ -0,0 +1,81 @@
+package wrapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_EvaluationAttribution(t *testing.T) {
+	blockingLocal := newMockLocalHook("local", []string{"curl"})
+	blockingLocal.allowAll = false
+
+	req := func() *hook.Request {
+		return &hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun}
+	}
+
+	t.Run("local short-circuit", func(t *testing.T) {
+		socketPath, lines := captureSocket(t)
+		w := NewWrapperCommand(blockingLocal, WithSocketPath(socketPath), WithEvaluationAttribution(true))
+
+		resp, err := w.evaluateHooks(req())
+		require.NoError(t, err)
+		assert.True(t, resp.Exit)
+		assert.Equal(t, map[string]interface{}{
+			"decided_by":    hook.StageLocal,
+			hook.StageLocal: hook.DecisionBlock,
+			hook.StageIPC:   hook.DecisionSkipped,
+		}, resp.Metadata[hook.MetadataAttribution])
+		assert.Empty(t, lines, "IPC is not consulted")
+	})
+
+	t.Run("IPC decides after local allow", func(t *testing.T) {
+		socketPath, lines := captureSocket(t)
+		w := NewWrapperCommand(newMockLocalHook("local", []string{"curl"}), WithSocketPath(socketPath), WithEvaluationAttribution(true))
+
+		resp, err := w.evaluateHooks(req())
+		require.NoError(t, err)
+		assert.False(t, resp.Exit)
+		assert.Equal(t, map[string]interface{}{
+			"decided_by":    hook.StageIPC,
+			hook.StageLocal: hook.DecisionAllow,
+			hook.StageIPC:   hook.DecisionAllow,
+		}, resp.Metadata[hook.MetadataAttribution])
+
+		// The interceptor learns what the local stage decided
+		assert.Contains(t, <-lines, `"attribution":{"local":"allow"}`)
+	})
+
+	t.Run("local only", func(t *testing.T) {
+		w := NewWrapperCommand(newMockLocalHook("local", []string{"curl"}), WithEvaluationAttribution(true))
+
+		resp, err := w.evaluateHooks(req())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"decided_by":    hook.StageLocal,
+			hook.StageLocal: hook.DecisionAllow,
+			hook.StageIPC:   hook.DecisionNone,
+		}, resp.Metadata[hook.MetadataAttribution])
+	})
+
+	t.Run("no hook", func(t *testing.T) {
+		w := NewWrapperCommand(nil, WithEvaluationAttribution(true))
+
+		resp, err := w.evaluateHooks(req())
+		require.NoError(t, err)
+		assert.Equal(t, hook.StageDefault, resp.Metadata[hook.MetadataAttribution].(map[string]interface{})["decided_by"])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		socketPath, lines := captureSocket(t)
+		w := NewWrapperCommand(newMockLocalHook("local", []string{"curl"}), WithSocketPath(socketPath))
+
+		resp, err := w.evaluateHooks(req())
+		require.NoError(t, err)
+		assert.NotContains(t, resp.Metadata, hook.MetadataAttribution)
+		assert.NotContains(t, <-lines, "attribution")
+	})
+}
@@ -0,0 +1,216 @@
+package wrapper
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// RunBatch evaluates a shell command line as a single pre-run batch request.
+// Lines holding fewer than two commands are not sent, since the commands are
+// evaluated individually when they run anyway. It is called by the BASH_ENV
+// shim installed by cmdhooks, through `cmdhooks run -batch`.
+func RunBatch(line string, opts ...WrapperOption) error {
+	envOpts, err := optionsFromEnv()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, envOpts...)
+
+	commands := ParseCommandList(line)
+	if len(commands) < 2 {
+		return nil
+	}
+
+	w := NewWrapperCommand(nil, opts...)
+	return w.RunBatch(commands)
+}
+
+// RunBatch sends commands to the hooks as one pre-run batch request and
+// returns an error if the hooks deny it
+func (w *WrapperCommand) RunBatch(commands [][]string) error {
+	if len(commands) == 0 {
+		return nil
+	}
+	for _, command := range commands {
+		if err := validateCommand(command); err != nil {
+			return err
+		}
+	}
+
+	w.logf("Evaluating batch of %d commands: %v", len(commands), commands)
+
+	req := &hook.Request{
+		Command:    commands[0],
+		PID:        os.Getpid(),
+		WorkingDir: w.workingDir(),
+		Env:        w.requestEnv(),
+		UID:        reportedUID(),
+		GID:        reportedGID(),
+		Hook:       hook.HookPreRunBatch,
+		Batch:      commands,
+	}
+
+	response, err := w.evaluateHooks(req)
+	if err != nil {
+		return fmt.Errorf("pre-run batch hook evaluation error: %w", err)
+	}
+
+	// A batch denied with an exit code stops the command list like any
+	// other denial
+	if response.Denied() {
+		parts := make([]string, len(commands))
+		for i, command := range commands {
+			parts[i] = strings.Join(command, " ")
+		}
+		return w.terminationError(strings.Join(parts, "; "), response)
+	}
+
+	return nil
+}
+
+// hookHandlesRequest checks if a hook handles the command in req, or for a
+// batch request, any of its commands
+func (w *WrapperCommand) hookHandlesRequest(hookCommands []string, req *hook.Request) bool {
+	if req.Hook != hook.HookPreRunBatch {
+		return len(req.Command) > 0 && w.hookHandlesCommand(hookCommands, req.Command[0])
+	}
+	for _, command := range req.Batch {
+		if len(command) > 0 && w.hookHandlesCommand(hookCommands, command[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// shellWord is a word of a command line; quoted words are never treated as
+// operators, reserved words or redirections
+type shellWord struct {
+	text   string
+	quoted bool
+}
+
+var (
+	// assignmentPattern matches a leading NAME=value variable assignment
+	assignmentPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+	// skippedWords may precede a command without being part of it
+	skippedWords = map[string]bool{
+		"!": true, "{": true, "}": true, "time": true,
+		"if": true, "then": true, "elif": true, "else": true, "fi": true,
+		"while": true, "until": true, "do": true, "done": true,
+	}
+
+	// compoundWords start a construct that is not itself a command
+	compoundWords = map[string]bool{"for": true, "select": true, "case": true, "esac": true, "function": true}
+)
+
+// ParseCommandList splits a shell command line into the argv of each
+// simple command it runs, e.g. "a -x && b | c" gives [[a -x] [b] [c]].
+//
+// It understands quoting, comments, the list and pipeline operators,
+// subshell parentheses, leading variable assignments, redirections and the
+// common reserved words. Expansions such as $VAR and $(...) are left as
+// written, so the result approximates what the shell will run.
+func ParseCommandList(line string) [][]string {
+	var (
+		commands [][]string
+		words    []shellWord
+		word     strings.Builder
+		inWord   bool
+		quoted   bool
+	)
+
+	endWord := func() {
+		if inWord {
+			words = append(words, shellWord{text: word.String(), quoted: quoted})
+		}
+		word.Reset()
+		inWord, quoted = false, false
+	}
+	endCommand := func() {
+		endWord()
+		if argv := commandArgv(words); len(argv) > 0 {
+			commands = append(commands, argv)
+		}
+		words = nil
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\'':
+			inWord, quoted = true, true
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				word.WriteString(line[i+1:])
+				i = len(line)
+			} else {
+				word.WriteString(line[i+1 : i+1+end])
+				i += end + 1
+			}
+		case c == '"':
+			inWord, quoted = true, true
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$`\n", line[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+		case c == '\\':
+			inWord, quoted = true, true
+			if i+1 < len(line) {
+				i++
+				if line[i] != '\n' {
+					word.WriteByte(line[i])
+				}
+			}
+		case c == ' ' || c == '\t':
+			endWord()
+		case c == '#' && !inWord:
+			endCommand()
+			return commands
+		case c == '&' && inWord && !quoted && strings.ContainsAny(word.String()[word.Len()-1:], "<>"):
+			// Part of a redirection such as 2>&1
+			word.WriteByte(c)
+		case strings.IndexByte(";&|()\n", c) >= 0:
+			endCommand()
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+	endCommand()
+	return commands
+}
+
+// commandArgv strips the words of a simple command that are not part of its
+// argv: reserved words and assignments before it, and redirections
+func commandArgv(words []shellWord) []string {
+	for len(words) > 0 && !words[0].quoted &&
+		(skippedWords[words[0].text] || assignmentPattern.MatchString(words[0].text)) {
+		words = words[1:]
+	}
+	if len(words) == 0 || (!words[0].quoted && compoundWords[words[0].text]) {
+		return nil
+	}
+
+	var argv []string
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if !w.quoted {
+			op := strings.TrimLeft(w.text, "0123456789")
+			if strings.HasPrefix(op, "<") || strings.HasPrefix(op, ">") {
+				if strings.Trim(op, "<>&|") == "" {
+					i++ // the target is the next word
+				}
+				continue
+			}
+		}
+		argv = append(argv, w.text)
+	}
+	return argv
+}
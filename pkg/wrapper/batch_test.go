@@ -0,0 +1,74 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestParseCommandList(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want [][]string
+	}{
+		{name: "single command", line: "ls -la", want: [][]string{{"ls", "-la"}}},
+		{name: "and list", line: "make && make test && make install", want: [][]string{{"make"}, {"make", "test"}, {"make", "install"}}},
+		{name: "pipeline and sequence", line: "cat f | grep x; echo done || true", want: [][]string{{"cat", "f"}, {"grep", "x"}, {"echo", "done"}, {"true"}}},
+		{name: "quoting", line: `echo 'a && b' "c | \"d\"" e\;f && rm -rf "$DIR"`, want: [][]string{{"echo", "a && b", `c | "d"`, "e;f"}, {"rm", "-rf", "$DIR"}}},
+		{name: "comment", line: "ls && pwd # && rm -rf /", want: [][]string{{"ls"}, {"pwd"}}},
+		{name: "redirections", line: "make >build.log 2>&1 && tar -cf out.tar . > /dev/null", want: [][]string{{"make"}, {"tar", "-cf", "out.tar", "."}}},
+		{name: "assignments and subshell", line: "FOO=1 BAR=2 env && (cd /tmp && ls) &", want: [][]string{{"env"}, {"cd", "/tmp"}, {"ls"}}},
+		{name: "reserved words", line: "if test -f x; then rm x; fi", want: [][]string{{"test", "-f", "x"}, {"rm", "x"}}},
+		{name: "loop header", line: "for f in *.go; do gofmt -l $f; done", want: [][]string{{"gofmt", "-l", "$f"}}},
+		{name: "quoted empty argument", line: `grep "" f`, want: [][]string{{"grep", "", "f"}}},
+		{name: "empty", line: "  ", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseCommandList(tt.line))
+		})
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	socketPath, lines := captureSocket(t)
+
+	w := NewWrapperCommand(nil, WithSocketPath(socketPath))
+	commands := ParseCommandList("git fetch && git reset --hard origin/main | tee log")
+	require.NoError(t, w.RunBatch(commands))
+
+	var req hook.Request
+	require.NoError(t, json.Unmarshal([]byte(<-lines), &req))
+	assert.Equal(t, hook.HookPreRunBatch, req.Hook)
+	assert.Equal(t, [][]string{{"git", "fetch"}, {"git", "reset", "--hard", "origin/main"}, {"tee", "log"}}, req.Batch)
+	assert.Equal(t, []string{"git", "fetch"}, req.Command)
+	assert.Empty(t, lines, "the batch is sent as a single request")
+}
+
+func TestRunBatch_LocalHook(t *testing.T) {
+	h := &mockLocalHook{
+		name:      "make-policy",
+		commands:  []string{"make"},
+		responses: map[string]*hook.Response{"rm:pre_run_batch": {Exit: true}},
+	}
+	w := NewWrapperCommand(h)
+
+	// The hook handles the batch through its second command
+	err := w.RunBatch([][]string{{"rm", "-rf", "build"}, {"make"}})
+	assert.EqualError(t, err, "process termination requested")
+	assert.Equal(t, 1, h.evalCount)
+
+	require.NoError(t, w.RunBatch([][]string{{"true"}, {"false"}}))
+	assert.Equal(t, 1, h.evalCount, "batches without a handled command skip the local hook")
+
+	// Denying with an exit code also stops the batch
+	code := 3
+	h.responses["rm:pre_run_batch"] = &hook.Response{ExitCode: &code}
+	assert.Error(t, w.RunBatch([][]string{{"rm", "-rf", "build"}, {"make"}}))
+}
@@ -0,0 +1,42 @@
+package wrapper
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// WithChaos makes the wrapper honor failures injected by the interceptor's
+// chaos testing (see hook.ChaosFail): the command is not run and its
+// post-run request reports the injected exit code. Without it, injected
+// failures are ignored and the command runs.
+func WithChaos(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.Chaos = enabled
+	}
+}
+
+// injectedFailure returns the exit code of a failure injected into a
+// pre-run response, if the wrapper honors them
+func (w *WrapperCommand) injectedFailure(resp *hook.Response) (int, bool) {
+	if !w.Chaos || resp.Metadata[hook.MetadataChaos] != hook.ChaosFail {
+		return 0, false
+	}
+	// JSON numbers decode as float64
+	switch code := resp.Metadata[hook.MetadataChaosExitCode].(type) {
+	case float64:
+		return int(code), true
+	case int:
+		return code, true
+	}
+	return 1, true
+}
+
+// chaosResult stands in for running command when chaos testing failed it,
+// recording the injection in the post-run metadata
+func (w *WrapperCommand) chaosResult(command string, metadata map[string]any) commandResult {
+	fmt.Fprintf(os.Stderr, "Chaos testing: injected failure of %s (exit code %d)\n", command, w.chaosExitCode)
+	metadata[hook.MetadataChaos] = hook.ChaosFail
+	return commandResult{exitCode: w.chaosExitCode, exitReason: hook.ExitReasonChaos}
+}
@@ -0,0 +1,63 @@
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_ChaosFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		chaos    bool
+		wantRun  bool
+		wantCode int
+	}{
+		{name: "ignored unless enabled", chaos: false, wantRun: true, wantCode: 0},
+		{name: "command skipped", chaos: true, wantRun: false, wantCode: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marker := filepath.Join(t.TempDir(), "ran")
+			var postRun *hook.Request
+			localHook := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+				if req.Hook == hook.HookPostRun {
+					postRun = req
+				}
+			}}
+			socketPath := serveResponse(t, `{"metadata":{"chaos":"fail","chaos_exit_code":3}}`)
+
+			w := NewWrapperCommand(localHook, WithSocketPath(socketPath), WithChaos(tt.chaos))
+			exitCode := exitCodeOf(t, w.Run([]string{"touch", marker}))
+
+			_, statErr := os.Stat(marker)
+			assert.Equal(t, tt.wantRun, statErr == nil)
+			assert.Equal(t, tt.wantCode, exitCode)
+			require.NotNil(t, postRun)
+			assert.Equal(t, tt.wantCode, postRun.ExitCode)
+			if tt.chaos {
+				assert.Equal(t, hook.ExitReasonChaos, postRun.ExitReason)
+				assert.Equal(t, hook.ChaosFail, postRun.Metadata[hook.MetadataChaos])
+			} else {
+				assert.NotContains(t, postRun.Metadata, hook.MetadataChaos)
+			}
+		})
+	}
+}
+
+func TestChaosFromEnv(t *testing.T) {
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	assert.False(t, NewWrapperCommand(nil, opts...).Chaos, "off by default")
+
+	t.Setenv(EnvChaos, "true")
+	opts, err = optionsFromEnv()
+	require.NoError(t, err)
+	assert.True(t, NewWrapperCommand(nil, opts...).Chaos)
+}
@@ -0,0 +1,38 @@
+package wrapper
+
+import (
+	"os"
+	"sync"
+)
+
+// cleanupRegistry tracks teardown actions, such as removing temp artifacts,
+// that must run before the wrapper exits. Actions run in reverse order of
+// registration and each runs exactly once, even if run is called again.
+type cleanupRegistry struct {
+	mu    sync.Mutex
+	funcs []func()
+}
+
+// add registers a cleanup action
+func (r *cleanupRegistry) add(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.funcs = append(r.funcs, fn)
+}
+
+// addFile registers a file to be removed on cleanup
+func (r *cleanupRegistry) addFile(path string) {
+	r.add(func() { os.Remove(path) })
+}
+
+// run executes and clears all registered actions
+func (r *cleanupRegistry) run() {
+	r.mu.Lock()
+	funcs := r.funcs
+	r.funcs = nil
+	r.mu.Unlock()
+
+	for i := len(funcs) - 1; i >= 0; i-- {
+		funcs[i]()
+	}
+}
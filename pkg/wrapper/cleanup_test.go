@@ -0,0 +1,78 @@
+package wrapper
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestCleanupRegistry(t *testing.T) {
+	var r cleanupRegistry
+	var order []int
+	r.add(func() { order = append(order, 1) })
+	r.add(func() { order = append(order, 2) })
+
+	r.run()
+	r.run()
+	assert.Equal(t, []int{2, 1}, order, "actions run once, in reverse order")
+}
+
+func TestWrapperCommand_NoTempArtifactsRemain(t *testing.T) {
+	blockPostRun := newMockLocalHook("test", []string{"sh"})
+	blockPostRun.allowAll = false
+	blockPostRun.responses["sh:"+string(hook.HookPreRun)] = &hook.Response{}
+
+	tests := []struct {
+		name     string
+		hook     hook.Hook
+		command  []string
+		wantErr  bool
+		wantExit int
+	}{
+		{
+			name:    "normal exit",
+			command: []string{"sh", "-c", "echo out; echo err >&2"},
+		},
+		{
+			name:     "non-zero exit",
+			command:  []string{"sh", "-c", "echo out; exit 3"},
+			wantExit: 3,
+		},
+		{
+			name:    "blocked post-run",
+			hook:    blockPostRun,
+			command: []string{"sh", "-c", "echo out"},
+			wantErr: true,
+		},
+		{
+			name:     "command not found",
+			command:  []string{"cmdhooks-definitely-missing-command"},
+			wantExit: ExitCodeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("TMPDIR", tmpDir)
+
+			cleanups := 0
+			wrapper := NewWrapperCommand(tt.hook, WithDeferredCleanup(func() { cleanups++ }))
+			err := wrapper.Run(tt.command)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.Equal(t, tt.wantExit, exitCodeOf(t, err))
+			}
+			assert.Equal(t, 1, cleanups, "cleanup should run exactly once")
+
+			entries, err := os.ReadDir(tmpDir)
+			require.NoError(t, err)
+			assert.Empty(t, entries, "temp artifacts should be removed")
+		})
+	}
+}
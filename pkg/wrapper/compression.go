@@ -0,0 +1,48 @@
+package wrapper
+
+import (
+	"fmt"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// WithRequestCompression offers compression to the interceptor in the first
+// request of the shared connection (see the wire protocol notes in package
+// hook). If the interceptor accepts, later messages on the connection are
+// compressed; otherwise they stay plain JSON. Connections carrying a single
+// exchange gain nothing from compression, so it only takes effect with
+// WithSharedConnection.
+func WithRequestCompression(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.RequestCompression = enabled
+	}
+}
+
+// offerEncoding adds the compression offer to the metadata of req
+func offerEncoding(req hook.Request) hook.Request {
+	metadata := make(map[string]interface{}, len(req.Metadata)+1)
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	metadata[hook.MetadataAcceptEncoding] = []string{hook.EncodingGzip}
+	req.Metadata = metadata
+	return req
+}
+
+// acceptEncoding switches the connection to the encoding the interceptor
+// announced in resp, if any, and removes the announcement
+func (c *hookConn) acceptEncoding(resp *hook.Response) error {
+	encoding, ok := resp.Metadata[hook.MetadataEncoding].(string)
+	if !ok {
+		return nil
+	}
+	delete(resp.Metadata, hook.MetadataEncoding)
+	if len(resp.Metadata) == 0 {
+		resp.Metadata = nil
+	}
+	if encoding != hook.EncodingGzip {
+		return fmt.Errorf("interceptor chose unsupported encoding %q", encoding)
+	}
+	c.encoding = encoding
+	return nil
+}
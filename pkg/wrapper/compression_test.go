@@ -0,0 +1,124 @@
+package wrapper
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// encodingServer accepts compression and keep-alive whenever the first
+// request of a connection offers them, recording the raw messages received
+type encodingServer struct {
+	mu  sync.Mutex
+	raw [][]byte
+}
+
+func (s *encodingServer) serve(t *testing.T) string {
+	socketPath := filepath.Join(t.TempDir(), "enc.sock")
+	l, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				encoding := ""
+				for first := true; scanner.Scan(); first = false {
+					s.mu.Lock()
+					s.raw = append(s.raw, append([]byte(nil), scanner.Bytes()...))
+					s.mu.Unlock()
+
+					data, err := hook.DecodeMessage(scanner.Bytes(), MaxIPCMessageBytes)
+					if err != nil {
+						return
+					}
+					var req hook.Request
+					if json.Unmarshal(data, &req) != nil {
+						return
+					}
+					resp := hook.Response{Metadata: map[string]interface{}{}}
+					if first && hook.AcceptsEncoding(req.Metadata, hook.EncodingGzip) {
+						resp.Metadata[hook.MetadataEncoding] = hook.EncodingGzip
+					}
+					if first && hook.ProtocolVersionOf(req.Metadata) >= 2 {
+						resp.Metadata[hook.MetadataProtocolVersion] = hook.ProtocolVersion
+					}
+					out, _ := json.Marshal(resp)
+					out, _ = hook.EncodeMessage(out, encoding)
+					conn.Write(append(out, '\n'))
+					if _, ok := resp.Metadata[hook.MetadataEncoding]; ok {
+						encoding = hook.EncodingGzip
+					}
+				}
+			}()
+		}
+	}()
+	return socketPath
+}
+
+func TestWrapperCommand_RequestCompression(t *testing.T) {
+	tests := []struct {
+		name           string
+		compression    bool
+		wantCompressed bool
+	}{
+		{name: "plain by default", compression: false, wantCompressed: false},
+		{name: "compressed after negotiation", compression: true, wantCompressed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &encodingServer{}
+			w := NewWrapperCommand(nil, WithSocketPath(srv.serve(t)), WithSharedConnection(true), WithRequestCompression(tt.compression))
+			require.NoError(t, w.Run([]string{"true"}))
+
+			srv.mu.Lock()
+			defer srv.mu.Unlock()
+			require.Len(t, srv.raw, 2)
+			var first hook.Request
+			require.NoError(t, json.Unmarshal(srv.raw[0], &first), "the first request is always plain JSON")
+			assert.Equal(t, tt.compression, hook.AcceptsEncoding(first.Metadata, hook.EncodingGzip))
+
+			assert.Equal(t, tt.wantCompressed, srv.raw[1][0] != '{')
+			data, err := hook.DecodeMessage(srv.raw[1], MaxIPCMessageBytes)
+			require.NoError(t, err)
+			var second hook.Request
+			require.NoError(t, json.Unmarshal(data, &second))
+			assert.Equal(t, hook.HookPostRun, second.Hook)
+			assert.NotContains(t, second.Metadata, hook.MetadataAcceptEncoding)
+		})
+	}
+}
+
+func TestAcceptEncoding(t *testing.T) {
+	c := &hookConn{}
+	resp := &hook.Response{Metadata: map[string]interface{}{hook.MetadataEncoding: "br"}}
+	assert.Error(t, c.acceptEncoding(resp))
+	assert.Empty(t, c.encoding)
+
+	resp = &hook.Response{Metadata: map[string]interface{}{hook.MetadataEncoding: hook.EncodingGzip, "note": "ok"}}
+	require.NoError(t, c.acceptEncoding(resp))
+	assert.Equal(t, hook.EncodingGzip, c.encoding)
+	assert.Equal(t, map[string]interface{}{"note": "ok"}, resp.Metadata)
+}
+
+func TestRequestCompressionFromEnv(t *testing.T) {
+	t.Setenv(EnvRequestCompression, "true")
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	assert.True(t, NewWrapperCommand(nil, opts...).RequestCompression)
+}
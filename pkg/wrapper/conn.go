@@ -0,0 +1,224 @@
+package wrapper
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// WithSharedConnection keeps the IPC connection open for the lifetime of
+// the wrapped command, so its pre-run and post-run requests travel over one
+// connection and IPC hooks can share per-connection state between them (see
+// hook.ConnectionStateFromContext). Interceptors predating keep-alive (see
+// the wire protocol notes in package hook) get one connection per request
+// instead.
+func WithSharedConnection(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.SharedConnection = enabled
+	}
+}
+
+// hookConn is a connection to the interceptor socket that can carry
+// several request/response exchanges
+type hookConn struct {
+	conn    net.Conn
+	network string
+	scanner *bufio.Scanner
+	// encoding applies to every message once the interceptor accepted it
+	encoding string
+	// keepAlive is set once the interceptor announced that it serves
+	// several requests per connection
+	keepAlive bool
+}
+
+// dialHook connects to the interceptor socket, over TLS if tlsConfig is set
+func dialHook(network, socketPath string, tlsConfig *tls.Config) (*hookConn, error) {
+	if network == "" {
+		network = NetworkStream
+	}
+	if tlsConfig != nil && network == NetworkSeqpacket {
+		return nil, fmt.Errorf("TLS requires a stream socket, not %s", network)
+	}
+	conn, err := dialSocket(network, socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to socket: %w", err)
+	}
+	if tlsConfig != nil {
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	c := &hookConn{conn: conn, network: network}
+	if network != NetworkSeqpacket {
+		// Read responses with a bounded scanner
+		c.scanner = bufio.NewScanner(conn)
+		c.scanner.Buffer(make([]byte, 0, 64*1024), MaxIPCMessageBytes)
+	}
+	return c, nil
+}
+
+// exchange sends a request and reads its response
+func (c *hookConn) exchange(req hook.Request) (*hook.Response, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	if data, err = hook.EncodeMessage(data, c.encoding); err != nil {
+		return nil, err
+	}
+
+	var resp *hook.Response
+	if c.network == NetworkSeqpacket {
+		resp, err = exchangePacket(c.conn, data)
+	} else {
+		resp, err = c.exchangeLine(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := c.acceptEncoding(resp); err != nil {
+		return nil, err
+	}
+	c.acceptProtocol(resp)
+	return resp, nil
+}
+
+// exchangeLine sends a newline-framed request and reads its response
+func (c *hookConn) exchangeLine(data []byte) (*hook.Response, error) {
+	if _, err := fmt.Fprintf(c.conn, "%s\n", string(data)); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if !c.scanner.Scan() {
+		if err := c.scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return nil, fmt.Errorf("no response from socket")
+	}
+	return parseResponse(c.scanner.Bytes())
+}
+
+// parseResponse decodes a response message, which may be compressed (see
+// hook.DecodeMessage)
+func parseResponse(data []byte) (*hook.Response, error) {
+	data, err := hook.DecodeMessage(data, MaxIPCMessageBytes)
+	if err != nil {
+		return nil, err
+	}
+	var resp hook.Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &resp, nil
+}
+
+// Close closes the connection
+func (c *hookConn) Close() error {
+	return c.conn.Close()
+}
+
+// errFilterReturnedNil is returned when the outbound request filter drops
+// a request, which is a configuration error rather than an IPC failure
+var errFilterReturnedNil = errors.New("outbound request filter returned nil")
+
+// filterRequest applies the outbound filter, if any, to req
+func filterRequest(req hook.Request, filter OutboundRequestFilter) (hook.Request, error) {
+	if filter == nil {
+		return req, nil
+	}
+	filtered := filter(&req)
+	if filtered == nil {
+		return req, errFilterReturnedNil
+	}
+	return *filtered, nil
+}
+
+// exchangeShared sends req over the wrapper's shared connection, opening it
+// on first use. The connection is closed with the wrapper's other temp
+// artifacts.
+func (w *WrapperCommand) exchangeShared(req hook.Request) (*hook.Response, error) {
+	req, err := filterRequest(req, w.OutboundFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.oneShot {
+		conn, err := dialHook(w.SocketNetwork, w.SocketPath, w.TLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		return conn.exchange(req)
+	}
+
+	if w.sharedConn == nil {
+		conn, err := dialHook(w.SocketNetwork, w.SocketPath, w.TLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		w.sharedConn = conn
+		w.cleanup.add(func() {
+			conn.Close()
+			w.sharedConn = nil
+		})
+		req = offerProtocol(req)
+		if w.RequestCompression {
+			req = offerEncoding(req)
+		}
+	}
+
+	conn := w.sharedConn
+	resp, err := conn.exchange(req)
+	if err == nil && !conn.keepAlive {
+		// A version 1 interceptor hangs up after answering
+		conn.Close()
+		w.sharedConn = nil
+		w.oneShot = true
+	}
+	return resp, err
+}
+
+// offerProtocol announces the wrapper's protocol version in the metadata
+// of req
+func offerProtocol(req hook.Request) hook.Request {
+	metadata := make(map[string]interface{}, len(req.Metadata)+1)
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	metadata[hook.MetadataProtocolVersion] = hook.ProtocolVersion
+	req.Metadata = metadata
+	return req
+}
+
+// acceptProtocol records whether the interceptor announced keep-alive in
+// resp, and removes the announcement
+func (c *hookConn) acceptProtocol(resp *hook.Response) {
+	if _, ok := resp.Metadata[hook.MetadataProtocolVersion]; !ok {
+		return
+	}
+	c.keepAlive = hook.ProtocolVersionOf(resp.Metadata) >= 2
+	delete(resp.Metadata, hook.MetadataProtocolVersion)
+	if len(resp.Metadata) == 0 {
+		resp.Metadata = nil
+	}
+}
+
+// newRequestID returns a random ID for correlating a command's requests
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,135 @@
+package wrapper
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// connRecorder answers every request on every connection with an allow
+// response, recording the requests by connection. It announces keep-alive to
+// wrappers offering it, unless oneShot makes it hang up after each response
+// as a version 1 interceptor does.
+type connRecorder struct {
+	oneShot bool
+
+	mu    sync.Mutex
+	conns [][]hook.Request
+}
+
+func (r *connRecorder) serve(t *testing.T) string {
+	socketPath := filepath.Join(t.TempDir(), "conn.sock")
+	l, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r.mu.Lock()
+			r.conns = append(r.conns, nil)
+			n := len(r.conns) - 1
+			r.mu.Unlock()
+
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for first := true; scanner.Scan(); first = false {
+					var req hook.Request
+					if json.Unmarshal(scanner.Bytes(), &req) == nil {
+						r.mu.Lock()
+						r.conns[n] = append(r.conns[n], req)
+						r.mu.Unlock()
+					}
+					if r.oneShot {
+						conn.Write([]byte("{}\n"))
+						return
+					}
+					if first && hook.ProtocolVersionOf(req.Metadata) >= 2 {
+						conn.Write([]byte(`{"metadata":{"protocol_version":2}}` + "\n"))
+						continue
+					}
+					conn.Write([]byte("{}\n"))
+				}
+			}()
+		}
+	}()
+	return socketPath
+}
+
+func TestWrapperCommand_SharedConnection(t *testing.T) {
+	tests := []struct {
+		name      string
+		shared    bool
+		oneShot   bool
+		wantConns int
+	}{
+		{name: "one connection per request by default", shared: false, wantConns: 2},
+		{name: "shared connection", shared: true, wantConns: 1},
+		{name: "one-shot interceptor", shared: true, oneShot: true, wantConns: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &connRecorder{oneShot: tt.oneShot}
+			w := NewWrapperCommand(nil, WithSocketPath(rec.serve(t)), WithSharedConnection(tt.shared))
+			require.NoError(t, w.Run([]string{"true"}))
+			assert.Nil(t, w.sharedConn, "the shared connection is closed after the run")
+
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			require.Len(t, rec.conns, tt.wantConns)
+			var reqs []hook.Request
+			for _, c := range rec.conns {
+				reqs = append(reqs, c...)
+			}
+			require.Len(t, reqs, 2)
+			assert.Equal(t, hook.HookPreRun, reqs[0].Hook)
+			assert.Equal(t, hook.HookPostRun, reqs[1].Hook)
+			assert.NotEmpty(t, reqs[0].RequestID)
+			assert.Equal(t, reqs[0].RequestID, reqs[1].RequestID, "pre-run and post-run share a request ID")
+			assert.Equal(t, tt.shared, hook.ProtocolVersionOf(reqs[0].Metadata) == hook.ProtocolVersion, "shared connections announce keep-alive")
+			assert.NotContains(t, reqs[1].Metadata, hook.MetadataProtocolVersion)
+		})
+	}
+}
+
+func TestSharedConnectionFromEnv(t *testing.T) {
+	t.Setenv(EnvSharedConnection, "true")
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	assert.True(t, NewWrapperCommand(nil, opts...).SharedConnection)
+}
+
+func TestWrapperCommand_RequestCorrelation(t *testing.T) {
+	socketPath, lines := captureSocket(t)
+	before := time.Now()
+	require.NoError(t, NewWrapperCommand(nil, WithSocketPath(socketPath)).Run([]string{"true"}))
+	after := time.Now()
+
+	var preRun, postRun hook.Request
+	require.NoError(t, json.Unmarshal([]byte(<-lines), &preRun))
+	require.NoError(t, json.Unmarshal([]byte(<-lines), &postRun))
+	require.Equal(t, hook.HookPreRun, preRun.Hook)
+	require.Equal(t, hook.HookPostRun, postRun.Hook)
+
+	assert.NotEmpty(t, preRun.RequestID)
+	assert.Equal(t, preRun.RequestID, postRun.RequestID, "both requests carry the command's ID")
+	assert.NotEmpty(t, preRun.CorrelationID)
+	assert.Equal(t, preRun.CorrelationID, postRun.CorrelationID, "both requests carry the command's correlation ID")
+
+	assert.True(t, preRun.StartedAt.Equal(postRun.StartedAt), "the post-run request keeps the command's start time")
+	assert.WithinRange(t, preRun.StartedAt, before, after)
+}
@@ -0,0 +1,22 @@
+package wrapper
+
+import "os"
+
+// reportedUID returns the UID the wrapper reports in requests, or nil on
+// platforms without one (os.Getuid returns -1 on Windows)
+func reportedUID() *int {
+	return nonNegative(os.Getuid())
+}
+
+// reportedGID returns the GID the wrapper reports in requests, or nil on
+// platforms without one
+func reportedGID() *int {
+	return nonNegative(os.Getgid())
+}
+
+func nonNegative(id int) *int {
+	if id < 0 {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,28 @@
+package wrapper
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_ReportsCredentials(t *testing.T) {
+	var reqs []*hook.Request
+	h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+		reqs = append(reqs, req)
+	}}
+	require.NoError(t, NewWrapperCommand(h).Run([]string{"true"}))
+
+	require.Len(t, reqs, 2)
+	for _, req := range reqs {
+		require.NotNil(t, req.UID, req.Hook)
+		require.NotNil(t, req.GID, req.Hook)
+		assert.Equal(t, os.Getuid(), *req.UID, req.Hook)
+		assert.Equal(t, os.Getgid(), *req.GID, req.Hook)
+		assert.NotContains(t, req.Metadata, hook.MetadataCredentialsVerified, "the wrapper only reports its identity")
+	}
+}
@@ -0,0 +1,32 @@
+package wrapper
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// deniedError reports a command the pre-run hook denied with an exit code
+// (see hook.Response.ExitCode)
+type deniedError struct {
+	code int
+}
+
+func (e *deniedError) Error() string {
+	return fmt.Sprintf("command denied with exit code %d", e.code)
+}
+
+// denial reports a command denied with resp.ExitCode on stderr, along with
+// the hook's reason if it gave one, and returns the error for Run
+func (w *WrapperCommand) denial(command string, resp *hook.Response) error {
+	code := *resp.ExitCode
+	if code < 0 || code > 255 {
+		return fmt.Errorf("invalid exit code %d from pre-run hook: must be between 0 and 255", code)
+	}
+	w.logf("✗ Command denied with exit code %d", code)
+	if reason := resp.BlockReason(); reason != "" {
+		fmt.Fprintf(os.Stderr, "Denied %s: %s\n", command, reason)
+	}
+	return &deniedError{code: code}
+}
@@ -0,0 +1,67 @@
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_DenyWithExitCode(t *testing.T) {
+	code := 77
+	tests := []struct {
+		name     string
+		local    *hook.Response
+		ipc      string
+		wantExit int
+		wantErr  string
+	}{
+		{name: "local hook", local: &hook.Response{ExitCode: &code, Reason: "no network in CI"}, wantExit: 77},
+		{name: "IPC hook", ipc: `{"exit_code":77}`, wantExit: 77},
+		{name: "exit code zero", ipc: `{"exit_code":0}`},
+		{name: "out of range", ipc: `{"exit_code":300}`, wantErr: "invalid exit code 300"},
+		{name: "exit still terminates", ipc: `{"exit":true,"exit_code":77}`, wantErr: "process termination requested"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marker := filepath.Join(t.TempDir(), "ran")
+
+			var opts []WrapperOption
+			var h hook.Hook
+			if tt.local != nil {
+				localHook := newMockLocalHook("test", []string{"touch"})
+				localHook.allowAll = false
+				localHook.responses["touch:pre_run"] = tt.local
+				h = localHook
+			} else {
+				opts = append(opts, WithSocketPath(serveResponse(t, tt.ipc)))
+			}
+
+			err := NewWrapperCommand(h, opts...).Run([]string{"touch", marker})
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.Equal(t, tt.wantExit, exitCodeOf(t, err))
+			}
+			_, statErr := os.Stat(marker)
+			assert.True(t, os.IsNotExist(statErr), "denied command must not run")
+		})
+	}
+}
+
+func TestDeniedResultFile(t *testing.T) {
+	resultFile := filepath.Join(t.TempDir(), "result")
+	socketPath := serveResponse(t, `{"exit_code":77,"reason":"denied"}`)
+	err := NewWrapperCommand(nil, WithSocketPath(socketPath), WithResultFile(resultFile)).Run([]string{"echo", "hi"})
+	assert.Equal(t, 77, exitCodeOf(t, err))
+
+	data, err := os.ReadFile(resultFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), ResultBlocked+"='true'")
+	assert.Contains(t, string(data), ResultReason+"='denied'")
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package wrapper
+
+import "net"
+
+// dialSocket connects to the interceptor's Unix socket
+func dialSocket(network, socketPath string) (net.Conn, error) {
+	return net.Dial(network, socketPath)
+}
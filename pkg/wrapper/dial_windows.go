@@ -0,0 +1,18 @@
+//go:build windows
+
+package wrapper
+
+import (
+	"net"
+
+	"github.com/codysoyland/cmdhooks/pkg/namedpipe"
+)
+
+// dialSocket connects to the named pipe standing in for the interceptor's
+// Unix socket (see namedpipe.Name)
+func dialSocket(network, socketPath string) (net.Conn, error) {
+	if network != NetworkStream {
+		return net.Dial(network, socketPath)
+	}
+	return namedpipe.Dial(namedpipe.Name(socketPath))
+}
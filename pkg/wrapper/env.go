@@ -0,0 +1,283 @@
+package wrapper
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables used by cmdhooks to configure wrapper processes.
+// The library sets these on the executed script so every wrapper inherits
+// the same configuration.
+const (
+	// EnvWorkingDir sets a fixed working directory for wrapped commands
+	EnvWorkingDir = "CMDHOOKS_WORKING_DIR"
+	// EnvChildUmask sets the umask (octal) for wrapped commands
+	EnvChildUmask = "CMDHOOKS_CHILD_UMASK"
+	// EnvCommandTimeouts sets per-command timeouts (see FormatCommandTimeouts)
+	EnvCommandTimeouts = "CMDHOOKS_COMMAND_TIMEOUTS"
+	// EnvCommandTimeout limits how long every command may run, as a Go
+	// duration such as "30s" (see WithCommandTimeout)
+	EnvCommandTimeout = "CMDHOOKS_COMMAND_TIMEOUT"
+	// EnvDeadlineHeadroom sets the part of each command timeout reserved
+	// for post-run evaluation, as a duration
+	EnvDeadlineHeadroom = "CMDHOOKS_DEADLINE_HEADROOM"
+	// EnvCaseInsensitive enables case-insensitive command matching
+	EnvCaseInsensitive = "CMDHOOKS_CASE_INSENSITIVE"
+	// EnvRequestFields limits IPC requests to a comma-separated list of
+	// fields (see AllowRequestFields)
+	EnvRequestFields = "CMDHOOKS_REQUEST_FIELDS"
+	// EnvArgv0Check enables argv[0] spoofing detection
+	EnvArgv0Check = "CMDHOOKS_ARGV0_CHECK"
+	// EnvTranscript enables execution details for transcripts
+	EnvTranscript = "CMDHOOKS_TRANSCRIPT"
+	// EnvSocketNetwork selects the socket network (NetworkStream or
+	// NetworkSeqpacket)
+	EnvSocketNetwork = "CMDHOOKS_SOCKET_NETWORK"
+	// EnvAttribution enables decision attribution metadata
+	EnvAttribution = "CMDHOOKS_ATTRIBUTION"
+	// EnvScratchIsolation gives each command a scratch directory
+	EnvScratchIsolation = "CMDHOOKS_SCRATCH_ISOLATION"
+	// EnvOutputHashing enables stdout/stderr digests in post-run metadata
+	EnvOutputHashing = "CMDHOOKS_OUTPUT_HASHING"
+	// EnvSharedConnection sends pre-run and post-run requests over one
+	// connection
+	EnvSharedConnection = "CMDHOOKS_SHARED_CONNECTION"
+	// EnvSelfTest makes the wrapper send a canary request instead of
+	// running the command
+	EnvSelfTest = "CMDHOOKS_SELF_TEST"
+	// EnvResultFile names the file each wrapper writes its hook decision
+	// to (see WithResultFile)
+	EnvResultFile = "CMDHOOKS_RESULT_FILE"
+	// EnvInterpretedScripts enables script detection for interpreters (see
+	// FormatInterpreters)
+	EnvInterpretedScripts = "CMDHOOKS_INTERPRETED_SCRIPTS"
+	// EnvPostRunExitCodes limits post-run IPC requests to the listed exit
+	// codes (see FormatPostRunExitCodes)
+	EnvPostRunExitCodes = "CMDHOOKS_POST_RUN_EXIT_CODES"
+	// EnvChaos makes wrappers honor failures injected by chaos testing
+	EnvChaos = "CMDHOOKS_CHAOS"
+	// EnvRequestCompression offers compression to the interceptor
+	EnvRequestCompression = "CMDHOOKS_REQUEST_COMPRESSION"
+	// EnvTimingBreakdown reports how long each phase of a command took
+	EnvTimingBreakdown = "CMDHOOKS_TIMING_BREAKDOWN"
+	// EnvOutputTranscript captures stdout and stderr in one ordered
+	// transcript
+	EnvOutputTranscript = "CMDHOOKS_OUTPUT_TRANSCRIPT"
+	// EnvStreamOutput shows output as it is written instead of once the
+	// command has finished
+	EnvStreamOutput = "CMDHOOKS_STREAM_OUTPUT"
+	// EnvSignalExitStatus reports commands killed by a signal with exit
+	// status 128 plus the signal number
+	EnvSignalExitStatus = "CMDHOOKS_SIGNAL_EXIT_STATUS"
+	// EnvPTY runs commands under a pseudo-terminal when stdin is a
+	// terminal
+	EnvPTY = "CMDHOOKS_PTY"
+	// EnvFailureMode is "open" or "closed" (see FailureMode)
+	EnvFailureMode = "CMDHOOKS_FAILURE_MODE"
+	// EnvEnvAllowlist forwards a comma-separated list of environment
+	// variables to hooks (see WithEnvAllowlist)
+	EnvEnvAllowlist = "CMDHOOKS_ENV_ALLOWLIST"
+	// EnvTLSCAFile, EnvTLSCertFile, EnvTLSKeyFile and EnvTLSServerName
+	// make wrappers connect over TLS (see TLSClientFiles); setting any of
+	// them enables TLS
+	EnvTLSCAFile     = "CMDHOOKS_TLS_CA_FILE"
+	EnvTLSCertFile   = "CMDHOOKS_TLS_CERT_FILE"
+	EnvTLSKeyFile    = "CMDHOOKS_TLS_KEY_FILE"
+	EnvTLSServerName = "CMDHOOKS_TLS_SERVER_NAME"
+)
+
+// optionsFromEnv builds wrapper options from the CMDHOOKS_* environment
+func optionsFromEnv() ([]WrapperOption, error) {
+	var opts []WrapperOption
+
+	// Auto-detect socket path from environment
+	if socketPath := os.Getenv("CMDHOOKS_SOCKET"); socketPath != "" {
+		opts = append(opts, WithSocketPath(socketPath))
+	}
+
+	switch network := os.Getenv(EnvSocketNetwork); network {
+	case "":
+	case NetworkStream, NetworkSeqpacket:
+		opts = append(opts, WithSocketNetwork(network))
+	default:
+		return nil, fmt.Errorf("invalid %s %q", EnvSocketNetwork, network)
+	}
+
+	// Auto-detect verbose mode from environment
+	if envBool("CMDHOOKS_VERBOSE") {
+		opts = append(opts, WithVerbose(true))
+	}
+
+	if envBool(EnvCaseInsensitive) {
+		opts = append(opts, WithCaseInsensitiveMatching(true))
+	}
+
+	if envBool(EnvArgv0Check) {
+		opts = append(opts, WithArgv0Check(true))
+	}
+
+	if envBool(EnvScratchIsolation) {
+		opts = append(opts, WithScratchDir(true))
+	}
+
+	if envBool(EnvAttribution) {
+		opts = append(opts, WithEvaluationAttribution(true))
+	}
+
+	if envBool(EnvSelfTest) {
+		opts = append(opts, WithSelfTest(true))
+	}
+
+	if envBool(EnvSharedConnection) {
+		opts = append(opts, WithSharedConnection(true))
+	}
+
+	if envBool(EnvChaos) {
+		opts = append(opts, WithChaos(true))
+	}
+
+	if envBool(EnvRequestCompression) {
+		opts = append(opts, WithRequestCompression(true))
+	}
+
+	if envBool(EnvTimingBreakdown) {
+		opts = append(opts, WithTimingBreakdown(true))
+	}
+
+	if envBool(EnvOutputHashing) {
+		opts = append(opts, WithOutputHashing(true))
+	}
+
+	if envBool(EnvOutputTranscript) {
+		opts = append(opts, WithOutputTranscript(true))
+	}
+
+	if envBool(EnvStreamOutput) {
+		opts = append(opts, WithStreamingOutput(true))
+	}
+
+	if envBool(EnvSignalExitStatus) {
+		opts = append(opts, WithSignalExitStatus(true))
+	}
+
+	if envBool(EnvPTY) {
+		opts = append(opts, WithPTY(true))
+	}
+
+	if v := strings.TrimSpace(os.Getenv(EnvFailureMode)); v != "" {
+		mode, err := ParseFailureMode(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvFailureMode, err)
+		}
+		opts = append(opts, WithFailureMode(mode))
+	}
+
+	if v := os.Getenv(EnvEnvAllowlist); v != "" {
+		var names []string
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		opts = append(opts, WithEnvAllowlist(names))
+	}
+
+	if envBool(EnvTranscript) {
+		opts = append(opts, WithTranscriptDetails(true))
+	}
+
+	files := TLSClientFiles{
+		CAFile:     os.Getenv(EnvTLSCAFile),
+		CertFile:   os.Getenv(EnvTLSCertFile),
+		KeyFile:    os.Getenv(EnvTLSKeyFile),
+		ServerName: os.Getenv(EnvTLSServerName),
+	}
+	if files != (TLSClientFiles{}) {
+		cfg, err := files.Load()
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+		}
+		opts = append(opts, WithTLS(cfg))
+	}
+
+	if path := os.Getenv(EnvResultFile); path != "" {
+		opts = append(opts, WithResultFile(path))
+	}
+
+	if dir := os.Getenv(EnvWorkingDir); dir != "" {
+		opts = append(opts, WithWorkingDir(dir))
+	}
+
+	if v := strings.TrimSpace(os.Getenv(EnvChildUmask)); v != "" {
+		mask, err := strconv.ParseUint(v, 8, 32)
+		if err != nil || mask > 0o777 {
+			return nil, fmt.Errorf("invalid %s %q: must be an octal mask between 0 and 0777", EnvChildUmask, v)
+		}
+		opts = append(opts, WithChildUmask(int(mask)))
+	}
+
+	if v := strings.TrimSpace(os.Getenv(EnvCommandTimeouts)); v != "" {
+		timeouts, err := parseCommandTimeouts(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvCommandTimeouts, err)
+		}
+		opts = append(opts, WithCommandTimeouts(timeouts))
+	}
+
+	if v := strings.TrimSpace(os.Getenv(EnvCommandTimeout)); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive duration", EnvCommandTimeout, v)
+		}
+		opts = append(opts, WithCommandTimeout(d))
+	}
+
+	if v := strings.TrimSpace(os.Getenv(EnvInterpretedScripts)); v != "" {
+		interpreters, err := parseInterpreters(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvInterpretedScripts, err)
+		}
+		opts = append(opts, WithInterpretedScripts(interpreters))
+	}
+
+	if v := strings.TrimSpace(os.Getenv(EnvPostRunExitCodes)); v != "" {
+		wants, err := parsePostRunExitCodes(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvPostRunExitCodes, err)
+		}
+		opts = append(opts, WithPostRunGate(wants))
+	}
+
+	if v := strings.TrimSpace(os.Getenv(EnvDeadlineHeadroom)); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive duration", EnvDeadlineHeadroom, v)
+		}
+		opts = append(opts, WithDeadlineHeadroom(d))
+	}
+
+	if v, ok := os.LookupEnv(EnvRequestFields); ok {
+		var fields []string
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				fields = append(fields, f)
+			}
+		}
+		if err := ValidateRequestFields(fields); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvRequestFields, err)
+		}
+		opts = append(opts, WithOutboundRequestFilter(AllowRequestFields(fields...)))
+	}
+
+	return opts, nil
+}
+
+// envBool reports whether an environment variable is set to a true value.
+// Any non-empty value other than "false" or "0" is considered true.
+func envBool(name string) bool {
+	v := strings.TrimSpace(os.Getenv(name))
+	return v != "" && strings.ToLower(v) != "false" && v != "0"
+}
@@ -0,0 +1,31 @@
+package wrapper
+
+import "os"
+
+// WithEnvAllowlist forwards the named environment variables to hooks in
+// Request.Env, e.g. HTTP_PROXY for a hook deciding whether curl may run.
+// Variables that are not set are left out. Without an allowlist no
+// environment is sent, so secrets in the wrapper's environment do not cross
+// the IPC boundary.
+func WithEnvAllowlist(names []string) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.EnvAllowlist = append([]string(nil), names...)
+	}
+}
+
+// requestEnv returns the allowlisted variables of the environment the
+// command inherits, or nil if none are set
+func (w *WrapperCommand) requestEnv() map[string]string {
+	var env map[string]string
+	for _, name := range w.EnvAllowlist {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if env == nil {
+			env = make(map[string]string)
+		}
+		env[name] = v
+	}
+	return env
+}
@@ -0,0 +1,71 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWithEnvAllowlist(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://proxy:3128")
+	t.Setenv("SECRET_TOKEN", "hunter2")
+
+	tests := []struct {
+		name string
+		opts []WrapperOption
+		want map[string]string
+	}{
+		{name: "nothing forwarded by default"},
+		{
+			name: "only listed variables that are set",
+			opts: []WrapperOption{WithEnvAllowlist([]string{"HTTP_PROXY", "CMDHOOKS_UNSET_VARIABLE"})},
+			want: map[string]string{"HTTP_PROXY": "http://proxy:3128"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envs := make(map[hook.HookType]map[string]string)
+			h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+				envs[req.Hook] = req.Env
+			}}
+			require.NoError(t, NewWrapperCommand(h, tt.opts...).Run([]string{"true"}))
+			assert.Equal(t, map[hook.HookType]map[string]string{hook.HookPreRun: tt.want, hook.HookPostRun: tt.want}, envs)
+		})
+	}
+}
+
+func TestWithEnvAllowlist_IPC(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://proxy:3128")
+	t.Setenv("SECRET_TOKEN", "hunter2")
+
+	socketPath, lines := captureSocket(t)
+	w := NewWrapperCommand(nil, WithSocketPath(socketPath), WithEnvAllowlist([]string{"HTTP_PROXY"}))
+	require.NoError(t, w.Run([]string{"true"}))
+
+	for _, want := range []hook.HookType{hook.HookPreRun, hook.HookPostRun} {
+		select {
+		case line := <-lines:
+			assert.NotContains(t, line, "hunter2")
+			var req hook.Request
+			require.NoError(t, json.Unmarshal([]byte(line), &req))
+			assert.Equal(t, want, req.Hook)
+			assert.Equal(t, map[string]string{"HTTP_PROXY": "http://proxy:3128"}, req.Env)
+		case <-time.After(time.Second):
+			t.Fatalf("no %s request sent", want)
+		}
+	}
+}
+
+func TestEnvAllowlistFromEnv(t *testing.T) {
+	t.Setenv(EnvEnvAllowlist, "HTTP_PROXY, NO_PROXY,")
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	w := NewWrapperCommand(nil, opts...)
+	assert.Equal(t, []string{"HTTP_PROXY", "NO_PROXY"}, w.EnvAllowlist)
+}
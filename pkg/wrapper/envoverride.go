@@ -0,0 +1,62 @@
+package wrapper
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// protectedEnv reports whether hooks may not change the variable name,
+// because wrappers rely on it to intercept child processes
+func protectedEnv(name string) bool {
+	return name == "PATH" || strings.HasPrefix(name, "CMDHOOKS_")
+}
+
+// applyEnvChanges returns env without the variables the pre-run hook unset,
+// and with its overrides set (see hook.Response.EnvOverride). Protected
+// and malformed names are skipped with a warning.
+func (w *WrapperCommand) applyEnvChanges(env []string) []string {
+	if len(w.envUnset) == 0 && len(w.envOverride) == 0 {
+		return env
+	}
+
+	valid := func(name string) bool {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring invalid environment variable name %q from hook\n", name)
+			return false
+		}
+		if protectedEnv(name) {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring hook change to protected environment variable %s\n", name)
+			return false
+		}
+		return true
+	}
+
+	remove := make(map[string]bool, len(w.envUnset)+len(w.envOverride))
+	for _, name := range w.envUnset {
+		if valid(name) {
+			remove[name] = true
+		}
+	}
+	names := make([]string, 0, len(w.envOverride))
+	for name := range w.envOverride {
+		if valid(name) {
+			remove[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	out := make([]string, 0, len(env)+len(names))
+	for _, e := range env {
+		name, _, _ := strings.Cut(e, "=")
+		if !remove[name] {
+			out = append(out, e)
+		}
+	}
+	for _, name := range names {
+		out = append(out, name+"="+w.envOverride[name])
+	}
+	return out
+}
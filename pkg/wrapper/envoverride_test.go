@@ -0,0 +1,102 @@
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// envDump returns a command that writes its environment to out
+func envDump(out string) []string {
+	return []string{"sh", "-c", `env > "$0"`, out}
+}
+
+// readEnv parses an environment dump into a map
+func readEnv(t *testing.T, path string) map[string]string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	env := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if name, value, ok := strings.Cut(line, "="); ok {
+			env[name] = value
+		}
+	}
+	return env
+}
+
+func TestWrapperCommand_EnvChanges(t *testing.T) {
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "s3cr3t")
+	t.Setenv("KEEP_ME", "yes")
+	t.Setenv("REPLACE_ME", "old")
+
+	t.Run("local hook", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "env")
+		localHook := newMockLocalHook("test", []string{"sh"})
+		localHook.allowAll = false
+		localHook.responses["sh:pre_run"] = &hook.Response{
+			EnvUnset:    []string{"AWS_SECRET_ACCESS_KEY"},
+			EnvOverride: map[string]string{"REPLACE_ME": "new", "INJECTED": "a=b"},
+		}
+		localHook.responses["sh:post_run"] = &hook.Response{}
+
+		require.NoError(t, NewWrapperCommand(localHook).Run(envDump(out)))
+
+		env := readEnv(t, out)
+		assert.NotContains(t, env, "AWS_SECRET_ACCESS_KEY")
+		assert.Equal(t, "yes", env["KEEP_ME"])
+		assert.Equal(t, "new", env["REPLACE_ME"])
+		assert.Equal(t, "a=b", env["INJECTED"])
+	})
+
+	t.Run("IPC hook", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "env")
+		socketPath := serveResponse(t, `{"env_unset":["AWS_SECRET_ACCESS_KEY"],"env_override":{"INJECTED":"ipc"}}`)
+
+		require.NoError(t, NewWrapperCommand(nil, WithSocketPath(socketPath)).Run(envDump(out)))
+
+		env := readEnv(t, out)
+		assert.NotContains(t, env, "AWS_SECRET_ACCESS_KEY")
+		assert.Equal(t, "ipc", env["INJECTED"])
+	})
+
+	t.Run("protected variables", func(t *testing.T) {
+		t.Setenv("CMDHOOKS_SOCKET", "/tmp/socket")
+		out := filepath.Join(t.TempDir(), "env")
+		localHook := newMockLocalHook("test", []string{"sh"})
+		localHook.allowAll = false
+		localHook.responses["sh:pre_run"] = &hook.Response{
+			EnvUnset:    []string{"CMDHOOKS_SOCKET"},
+			EnvOverride: map[string]string{"PATH": "/nowhere", "": "x", "BAD=NAME": "x"},
+		}
+		localHook.responses["sh:post_run"] = &hook.Response{}
+
+		require.NoError(t, NewWrapperCommand(localHook).Run(envDump(out)))
+
+		env := readEnv(t, out)
+		assert.Equal(t, "/tmp/socket", env["CMDHOOKS_SOCKET"])
+		assert.NotEqual(t, "/nowhere", env["PATH"])
+		assert.NotContains(t, env, "BAD")
+	})
+
+	t.Run("changes last one run", func(t *testing.T) {
+		localHook := newMockLocalHook("test", []string{"sh"})
+		localHook.allowAll = false
+		localHook.responses["sh:pre_run"] = &hook.Response{EnvOverride: map[string]string{"INJECTED": "once"}}
+		localHook.responses["sh:post_run"] = &hook.Response{}
+		w := NewWrapperCommand(localHook)
+
+		out := filepath.Join(t.TempDir(), "env")
+		require.NoError(t, w.Run(envDump(out)))
+		assert.Equal(t, "once", readEnv(t, out)["INJECTED"])
+
+		localHook.responses["sh:pre_run"] = &hook.Response{}
+		require.NoError(t, w.Run(envDump(out)))
+		assert.NotContains(t, readEnv(t, out), "INJECTED")
+	})
+}
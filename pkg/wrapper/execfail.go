@@ -0,0 +1,69 @@
+package wrapper
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// Exit codes for commands that cannot be run, matching shell semantics
+const (
+	ExitCodeNotExecutable = 126
+	ExitCodeNotFound      = 127
+)
+
+// Errors matched, with errors.Is, by the ExitError of a command that could
+// not be run
+var (
+	ErrCommandNotFound      = errors.New("command not found")
+	ErrCommandNotExecutable = errors.New("command not executable")
+)
+
+// exitReasonError returns the error matching reason, or nil
+func exitReasonError(reason hook.ExitReason) error {
+	switch reason {
+	case hook.ExitReasonNotFound:
+		return ErrCommandNotFound
+	case hook.ExitReasonNotExecutable:
+		return ErrCommandNotExecutable
+	}
+	return nil
+}
+
+// lookupFailure classifies a failed PATH lookup. lookPathIn fails both
+// for missing commands and for files that exist but are not executable, so
+// the clean PATH is searched again for a file with the command's name.
+func (w *WrapperCommand) lookupFailure(cmd string) (int, hook.ExitReason) {
+	candidates := []string{cmd}
+	if !strings.Contains(cmd, "/") {
+		candidates = nil
+		for _, dir := range filepath.SplitList(w.getCleanPath()) {
+			if dir == "" {
+				dir = "."
+			}
+			candidates = append(candidates, filepath.Join(dir, cmd))
+		}
+	}
+
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return ExitCodeNotExecutable, hook.ExitReasonNotExecutable
+		}
+	}
+	return ExitCodeNotFound, hook.ExitReasonNotFound
+}
+
+// startFailure classifies an error starting a command that was found on
+// PATH. Permission errors, unrecognized formats and missing interpreters
+// mean the command cannot be executed; other errors keep exit code 1.
+func startFailure(err error) (int, hook.ExitReason) {
+	if errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.ENOEXEC) || errors.Is(err, fs.ErrNotExist) {
+		return ExitCodeNotExecutable, hook.ExitReasonNotExecutable
+	}
+	return 1, ""
+}
@@ -0,0 +1,52 @@
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_ExecFailureExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "not-executable"), []byte("#!/bin/sh\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad-interpreter"), []byte("#!/cmdhooks/missing/interpreter\n"), 0755))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "a-directory"), 0755))
+	t.Setenv("PATH", dir)
+
+	tests := []struct {
+		name       string
+		command    string
+		wantCode   int
+		wantReason hook.ExitReason
+		wantErr    error
+	}{
+		{name: "missing file", command: "cmdhooks-missing-command", wantCode: ExitCodeNotFound, wantReason: hook.ExitReasonNotFound, wantErr: ErrCommandNotFound},
+		{name: "directory on PATH", command: "a-directory", wantCode: ExitCodeNotFound, wantReason: hook.ExitReasonNotFound, wantErr: ErrCommandNotFound},
+		{name: "not executable", command: "not-executable", wantCode: ExitCodeNotExecutable, wantReason: hook.ExitReasonNotExecutable, wantErr: ErrCommandNotExecutable},
+		{name: "not executable by path", command: filepath.Join(dir, "not-executable"), wantCode: ExitCodeNotExecutable, wantReason: hook.ExitReasonNotExecutable, wantErr: ErrCommandNotExecutable},
+		{name: "missing interpreter", command: "bad-interpreter", wantCode: ExitCodeNotExecutable, wantReason: hook.ExitReasonNotExecutable, wantErr: ErrCommandNotExecutable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var postRun *hook.Request
+			h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+				if req.Hook == hook.HookPostRun {
+					postRun = req
+				}
+			}}
+
+			err := NewWrapperCommand(h).Run([]string{tt.command})
+			assert.Equal(t, tt.wantCode, exitCodeOf(t, err))
+			assert.ErrorIs(t, err, tt.wantErr)
+			require.NotNil(t, postRun)
+			assert.Equal(t, tt.wantCode, postRun.ExitCode)
+			assert.Equal(t, tt.wantReason, postRun.ExitReason)
+		})
+	}
+}
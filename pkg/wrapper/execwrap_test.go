@@ -0,0 +1,54 @@
+package wrapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_ExecWrapper(t *testing.T) {
+	t.Run("runs around execution in order", func(t *testing.T) {
+		var events []string
+		var observed int
+		trace := func(name string) ExecWrapper {
+			return func(next func() (int, error)) (int, error) {
+				events = append(events, name+":before")
+				code, err := next()
+				observed = code
+				events = append(events, name+":after")
+				return code, err
+			}
+		}
+
+		w := NewWrapperCommand(nil, WithExecWrapper(trace("outer")), WithExecWrapper(trace("inner")))
+		exitCode := exitCodeOf(t, w.Run([]string{"sh", "-c", "exit 3"}))
+
+		assert.Equal(t, []string{"outer:before", "inner:before", "inner:after", "outer:after"}, events)
+		assert.Equal(t, 3, observed)
+		assert.Equal(t, 3, exitCode)
+	})
+
+	t.Run("can modify the exit code", func(t *testing.T) {
+		var postRun *hook.Request
+		h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+			if req.Hook == hook.HookPostRun {
+				postRun = req
+			}
+		}}
+
+		w := NewWrapperCommand(h, WithExecWrapper(func(next func() (int, error)) (int, error) {
+			if _, err := next(); err != nil {
+				return 1, err
+			}
+			return 7, nil
+		}))
+		exitCode := exitCodeOf(t, w.Run([]string{"true"}))
+
+		assert.Equal(t, 7, exitCode)
+		require.NotNil(t, postRun)
+		assert.Equal(t, 7, postRun.ExitCode)
+	})
+}
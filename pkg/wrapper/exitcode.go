@@ -0,0 +1,33 @@
+package wrapper
+
+import (
+	"fmt"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// ExitError is returned by Run when the wrapped command exits with a
+// non-zero code, or a hook denies it with one. The wrapper leaves exiting to
+// its caller, which should exit with ExitCode to forward the command's
+// status. A command that could not be run at all matches
+// ErrCommandNotFound or ErrCommandNotExecutable.
+type ExitError struct {
+	Code int
+	// Reason explains a code the command did not produce itself
+	Reason hook.ExitReason
+}
+
+// Error returns "exit status N", as exec.ExitError does
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the exit code of the wrapped command
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// Unwrap returns the error matching Reason, if any
+func (e *ExitError) Unwrap() error {
+	return exitReasonError(e.Reason)
+}
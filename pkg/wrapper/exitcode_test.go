@@ -0,0 +1,38 @@
+package wrapper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exitCodeOf returns the exit code Run reported through err: 0 for nil and
+// the code of an ExitError. Any other error fails the test.
+func exitCodeOf(t *testing.T, err error) int {
+	t.Helper()
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	require.True(t, errors.As(err, &exitErr), "unexpected error: %v", err)
+	return exitErr.ExitCode()
+}
+
+func TestWrapperCommand_ExitError(t *testing.T) {
+	w := NewWrapperCommand(nil)
+
+	t.Run("non-zero exit is returned", func(t *testing.T) {
+		err := w.Run([]string{"sh", "-c", "exit 42"})
+		var exitErr *ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 42, exitErr.ExitCode())
+		assert.EqualError(t, err, "exit status 42")
+		assert.NotErrorIs(t, err, ErrCommandNotFound, "the command ran")
+	})
+
+	t.Run("zero exit returns nil", func(t *testing.T) {
+		assert.NoError(t, w.Run([]string{"true"}))
+	})
+}
@@ -0,0 +1,39 @@
+package wrapper
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// RequestExplanation asks the interceptor listening on socketPath how it
+// would decide command at pre-run, without running it. It returns the
+// decision and the trace of the rules and hooks consulted to reach it.
+// network is NetworkStream or NetworkSeqpacket; empty means stream.
+func RequestExplanation(network, socketPath string, command []string) (*hook.Response, []hook.TraceStep, error) {
+	wd, _ := os.Getwd()
+	req := hook.Request{
+		Command:    command,
+		PID:        os.Getpid(),
+		WorkingDir: wd,
+		Hook:       hook.HookExplain,
+	}
+	resp, err := runHook(network, socketPath, nil, req, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	value, ok := resp.Metadata[hook.MetadataExplain]
+	if !ok {
+		reason, _ := resp.Metadata["error"].(string)
+		if reason == "" {
+			reason = "interceptor does not support explaining"
+		}
+		return nil, nil, fmt.Errorf("explain failed: %s", reason)
+	}
+	steps, err := hook.DecodeTrace(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, steps, nil
+}
@@ -0,0 +1,90 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// FailureMode decides what happens to a command when IPC evaluation cannot
+// reach the interceptor: no socket is configured, the connection fails, or
+// the response cannot be read
+type FailureMode int
+
+const (
+	// FailUnset keeps the historical behavior: commands run when no socket
+	// is configured, and the wrapper fails with an error when the
+	// interceptor cannot be reached
+	FailUnset FailureMode = iota
+	// FailOpen lets the command run as if the interceptor had allowed it
+	FailOpen
+	// FailClosed blocks the command, as a denial by the interceptor would
+	FailClosed
+)
+
+// String returns "open", "closed" or "unset", as carried in EnvFailureMode
+func (m FailureMode) String() string {
+	switch m {
+	case FailOpen:
+		return "open"
+	case FailClosed:
+		return "closed"
+	case FailUnset:
+		return "unset"
+	default:
+		return fmt.Sprintf("FailureMode(%d)", int(m))
+	}
+}
+
+// ParseFailureMode parses the output of FailureMode.String for FailOpen or
+// FailClosed
+func ParseFailureMode(s string) (FailureMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "open":
+		return FailOpen, nil
+	case "closed":
+		return FailClosed, nil
+	default:
+		return FailUnset, fmt.Errorf("unknown failure mode %q, want open or closed", s)
+	}
+}
+
+// WithFailureMode sets what happens to a command when IPC evaluation
+// cannot reach the interceptor (see FailureMode). Unreachable covers a
+// missing socket configuration, so FailClosed also blocks every command of
+// a wrapper run without a socket.
+func WithFailureMode(mode FailureMode) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.FailureMode = mode
+	}
+}
+
+// errNoSocket is the failure of IPC evaluation without a socket
+var errNoSocket = errors.New("no interceptor socket configured")
+
+// unreachable returns the outcome of IPC evaluation that failed with err
+// under the configured failure mode: a nil response to carry on without
+// IPC, a blocking response, or an error
+func (w *WrapperCommand) unreachable(req *hook.Request, err error) (*hook.Response, error) {
+	if errors.Is(err, errFilterReturnedNil) {
+		return nil, fmt.Errorf("IPC hook evaluation failed: %w", err)
+	}
+	switch w.FailureMode {
+	case FailOpen:
+		w.logf("Interceptor unavailable (%v); failing open: %v", err, req.Command)
+		return nil, nil
+	case FailClosed:
+		w.logf("Interceptor unavailable (%v); failing closed: %v", err, req.Command)
+		return &hook.Response{
+			Exit:   true,
+			Reason: fmt.Sprintf("interceptor unavailable: %v", err),
+		}, nil
+	default:
+		if errors.Is(err, errNoSocket) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("IPC hook evaluation failed: %w", err)
+	}
+}
@@ -0,0 +1,88 @@
+package wrapper
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// refusingSocket returns the path of a socket file nothing listens on
+func refusingSocket(t *testing.T) string {
+	socketPath := filepath.Join(t.TempDir(), "refused.sock")
+	l, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, l.Close())
+	return socketPath
+}
+
+func TestWrapperCommand_FailureMode(t *testing.T) {
+	sockets := map[string]func(t *testing.T) string{
+		"missing socket":     func(t *testing.T) string { return "" },
+		"refused connection": refusingSocket,
+		"malformed response": func(t *testing.T) string { return serveResponse(t, "not json") },
+	}
+
+	tests := []struct {
+		mode    FailureMode
+		socket  string
+		wantRun bool
+		wantErr string
+	}{
+		{FailOpen, "missing socket", true, ""},
+		{FailOpen, "refused connection", true, ""},
+		{FailOpen, "malformed response", true, ""},
+		{FailClosed, "missing socket", false, "interceptor unavailable: no interceptor socket configured"},
+		{FailClosed, "refused connection", false, "interceptor unavailable"},
+		{FailClosed, "malformed response", false, "interceptor unavailable"},
+		{FailUnset, "missing socket", true, ""},
+		{FailUnset, "refused connection", false, "IPC hook evaluation failed"},
+		{FailUnset, "malformed response", false, "IPC hook evaluation failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String()+"/"+tt.socket, func(t *testing.T) {
+			marker := filepath.Join(t.TempDir(), "ran")
+			h := &recordingLocalHook{onEvaluate: func(*hook.Request) {}}
+			w := NewWrapperCommand(h, WithSocketPath(sockets[tt.socket](t)), WithFailureMode(tt.mode))
+
+			err := w.Run([]string{"touch", marker})
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			_, statErr := os.Stat(marker)
+			assert.Equal(t, tt.wantRun, statErr == nil)
+		})
+	}
+}
+
+func TestWrapperCommand_FailureModeFilterError(t *testing.T) {
+	// A filter dropping requests is a configuration error, never failed open
+	w := NewWrapperCommand(&recordingLocalHook{onEvaluate: func(*hook.Request) {}},
+		WithSocketPath(serveResponse(t, `{}`)),
+		WithFailureMode(FailOpen),
+		WithOutboundRequestFilter(func(*hook.Request) *hook.Request { return nil }),
+	)
+	assert.ErrorContains(t, w.Run([]string{"true"}), "outbound request filter returned nil")
+}
+
+func TestFailureModeFromEnv(t *testing.T) {
+	for value, want := range map[string]FailureMode{"open": FailOpen, "closed": FailClosed, " Closed ": FailClosed} {
+		t.Setenv(EnvFailureMode, value)
+		opts, err := optionsFromEnv()
+		require.NoError(t, err)
+		assert.Equal(t, want, NewWrapperCommand(nil, opts...).FailureMode, value)
+	}
+
+	t.Setenv(EnvFailureMode, "sometimes")
+	_, err := optionsFromEnv()
+	assert.Error(t, err)
+}
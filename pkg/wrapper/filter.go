@@ -0,0 +1,136 @@
+package wrapper
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// Request fields understood by AllowRequestFields. Individual metadata keys
+// are selected with a "metadata." prefix, e.g. "metadata.stdout_file".
+const (
+	FieldCommand       = "command"      // full argv, and of each batched command
+	FieldCommandName   = "command_name" // argv[0] only, also for batched commands
+	FieldPID           = "pid"
+	FieldResolvedPath  = "resolved_path"
+	FieldLookupPath    = "lookup_path"
+	FieldWorkingDir    = "working_dir"
+	FieldEnv           = "env"
+	FieldUID           = "uid"
+	FieldGID           = "gid"
+	FieldRequestID     = "request_id"
+	FieldCorrelationID = "correlation_id"
+	FieldStartedAt     = "started_at"
+	FieldExitCode      = "exit_code"
+	FieldExitReason    = "exit_reason"
+	FieldDuration      = "duration"
+	FieldMetadata      = "metadata" // all metadata keys
+
+	metadataFieldPrefix = "metadata."
+)
+
+// OutboundRequestFilter rewrites a request right before it is sent over IPC.
+// It receives a copy of the request and returns the request to transmit.
+// Filters control what crosses the process boundary; they do not affect
+// local hook evaluation.
+type OutboundRequestFilter func(req *hook.Request) *hook.Request
+
+// WithOutboundRequestFilter sets a filter applied to every IPC request
+// before it is marshaled
+func WithOutboundRequestFilter(fn OutboundRequestFilter) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.OutboundFilter = fn
+	}
+}
+
+// ValidateRequestFields checks that every field name is understood by
+// AllowRequestFields
+func ValidateRequestFields(fields []string) error {
+	for _, f := range fields {
+		switch f {
+		case FieldCommand, FieldCommandName, FieldPID, FieldResolvedPath, FieldLookupPath, FieldWorkingDir, FieldEnv, FieldUID, FieldGID, FieldRequestID, FieldCorrelationID, FieldStartedAt, FieldExitCode, FieldExitReason, FieldDuration, FieldMetadata:
+		default:
+			if !strings.HasPrefix(f, metadataFieldPrefix) || f == metadataFieldPrefix {
+				return fmt.Errorf("unknown request field %q", f)
+			}
+		}
+	}
+	return nil
+}
+
+// AllowRequestFields returns a filter that transmits only the listed fields.
+// The hook type is always sent, since the interceptor needs it to route the
+// request; every other field is cleared unless allowed.
+func AllowRequestFields(fields ...string) OutboundRequestFilter {
+	allowed := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		allowed[f] = true
+	}
+
+	return func(req *hook.Request) *hook.Request {
+		out := &hook.Request{Hook: req.Hook}
+
+		switch {
+		case allowed[FieldCommand]:
+			out.Command = req.Command
+			out.Batch = req.Batch
+		case allowed[FieldCommandName]:
+			if len(req.Command) > 0 {
+				out.Command = req.Command[:1]
+			}
+			for _, command := range req.Batch {
+				out.Batch = append(out.Batch, command[:min(len(command), 1)])
+			}
+		}
+		if allowed[FieldPID] {
+			out.PID = req.PID
+		}
+		if allowed[FieldResolvedPath] {
+			out.ResolvedPath = req.ResolvedPath
+		}
+		if allowed[FieldLookupPath] {
+			out.LookupPath = req.LookupPath
+		}
+		if allowed[FieldWorkingDir] {
+			out.WorkingDir = req.WorkingDir
+		}
+		if allowed[FieldEnv] {
+			out.Env = req.Env
+		}
+		if allowed[FieldUID] {
+			out.UID = req.UID
+		}
+		if allowed[FieldGID] {
+			out.GID = req.GID
+		}
+		if allowed[FieldRequestID] {
+			out.RequestID = req.RequestID
+		}
+		if allowed[FieldCorrelationID] {
+			out.CorrelationID = req.CorrelationID
+		}
+		if allowed[FieldStartedAt] {
+			out.StartedAt = req.StartedAt
+		}
+		if allowed[FieldExitCode] {
+			out.ExitCode = req.ExitCode
+		}
+		if allowed[FieldExitReason] {
+			out.ExitReason = req.ExitReason
+		}
+		if allowed[FieldDuration] {
+			out.Duration = req.Duration
+		}
+
+		for k, v := range req.Metadata {
+			if allowed[FieldMetadata] || allowed[metadataFieldPrefix+k] {
+				if out.Metadata == nil {
+					out.Metadata = make(map[string]interface{})
+				}
+				out.Metadata[k] = v
+			}
+		}
+		return out
+	}
+}
@@ -0,0 +1,198 @@
+package wrapper
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// captureSocket accepts IPC connections, records the raw request lines and
+// answers each with an allow response
+func captureSocket(t *testing.T) (string, <-chan string) {
+	socketPath := filepath.Join(t.TempDir(), "capture.sock")
+	l, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			scanner := bufio.NewScanner(conn)
+			if scanner.Scan() {
+				lines <- scanner.Text()
+				conn.Write([]byte("{}\n"))
+			}
+			conn.Close()
+		}
+	}()
+	return socketPath, lines
+}
+
+func TestAllowRequestFields(t *testing.T) {
+	req := &hook.Request{
+		Command:       []string{"curl", "-H", "Authorization: secret", "https://example.com"},
+		PID:           42,
+		ResolvedPath:  "/usr/bin/curl",
+		WorkingDir:    "/home/user",
+		Env:           map[string]string{"HTTP_PROXY": "http://proxy:3128"},
+		Hook:          hook.HookPostRun,
+		RequestID:     "a1b2c3",
+		CorrelationID: "a1b2c3",
+		StartedAt:     time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		ExitCode:      1,
+		Duration:      time.Second,
+		Metadata:      map[string]interface{}{"cwd": "/home/user", "timed_out": true},
+	}
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   *hook.Request
+	}{
+		{
+			name:   "nothing allowed keeps only the hook type",
+			fields: nil,
+			want:   &hook.Request{Hook: hook.HookPostRun},
+		},
+		{
+			name:   "command name drops arguments",
+			fields: []string{FieldCommandName, FieldExitCode},
+			want:   &hook.Request{Command: []string{"curl"}, Hook: hook.HookPostRun, ExitCode: 1},
+		},
+		{
+			name:   "individual metadata keys",
+			fields: []string{FieldCommand, FieldPID, FieldDuration, "metadata.timed_out"},
+			want: &hook.Request{
+				Command:  req.Command,
+				PID:      42,
+				Hook:     hook.HookPostRun,
+				Duration: time.Second,
+				Metadata: map[string]interface{}{"timed_out": true},
+			},
+		},
+		{
+			name:   "working directory",
+			fields: []string{FieldCommandName, FieldWorkingDir},
+			want:   &hook.Request{Command: []string{"curl"}, WorkingDir: "/home/user", Hook: hook.HookPostRun},
+		},
+		{
+			name:   "resolved path",
+			fields: []string{FieldCommandName, FieldResolvedPath},
+			want:   &hook.Request{Command: []string{"curl"}, ResolvedPath: "/usr/bin/curl", Hook: hook.HookPostRun},
+		},
+		{
+			name:   "correlation",
+			fields: []string{FieldRequestID, FieldCorrelationID, FieldStartedAt},
+			want: &hook.Request{
+				Hook:          hook.HookPostRun,
+				RequestID:     req.RequestID,
+				CorrelationID: req.CorrelationID,
+				StartedAt:     req.StartedAt,
+			},
+		},
+		{
+			name:   "environment",
+			fields: []string{FieldEnv},
+			want:   &hook.Request{Env: req.Env, Hook: hook.HookPostRun},
+		},
+		{
+			name:   "all metadata",
+			fields: []string{FieldMetadata},
+			want:   &hook.Request{Hook: hook.HookPostRun, Metadata: req.Metadata},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, AllowRequestFields(tt.fields...)(req))
+		})
+	}
+}
+
+func TestValidateRequestFields(t *testing.T) {
+	assert.NoError(t, ValidateRequestFields([]string{"command", "command_name", "pid", "resolved_path", "lookup_path", "working_dir", "correlation_id", "started_at", "env", "uid", "gid", "exit_code", "duration", "metadata", "metadata.cwd"}))
+	assert.Error(t, ValidateRequestFields([]string{"hostname"}))
+	assert.Error(t, ValidateRequestFields([]string{"metadata."}))
+}
+
+func TestOutboundRequestFilter_TransmittedBytes(t *testing.T) {
+	socketPath, lines := captureSocket(t)
+
+	w := NewWrapperCommand(nil,
+		WithSocketPath(socketPath),
+		WithOutboundRequestFilter(AllowRequestFields(FieldCommandName)),
+	)
+	req := &hook.Request{
+		Command:  []string{"curl", "--password", "hunter2"},
+		PID:      1234,
+		Hook:     hook.HookPreRun,
+		Metadata: map[string]interface{}{"cwd": "/home/user/secret-project"},
+	}
+
+	resp, err := w.evaluateIPCHook(context.Background(), req, &hook.Response{
+		Metadata: map[string]interface{}{"local_token": "abc"},
+	})
+	require.NoError(t, err)
+	assert.False(t, resp.Exit)
+
+	line := <-lines
+	// Like pid, the start time is always sent, as its zero value
+	assert.JSONEq(t, `{"command":["curl"],"pid":0,"hook":"pre_run","started_at":"0001-01-01T00:00:00Z"}`, line)
+	for _, leaked := range []string{"hunter2", "--password", "1234", "secret-project", "local_token"} {
+		assert.NotContains(t, line, leaked)
+	}
+
+	// The caller's request is left untouched
+	assert.Equal(t, []string{"curl", "--password", "hunter2"}, req.Command)
+}
+
+func TestOutboundRequestFilter_Nil(t *testing.T) {
+	socketPath, _ := captureSocket(t)
+
+	w := NewWrapperCommand(nil,
+		WithSocketPath(socketPath),
+		WithOutboundRequestFilter(func(*hook.Request) *hook.Request { return nil }),
+	)
+	_, err := w.evaluateIPCHook(context.Background(), &hook.Request{Command: []string{"ls"}, Hook: hook.HookPreRun}, nil)
+	assert.Error(t, err)
+}
+
+func TestRequestFieldsFromEnv(t *testing.T) {
+	t.Setenv(EnvRequestFields, "command_name, metadata.timed_out")
+
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	w := NewWrapperCommand(nil, opts...)
+	require.NotNil(t, w.OutboundFilter)
+	got := w.OutboundFilter(&hook.Request{Command: []string{"ls", "-la"}, PID: 7, Hook: hook.HookPreRun})
+	assert.Equal(t, &hook.Request{Command: []string{"ls"}, Hook: hook.HookPreRun}, got)
+
+	t.Setenv(EnvRequestFields, "command,hostname")
+	_, err = optionsFromEnv()
+	assert.Error(t, err)
+}
+
+func TestAllowRequestFields_Batch(t *testing.T) {
+	req := &hook.Request{
+		Command: []string{"curl", "-u", "user:secret"},
+		Hook:    hook.HookPreRunBatch,
+		Batch:   [][]string{{"curl", "-u", "user:secret"}, {"sh"}},
+	}
+
+	got := AllowRequestFields(FieldCommandName)(req)
+	assert.Equal(t, [][]string{{"curl"}, {"sh"}}, got.Batch)
+	assert.Equal(t, req.Batch, AllowRequestFields(FieldCommand)(req).Batch)
+	assert.Nil(t, AllowRequestFields()(req).Batch)
+}
@@ -0,0 +1,165 @@
+package wrapper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// scriptHeader starts every wrapper script, and identifies the files
+// Uninstall may remove
+const scriptHeader = "#!/usr/bin/env bash\n# CmdHooks wrapper for "
+
+// ValidateCommandName rejects command names that are unsafe to use as a
+// wrapper filename
+func ValidateCommandName(command string) error {
+	switch {
+	case command == "":
+		return fmt.Errorf("invalid command name: empty")
+	case command == "." || command == "..":
+		return fmt.Errorf("invalid command name %q", command)
+	case strings.ContainsRune(command, '/') || strings.ContainsRune(command, os.PathSeparator):
+		return fmt.Errorf("invalid command name %q: must not contain path separators", command)
+	case strings.IndexFunc(command, unicode.IsControl) >= 0:
+		// The name is written into the script's header comment, where a
+		// newline would start a new shell line
+		return fmt.Errorf("invalid command name %q: must not contain control characters", command)
+	}
+	return nil
+}
+
+// ShellQuote returns a shell-safe single-quoted string. It wraps the input
+// in single quotes and escapes existing single quotes using the POSIX-safe
+// pattern of closing the quote, adding an escaped quote and reopening it.
+func ShellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	// Replace every single quote ' with '\''
+	// This closes the existing quote, inserts an escaped single quote, and reopens the quote.
+	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
+}
+
+// Script returns the wrapper script that runs command through wrapperCmd
+// (e.g. "cmdhooks run"). The script marks dir as the wrapper directory, so
+// the wrapper removes it from PATH when looking up the real command even
+// when no cmdhooks library set CMDHOOKS_WRAPPER_DIR.
+func Script(dir string, wrapperCmd []string, command string) string {
+	// Build exec command with safe shell quoting: wrapperCmd + command + "$@"
+	quotedWrapper := make([]string, 0, len(wrapperCmd))
+	for _, part := range wrapperCmd {
+		quotedWrapper = append(quotedWrapper, ShellQuote(part))
+	}
+	wrapperExec := strings.Join(append(quotedWrapper, ShellQuote(command)), " ") + " \"$@\""
+
+	return fmt.Sprintf(`%s%s (defaults to 'cmdhooks run', configurable via WithWrapperPath)
+CMDHOOKS_WRAPPER_DIR=%s exec %s
+`, scriptHeader, command, ShellQuote(dir), wrapperExec)
+}
+
+// WriteScript writes the executable wrapper script for command into dir
+func WriteScript(dir string, wrapperCmd []string, command string) error {
+	if err := ValidateCommandName(command); err != nil {
+		return err
+	}
+	wrapperPath := filepath.Join(dir, filepath.Base(command))
+	if filepath.Dir(wrapperPath) != filepath.Clean(dir) {
+		return fmt.Errorf("invalid command name %q: resolves outside the wrapper directory", command)
+	}
+
+	if err := os.WriteFile(wrapperPath, []byte(Script(dir, wrapperCmd, command)), 0600); err != nil {
+		return err
+	}
+
+	// Make wrapper executable
+	return os.Chmod(wrapperPath, 0700)
+}
+
+// Install writes persistent wrapper scripts for commands into dir, creating
+// it if needed. Putting dir first on PATH then routes the commands through
+// wrapperCmd, without the cmdhooks library; wrappers reach the interceptor
+// named by CMDHOOKS_SOCKET, and run commands unchecked when it is unset.
+// Existing files other than wrapper scripts are never overwritten.
+func Install(dir string, wrapperCmd []string, commands []string) error {
+	if len(wrapperCmd) == 0 {
+		return fmt.Errorf("no wrapper command given")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, command := range commands {
+		if err := ValidateCommandName(command); err != nil {
+			return err
+		}
+		// Wrapper scripts run under bash, so wrapping it would recurse
+		if command == "bash" {
+			return fmt.Errorf("invalid command 'bash': wrapping bash can cause recursive invocation")
+		}
+		path := filepath.Join(dir, command)
+		if _, err := os.Lstat(path); err == nil && !isWrapperScript(path) {
+			return fmt.Errorf("%s already exists and is not a cmdhooks wrapper", path)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, command := range commands {
+		if err := WriteScript(dir, wrapperCmd, command); err != nil {
+			return err
+		}
+		// Unlike per-run wrappers, installed ones serve every user of dir
+		if err := os.Chmod(filepath.Join(dir, command), 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Uninstall removes the wrapper scripts written by Install from dir and
+// returns the names of the removed commands. Other files, and dir itself,
+// are left in place.
+func Uninstall(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !isWrapperScript(path) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, entry.Name())
+	}
+	return removed, nil
+}
+
+// isWrapperScript reports whether path is a regular file, not a symlink,
+// holding a wrapper script
+func isWrapperScript(path string) bool {
+	if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	header := make([]byte, len(scriptHeader))
+	if _, err := io.ReadFull(f, header); err != nil {
+		return false
+	}
+	return bytes.Equal(header, []byte(scriptHeader))
+}
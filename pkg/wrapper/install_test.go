@@ -0,0 +1,80 @@
+package wrapper
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstall(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bin")
+	// Print the wrapper directory and the arguments instead of running hooks
+	wrapperCmd := []string{"sh", "-c", `echo "$CMDHOOKS_WRAPPER_DIR $*"`, "sh"}
+
+	require.NoError(t, Install(dir, wrapperCmd, []string{"git", "curl"}))
+
+	for _, command := range []string{"git", "curl"} {
+		info, err := os.Stat(filepath.Join(dir, command))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	}
+
+	out, err := exec.Command(filepath.Join(dir, "git"), "status", "it's").Output()
+	require.NoError(t, err)
+	assert.Equal(t, dir+" git status it's\n", string(out), "the script pins its directory and passes arguments through")
+
+	// Reinstalling over existing wrappers is fine
+	require.NoError(t, Install(dir, wrapperCmd, []string{"git"}))
+}
+
+func TestInstallRejects(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "make"), []byte("#!/bin/sh\n"), 0755))
+
+	tests := []struct {
+		name       string
+		wrapperCmd []string
+		commands   []string
+	}{
+		{"no wrapper command", nil, []string{"git"}},
+		{"path separator", []string{"cmdhooks", "run"}, []string{"../git"}},
+		{"newline", []string{"cmdhooks", "run"}, []string{"git", "x\ntouch /tmp/p"}},
+		{"bash", []string{"cmdhooks", "run"}, []string{"bash"}},
+		{"foreign file", []string{"cmdhooks", "run"}, []string{"git", "make"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, Install(dir, tt.wrapperCmd, tt.commands))
+			assert.NoFileExists(t, filepath.Join(dir, "git"), "nothing is written on error")
+		})
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "make"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n", string(content))
+}
+
+func TestUninstall(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Install(dir, []string{"cmdhooks", "run"}, []string{"git", "curl"}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "make"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "git"), filepath.Join(dir, "git-link")))
+
+	removed, err := Uninstall(dir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"git", "curl"}, removed)
+
+	assert.NoFileExists(t, filepath.Join(dir, "git"))
+	assert.NoFileExists(t, filepath.Join(dir, "curl"))
+	assert.FileExists(t, filepath.Join(dir, "make"), "other files are kept")
+	_, err = os.Lstat(filepath.Join(dir, "git-link"))
+	assert.NoError(t, err, "symlinks are kept")
+	assert.DirExists(t, dir)
+
+	_, err = Uninstall(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
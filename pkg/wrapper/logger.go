@@ -0,0 +1,31 @@
+package wrapper
+
+import (
+	"log"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// WithLogger sends the wrapper's diagnostic output to l, whether or not it
+// is verbose. Without a logger, output goes to the standard logger when
+// verbose and is discarded otherwise.
+func WithLogger(l hook.Logger) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.Logger = l
+	}
+}
+
+// logging reports whether diagnostic output is written anywhere
+func (w *WrapperCommand) logging() bool {
+	return w.Logger != nil || w.Verbose
+}
+
+// logf writes diagnostic output, if enabled
+func (w *WrapperCommand) logf(format string, v ...any) {
+	switch {
+	case w.Logger != nil:
+		w.Logger.Printf(format, v...)
+	case w.Verbose:
+		log.Printf(format, v...)
+	}
+}
@@ -0,0 +1,39 @@
+package wrapper
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// lookPathIn is exec.LookPath searching the directories of path instead of
+// $PATH, so commands are resolved without changing the process environment.
+// Names containing a path separator are checked as given.
+func lookPathIn(cmd, path string) (string, error) {
+	if strings.ContainsRune(cmd, '/') || strings.ContainsRune(cmd, filepath.Separator) {
+		return exec.LookPath(cmd)
+	}
+
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			// Unix shell semantics: an empty PATH entry means "."
+			dir = "."
+		}
+		candidate := filepath.Join(dir, cmd)
+		if !strings.ContainsRune(candidate, filepath.Separator) {
+			// Keep the candidate from being searched for in $PATH itself
+			candidate = "." + string(filepath.Separator) + candidate
+		}
+		found, err := exec.LookPath(candidate)
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(found) {
+			// As exec.LookPath, refuse commands found relative to the
+			// current directory
+			return found, &exec.Error{Name: cmd, Err: exec.ErrDot}
+		}
+		return found, nil
+	}
+	return "", &exec.Error{Name: cmd, Err: exec.ErrNotFound}
+}
@@ -0,0 +1,89 @@
+package wrapper
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookPathIn(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(first, "tool"), []byte("#!/bin/sh\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(second, "tool"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(first, "other"), []byte("#!/bin/sh\n"), 0755))
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	relative, err := filepath.Rel(wd, second)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		cmd     string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{name: "found in first dir", cmd: "other", path: first + ":" + second, want: filepath.Join(first, "other")},
+		{name: "skips non-executable file", cmd: "tool", path: first + ":" + second, want: filepath.Join(second, "tool")},
+		{name: "not found", cmd: "cmdhooks-missing-command", path: first + ":" + second, wantErr: exec.ErrNotFound},
+		{name: "not searched outside path", cmd: "other", path: second, wantErr: exec.ErrNotFound},
+		{name: "empty path", cmd: "other", path: "", wantErr: exec.ErrNotFound},
+		{name: "relative dir", cmd: "tool", path: relative, wantErr: exec.ErrDot},
+		{name: "name with separator", cmd: filepath.Join(first, "other"), path: "", want: filepath.Join(first, "other")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lookPathIn(tt.cmd, tt.path)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWrapperCommand_ConcurrentLookup(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cmdhooks-test-tool"), []byte("#!/bin/sh\nexit 0\n"), 0755))
+	path := strings.Join([]string{dir, os.Getenv("PATH")}, string(os.PathListSeparator))
+	t.Setenv("PATH", path)
+
+	const workers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*2)
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- NewWrapperCommand(nil).Run([]string{"cmdhooks-test-tool"})
+		}()
+		go func() {
+			defer wg.Done()
+			_, _, err := NewWrapperCommand(nil).lookPath("cmdhooks-missing-command")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	missing := 0
+	for err := range errs {
+		if errors.Is(err, exec.ErrNotFound) {
+			missing++
+			continue
+		}
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, workers, missing)
+	assert.Equal(t, path, os.Getenv("PATH"), "resolving commands must not change PATH")
+}
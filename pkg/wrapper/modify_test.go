@@ -0,0 +1,80 @@
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// argvScript returns a command that writes its arguments, one per line, to
+// out
+func argvScript(out string, args ...string) []string {
+	return append([]string{"sh", "-c", `printf '%s\n' "$@" > "$0"`, out}, args...)
+}
+
+func TestWrapperCommand_ModifiedCommand(t *testing.T) {
+	t.Run("local hook rewrite", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "argv")
+		localHook := newMockLocalHook("test", []string{"curl"})
+		localHook.allowAll = false
+		localHook.responses["curl:pre_run"] = &hook.Response{ModifiedCommand: argvScript(out, "--fail", "https://example.com/a b")}
+		localHook.responses["curl:post_run"] = &hook.Response{}
+
+		require.NoError(t, NewWrapperCommand(localHook).Run([]string{"curl", "https://example.com/a b"}))
+
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Equal(t, "--fail\nhttps://example.com/a b\n", string(data))
+	})
+
+	t.Run("IPC hook rewrite", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "argv")
+		var postRun *hook.Request
+		localHook := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+			if req.Hook == hook.HookPostRun {
+				postRun = req
+			}
+		}}
+		socketPath := serveResponse(t, `{"modified_command":["sh","-c","printf '%s\\n' \"$@\" > \"$0\"","`+out+`","stripped"]}`)
+
+		require.NoError(t, NewWrapperCommand(localHook, WithSocketPath(socketPath)).Run([]string{"rm", "-rf", "stripped"}))
+
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Equal(t, "stripped\n", string(data))
+		require.NotNil(t, postRun)
+		assert.Equal(t, []string{"rm", "-rf", "stripped"}, postRun.Command, "post-run keeps the original command")
+		assert.Equal(t, []string{"sh", "-c", `printf '%s\n' "$@" > "$0"`, out, "stripped"}, postRun.Metadata[hook.MetadataModifiedCommand])
+	})
+
+	t.Run("empty command name rejected", func(t *testing.T) {
+		localHook := newMockLocalHook("test", []string{"echo"})
+		localHook.allowAll = false
+		localHook.responses["echo:pre_run"] = &hook.Response{ModifiedCommand: []string{"", "x"}}
+
+		err := NewWrapperCommand(localHook).Run([]string{"echo", "hi"})
+		assert.ErrorContains(t, err, "invalid modified command")
+	})
+
+	t.Run("empty slice rejected", func(t *testing.T) {
+		socketPath := serveResponse(t, `{"modified_command":[]}`)
+
+		err := NewWrapperCommand(nil, WithSocketPath(socketPath)).Run([]string{"echo", "hi"})
+		assert.ErrorContains(t, err, "invalid modified command")
+	})
+
+	t.Run("ignored when blocking", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "argv")
+		localHook := newMockLocalHook("test", []string{"echo"})
+		localHook.allowAll = false
+		localHook.responses["echo:pre_run"] = &hook.Response{Exit: true, ModifiedCommand: argvScript(out)}
+
+		assert.Error(t, NewWrapperCommand(localHook).Run([]string{"echo", "hi"}))
+		assert.NoFileExists(t, out)
+	})
+}
@@ -0,0 +1,49 @@
+package wrapper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// WithOutputHashing attaches the SHA-256 digests of each command's stdout
+// and stderr to its post-run request, under hook.MetadataStdoutSHA256 and
+// hook.MetadataStderrSHA256. The digests are computed as the output is
+// captured, so the output is never read twice.
+//
+// The command then writes to pipes instead of directly to the capture
+// files, so the wrapper waits until every process holding them open, such
+// as a background child, has exited or closed its output.
+func WithOutputHashing(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.OutputHashing = enabled
+	}
+}
+
+// hashingWriter writes to an underlying writer while hashing what was
+// written
+type hashingWriter struct {
+	w io.Writer
+	h hash.Hash
+	n int64
+}
+
+func newHashingWriter(w io.Writer) *hashingWriter {
+	return &hashingWriter{w: w, h: sha256.New()}
+}
+
+// Write writes p to the underlying writer and hashes the bytes accepted
+func (hw *hashingWriter) Write(p []byte) (int, error) {
+	n, err := hw.w.Write(p)
+	hw.h.Write(p[:n])
+	hw.n += int64(n)
+	return n, err
+}
+
+// digest returns the digest of everything written so far
+func (hw *hashingWriter) digest() *hook.OutputDigest {
+	return &hook.OutputDigest{SHA256: hex.EncodeToString(hw.h.Sum(nil)), Bytes: hw.n}
+}
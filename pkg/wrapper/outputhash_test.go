@@ -0,0 +1,62 @@
+package wrapper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_OutputHashing(t *testing.T) {
+	// fileSHA256 hashes a captured output file independently of the wrapper
+	fileSHA256 := func(path string) string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+
+	var postRun *hook.Request
+	var stdoutSHA, stderrSHA string
+	h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+		if req.Hook == hook.HookPostRun {
+			postRun = req
+			stdoutSHA = fileSHA256(req.Metadata["stdout_file"].(string))
+			stderrSHA = fileSHA256(req.Metadata["stderr_file"].(string))
+		}
+	}}
+
+	// More output than a pipe buffer holds, so it is hashed in several writes
+	w := NewWrapperCommand(h, WithOutputHashing(true), WithTranscriptDetails(true))
+	assert.Equal(t, 3, exitCodeOf(t, w.Run([]string{"sh", "-c", "seq 1 20000; printf oops >&2; exit 3"})))
+	require.NotNil(t, postRun)
+
+	assert.Equal(t, stdoutSHA, postRun.Metadata[hook.MetadataStdoutSHA256])
+	assert.Equal(t, stderrSHA, postRun.Metadata[hook.MetadataStderrSHA256])
+	// sha256("oops")
+	assert.Equal(t, "d13f2eadd4ed5b027fa773a29520cc0d65ce374365d641112de786f8a029c2fe", postRun.Metadata[hook.MetadataStderrSHA256])
+
+	// Transcripts reuse the streamed digests
+	details := postRun.Metadata[hook.MetadataExecution].(hook.ExecutionDetails)
+	assert.Equal(t, stdoutSHA, details.Stdout.SHA256)
+	assert.Equal(t, int64(108894), details.Stdout.Bytes)
+
+	// Disabled by default
+	postRun = nil
+	require.NoError(t, NewWrapperCommand(h).Run([]string{"true"}))
+	require.NotNil(t, postRun)
+	assert.NotContains(t, postRun.Metadata, hook.MetadataStdoutSHA256)
+	assert.NotContains(t, postRun.Metadata, hook.MetadataStderrSHA256)
+}
+
+func TestOutputHashingFromEnv(t *testing.T) {
+	t.Setenv(EnvOutputHashing, "true")
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	assert.True(t, NewWrapperCommand(nil, opts...).OutputHashing)
+}
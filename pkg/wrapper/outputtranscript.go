@@ -0,0 +1,101 @@
+package wrapper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// WithOutputTranscript captures each command's stdout and stderr in a
+// single transcript file that tags every chunk with the stream it was
+// written to (see hook.OutputTranscriptWriter). The output is replayed to
+// the matching stream in the order it was written, and post-run hooks find
+// the transcript under hook.MetadataOutputTranscript instead of separate
+// stdout and stderr files.
+//
+// Chunks are ordered as the wrapper reads them from the command's pipes,
+// so writes to both streams in quick succession may be reordered relative
+// to each other. As with output hashing, the wrapper waits until every
+// process holding the pipes open has exited or closed its output.
+func WithOutputTranscript(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.OutputTranscript = enabled
+	}
+}
+
+// captureTranscript points the command's stdout and stderr at a transcript
+// file, recorded in result
+func (w *WrapperCommand) captureTranscript(execCmd *exec.Cmd, result *commandResult) (func(), error) {
+	file, err := os.CreateTemp("", "cmdhooks-output-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output transcript file: %w", err)
+	}
+	w.cleanup.addFile(file.Name())
+
+	transcript := hook.NewOutputTranscriptWriter(file)
+	execCmd.Stdout = transcript.Stream(hook.OutputStdout)
+	execCmd.Stderr = transcript.Stream(hook.OutputStderr)
+	result.transcriptFile = file.Name()
+	return func() { file.Close() }, nil
+}
+
+// replayTranscript copies each chunk of the transcript at path to the
+// writer of its stream, in order
+func replayTranscript(path string, stdout, stderr io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := hook.NewOutputTranscriptReader(f)
+	for {
+		chunk, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		out := stdout
+		if chunk.Stream == hook.OutputStderr {
+			out = stderr
+		}
+		if _, err := out.Write(chunk.Data); err != nil {
+			return err
+		}
+	}
+}
+
+// digestTranscript hashes each stream of the transcript at path
+func digestTranscript(path string) (stdout, stderr hook.OutputDigest, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return stdout, stderr, err
+	}
+	defer f.Close()
+
+	hashes := map[hook.OutputStream]hash.Hash{hook.OutputStdout: sha256.New(), hook.OutputStderr: sha256.New()}
+	sizes := make(map[hook.OutputStream]int64)
+	r := hook.NewOutputTranscriptReader(f)
+	for {
+		chunk, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return stdout, stderr, err
+		}
+		hashes[chunk.Stream].Write(chunk.Data)
+		sizes[chunk.Stream] += int64(len(chunk.Data))
+	}
+	stdout = hook.OutputDigest{SHA256: hex.EncodeToString(hashes[hook.OutputStdout].Sum(nil)), Bytes: sizes[hook.OutputStdout]}
+	stderr = hook.OutputDigest{SHA256: hex.EncodeToString(hashes[hook.OutputStderr].Sum(nil)), Bytes: sizes[hook.OutputStderr]}
+	return stdout, stderr, nil
+}
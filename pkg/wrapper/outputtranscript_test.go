@@ -0,0 +1,86 @@
+package wrapper
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_OutputTranscript(t *testing.T) {
+	var postRun *hook.Request
+	var chunks []hook.OutputChunk
+	var stdout, stderr hook.OutputDigest
+	h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+		if req.Hook == hook.HookPostRun {
+			postRun = req
+			path := req.Metadata[hook.MetadataOutputTranscript].(string)
+			var err error
+			chunks, err = hook.ReadOutputTranscript(path)
+			require.NoError(t, err)
+			stdout, stderr, err = digestTranscript(path)
+			require.NoError(t, err)
+		}
+	}}
+
+	// Pauses between writes so the wrapper reads them in order
+	script := "echo one; sleep 0.1; echo two >&2; sleep 0.1; echo three; sleep 0.1; echo four >&2; exit 3"
+	w := NewWrapperCommand(h, WithOutputTranscript(true), WithOutputHashing(true), WithTranscriptDetails(true))
+	assert.Equal(t, 3, exitCodeOf(t, w.Run([]string{"sh", "-c", script})))
+	require.NotNil(t, postRun)
+
+	assert.NotContains(t, postRun.Metadata, "stdout_file")
+	assert.NotContains(t, postRun.Metadata, "stderr_file")
+	assert.Equal(t, "one\ntwo\nthree\nfour\n", string(hook.CombinedOutput(chunks)))
+	assert.Equal(t, "one\nthree\n", string(hook.StreamOutput(chunks, hook.OutputStdout)))
+	assert.Equal(t, "two\nfour\n", string(hook.StreamOutput(chunks, hook.OutputStderr)))
+
+	// Digests cover each stream on its own
+	assert.Equal(t, stdout.SHA256, postRun.Metadata[hook.MetadataStdoutSHA256])
+	assert.Equal(t, stderr.SHA256, postRun.Metadata[hook.MetadataStderrSHA256])
+	assert.Equal(t, int64(len("one\nthree\n")), stdout.Bytes)
+	details := postRun.Metadata[hook.MetadataExecution].(hook.ExecutionDetails)
+	assert.Equal(t, stderr, details.Stderr)
+
+	// Without output hashing, transcripts hash the captured output
+	postRun = nil
+	w = NewWrapperCommand(h, WithOutputTranscript(true), WithTranscriptDetails(true))
+	assert.Equal(t, 3, exitCodeOf(t, w.Run([]string{"sh", "-c", script})))
+	require.NotNil(t, postRun)
+	assert.NotContains(t, postRun.Metadata, hook.MetadataStdoutSHA256)
+	details = postRun.Metadata[hook.MetadataExecution].(hook.ExecutionDetails)
+	assert.Equal(t, stdout, details.Stdout)
+	assert.Equal(t, stderr, details.Stderr)
+}
+
+func TestReplayTranscript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript")
+	var buf bytes.Buffer
+	transcript := hook.NewOutputTranscriptWriter(&buf)
+	_, _ = transcript.Stream(hook.OutputStdout).Write([]byte("out1\n"))
+	_, _ = transcript.Stream(hook.OutputStderr).Write([]byte("err1\n"))
+	_, _ = transcript.Stream(hook.OutputStdout).Write([]byte("out2\n"))
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+
+	// A writer shared by both streams sees the original interleaving
+	var combined bytes.Buffer
+	require.NoError(t, replayTranscript(path, &combined, &combined))
+	assert.Equal(t, "out1\nerr1\nout2\n", combined.String())
+
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, replayTranscript(path, &stdout, &stderr))
+	assert.Equal(t, "out1\nout2\n", stdout.String())
+	assert.Equal(t, "err1\n", stderr.String())
+}
+
+func TestOutputTranscriptFromEnv(t *testing.T) {
+	t.Setenv(EnvOutputTranscript, "true")
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	assert.True(t, NewWrapperCommand(nil, opts...).OutputTranscript)
+}
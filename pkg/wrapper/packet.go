@@ -0,0 +1,46 @@
+package wrapper
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// IPC socket networks
+const (
+	// NetworkStream frames messages with newlines over a stream socket
+	NetworkStream = "unix"
+	// NetworkSeqpacket sends one message per packet (Linux only)
+	NetworkSeqpacket = "unixpacket"
+)
+
+// WithSocketNetwork sets the network used to reach the interceptor socket
+func WithSocketNetwork(network string) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.SocketNetwork = network
+	}
+}
+
+// exchangePacket sends a request as a single packet and reads the response
+// packet. The read buffer is one byte larger than the limit so oversized
+// (truncated) responses are detected instead of parsed.
+func exchangePacket(conn net.Conn, data []byte) (*hook.Response, error) {
+	if len(data) > MaxIPCMessageBytes {
+		return nil, fmt.Errorf("request exceeds %d bytes", MaxIPCMessageBytes)
+	}
+	if _, err := conn.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	buf := make([]byte, MaxIPCMessageBytes+1)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if n > MaxIPCMessageBytes {
+		return nil, fmt.Errorf("response exceeds %d bytes", MaxIPCMessageBytes)
+	}
+
+	return parseResponse(buf[:n])
+}
@@ -0,0 +1,61 @@
+//go:build linux
+
+package wrapper
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestRunHookSeqpacket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "seq.sock")
+	l, err := net.Listen("unixpacket", socketPath)
+	require.NoError(t, err)
+	defer l.Close()
+
+	received := make(chan hook.Request, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, MaxIPCMessageBytes)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		var req hook.Request
+		if json.Unmarshal(buf[:n], &req) == nil {
+			received <- req
+		}
+		conn.Write([]byte(`{"exit":true,"metadata":{"rule":"deny"}}`))
+	}()
+
+	w := NewWrapperCommand(nil, WithSocketPath(socketPath), WithSocketNetwork(NetworkSeqpacket))
+	resp, err := w.evaluateHooks(&hook.Request{Command: []string{"curl", "-s"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.True(t, resp.Exit)
+	assert.Equal(t, "deny", resp.Metadata["rule"])
+
+	req := <-received
+	assert.Equal(t, []string{"curl", "-s"}, req.Command)
+}
+
+func TestSocketNetworkFromEnv(t *testing.T) {
+	t.Setenv(EnvSocketNetwork, NetworkSeqpacket)
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, NetworkSeqpacket, NewWrapperCommand(nil, opts...).SocketNetwork)
+
+	t.Setenv(EnvSocketNetwork, "tcp")
+	_, err = optionsFromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package wrapper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// maxGatedExitCode is the highest exit code described by
+// FormatPostRunExitCodes. Codes outside 0 to maxGatedExitCode, such as the
+// -1 reported for a command killed by a signal, always get post-run.
+const maxGatedExitCode = 255
+
+// WithPostRunGate makes the wrapper skip the post-run IPC request of a
+// command whose exit code wants reports false, saving a round trip when no
+// hook needs it. Hooks that are evaluated locally are gated by their own
+// hook.PostRunFilter instead.
+func WithPostRunGate(wants func(exitCode int) bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.PostRunGate = wants
+	}
+}
+
+// wantsIPCPostRun reports whether the post-run request for exitCode should
+// be sent to the interceptor
+func (w *WrapperCommand) wantsIPCPostRun(exitCode int) bool {
+	return w.PostRunGate == nil || w.PostRunGate(exitCode)
+}
+
+// wantsLocalPostRun reports whether the local hook wants the post-run
+// request for exitCode
+func (w *WrapperCommand) wantsLocalPostRun(exitCode int) bool {
+	return w.Hook == nil || hook.WantsPostRun(w.Hook, exitCode)
+}
+
+// FormatPostRunExitCodes encodes the exit codes from 0 to 255 that wants
+// accepts for the CMDHOOKS_POST_RUN_EXIT_CODES environment variable, as
+// comma-separated codes and lo-hi ranges, or "none"
+func FormatPostRunExitCodes(wants func(exitCode int) bool) string {
+	var parts []string
+	for code := 0; code <= maxGatedExitCode; code++ {
+		if !wants(code) {
+			continue
+		}
+		end := code
+		for end < maxGatedExitCode && wants(end+1) {
+			end++
+		}
+		if end == code {
+			parts = append(parts, strconv.Itoa(code))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", code, end))
+		}
+		code = end
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ",")
+}
+
+// parsePostRunExitCodes decodes the output of FormatPostRunExitCodes
+func parsePostRunExitCodes(s string) (func(exitCode int) bool, error) {
+	var wanted [maxGatedExitCode + 1]bool
+	if s != "none" {
+		for _, part := range strings.Split(s, ",") {
+			part = strings.TrimSpace(part)
+			loStr, hiStr, isRange := strings.Cut(part, "-")
+			lo, err := strconv.Atoi(loStr)
+			hi := lo
+			if err == nil && isRange {
+				hi, err = strconv.Atoi(hiStr)
+			}
+			if err != nil || lo < 0 || hi > maxGatedExitCode || lo > hi {
+				return nil, fmt.Errorf("invalid exit code range %q", part)
+			}
+			for code := lo; code <= hi; code++ {
+				wanted[code] = true
+			}
+		}
+	}
+	return func(exitCode int) bool {
+		if exitCode < 0 || exitCode > maxGatedExitCode {
+			return true
+		}
+		return wanted[exitCode]
+	}, nil
+}
@@ -0,0 +1,104 @@
+package wrapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// failuresOnly wants post-run only for commands that failed
+func failuresOnly(exitCode int) bool { return exitCode != 0 }
+
+// failureLocalHook is a local hook that only wants post-run for failures
+type failureLocalHook struct {
+	recordingLocalHook
+}
+
+func (f *failureLocalHook) WantsPostRun(exitCode int) bool { return failuresOnly(exitCode) }
+
+func TestWrapperCommand_PostRunGate(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   string
+		gate      func(int) bool
+		wantHooks []hook.HookType
+	}{
+		{name: "success skips post-run", command: "true", gate: failuresOnly, wantHooks: []hook.HookType{hook.HookPreRun}},
+		{name: "failure sends post-run", command: "false", gate: failuresOnly, wantHooks: []hook.HookType{hook.HookPreRun, hook.HookPostRun}},
+		{name: "no gate", command: "true", wantHooks: []hook.HookType{hook.HookPreRun, hook.HookPostRun}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &connRecorder{}
+			opts := []WrapperOption{WithSocketPath(rec.serve(t))}
+			if tt.gate != nil {
+				opts = append(opts, WithPostRunGate(tt.gate))
+			}
+			w := NewWrapperCommand(nil, opts...)
+			w.Run([]string{tt.command})
+
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			var hooks []hook.HookType
+			for _, c := range rec.conns {
+				for _, req := range c {
+					hooks = append(hooks, req.Hook)
+				}
+			}
+			assert.Equal(t, tt.wantHooks, hooks)
+		})
+	}
+}
+
+func TestWrapperCommand_LocalPostRunFilter(t *testing.T) {
+	var hooks []hook.HookType
+	h := &failureLocalHook{recordingLocalHook{onEvaluate: func(req *hook.Request) {
+		hooks = append(hooks, req.Hook)
+	}}}
+
+	require.NoError(t, NewWrapperCommand(h).Run([]string{"true"}))
+	assert.Equal(t, []hook.HookType{hook.HookPreRun}, hooks)
+
+	hooks = nil
+	NewWrapperCommand(h).Run([]string{"false"})
+	assert.Equal(t, []hook.HookType{hook.HookPreRun, hook.HookPostRun}, hooks)
+}
+
+func TestPostRunExitCodesEnvRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		wants func(int) bool
+		want  string
+	}{
+		{name: "failures", wants: failuresOnly, want: "1-255"},
+		{name: "none", wants: func(int) bool { return false }, want: "none"},
+		{name: "mixed", wants: func(c int) bool { return c == 0 || c == 2 || c == 3 || c == 255 }, want: "0,2-3,255"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := FormatPostRunExitCodes(tt.wants)
+			assert.Equal(t, tt.want, encoded)
+
+			t.Setenv(EnvPostRunExitCodes, encoded)
+			opts, err := optionsFromEnv()
+			require.NoError(t, err)
+			gate := NewWrapperCommand(nil, opts...).PostRunGate
+			require.NotNil(t, gate)
+			for code := 0; code <= 255; code++ {
+				assert.Equal(t, tt.wants(code), gate(code), "exit code %d", code)
+			}
+			assert.True(t, gate(-1), "codes outside 0-255 always get post-run")
+		})
+	}
+
+	for _, bad := range []string{"x", "1-", "-1", "5-2", "0-256"} {
+		t.Setenv(EnvPostRunExitCodes, bad)
+		_, err := optionsFromEnv()
+		assert.Error(t, err, bad)
+	}
+}
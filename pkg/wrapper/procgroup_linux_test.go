@@ -0,0 +1,62 @@
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// processGone reports whether pid has exited, counting zombies left for a
+// parent that does not reap them
+func processGone(pid int) bool {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return true
+	}
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	return len(fields) > 0 && fields[0] == "Z"
+}
+
+func TestWrapperCommand_CommandTimeout(t *testing.T) {
+	var postRun *hook.Request
+	var output []byte
+	h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+		if req.Hook == hook.HookPostRun {
+			postRun = req
+			output, _ = os.ReadFile(req.Metadata["stdout_file"].(string))
+		}
+	}}
+	w := NewWrapperCommand(h, WithCommandTimeout(200*time.Millisecond))
+
+	// The command exceeds the budget, and takes its background child with it
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	start := time.Now()
+	exitCode := exitCodeOf(t, w.Run([]string{"sh", "-c", "sleep 30 & echo $! > " + pidFile + "; echo partial; wait"}))
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	assert.Equal(t, TimeoutExitCode, exitCode)
+	require.NotNil(t, postRun)
+	assert.Equal(t, true, postRun.Metadata["timed_out"])
+	assert.Equal(t, "partial\n", string(output), "post-run hooks get the output written so far")
+
+	data, err := os.ReadFile(pidFile)
+	require.NoError(t, err)
+	child, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return processGone(child) }, time.Second, 10*time.Millisecond,
+		"the command's process group is terminated")
+
+	// A command within the budget is unaffected
+	postRun = nil
+	assert.Equal(t, 0, exitCodeOf(t, w.Run([]string{"sleep", "0.05"})))
+	require.NotNil(t, postRun)
+	assert.NotContains(t, postRun.Metadata, "timed_out")
+}
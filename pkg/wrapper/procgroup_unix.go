@@ -0,0 +1,33 @@
+//go:build !windows
+
+package wrapper
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts cmd as the leader of a new process group, so it
+// can be signaled together with its children
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// signalProcessGroup sends sig to every process in the group p leads
+func signalProcessGroup(p *os.Process, sig syscall.Signal) error {
+	return syscall.Kill(-p.Pid, sig)
+}
+
+// forwardSignal sends sig to the process group cmd leads, or to cmd alone
+// if it shares the wrapper's group: keystrokes such as Ctrl-C already
+// signal that whole group
+func forwardSignal(cmd *exec.Cmd, sig syscall.Signal) error {
+	if attr := cmd.SysProcAttr; attr != nil && (attr.Setpgid || attr.Setsid) {
+		return signalProcessGroup(cmd.Process, sig)
+	}
+	return cmd.Process.Signal(sig)
+}
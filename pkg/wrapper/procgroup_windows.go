@@ -0,0 +1,23 @@
+//go:build windows
+
+package wrapper
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup does nothing: Windows has no process groups to signal
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup kills p, the only signal Windows supports
+func signalProcessGroup(p *os.Process, sig syscall.Signal) error {
+	return p.Kill()
+}
+
+// forwardSignal does nothing: console control events already reach every
+// process attached to the console
+func forwardSignal(cmd *exec.Cmd, sig syscall.Signal) error {
+	return nil
+}
@@ -0,0 +1,25 @@
+package wrapper
+
+import "os"
+
+// WithPTY runs each command under a pseudo-terminal when the wrapper's
+// stdin is a terminal, so programs such as ssh and git keep prompting,
+// paging and coloring as they do when run from a shell. Input and output
+// are proxied between the terminal and the pseudo-terminal while the
+// command runs, and window size changes are passed on.
+//
+// A pseudo-terminal has one output stream, so post-run hooks find
+// everything the command wrote, with terminal line endings, in the stdout
+// file (or the stdout stream of the output transcript), and the stderr file
+// is empty. Only supported on Linux; elsewhere, and when stdin is not a
+// terminal, commands run as without this option.
+func WithPTY(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.PTY = enabled
+	}
+}
+
+// usePTY reports whether the command runs under a pseudo-terminal
+func (w *WrapperCommand) usePTY() bool {
+	return w.PTY && isTerminal(os.Stdin)
+}
@@ -0,0 +1,165 @@
+package wrapper
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// ptySession proxies a terminal to a command running under a
+// pseudo-terminal
+type ptySession struct {
+	master, slave *os.File
+	// restore returns the terminal to the mode it had before the session
+	restore func()
+	// resize receives SIGWINCH while the session is open
+	resize chan os.Signal
+	// outputDone is closed once the command's output has been copied
+	outputDone chan struct{}
+}
+
+// startPTY points the command's stdio at a new pseudo-terminal, which
+// becomes its controlling terminal, and starts proxying: the wrapper's
+// stdin is put in raw mode and copied to the command, and the command's
+// output is copied to the wrapper's stdout and to output
+func startPTY(execCmd *exec.Cmd, output io.Writer) (*ptySession, error) {
+	stdin, stdout := os.Stdin, os.Stdout
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	_ = copyWinsize(stdin, master)
+
+	restore, err := makeRaw(stdin)
+	if err != nil {
+		master.Close()
+		slave.Close()
+		return nil, err
+	}
+
+	execCmd.Stdin = slave
+	execCmd.Stdout = slave
+	execCmd.Stderr = slave
+	if execCmd.SysProcAttr == nil {
+		execCmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// Ctty is the child's stdin, the pseudo-terminal
+	execCmd.SysProcAttr.Setsid = true
+	// The new session is also a new process group
+	execCmd.SysProcAttr.Setpgid = false
+	execCmd.SysProcAttr.Setctty = true
+	execCmd.SysProcAttr.Ctty = 0
+
+	s := &ptySession{
+		master:     master,
+		slave:      slave,
+		restore:    restore,
+		resize:     make(chan os.Signal, 1),
+		outputDone: make(chan struct{}),
+	}
+	signal.Notify(s.resize, syscall.SIGWINCH)
+	go func() {
+		for range s.resize {
+			_ = copyWinsize(stdin, master)
+		}
+	}()
+	go func() {
+		// Ends with EIO once every holder of the slave side has closed it
+		_, _ = io.Copy(io.MultiWriter(stdout, output), master)
+		close(s.outputDone)
+	}()
+	go func() {
+		// Ends when the master is closed after the command has finished
+		_, _ = io.Copy(master, stdin)
+	}()
+	return s, nil
+}
+
+// finish waits for the command's remaining output once it has exited, and
+// restores the terminal
+func (s *ptySession) finish() {
+	s.slave.Close()
+	<-s.outputDone
+	signal.Stop(s.resize)
+	close(s.resize)
+	s.master.Close()
+	s.restore()
+}
+
+// openPTY opens a new pseudo-terminal pair
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open pseudo-terminal: %w", err)
+	}
+	var unlock int32
+	if err := ioctl(master, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("failed to unlock pseudo-terminal: %w", err)
+	}
+	var n uint32
+	if err := ioctl(master, syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("failed to get pseudo-terminal number: %w", err)
+	}
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("failed to open pseudo-terminal: %w", err)
+	}
+	return master, slave, nil
+}
+
+// isTerminal reports whether f is a terminal
+func isTerminal(f *os.File) bool {
+	var termios syscall.Termios
+	return ioctl(f, syscall.TCGETS, unsafe.Pointer(&termios)) == nil
+}
+
+// makeRaw puts the terminal f in raw mode, so keystrokes reach the command
+// unprocessed, and returns a function restoring its previous mode
+func makeRaw(f *os.File) (func(), error) {
+	var old syscall.Termios
+	if err := ioctl(f, syscall.TCGETS, unsafe.Pointer(&old)); err != nil {
+		return nil, fmt.Errorf("failed to read terminal mode: %w", err)
+	}
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(f, syscall.TCSETS, unsafe.Pointer(&raw)); err != nil {
+		return nil, fmt.Errorf("failed to set terminal mode: %w", err)
+	}
+	return func() { _ = ioctl(f, syscall.TCSETS, unsafe.Pointer(&old)) }, nil
+}
+
+// winsize is the terminal window size read and set by TIOCGWINSZ and
+// TIOCSWINSZ
+type winsize struct {
+	Row, Col, Xpixel, Ypixel uint16
+}
+
+// copyWinsize sets the window size of the terminal to to that of from
+func copyWinsize(from, to *os.File) error {
+	var ws winsize
+	if err := ioctl(from, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return err
+	}
+	return ioctl(to, syscall.TIOCSWINSZ, unsafe.Pointer(&ws))
+}
+
+// ioctl performs the ioctl request on f
+func ioctl(f *os.File, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
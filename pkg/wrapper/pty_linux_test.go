@@ -0,0 +1,81 @@
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestOpenPTY(t *testing.T) {
+	master, slave, err := openPTY()
+	require.NoError(t, err)
+	defer master.Close()
+	defer slave.Close()
+
+	assert.True(t, isTerminal(slave))
+	regular, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	require.NoError(t, err)
+	defer regular.Close()
+	assert.False(t, isTerminal(regular))
+
+	_, err = slave.Write([]byte("hello\n"))
+	require.NoError(t, err)
+	buf := make([]byte, 64)
+	n, err := master.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\r\n", string(buf[:n]))
+}
+
+func TestWrapperCommand_PTY(t *testing.T) {
+	// Give the wrapper a terminal on stdin, as an interactive shell would
+	master, slave, err := openPTY()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		master.Close()
+		slave.Close()
+	})
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	oldStdin, oldStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = slave, devNull
+	t.Cleanup(func() {
+		os.Stdin, os.Stdout = oldStdin, oldStdout
+		devNull.Close()
+	})
+
+	var captured []byte
+	h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+		if req.Hook == hook.HookPostRun {
+			var err error
+			captured, err = os.ReadFile(req.Metadata["stdout_file"].(string))
+			require.NoError(t, err)
+		}
+	}}
+
+	script := "test -t 0 && test -t 1 && test -t 2 && echo tty"
+	w := NewWrapperCommand(h, WithPTY(true))
+	exitCode := exitCodeOf(t, w.Run([]string{"sh", "-c", script}))
+	assert.Equal(t, 0, exitCode, "the command runs under a terminal")
+	assert.Equal(t, "tty\r\n", string(captured), "post-run hooks still get the output")
+
+	// Without the option, the command's output is captured to files
+	captured = nil
+	w = NewWrapperCommand(h)
+	exitCode = exitCodeOf(t, w.Run([]string{"sh", "-c", script}))
+	assert.Equal(t, 1, exitCode)
+	assert.Empty(t, captured)
+}
+
+func TestWrapperCommand_PTYInteractive(t *testing.T) {
+	if !isTerminal(os.Stdin) {
+		t.Skip("stdin is not a terminal")
+	}
+
+	w := NewWrapperCommand(&recordingLocalHook{onEvaluate: func(*hook.Request) {}}, WithPTY(true))
+	assert.Equal(t, 0, exitCodeOf(t, w.Run([]string{"test", "-t", "1"})))
+}
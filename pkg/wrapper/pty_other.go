@@ -0,0 +1,28 @@
+//go:build !linux
+
+package wrapper
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// ptySession is not available on this platform
+type ptySession struct{}
+
+// startPTY fails: pseudo-terminals are only supported on Linux
+func startPTY(execCmd *exec.Cmd, output io.Writer) (*ptySession, error) {
+	return nil, fmt.Errorf("pseudo-terminals are not supported on %s", runtime.GOOS)
+}
+
+// finish does nothing
+func (s *ptySession) finish() {}
+
+// isTerminal reports no terminal, so commands never run under a
+// pseudo-terminal
+func isTerminal(f *os.File) bool {
+	return false
+}
@@ -0,0 +1,80 @@
+package wrapper
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// serveResponse answers every request on a new socket with the raw JSON
+// response line
+func serveResponse(t *testing.T, response string) string {
+	socketPath := filepath.Join(t.TempDir(), "reason.sock")
+	l, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					conn.Write([]byte(response + "\n"))
+				}
+			}()
+		}
+	}()
+	return socketPath
+}
+
+func TestWrapperCommand_BlockReason(t *testing.T) {
+	t.Run("local hook denial", func(t *testing.T) {
+		localHook := newMockLocalHook("test", []string{"echo"})
+		localHook.allowAll = false
+		localHook.responses["echo:pre_run"] = &hook.Response{Exit: true, Reason: "echo is not allowed here"}
+
+		err := NewWrapperCommand(localHook).Run([]string{"echo", "test"})
+		assert.EqualError(t, err, "process termination requested: echo is not allowed here")
+	})
+
+	t.Run("IPC hook denial", func(t *testing.T) {
+		socketPath := serveResponse(t, `{"exit":true,"reason":"network access denied"}`)
+
+		err := NewWrapperCommand(nil, WithSocketPath(socketPath)).Run([]string{"echo", "test"})
+		assert.EqualError(t, err, "process termination requested: network access denied")
+	})
+
+	t.Run("IPC hook denial with legacy reason metadata", func(t *testing.T) {
+		socketPath := serveResponse(t, `{"exit":true,"metadata":{"reason":"quota exceeded"}}`)
+
+		err := NewWrapperCommand(nil, WithSocketPath(socketPath)).Run([]string{"echo", "test"})
+		assert.EqualError(t, err, "process termination requested: quota exceeded")
+	})
+
+	t.Run("denial without a reason", func(t *testing.T) {
+		socketPath := serveResponse(t, `{"exit":true}`)
+
+		err := NewWrapperCommand(nil, WithSocketPath(socketPath)).Run([]string{"echo", "test"})
+		assert.EqualError(t, err, "process termination requested")
+	})
+
+	t.Run("runHook preserves the reason", func(t *testing.T) {
+		socketPath := serveResponse(t, `{"exit":true,"reason":"blocked by policy"}`)
+
+		resp, err := runHook(NetworkStream, socketPath, nil, hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun}, nil)
+		require.NoError(t, err)
+		assert.True(t, resp.Exit)
+		assert.Equal(t, "blocked by policy", resp.Reason)
+	})
+}
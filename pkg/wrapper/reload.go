@@ -0,0 +1,36 @@
+package wrapper
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// EnvReloadToken holds the token `cmdhooks reload` authenticates with, so
+// it does not appear in the process list
+const EnvReloadToken = "CMDHOOKS_RELOAD_TOKEN"
+
+// RequestReload asks the interceptor listening on socketPath to reload its
+// hook, authenticating with token, and returns the name of the new hook.
+// network is NetworkStream or NetworkSeqpacket; empty means stream.
+func RequestReload(network, socketPath, token string) (string, error) {
+	req := hook.Request{
+		PID:      os.Getpid(),
+		Hook:     hook.HookReload,
+		Metadata: map[string]interface{}{hook.MetadataReloadToken: token},
+	}
+	resp, err := runHook(network, socketPath, nil, req, nil)
+	if err != nil {
+		return "", err
+	}
+	if reloaded, _ := resp.Metadata["reloaded"].(bool); !reloaded {
+		reason, _ := resp.Metadata["error"].(string)
+		if reason == "" {
+			reason = "interceptor does not support reloading"
+		}
+		return "", fmt.Errorf("hook reload failed: %s", reason)
+	}
+	name, _ := resp.Metadata["hook"].(string)
+	return name, nil
+}
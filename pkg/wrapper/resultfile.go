@@ -0,0 +1,99 @@
+package wrapper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// Keys written to the result file, one KEY='value' line each. Values are
+// single-quoted with ShellQuote and never span lines, so the file can be
+// sourced by a shell or split on the first '=' by other readers.
+const (
+	ResultCommand  = "CMDHOOKS_RESULT_COMMAND"   // the wrapped command line
+	ResultStage    = "CMDHOOKS_RESULT_STAGE"     // pre_run or post_run
+	ResultBlocked  = "CMDHOOKS_RESULT_BLOCKED"   // true or false
+	ResultReason   = "CMDHOOKS_RESULT_REASON"    // the hook's reason (see hook.Response.BlockReason)
+	ResultWarnings = "CMDHOOKS_RESULT_WARNINGS"  // the hook's "warnings" metadata, joined by "; "
+	ResultExitCode = "CMDHOOKS_RESULT_EXIT_CODE" // the command's exit code, post_run only
+)
+
+// WithResultFile makes the wrapper write the outcome of each hook
+// evaluation to path: after pre-run, and again after post-run if the
+// command ran. The file is replaced each time, so it describes the last
+// evaluated stage of the last wrapped command.
+func WithResultFile(path string) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.ResultFile = path
+	}
+}
+
+// writeResultFile records a hook decision in the result file, if one is
+// configured. exitCode is only written for post-run. Failures are reported
+// but never affect the command.
+func (w *WrapperCommand) writeResultFile(command []string, stage hook.HookType, resp *hook.Response, exitCode int) {
+	if w.ResultFile == "" {
+		return
+	}
+
+	reason := resp.BlockReason()
+	lines := []string{
+		resultLine(ResultCommand, strings.Join(command, " ")),
+		resultLine(ResultStage, string(stage)),
+		resultLine(ResultBlocked, strconv.FormatBool(resp.Denied())),
+		resultLine(ResultReason, reason),
+		resultLine(ResultWarnings, strings.Join(responseWarnings(resp), "; ")),
+	}
+	if stage == hook.HookPostRun {
+		lines = append(lines, resultLine(ResultExitCode, strconv.Itoa(exitCode)))
+	}
+
+	// Write a sibling file and rename it, so readers never see a partial
+	// result
+	tmp, err := os.CreateTemp(filepath.Dir(w.ResultFile), ".cmdhooks-result-*")
+	if err == nil {
+		_, err = tmp.WriteString(strings.Join(lines, "\n") + "\n")
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), w.ResultFile)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write result file %s: %v\n", w.ResultFile, err)
+	}
+}
+
+// resultLine formats one KEY='value' line, folding newlines into spaces
+func resultLine(key, value string) string {
+	value = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(value)
+	return key + "=" + ShellQuote(value)
+}
+
+// responseWarnings returns the "warnings" metadata of resp, given either as
+// a string or a list of strings
+func responseWarnings(resp *hook.Response) []string {
+	switch v := resp.Metadata["warnings"].(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		var warnings []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				warnings = append(warnings, s)
+			}
+		}
+		return warnings
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package wrapper
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// readResultFile parses a result file into its keys and unquoted values
+func readResultFile(t *testing.T, path string) map[string]string {
+	t.Helper()
+	out, err := exec.Command("bash", "-c", `set -a && . "$1" && env | grep ^CMDHOOKS_RESULT_`, "bash", path).Output()
+	require.NoError(t, err)
+
+	fields := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		key, value, _ := strings.Cut(line, "=")
+		fields[key] = value
+	}
+	return fields
+}
+
+func TestWrapperCommand_ResultFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		command []string
+		want    map[string]string
+	}{
+		{
+			name:    "allowed",
+			command: []string{"sh", "-c", "exit 3"},
+			want: map[string]string{
+				ResultCommand:  "sh -c exit 3",
+				ResultStage:    "post_run",
+				ResultBlocked:  "false",
+				ResultReason:   "",
+				ResultWarnings: "deprecated flag; slow\nmirror",
+				ResultExitCode: "3",
+			},
+		},
+		{
+			name:    "blocked",
+			command: []string{"curl", "https://example.com"},
+			want: map[string]string{
+				ResultCommand:  "curl https://example.com",
+				ResultStage:    "pre_run",
+				ResultBlocked:  "true",
+				ResultReason:   "network access is not allowed",
+				ResultWarnings: "",
+			},
+		},
+	}
+
+	h := &mockLocalHook{
+		name:     "test",
+		commands: []string{"sh", "curl"},
+		responses: map[string]*hook.Response{
+			"sh:pre_run": {},
+			"sh:post_run": {Metadata: map[string]interface{}{
+				"warnings": []interface{}{"deprecated flag", "slow\nmirror"},
+			}},
+			"curl:pre_run": {Exit: true, Metadata: map[string]interface{}{
+				"reason": "network access is not allowed",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "result.env")
+			w := NewWrapperCommand(h, WithResultFile(path))
+			_ = w.Run(tt.command)
+
+			fields := readResultFile(t, path)
+			// Newlines are folded, so every value stays on one line
+			tt.want[ResultWarnings] = strings.ReplaceAll(tt.want[ResultWarnings], "\n", " ")
+			for key, want := range tt.want {
+				assert.Equal(t, want, fields[key], key)
+			}
+			if _, ok := tt.want[ResultExitCode]; !ok {
+				assert.NotContains(t, fields, ResultExitCode)
+			}
+
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), len(tt.want))
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package wrapper
+
+import (
+	"fmt"
+	"os"
+)
+
+// ScratchEnv names the environment variable holding a command's scratch
+// directory
+const ScratchEnv = "CMDHOOKS_SCRATCH"
+
+// WithScratchDir gives every wrapped command a private temp directory,
+// exported as CMDHOOKS_SCRATCH and reported to hooks as "scratch_dir". The
+// directory is removed once the command and its post-run hook have finished.
+func WithScratchDir(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.ScratchDir = enabled
+	}
+}
+
+// createScratchDir creates the scratch directory for the current command and
+// registers its removal on every exit path
+func (w *WrapperCommand) createScratchDir(metadata map[string]any) error {
+	dir, err := os.MkdirTemp("", "cmdhooks-scratch-*")
+	if err != nil {
+		return fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	w.cleanup.add(func() { os.RemoveAll(dir) })
+	w.scratchDir = dir
+	metadata["scratch_dir"] = dir
+	return nil
+}
+
+// scratchEnv returns the environment entry exporting the scratch directory,
+// or nil if there is none
+func (w *WrapperCommand) scratchEnv() []string {
+	if w.scratchDir == "" {
+		return nil
+	}
+	return []string{ScratchEnv + "=" + w.scratchDir}
+}
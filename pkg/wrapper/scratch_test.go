@@ -0,0 +1,91 @@
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_ScratchDir(t *testing.T) {
+	script := `test -d "$CMDHOOKS_SCRATCH" && touch "$CMDHOOKS_SCRATCH/artifact" && printf %s "$CMDHOOKS_SCRATCH"`
+
+	var preRunDir, postRunDir string
+	artifactAtPostRun := false
+	h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+		dir, _ := req.Metadata["scratch_dir"].(string)
+		switch req.Hook {
+		case hook.HookPreRun:
+			preRunDir = dir
+		case hook.HookPostRun:
+			postRunDir = dir
+			if data, err := os.ReadFile(req.Metadata["stdout_file"].(string)); err == nil {
+				assert.Equal(t, dir, string(data), "the command sees the same directory")
+			}
+			_, err := os.Stat(filepath.Join(dir, "artifact"))
+			artifactAtPostRun = err == nil
+		}
+	}}
+
+	w := NewWrapperCommand(h, WithScratchDir(true))
+	require.NoError(t, w.Run([]string{"sh", "-c", script}))
+
+	require.NotEmpty(t, preRunDir)
+	assert.Equal(t, preRunDir, postRunDir)
+	assert.True(t, artifactAtPostRun, "post-run hooks can inspect artifacts")
+	_, err := os.Stat(preRunDir)
+	assert.True(t, os.IsNotExist(err), "scratch dir is removed after the command")
+
+	// Each command gets its own directory
+	first := preRunDir
+	require.NoError(t, w.Run([]string{"sh", "-c", script}))
+	assert.NotEqual(t, first, preRunDir)
+}
+
+func TestWrapperCommand_ScratchDirRemovedOnAllExitPaths(t *testing.T) {
+	blockPostRun := newMockLocalHook("test", []string{"sh"})
+	blockPostRun.allowAll = false
+	blockPostRun.responses["sh:"+string(hook.HookPreRun)] = &hook.Response{}
+
+	blockPreRun := newMockLocalHook("test", []string{"sh"})
+	blockPreRun.allowAll = false
+
+	tests := []struct {
+		name    string
+		hook    hook.Hook
+		command []string
+	}{
+		{name: "non-zero exit", command: []string{"sh", "-c", "touch $CMDHOOKS_SCRATCH/x; exit 3"}},
+		{name: "blocked pre-run", hook: blockPreRun, command: []string{"sh", "-c", "true"}},
+		{name: "blocked post-run", hook: blockPostRun, command: []string{"sh", "-c", "touch $CMDHOOKS_SCRATCH/x"}},
+		{name: "command not found", command: []string{"cmdhooks-definitely-missing-command"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("TMPDIR", tmpDir)
+
+			_ = NewWrapperCommand(tt.hook, WithScratchDir(true)).Run(tt.command)
+
+			entries, err := os.ReadDir(tmpDir)
+			require.NoError(t, err)
+			for _, e := range entries {
+				assert.False(t, strings.HasPrefix(e.Name(), "cmdhooks-scratch-"), "leftover %s", e.Name())
+			}
+		})
+	}
+}
+
+func TestWrapperCommand_NoScratchDirByDefault(t *testing.T) {
+	var postRun *hook.Request
+	h := &recordingLocalHook{onEvaluate: func(req *hook.Request) { postRun = req }}
+
+	require.NoError(t, NewWrapperCommand(h).Run([]string{"true"}))
+	assert.NotContains(t, postRun.Metadata, "scratch_dir")
+}
@@ -0,0 +1,175 @@
+package wrapper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// DefaultInterpreters maps the interpreters recognized by script detection
+// to the position of the script among their non-option arguments
+var DefaultInterpreters = map[string]int{
+	"python":  1,
+	"python3": 1,
+	"node":    1,
+	"ruby":    1,
+	"bash":    1,
+	"sh":      1,
+}
+
+// inlineCodeFlags run code given on the command line instead of a script,
+// or a module instead of a file, so no script path follows them
+var inlineCodeFlags = map[string]bool{
+	"-c":      true, // python, bash, sh
+	"-e":      true, // node, ruby
+	"-m":      true, // python
+	"-p":      true, // node
+	"--eval":  true, // node
+	"--print": true, // node
+}
+
+// WithInterpretedScripts enables script detection: when the command is one
+// of the interpreters in the map (matched by basename), the script it runs
+// is taken from its arguments and recorded in the request metadata under
+// hook.MetadataScriptPath and hook.MetadataScriptSHA256, so hooks can judge
+// the script rather than the interpreter. Each interpreter maps to the
+// position of the script among its arguments, counting from 1 and skipping
+// options (arguments starting with "-" before any "--"). Commands given
+// inline with -c, -e, -m and similar carry no script. Options that take a
+// separate value, like `python -W error foo.py`, are not understood, so the
+// position must account for their values.
+func WithInterpretedScripts(interpreters map[string]int) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.Interpreters = interpreters
+	}
+}
+
+// ValidateInterpreters checks that every interpreter is a valid command
+// name that can be carried in the environment and every position is
+// positive
+func ValidateInterpreters(interpreters map[string]int) error {
+	for name, pos := range interpreters {
+		if err := ValidateCommandName(name); err != nil {
+			return err
+		}
+		if strings.ContainsAny(name, "=,") {
+			return fmt.Errorf("invalid interpreter name %q", name)
+		}
+		if pos < 1 {
+			return fmt.Errorf("script position for %q must be positive", name)
+		}
+	}
+	return nil
+}
+
+// FormatInterpreters encodes interpreters for the
+// CMDHOOKS_INTERPRETED_SCRIPTS environment variable as comma-separated
+// name=position pairs
+func FormatInterpreters(interpreters map[string]int) string {
+	names := make([]string, 0, len(interpreters))
+	for name := range interpreters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+strconv.Itoa(interpreters[name]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// parseInterpreters decodes the output of FormatInterpreters
+func parseInterpreters(s string) (map[string]int, error) {
+	interpreters := make(map[string]int)
+	for _, pair := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid interpreter %q: expected name=position", pair)
+		}
+		pos, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interpreter %q: %w", pair, err)
+		}
+		interpreters[name] = pos
+	}
+	if err := ValidateInterpreters(interpreters); err != nil {
+		return nil, err
+	}
+	return interpreters, nil
+}
+
+// ScriptPath returns the script command runs through one of interpreters,
+// as given on the command line
+func ScriptPath(command []string, interpreters map[string]int) (string, bool) {
+	if len(command) == 0 {
+		return "", false
+	}
+	pos, ok := interpreters[filepath.Base(command[0])]
+	if !ok {
+		return "", false
+	}
+
+	options := true
+	for _, arg := range command[1:] {
+		if options && strings.HasPrefix(arg, "-") && arg != "-" {
+			if arg == "--" {
+				options = false
+			} else if inlineCodeFlags[arg] {
+				return "", false
+			}
+			continue
+		}
+		if pos--; pos == 0 {
+			// "-" reads the script from stdin
+			if arg == "-" {
+				return "", false
+			}
+			return arg, true
+		}
+	}
+	return "", false
+}
+
+// detectScript records the script an interpreter command runs in metadata
+func (w *WrapperCommand) detectScript(command []string, metadata map[string]any) {
+	script, ok := ScriptPath(command, w.Interpreters)
+	if !ok {
+		return
+	}
+	if !filepath.IsAbs(script) {
+		script = filepath.Join(w.workingDir(), script)
+	}
+	metadata[hook.MetadataScriptPath] = script
+
+	digest, err := fileSHA256(script)
+	if err != nil {
+		// The interpreter reports a missing script the usual way
+		w.logf("Script detection: cannot hash %s: %v", script, err)
+		return
+	}
+	metadata[hook.MetadataScriptSHA256] = digest
+}
+
+// fileSHA256 returns the hex SHA-256 digest of the file at path
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
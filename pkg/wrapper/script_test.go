@@ -0,0 +1,99 @@
+package wrapper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestScriptPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		command []string
+		want    string
+	}{
+		{name: "python", command: []string{"python", "foo.py", "--verbose"}, want: "foo.py"},
+		{name: "python3 with options", command: []string{"python3", "-u", "-B", "foo.py"}, want: "foo.py"},
+		{name: "python module", command: []string{"python", "-m", "http.server"}},
+		{name: "python inline code", command: []string{"python3", "-c", "print(1)"}},
+		{name: "node", command: []string{"node", "server.js", "8080"}, want: "server.js"},
+		{name: "node eval", command: []string{"node", "--eval", "1"}},
+		{name: "ruby", command: []string{"ruby", "-w", "tool.rb"}, want: "tool.rb"},
+		{name: "ruby inline code", command: []string{"ruby", "-e", "puts 1"}},
+		{name: "bash", command: []string{"bash", "./deploy.sh", "prod"}, want: "./deploy.sh"},
+		{name: "bash after double dash", command: []string{"bash", "--", "-odd.sh"}, want: "-odd.sh"},
+		{name: "bash -c", command: []string{"bash", "-c", "echo hi"}},
+		{name: "interpreter by path", command: []string{"/usr/bin/python3", "foo.py"}, want: "foo.py"},
+		{name: "script from stdin", command: []string{"python", "-"}},
+		{name: "no script", command: []string{"python"}},
+		{name: "not an interpreter", command: []string{"curl", "foo.py"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ScriptPath(tt.command, DefaultInterpreters)
+			assert.Equal(t, tt.want != "", ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Custom positions select a later argument, e.g. after a subcommand
+	got, ok := ScriptPath([]string{"deno", "run", "main.ts"}, map[string]int{"deno": 2})
+	assert.True(t, ok)
+	assert.Equal(t, "main.ts", got)
+}
+
+func TestWrapperCommand_InterpretedScripts(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("exit 0\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "task.sh"), content, 0600))
+	sum := sha256.Sum256(content)
+
+	run := func(opts []WrapperOption, command ...string) map[string]interface{} {
+		var preRun *hook.Request
+		h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+			if req.Hook == hook.HookPreRun {
+				preRun = req
+			}
+		}}
+		w := NewWrapperCommand(h, append(opts, WithWorkingDir(dir))...)
+		w.Run(command)
+		require.NotNil(t, preRun)
+		return preRun.Metadata
+	}
+
+	enabled := []WrapperOption{WithInterpretedScripts(DefaultInterpreters)}
+
+	metadata := run(enabled, "sh", "task.sh")
+	assert.Equal(t, filepath.Join(dir, "task.sh"), metadata[hook.MetadataScriptPath], "relative paths resolve against the working directory")
+	assert.Equal(t, hex.EncodeToString(sum[:]), metadata[hook.MetadataScriptSHA256])
+
+	metadata = run(enabled, "sh", "missing.sh")
+	assert.Equal(t, filepath.Join(dir, "missing.sh"), metadata[hook.MetadataScriptPath])
+	assert.NotContains(t, metadata, hook.MetadataScriptSHA256, "unreadable scripts are not hashed")
+
+	metadata = run(nil, "sh", "task.sh")
+	assert.NotContains(t, metadata, hook.MetadataScriptPath, "disabled by default")
+}
+
+func TestInterpretersEnvRoundTrip(t *testing.T) {
+	interpreters := map[string]int{"python3": 1, "deno": 2}
+	t.Setenv(EnvInterpretedScripts, FormatInterpreters(interpreters))
+
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, interpreters, NewWrapperCommand(nil, opts...).Interpreters)
+
+	for _, bad := range []string{"python", "python=first", "python=0", "a/b=1"} {
+		t.Setenv(EnvInterpretedScripts, bad)
+		_, err := optionsFromEnv()
+		assert.Error(t, err, bad)
+	}
+}
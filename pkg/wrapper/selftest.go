@@ -0,0 +1,45 @@
+package wrapper
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// WithSelfTest makes Run send a canary pre-run request for the command
+// instead of running it, and succeed only if the interceptor confirms the
+// canary. cmdhooks uses it to check the wrapper→interceptor→hook path at
+// startup.
+func WithSelfTest(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.SelfTest = enabled
+	}
+}
+
+// runSelfTest sends the canary request for command. The outbound filter is
+// bypassed, since it could strip the marker that keeps the interceptor from
+// acting on the decision; the canary carries only the command name.
+func (w *WrapperCommand) runSelfTest(command []string) error {
+	if w.SocketPath == "" {
+		return fmt.Errorf("self-test: no interceptor socket configured")
+	}
+
+	req := hook.Request{
+		Command:   command[:1],
+		PID:       os.Getpid(),
+		Hook:      hook.HookPreRun,
+		RequestID: newRequestID(),
+		Metadata:  map[string]interface{}{hook.MetadataSelfTest: true},
+	}
+	resp, err := runHook(w.SocketNetwork, w.SocketPath, w.TLSConfig, req, nil)
+	if err != nil {
+		return fmt.Errorf("self-test: %w", err)
+	}
+	if confirmed, _ := resp.Metadata[hook.MetadataSelfTest].(bool); !confirmed {
+		return fmt.Errorf("self-test: interceptor did not confirm the canary request")
+	}
+
+	w.logf("Self-test passed for %s (hook decision: %v)", command[0], resp.Metadata["decision"])
+	return nil
+}
@@ -0,0 +1,84 @@
+package wrapper
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// forwardedSignals are relayed to the wrapped command while it runs
+var forwardedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
+
+// signalRelay forwards the signals the wrapper receives to the wrapped
+// command, so it stops as the user asked while the wrapper stays alive to
+// run post-run hooks and flush the output
+type signalRelay struct {
+	w       *WrapperCommand
+	signals chan os.Signal
+	done    chan struct{}
+
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	pending []os.Signal
+}
+
+// relaySignals starts catching forwardedSignals. Signals arriving before
+// the command is attached are forwarded once it is.
+func (w *WrapperCommand) relaySignals() *signalRelay {
+	r := &signalRelay{
+		w:       w,
+		signals: make(chan os.Signal, len(forwardedSignals)),
+		done:    make(chan struct{}),
+	}
+	signal.Notify(r.signals, forwardedSignals...)
+	go func() {
+		for {
+			select {
+			case sig := <-r.signals:
+				r.forward(sig)
+			case <-r.done:
+				return
+			}
+		}
+	}()
+	return r
+}
+
+// attach sets the started command signals are forwarded to
+func (r *signalRelay) attach(cmd *exec.Cmd) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cmd = cmd
+	for _, sig := range r.pending {
+		r.send(sig)
+	}
+	r.pending = nil
+}
+
+// forward sends sig to the command, or holds it until the command starts
+func (r *signalRelay) forward(sig os.Signal) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cmd == nil {
+		r.pending = append(r.pending, sig)
+		return
+	}
+	r.send(sig)
+}
+
+// send delivers sig to the attached command, with r.mu held
+func (r *signalRelay) send(sig os.Signal) {
+	if err := forwardSignal(r.cmd, sig.(syscall.Signal)); err != nil {
+		r.w.logf("Failed to forward %v to %s: %v", sig, r.cmd.Path, err)
+		return
+	}
+	r.w.logf("Forwarded %v to %s", sig, r.cmd.Path)
+}
+
+// stop restores the default handling of forwardedSignals
+func (r *signalRelay) stop() {
+	signal.Stop(r.signals)
+	close(r.done)
+}
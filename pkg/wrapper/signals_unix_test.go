@@ -0,0 +1,76 @@
+//go:build !windows
+
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_ForwardsSignals(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		sig  syscall.Signal
+		opts []WrapperOption
+	}{
+		{name: "SIGTERM", sig: syscall.SIGTERM},
+		{name: "SIGINT", sig: syscall.SIGINT},
+		// A command with a timeout leads its own process group
+		{name: "process group", sig: syscall.SIGHUP, opts: []WrapperOption{WithCommandTimeout(time.Minute)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var postRun *hook.Request
+			var output []byte
+			h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+				if req.Hook == hook.HookPostRun {
+					postRun = req
+					output, _ = os.ReadFile(req.Metadata["stdout_file"].(string))
+				}
+			}}
+
+			dir := t.TempDir()
+			ready := filepath.Join(dir, "ready")
+			script := "trap 'echo caught; exit 7' " + signalName(tt.sig) + "; touch " + ready + "; while :; do sleep 0.05; done"
+
+			done := make(chan error, 1)
+			w := NewWrapperCommand(h, tt.opts...)
+			go func() { done <- w.Run([]string{"sh", "-c", script}) }()
+
+			require.Eventually(t, func() bool {
+				_, err := os.Stat(ready)
+				return err == nil
+			}, 5*time.Second, 10*time.Millisecond)
+			require.NoError(t, syscall.Kill(os.Getpid(), tt.sig))
+
+			select {
+			case err := <-done:
+				assert.Equal(t, 7, exitCodeOf(t, err), "the command handled the forwarded signal")
+			case <-time.After(5 * time.Second):
+				t.Fatal("the command should stop when the wrapper is signaled")
+			}
+			require.NotNil(t, postRun, "post-run hooks still run")
+			assert.Equal(t, "caught\n", string(output))
+		})
+	}
+}
+
+// signalName returns the name sh's trap uses for sig
+func signalName(sig syscall.Signal) string {
+	switch sig {
+	case syscall.SIGINT:
+		return "INT"
+	case syscall.SIGTERM:
+		return "TERM"
+	case syscall.SIGHUP:
+		return "HUP"
+	}
+	return ""
+}
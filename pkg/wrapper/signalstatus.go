@@ -0,0 +1,31 @@
+package wrapper
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// WithSignalExitStatus reports a command killed by a signal with the
+// shell-style exit status 128 plus the signal number, e.g. 137 for
+// SIGKILL, instead of -1, which the wrapper's own exit would turn into 255.
+// Post-run hooks see the same status.
+//
+// It is opt-in because it changes what post-run hooks see: by default a
+// signal kill is reported as -1, which hooks can tell apart from any real
+// exit status and which the post-run gate always lets through, whereas 137
+// could also be a command's own exit status.
+func WithSignalExitStatus(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.SignalExitStatus = enabled
+	}
+}
+
+// exitStatus returns the exit code of a command that exited unsuccessfully
+func (w *WrapperCommand) exitStatus(exitErr *exec.ExitError) int {
+	if w.SignalExitStatus {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+	}
+	return exitErr.ExitCode()
+}
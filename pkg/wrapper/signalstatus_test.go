@@ -0,0 +1,48 @@
+package wrapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_SignalExitStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		script   string
+		wantCode int
+	}{
+		{name: "SIGKILL", enabled: true, script: "kill -KILL $$", wantCode: 137},
+		{name: "SIGTERM", enabled: true, script: "kill -TERM $$", wantCode: 143},
+		{name: "normal exit unchanged", enabled: true, script: "exit 3", wantCode: 3},
+		{name: "disabled", enabled: false, script: "kill -KILL $$", wantCode: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var postRun *hook.Request
+			h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+				if req.Hook == hook.HookPostRun {
+					postRun = req
+				}
+			}}
+
+			w := NewWrapperCommand(h, WithSignalExitStatus(tt.enabled))
+			exitCode := exitCodeOf(t, w.Run([]string{"sh", "-c", tt.script}))
+			assert.Equal(t, tt.wantCode, exitCode)
+			require.NotNil(t, postRun)
+			assert.Equal(t, tt.wantCode, postRun.ExitCode, "post-run hooks see the same status")
+		})
+	}
+}
+
+func TestSignalExitStatusFromEnv(t *testing.T) {
+	t.Setenv(EnvSignalExitStatus, "true")
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	assert.True(t, NewWrapperCommand(nil, opts...).SignalExitStatus)
+}
@@ -0,0 +1,30 @@
+package wrapper
+
+import (
+	"io"
+	"os"
+	"os/exec"
+)
+
+// WithStreamingOutput shows each command's output as it is written instead
+// of replaying it once the command has finished, so long-running and
+// tail -f style commands are not silent until they exit. The output is
+// still captured for post-run hooks, in the stdout and stderr files or the
+// output transcript. Off by default.
+//
+// As with output hashing, the wrapper copies the output through pipes and
+// waits until every process holding them open has exited or closed its
+// output.
+func WithStreamingOutput(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.StreamOutput = enabled
+	}
+}
+
+// streamOutput tees the command's captured stdout and stderr to the
+// wrapper's own, marking result so the output is not replayed
+func streamOutput(execCmd *exec.Cmd, result *commandResult) {
+	execCmd.Stdout = io.MultiWriter(os.Stdout, execCmd.Stdout)
+	execCmd.Stderr = io.MultiWriter(os.Stderr, execCmd.Stderr)
+	result.streamed = true
+}
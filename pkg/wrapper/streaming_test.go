@@ -0,0 +1,61 @@
+package wrapper
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_StreamingOutput(t *testing.T) {
+	r, pw, err := os.Pipe()
+	require.NoError(t, err)
+	oldStdout := os.Stdout
+	os.Stdout = pw
+	t.Cleanup(func() { os.Stdout = oldStdout })
+
+	var captured []byte
+	h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+		if req.Hook == hook.HookPostRun {
+			var err error
+			captured, err = os.ReadFile(req.Metadata["stdout_file"].(string))
+			require.NoError(t, err)
+		}
+	}}
+
+	done := make(chan error, 1)
+	w := NewWrapperCommand(h, WithStreamingOutput(true))
+	go func() {
+		done <- w.Run([]string{"sh", "-c", "echo first; sleep 0.5; echo second"})
+		pw.Close()
+	}()
+
+	// The first line shows up while the command is still running
+	out := bufio.NewReader(r)
+	line, err := out.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "first\n", line)
+	select {
+	case <-done:
+		t.Fatal("output should stream before the command finishes")
+	default:
+	}
+
+	require.NoError(t, <-done)
+	rest, err := io.ReadAll(out)
+	require.NoError(t, err)
+	assert.Equal(t, "second\n", string(rest), "streamed output is not replayed")
+	assert.Equal(t, "first\nsecond\n", string(captured), "post-run hooks still get the full output")
+}
+
+func TestStreamingOutputFromEnv(t *testing.T) {
+	t.Setenv(EnvStreamOutput, "true")
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	assert.True(t, NewWrapperCommand(nil, opts...).StreamOutput)
+}
@@ -0,0 +1,139 @@
+package wrapper
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTimeoutKey is the CommandTimeouts key used for commands that
+	// match no other entry
+	DefaultTimeoutKey = "*"
+
+	// TimeoutExitCode is the exit code reported for commands killed after
+	// exceeding their timeout, matching timeout(1)
+	TimeoutExitCode = 124
+
+	// timeoutGracePeriod is how long a timed-out command has to exit after
+	// SIGTERM before it is killed
+	timeoutGracePeriod = 5 * time.Second
+)
+
+// WithDeadlineHeadroom reserves part of each command timeout for post-run
+// evaluation: a command with a timeout is asked to stop d before its
+// deadline, so post-run hooks still run within the timeout when the command
+// uses up its time. The headroom is capped at half the timeout.
+func WithDeadlineHeadroom(d time.Duration) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.DeadlineHeadroom = d
+	}
+}
+
+// WithCommandTimeout limits how long each wrapped command may run. A
+// command still running after d is sent SIGTERM, along with the rest of its
+// process group, and SIGKILL if it does not exit within a grace period; it
+// then exits with TimeoutExitCode, and post-run hooks receive "timed_out"
+// metadata with the output written so far. Commands with a timeout run in
+// their own process group, so they cannot read from the terminal. Entries
+// of WithCommandTimeouts take precedence.
+func WithCommandTimeout(d time.Duration) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.CommandTimeout = d
+	}
+}
+
+// runLimit returns how long a command with the given timeout may run before
+// it is stopped, after reserving the deadline headroom
+func (w *WrapperCommand) runLimit(timeout time.Duration) time.Duration {
+	if timeout <= 0 || w.DeadlineHeadroom <= 0 {
+		return timeout
+	}
+	return timeout - min(w.DeadlineHeadroom, timeout/2)
+}
+
+// timeoutFor returns the timeout configured for cmd, or 0 for none.
+// An exact name match wins over a glob match, and glob matches win over the
+// default entry, then CommandTimeout. Among glob matches the lexically
+// first pattern is used so the choice is deterministic.
+func (w *WrapperCommand) timeoutFor(cmd string) time.Duration {
+	if len(w.CommandTimeouts) == 0 {
+		return w.CommandTimeout
+	}
+	if d, ok := w.CommandTimeouts[cmd]; ok {
+		return d
+	}
+
+	patterns := make([]string, 0, len(w.CommandTimeouts))
+	for pattern := range w.CommandTimeouts {
+		if pattern != DefaultTimeoutKey {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, cmd); matched {
+			return w.CommandTimeouts[pattern]
+		}
+	}
+
+	if d, ok := w.CommandTimeouts[DefaultTimeoutKey]; ok {
+		return d
+	}
+	return w.CommandTimeout
+}
+
+// ValidateCommandTimeouts checks that every key is a valid pattern that can
+// be carried in the environment and every timeout is positive
+func ValidateCommandTimeouts(timeouts map[string]time.Duration) error {
+	for key, d := range timeouts {
+		if key == "" || strings.ContainsAny(key, "=,") {
+			return fmt.Errorf("invalid command timeout key %q", key)
+		}
+		if _, err := path.Match(key, ""); err != nil {
+			return fmt.Errorf("invalid command timeout pattern %q: %w", key, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("timeout for %q must be positive", key)
+		}
+	}
+	return nil
+}
+
+// FormatCommandTimeouts encodes timeouts for the CMDHOOKS_COMMAND_TIMEOUTS
+// environment variable as comma-separated name=duration pairs
+func FormatCommandTimeouts(timeouts map[string]time.Duration) string {
+	keys := make([]string, 0, len(timeouts))
+	for key := range timeouts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+timeouts[key].String())
+	}
+	return strings.Join(pairs, ",")
+}
+
+// parseCommandTimeouts decodes the output of FormatCommandTimeouts
+func parseCommandTimeouts(s string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid command timeout %q: expected name=duration", pair)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid command timeout %q: %w", pair, err)
+		}
+		timeouts[key] = d
+	}
+	if err := ValidateCommandTimeouts(timeouts); err != nil {
+		return nil, err
+	}
+	return timeouts, nil
+}
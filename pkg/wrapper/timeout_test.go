@@ -0,0 +1,180 @@
+package wrapper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_timeoutFor(t *testing.T) {
+	w := NewWrapperCommand(nil, WithCommandTimeouts(map[string]time.Duration{
+		"make":        time.Hour,
+		"curl":        30 * time.Second,
+		"terraform-*": 10 * time.Minute,
+		"terraform-x": time.Minute,
+		"*":           5 * time.Minute,
+	}))
+
+	tests := []struct {
+		cmd  string
+		want time.Duration
+	}{
+		{"make", time.Hour},
+		{"curl", 30 * time.Second},
+		{"terraform-plan", 10 * time.Minute},
+		{"terraform-x", time.Minute},
+		{"ls", 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			assert.Equal(t, tt.want, w.timeoutFor(tt.cmd))
+		})
+	}
+
+	t.Run("no default", func(t *testing.T) {
+		w := NewWrapperCommand(nil, WithCommandTimeouts(map[string]time.Duration{"curl": time.Second}))
+		assert.Zero(t, w.timeoutFor("make"))
+	})
+
+	t.Run("command timeout", func(t *testing.T) {
+		w := NewWrapperCommand(nil,
+			WithCommandTimeout(time.Minute),
+			WithCommandTimeouts(map[string]time.Duration{"curl": time.Second}),
+		)
+		assert.Equal(t, time.Second, w.timeoutFor("curl"))
+		assert.Equal(t, time.Minute, w.timeoutFor("make"))
+		assert.Equal(t, time.Minute, NewWrapperCommand(nil, WithCommandTimeout(time.Minute)).timeoutFor("make"))
+	})
+}
+
+func TestWrapperCommand_CommandTimeoutExceeded(t *testing.T) {
+	var postRun *hook.Request
+	h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+		if req.Hook == hook.HookPostRun {
+			postRun = req
+		}
+	}}
+
+	w := NewWrapperCommand(h, WithCommandTimeouts(map[string]time.Duration{
+		"sleep": 100 * time.Millisecond,
+		"*":     time.Minute,
+	}))
+
+	start := time.Now()
+	exitCode := exitCodeOf(t, w.Run([]string{"sleep", "10"}))
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	assert.Equal(t, TimeoutExitCode, exitCode)
+	require.NotNil(t, postRun)
+	assert.Equal(t, TimeoutExitCode, postRun.ExitCode)
+	assert.Equal(t, true, postRun.Metadata["timed_out"])
+
+	// Commands within their timeout are unaffected
+	postRun = nil
+	assert.Equal(t, 0, exitCodeOf(t, w.Run([]string{"true"})))
+	require.NotNil(t, postRun)
+	assert.NotContains(t, postRun.Metadata, "timed_out")
+}
+
+func TestWrapperCommand_runLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		headroom time.Duration
+		timeout  time.Duration
+		want     time.Duration
+	}{
+		{"no headroom", 0, time.Minute, time.Minute},
+		{"no timeout", time.Second, 0, 0},
+		{"reserved", 10 * time.Second, time.Minute, 50 * time.Second},
+		{"capped at half the timeout", time.Minute, 10 * time.Second, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWrapperCommand(nil, WithDeadlineHeadroom(tt.headroom))
+			assert.Equal(t, tt.want, w.runLimit(tt.timeout))
+		})
+	}
+}
+
+func TestWrapperCommand_DeadlineHeadroom(t *testing.T) {
+	const timeout = time.Second
+	var postRunAt time.Duration
+	start := time.Now()
+	h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+		if req.Hook == hook.HookPostRun {
+			postRunAt = time.Since(start)
+		}
+	}}
+
+	w := NewWrapperCommand(h,
+		WithCommandTimeouts(map[string]time.Duration{"sleep": timeout}),
+		WithDeadlineHeadroom(400*time.Millisecond),
+	)
+	exitCode := exitCodeOf(t, w.Run([]string{"sleep", "10"}))
+
+	assert.Equal(t, TimeoutExitCode, exitCode)
+	require.NotZero(t, postRunAt, "post-run hooks ran")
+	assert.GreaterOrEqual(t, postRunAt, 600*time.Millisecond, "the command used its share of the timeout")
+	assert.Less(t, postRunAt, timeout, "post-run evaluation started within the reserved headroom")
+}
+
+func TestCommandTimeoutsEnvRoundTrip(t *testing.T) {
+	timeouts := map[string]time.Duration{
+		"make":   time.Hour,
+		"curl-*": 30 * time.Second,
+		"*":      time.Minute,
+	}
+	t.Setenv(EnvCommandTimeouts, FormatCommandTimeouts(timeouts))
+
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	w := NewWrapperCommand(nil, opts...)
+	assert.Equal(t, timeouts, w.CommandTimeouts)
+
+	for _, bad := range []string{"make", "make=soon", "make=-1s", "[=1s"} {
+		t.Setenv(EnvCommandTimeouts, bad)
+		_, err := optionsFromEnv()
+		assert.Error(t, err, bad)
+	}
+	t.Setenv(EnvCommandTimeouts, "")
+
+	t.Setenv(EnvDeadlineHeadroom, "2s")
+	opts, err = optionsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, NewWrapperCommand(nil, opts...).DeadlineHeadroom)
+
+	for _, bad := range []string{"soon", "0s", "-1s"} {
+		t.Setenv(EnvDeadlineHeadroom, bad)
+		_, err := optionsFromEnv()
+		assert.Error(t, err, bad)
+	}
+	t.Setenv(EnvDeadlineHeadroom, "")
+
+	t.Setenv(EnvCommandTimeout, "250ms")
+	opts, err = optionsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, NewWrapperCommand(nil, opts...).CommandTimeout)
+
+	for _, bad := range []string{"soon", "0s", "-1s"} {
+		t.Setenv(EnvCommandTimeout, bad)
+		_, err := optionsFromEnv()
+		assert.Error(t, err, bad)
+	}
+}
+
+// recordingLocalHook allows every command and reports each request
+type recordingLocalHook struct {
+	onEvaluate func(req *hook.Request)
+}
+
+func (r *recordingLocalHook) Name() string       { return "recording" }
+func (r *recordingLocalHook) Commands() []string { return []string{"*"} }
+func (r *recordingLocalHook) EvaluateLocal(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	r.onEvaluate(req)
+	return &hook.Response{}, nil
+}
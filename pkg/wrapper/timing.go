@@ -0,0 +1,44 @@
+package wrapper
+
+import (
+	"time"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// phaseTimes holds how long the phases of the current command took
+type phaseTimes struct {
+	preRun    time.Duration
+	execution time.Duration
+	postRun   time.Duration
+}
+
+// WithTimingBreakdown makes the wrapper report how long the pre-run
+// evaluation took in the post-run request (see
+// hook.MetadataPreRunDuration), alongside the execution time it always
+// reports. With verbose output, every phase is also logged once the
+// command's output has been replayed.
+func WithTimingBreakdown(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.TimingBreakdown = enabled
+	}
+}
+
+// reportPreRunTime adds the pre-run evaluation time to post-run metadata,
+// if the timing breakdown is enabled
+func (w *WrapperCommand) reportPreRunTime(metadata map[string]any) {
+	if w.TimingBreakdown {
+		metadata[hook.MetadataPreRunDuration] = w.timing.preRun
+	}
+}
+
+// logTiming logs the time spent in each phase, if the timing breakdown is
+// enabled and output is verbose
+func (w *WrapperCommand) logTiming(replay time.Duration) {
+	if !w.TimingBreakdown || !w.logging() {
+		return
+	}
+	t := w.timing
+	w.logf("Timing: pre-run %v, execution %v, post-run %v, output replay %v, total %v",
+		t.preRun, t.execution, t.postRun, replay, t.preRun+t.execution+t.postRun+replay)
+}
@@ -0,0 +1,41 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestWrapperCommand_TimingBreakdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "enabled", enabled: true},
+		{name: "disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socketPath, lines := captureSocket(t)
+			w := NewWrapperCommand(nil, WithSocketPath(socketPath), WithTimingBreakdown(tt.enabled))
+			require.NoError(t, w.Run([]string{"true"}))
+
+			var preRun, postRun hook.Request
+			require.NoError(t, json.Unmarshal([]byte(<-lines), &preRun))
+			require.NoError(t, json.Unmarshal([]byte(<-lines), &postRun))
+			assert.NotContains(t, preRun.Metadata, hook.MetadataPreRunDuration)
+			if !tt.enabled {
+				assert.NotContains(t, postRun.Metadata, hook.MetadataPreRunDuration)
+				return
+			}
+			assert.Greater(t, postRun.Metadata[hook.MetadataPreRunDuration], float64(0))
+			assert.Positive(t, w.timing.execution)
+			assert.Positive(t, w.timing.postRun)
+		})
+	}
+}
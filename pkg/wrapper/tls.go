@@ -0,0 +1,72 @@
+package wrapper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// DefaultTLSServerName is the name verified in the interceptor's
+// certificate when TLSClientFiles leaves ServerName empty
+const DefaultTLSServerName = "localhost"
+
+// WithTLS makes the wrapper connect to the interceptor over TLS, configured
+// by cfg. cfg must set ServerName, since a socket path names no host. The
+// interceptor must be configured for TLS as well.
+func WithTLS(cfg *tls.Config) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.TLSConfig = cfg
+	}
+}
+
+// TLSClientFiles locates the PEM files a wrapper uses to verify the
+// interceptor and to authenticate itself. Wrappers run in separate
+// processes, so their TLS configuration is passed as files.
+type TLSClientFiles struct {
+	// CAFile holds the certificates the interceptor's certificate is
+	// verified against. Empty uses the system roots.
+	CAFile string
+	// CertFile and KeyFile hold the client certificate presented to an
+	// interceptor that requires one. Both or neither must be set.
+	CertFile string
+	KeyFile  string
+	// ServerName is the name verified in the interceptor's certificate,
+	// DefaultTLSServerName if empty
+	ServerName string
+}
+
+// Load reads the files into a client TLS configuration
+func (f TLSClientFiles) Load() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName: f.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = DefaultTLSServerName
+	}
+
+	if f.CAFile != "" {
+		data, err := os.ReadFile(f.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", f.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if (f.CertFile == "") != (f.KeyFile == "") {
+		return nil, fmt.Errorf("client certificate and key files must be set together")
+	}
+	if f.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
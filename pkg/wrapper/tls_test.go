@@ -0,0 +1,187 @@
+package wrapper
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+	"github.com/codysoyland/cmdhooks/pkg/interceptor"
+)
+
+// testPKI is a self-signed CA with a server and a client certificate
+// issued by it, written as PEM files
+type testPKI struct {
+	caFile     string
+	serverCert tls.Certificate
+	clientCert string
+	clientKey  string
+	pool       *x509.CertPool
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cmdhooks test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: DefaultTLSServerName},
+			DNSNames:     []string{DefaultTLSServerName},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+
+	pki := &testPKI{
+		caFile:     filepath.Join(dir, "ca.pem"),
+		clientCert: filepath.Join(dir, "client.pem"),
+		clientKey:  filepath.Join(dir, "client-key.pem"),
+		pool:       x509.NewCertPool(),
+	}
+	pki.pool.AddCert(ca)
+	require.NoError(t, os.WriteFile(pki.caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600))
+
+	serverPEM, serverKeyPEM := issue(2, x509.ExtKeyUsageServerAuth)
+	pki.serverCert, err = tls.X509KeyPair(serverPEM, serverKeyPEM)
+	require.NoError(t, err)
+
+	clientPEM, clientKeyPEM := issue(3, x509.ExtKeyUsageClientAuth)
+	require.NoError(t, os.WriteFile(pki.clientCert, clientPEM, 0600))
+	require.NoError(t, os.WriteFile(pki.clientKey, clientKeyPEM, 0600))
+	return pki
+}
+
+// allowHook is an IPCHook allowing every command
+type allowHook struct{}
+
+func (allowHook) Name() string       { return "allow" }
+func (allowHook) Commands() []string { return []string{"*"} }
+func (allowHook) EvaluateIPC(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	return &hook.Response{Reason: "allowed over TLS"}, nil
+}
+
+func TestRunHookTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	socketPath := filepath.Join(t.TempDir(), "tls.sock")
+	i := interceptor.New(socketPath, false, allowHook{})
+	i.SetTLS(&tls.Config{Certificates: []tls.Certificate{pki.serverCert}, ClientCAs: pki.pool})
+	require.NoError(t, i.Start())
+	defer i.Stop()
+
+	req := hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun}
+	tests := []struct {
+		name    string
+		files   *TLSClientFiles
+		wantErr bool
+	}{
+		{
+			name:  "mutually authenticated",
+			files: &TLSClientFiles{CAFile: pki.caFile, CertFile: pki.clientCert, KeyFile: pki.clientKey},
+		},
+		{
+			name:    "client without certificate is rejected",
+			files:   &TLSClientFiles{CAFile: pki.caFile},
+			wantErr: true,
+		},
+		{
+			name:    "untrusted server is rejected",
+			files:   &TLSClientFiles{CertFile: pki.clientCert, KeyFile: pki.clientKey},
+			wantErr: true,
+		},
+		{
+			name:    "plaintext client is rejected",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg *tls.Config
+			if tt.files != nil {
+				var err error
+				cfg, err = tt.files.Load()
+				require.NoError(t, err)
+			}
+
+			resp, err := runHook(NetworkStream, socketPath, cfg, req, nil)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "allowed over TLS", resp.Reason)
+		})
+	}
+}
+
+func TestTLSClientFilesLoad(t *testing.T) {
+	pki := newTestPKI(t)
+
+	cfg, err := TLSClientFiles{CAFile: pki.caFile, CertFile: pki.clientCert, KeyFile: pki.clientKey}.Load()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTLSServerName, cfg.ServerName)
+	assert.Len(t, cfg.Certificates, 1)
+	assert.NotNil(t, cfg.RootCAs)
+
+	_, err = TLSClientFiles{CAFile: filepath.Join(t.TempDir(), "missing.pem")}.Load()
+	assert.ErrorContains(t, err, "failed to read CA file")
+	_, err = TLSClientFiles{CAFile: pki.clientKey}.Load()
+	assert.ErrorContains(t, err, "no certificates found")
+	_, err = TLSClientFiles{CertFile: pki.clientCert}.Load()
+	assert.ErrorContains(t, err, "must be set together")
+}
+
+func TestTLSFromEnv(t *testing.T) {
+	pki := newTestPKI(t)
+	t.Setenv(EnvTLSCAFile, pki.caFile)
+	t.Setenv(EnvTLSServerName, "interceptor.internal")
+	opts, err := optionsFromEnv()
+	require.NoError(t, err)
+	w := NewWrapperCommand(nil, opts...)
+	require.NotNil(t, w.TLSConfig)
+	assert.Equal(t, "interceptor.internal", w.TLSConfig.ServerName)
+
+	t.Setenv(EnvTLSCertFile, pki.clientCert)
+	_, err = optionsFromEnv()
+	assert.ErrorContains(t, err, "invalid TLS configuration")
+}
@@ -0,0 +1,83 @@
+package wrapper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+// MaxTranscriptEnvBytes caps the environment snapshot sent with each
+// post-run request, keeping requests well under MaxIPCMessageBytes
+const MaxTranscriptEnvBytes = 16 * 1024
+
+// WithTranscriptDetails attaches hook.ExecutionDetails (environment
+// snapshot and output digests) to post-run requests, for transcripts
+func WithTranscriptDetails(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.TranscriptDetails = enabled
+	}
+}
+
+// executionDetails describes the environment and output of a finished command
+func (w *WrapperCommand) executionDetails(result commandResult) hook.ExecutionDetails {
+	details := hook.ExecutionDetails{WorkingDir: w.workingDir()}
+	details.Env, details.EnvTruncated = envSnapshot(append(os.Environ(), w.scratchEnv()...), MaxTranscriptEnvBytes)
+
+	// Reuse digests computed while the output was captured
+	var err error
+	if result.transcriptFile != "" && (result.stdoutDigest == nil || result.stderrDigest == nil) {
+		if details.Stdout, details.Stderr, err = digestTranscript(result.transcriptFile); err != nil {
+			w.logf("Failed to hash output: %v", err)
+		}
+		return details
+	}
+	if result.stdoutDigest != nil {
+		details.Stdout = *result.stdoutDigest
+	} else if details.Stdout, err = digestFile(result.stdoutFile); err != nil {
+		w.logf("Failed to hash stdout: %v", err)
+	}
+	if result.stderrDigest != nil {
+		details.Stderr = *result.stderrDigest
+	} else if details.Stderr, err = digestFile(result.stderrFile); err != nil {
+		w.logf("Failed to hash stderr: %v", err)
+	}
+	return details
+}
+
+// envSnapshot returns env sorted and cut to at most limit bytes, and whether
+// any entries were dropped
+func envSnapshot(env []string, limit int) ([]string, bool) {
+	sorted := append([]string(nil), env...)
+	sort.Strings(sorted)
+
+	size := 0
+	for n, e := range sorted {
+		size += len(e)
+		if size > limit {
+			return sorted[:n], true
+		}
+	}
+	return sorted, false
+}
+
+// digestFile hashes a captured output file. An empty path yields the digest
+// of empty output.
+func digestFile(path string) (hook.OutputDigest, error) {
+	h := sha256.New()
+	var n int64
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return hook.OutputDigest{}, err
+		}
+		defer f.Close()
+		if n, err = io.Copy(h, f); err != nil {
+			return hook.OutputDigest{}, err
+		}
+	}
+	return hook.OutputDigest{SHA256: hex.EncodeToString(h.Sum(nil)), Bytes: n}, nil
+}
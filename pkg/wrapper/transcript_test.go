@@ -0,0 +1,83 @@
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codysoyland/cmdhooks/pkg/hook"
+)
+
+func TestEnvSnapshot(t *testing.T) {
+	env := []string{"B=2", "A=1", "C=3"}
+
+	got, truncated := envSnapshot(env, 100)
+	assert.Equal(t, []string{"A=1", "B=2", "C=3"}, got)
+	assert.False(t, truncated)
+
+	got, truncated = envSnapshot(env, 7)
+	assert.Equal(t, []string{"A=1", "B=2"}, got)
+	assert.True(t, truncated)
+
+	assert.Equal(t, []string{"B=2", "A=1", "C=3"}, env, "input is not modified")
+}
+
+func TestDigestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0600))
+
+	d, err := digestFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, hook.OutputDigest{
+		SHA256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		Bytes:  6,
+	}, d)
+
+	empty, err := digestFile("")
+	require.NoError(t, err)
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", empty.SHA256)
+	assert.Zero(t, empty.Bytes)
+}
+
+func TestWrapperCommand_TranscriptDetails(t *testing.T) {
+	t.Setenv("TRANSCRIPT_TEST_VAR", "present")
+	dir := t.TempDir()
+
+	var postRun *hook.Request
+	h := &recordingLocalHook{onEvaluate: func(req *hook.Request) {
+		if req.Hook == hook.HookPostRun {
+			postRun = req
+		}
+	}}
+
+	w := NewWrapperCommand(h, WithTranscriptDetails(true), WithWorkingDir(dir))
+	require.NoError(t, w.Run([]string{"sh", "-c", "printf hello; printf oops >&2"}))
+	require.NotNil(t, postRun)
+
+	details, ok := postRun.Metadata[hook.MetadataExecution].(hook.ExecutionDetails)
+	require.True(t, ok)
+	assert.Equal(t, dir, details.WorkingDir)
+	assert.Contains(t, details.Env, "TRANSCRIPT_TEST_VAR=present")
+	assert.Equal(t, int64(5), details.Stdout.Bytes)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", details.Stdout.SHA256)
+	assert.Equal(t, int64(4), details.Stderr.Bytes)
+
+	// Disabled by default
+	postRun = nil
+	require.NoError(t, NewWrapperCommand(h).Run([]string{"true"}))
+	require.NotNil(t, postRun)
+	assert.NotContains(t, postRun.Metadata, hook.MetadataExecution)
+}
+
+func TestWrapperCommand_TranscriptDetailsEnvLimit(t *testing.T) {
+	t.Setenv("TRANSCRIPT_HUGE", strings.Repeat("x", MaxTranscriptEnvBytes))
+
+	w := NewWrapperCommand(nil, WithTranscriptDetails(true))
+	details := w.executionDetails(commandResult{})
+	assert.True(t, details.EnvTruncated)
+	assert.LessOrEqual(t, len(strings.Join(details.Env, "")), MaxTranscriptEnvBytes)
+}
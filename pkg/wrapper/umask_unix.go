@@ -0,0 +1,25 @@
+//go:build !windows
+
+package wrapper
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// umaskMutex serializes temporary umask changes, which are process-wide
+var umaskMutex sync.Mutex
+
+// startWithUmask starts cmd with the given file-creation mask. The umask is
+// inherited by the child at fork time, so the wrapper's own umask is
+// restored as soon as the child has started.
+func startWithUmask(cmd *exec.Cmd, mask int) error {
+	umaskMutex.Lock()
+	defer umaskMutex.Unlock()
+
+	old := syscall.Umask(mask)
+	defer syscall.Umask(old)
+
+	return cmd.Start()
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapperCommand_ChildUmask(t *testing.T) {
+	// Start from a permissive umask so the configured mask is observable
+	old := syscall.Umask(0o022)
+	defer syscall.Umask(old)
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "created")
+
+	wrapper := NewWrapperCommand(nil, WithChildUmask(0o077))
+	require.NoError(t, wrapper.Run([]string{"touch", target}))
+
+	info, err := os.Stat(target)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// The wrapper's own umask must be restored after the child starts
+	current := syscall.Umask(0o022)
+	assert.Equal(t, 0o022, current)
+}
+
+func TestChildUmaskFromEnv(t *testing.T) {
+	t.Run("valid octal mask", func(t *testing.T) {
+		t.Setenv(EnvChildUmask, "027")
+		opts, err := optionsFromEnv()
+		require.NoError(t, err)
+
+		w := NewWrapperCommand(nil, opts...)
+		require.NotNil(t, w.ChildUmask)
+		assert.Equal(t, 0o027, *w.ChildUmask)
+	})
+
+	t.Run("invalid mask", func(t *testing.T) {
+		t.Setenv(EnvChildUmask, "999")
+		_, err := optionsFromEnv()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package wrapper
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// startWithUmask fails: Windows has no file-creation mask
+func startWithUmask(cmd *exec.Cmd, mask int) error {
+	return fmt.Errorf("a child umask is not supported on windows")
+}
@@ -1,18 +1,17 @@
 package wrapper
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"maps"
-	"net"
 	"os"
 	"os/exec"
+	"runtime/debug"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 
 	"github.com/codysoyland/cmdhooks/pkg/hook"
@@ -24,9 +23,6 @@ const (
 	MaxIPCMessageBytes = 64 * 1024 // 64 KiB
 )
 
-// pathMutex protects PATH environment variable manipulation to prevent race conditions
-var pathMutex sync.Mutex
-
 // validateCommand checks if a command slice is valid (non-empty)
 func validateCommand(cmd []string) error {
 	if len(cmd) == 0 {
@@ -40,6 +36,120 @@ type WrapperCommand struct {
 	Hook       hook.Hook // Single hook for command evaluation
 	SocketPath string
 	Verbose    bool
+	// SocketNetwork is NetworkStream (the default) or NetworkSeqpacket and
+	// must match the interceptor's socket type
+	SocketNetwork string
+	// WorkingDir, if set, is the directory wrapped commands run in,
+	// regardless of the directory the calling script is in.
+	WorkingDir string
+	// ChildUmask, if set, is the file-creation mask applied to wrapped
+	// commands. Nil leaves the inherited umask unchanged.
+	ChildUmask *int
+	// CommandTimeouts limits how long wrapped commands may run, keyed by
+	// command name or glob pattern, with DefaultTimeoutKey as the fallback.
+	// Commands that exceed their timeout are terminated.
+	CommandTimeouts map[string]time.Duration
+	// CommandTimeout limits how long commands matched by no CommandTimeouts
+	// entry may run
+	CommandTimeout time.Duration
+	// DeadlineHeadroom is the part of each command timeout reserved for
+	// post-run evaluation
+	DeadlineHeadroom time.Duration
+	// CaseInsensitive matches command names against the hook's commands
+	// ignoring case, for case-insensitive filesystems
+	CaseInsensitive bool
+	// OutboundFilter, if set, rewrites each request right before it is
+	// sent over IPC, to limit what leaves the wrapper process
+	OutboundFilter OutboundRequestFilter
+	// Argv0Check resolves each command to its real binary before pre-run
+	// evaluation and flags argv[0] values that name a different program
+	Argv0Check bool
+	// TranscriptDetails attaches the environment and output digests of each
+	// command to its post-run request
+	TranscriptDetails bool
+	// Attribution records which evaluation stage decided each request
+	Attribution bool
+	// ScratchDir gives each command a private temp directory that is
+	// removed after its post-run hook
+	ScratchDir bool
+	// OutputHashing attaches SHA-256 digests of each command's stdout and
+	// stderr to its post-run request
+	OutputHashing bool
+	// SharedConnection sends a command's pre-run and post-run requests over
+	// one IPC connection
+	SharedConnection bool
+	// SelfTest sends a canary request instead of running the command
+	SelfTest bool
+	// ResultFile, if set, receives the outcome of each hook evaluation
+	ResultFile string
+	// Interpreters, if set, enables script detection for the listed
+	// interpreters, mapped to the position of the script argument
+	Interpreters map[string]int
+	// PostRunGate, if set, decides by exit code whether the post-run IPC
+	// request is sent
+	PostRunGate func(exitCode int) bool
+	// RequestCompression offers compression for the later messages of
+	// each IPC connection
+	RequestCompression bool
+	// Chaos honors failures injected by the interceptor's chaos testing
+	Chaos bool
+	// TimingBreakdown reports the pre-run evaluation time in post-run
+	// metadata, and logs every phase when verbose
+	TimingBreakdown bool
+	// TLSConfig, if set, makes IPC connections use TLS
+	TLSConfig *tls.Config
+	// OutputTranscript captures stdout and stderr in one ordered
+	// transcript instead of separate files
+	OutputTranscript bool
+	// FailureMode decides what happens to commands when the interceptor
+	// cannot be reached
+	FailureMode FailureMode
+	// StreamOutput shows output as it is written instead of once the
+	// command has finished
+	StreamOutput bool
+	// PTY runs commands under a pseudo-terminal when stdin is a terminal
+	PTY bool
+	// EnvAllowlist names the environment variables forwarded to hooks
+	EnvAllowlist []string
+	// SignalExitStatus reports commands killed by a signal with exit
+	// status 128 plus the signal number
+	SignalExitStatus bool
+	// Logger, if set, receives diagnostic output (see WithLogger)
+	Logger hook.Logger
+
+	// scratchDir is the current command's scratch directory, if any
+	scratchDir string
+	// requestID correlates the current command's requests
+	requestID string
+	// startedAt is when the current command was invoked
+	startedAt time.Time
+	// resolvedPath is the binary the current command runs, if found
+	resolvedPath string
+	// lookupPath is where PATH lookup found the current command, before
+	// symlinks are resolved
+	lookupPath string
+	// sharedConn is the open connection used with SharedConnection
+	sharedConn *hookConn
+	// oneShot is set when the interceptor did not announce keep-alive on
+	// the shared connection, so each later request dials its own
+	oneShot bool
+	// chaosFailed is set when chaos testing failed the current command
+	// with chaosExitCode
+	chaosFailed   bool
+	chaosExitCode int
+	// envUnset and envOverride are the environment changes the pre-run
+	// hook asked for
+	envUnset    []string
+	envOverride map[string]string
+	// timing records the current command's phases for TimingBreakdown
+	timing phaseTimes
+
+	// execWrappers decorate the execution of the wrapped command
+	execWrappers []ExecWrapper
+
+	// cleanup holds temp artifacts and other teardown actions that run
+	// exactly once before the wrapper exits, on every exit path
+	cleanup cleanupRegistry
 }
 
 // WrapperOption is a functional option for configuring WrapperCommand
@@ -59,17 +169,78 @@ func WithVerbose(verbose bool) WrapperOption {
 	}
 }
 
-// Run executes a command with pre- and post-run hook evaluation
-func Run(cmd []string, opts ...WrapperOption) error {
-	// Auto-detect socket path from environment
-	if socketPath := os.Getenv("CMDHOOKS_SOCKET"); socketPath != "" {
-		opts = append(opts, WithSocketPath(socketPath))
+// WithWorkingDir runs wrapped commands in a fixed directory
+func WithWorkingDir(dir string) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.WorkingDir = dir
+	}
+}
+
+// WithChildUmask sets the file-creation mask for wrapped commands
+func WithChildUmask(mask int) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.ChildUmask = &mask
+	}
+}
+
+// WithCommandTimeouts sets per-command timeouts for wrapped commands
+func WithCommandTimeouts(timeouts map[string]time.Duration) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.CommandTimeouts = timeouts
+	}
+}
+
+// WithCaseInsensitiveMatching enables case-insensitive command matching
+func WithCaseInsensitiveMatching(enabled bool) WrapperOption {
+	return func(w *WrapperCommand) {
+		w.CaseInsensitive = enabled
+	}
+}
+
+// ExecWrapper decorates the execution of a wrapped command, for example to
+// add tracing or run it under a profiler. It must call next to run the
+// command, and returns the exit code to report (normally next's). next
+// returns a non-nil error only if the command could not be run at all.
+type ExecWrapper func(next func() (int, error)) (int, error)
+
+// WithExecWrapper adds an ExecWrapper around command execution. When several
+// are added, the first one added is the outermost.
+func WithExecWrapper(fn ExecWrapper) WrapperOption {
+	return func(w *WrapperCommand) {
+		if fn != nil {
+			w.execWrappers = append(w.execWrappers, fn)
+		}
+	}
+}
+
+// wrapExec applies the configured exec wrappers to run
+func (w *WrapperCommand) wrapExec(run func() (int, error)) func() (int, error) {
+	for j := len(w.execWrappers) - 1; j >= 0; j-- {
+		wrap, next := w.execWrappers[j], run
+		run = func() (int, error) { return wrap(next) }
+	}
+	return run
+}
+
+// WithDeferredCleanup registers fn to run once the wrapped command has
+// finished and all hooks have been evaluated, right before the wrapper exits.
+// It runs on every exit path, including blocked and non-zero exits.
+func WithDeferredCleanup(fn func()) WrapperOption {
+	return func(w *WrapperCommand) {
+		if fn != nil {
+			w.cleanup.add(fn)
+		}
 	}
+}
 
-	// Auto-detect verbose mode from environment
-	if v := strings.TrimSpace(os.Getenv("CMDHOOKS_VERBOSE")); v != "" && strings.ToLower(v) != "false" && v != "0" {
-		opts = append(opts, WithVerbose(true))
+// Run executes a command with pre- and post-run hook evaluation
+func Run(cmd []string, opts ...WrapperOption) error {
+	// Auto-detect configuration (socket path, verbose mode, ...) from environment
+	envOpts, err := optionsFromEnv()
+	if err != nil {
+		return err
 	}
+	opts = append(opts, envOpts...)
 
 	// Use nil hook - wrapper will rely on socket-based IPC
 	w := NewWrapperCommand(nil, opts...)
@@ -93,57 +264,112 @@ func (w *WrapperCommand) Run(command []string) error {
 		return err
 	}
 
+	if w.SelfTest {
+		return w.runSelfTest(command)
+	}
+
+	// Temp artifacts must outlive post-run evaluation, so they are released
+	// when Run returns
+	defer w.cleanup.run()
+
 	cmd := command[0]
 	args := command[1:]
 
-	if w.Verbose {
-		log.Printf("Wrapper: %s %v", cmd, args)
+	if w.WorkingDir != "" {
+		if info, err := os.Stat(w.WorkingDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("working directory %s is not a directory", w.WorkingDir)
+		}
+	}
+
+	w.logf("Wrapper: %s %v", cmd, args)
+
+	// Pre-run and post-run requests share an ID and start time so hooks can
+	// correlate them
+	w.requestID = newRequestID()
+	w.startedAt = time.Now()
+
+	// Hooks see the binary the command resolves to; if it cannot be found,
+	// executeCommand reports the failure the usual way
+	var err error
+	if w.lookupPath, w.resolvedPath, err = w.resolveBinary(cmd); err != nil {
+		w.logf("Cannot resolve %s: %v", cmd, err)
 	}
 
 	// Create basic metadata
 	metadata := make(map[string]any)
-
-	if w.Verbose {
-		log.Printf("Evaluating hooks for %s...", cmd)
+	w.scratchDir = ""
+	if w.ScratchDir {
+		if err := w.createScratchDir(metadata); err != nil {
+			return err
+		}
 	}
+	if w.Argv0Check {
+		w.checkArgv0(cmd, w.resolvedPath, metadata)
+	}
+	if w.Interpreters != nil {
+		w.detectScript(command, metadata)
+	}
+
+	w.logf("Evaluating hooks for %s...", cmd)
 
-	// Pre-run hook evaluation
-	if err := w.executePreRun(command, metadata); err != nil {
+	// Pre-run hook evaluation, which may rewrite the command
+	w.timing = phaseTimes{}
+	preRunStart := time.Now()
+	effective, err := w.executePreRun(command, metadata)
+	w.timing.preRun = time.Since(preRunStart)
+	var denied *deniedError
+	if errors.As(err, &denied) {
+		// The hook denied the command without stopping the script
+		return w.outputResults(commandResult{exitCode: denied.code})
+	}
+	if err != nil {
 		return err
 	}
 
-	// Execute the actual command
+	// Execute the actual command, unless chaos testing failed it
 	startTime := time.Now()
-	exitCode, stdoutFile, stderrFile, err := w.executeCommand(cmd, args)
-	duration := time.Since(startTime)
-
-	// Ensure files are cleaned up
-	if stdoutFile != "" {
-		defer os.Remove(stdoutFile)
-	}
-	if stderrFile != "" {
-		defer os.Remove(stderrFile)
+	var result commandResult
+	if w.chaosFailed {
+		result = w.chaosResult(strings.Join(command, " "), metadata)
+	} else {
+		result, err = w.executeCommand(effective[0], effective[1:])
 	}
+	duration := time.Since(startTime)
+	w.timing.execution = duration
 
 	// Post-run hook evaluation
-	if postErr := w.executePostRun(command, metadata, exitCode, duration, stdoutFile, stderrFile); postErr != nil {
+	w.reportPreRunTime(metadata)
+	postRunStart := time.Now()
+	if postErr := w.executePostRun(command, metadata, result, duration); postErr != nil {
 		return postErr
 	}
+	w.timing.postRun = time.Since(postRunStart)
 
-	// Output results and handle exit
-	w.outputResults(stdoutFile, stderrFile, exitCode)
-
+	// Output results and forward the exit code
+	if exitErr := w.outputResults(result); exitErr != nil {
+		return exitErr
+	}
 	return err
 }
 
-// hookHandlesCommand checks if a hook handles the given command
+// hookHandlesCommand checks if a hook handles the given command, see
+// hook.HandlesCommand
 func (w *WrapperCommand) hookHandlesCommand(hookCommands []string, requestCommand string) bool {
-	for _, cmd := range hookCommands {
-		if cmd == requestCommand || cmd == "*" {
-			return true
+	return hook.HandlesCommand(hookCommands, requestCommand, w.CaseInsensitive)
+}
+
+// callLocalHook calls the local hook, converting a panic into a
+// *hook.PanicError so a faulty hook denies the command instead of crashing
+// the wrapper
+func (w *WrapperCommand) callLocalHook(ctx context.Context, h hook.LocalHook, req *hook.Request) (resp *hook.Response, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr := &hook.PanicError{Value: r, Stack: debug.Stack()}
+			w.logf("Local hook %s panicked: %v\n%s", h.Name(), r, panicErr.Stack)
+			resp, err = nil, panicErr
 		}
-	}
-	return false
+	}()
+	return h.EvaluateLocal(ctx, req)
 }
 
 // evaluateLocalHook evaluates the local hook if present and handles the command
@@ -152,20 +378,21 @@ func (w *WrapperCommand) evaluateLocalHook(ctx context.Context, req *hook.Reques
 	if !ok {
 		return nil, nil
 	}
+	if req.Hook == hook.HookPostRun && !w.wantsLocalPostRun(req.ExitCode) {
+		return nil, nil
+	}
 
 	// Check if this hook handles this command
-	if len(req.Command) == 0 || !w.hookHandlesCommand(localHook.Commands(), req.Command[0]) {
+	if !w.hookHandlesRequest(localHook.Commands(), req) {
 		return nil, nil
 	}
 
-	response, err := localHook.EvaluateLocal(ctx, req)
+	response, err := w.callLocalHook(ctx, localHook, req)
 	if err != nil {
 		return nil, fmt.Errorf("local hook %s error: %w", localHook.Name(), err)
 	}
 
-	if w.Verbose {
-		log.Printf("Local hook %s evaluated", localHook.Name())
-	}
+	w.logf("Local hook %s evaluated", localHook.Name())
 
 	return response, nil
 }
@@ -173,12 +400,14 @@ func (w *WrapperCommand) evaluateLocalHook(ctx context.Context, req *hook.Reques
 // evaluateIPCHook evaluates hooks via IPC, merging metadata from local response
 func (w *WrapperCommand) evaluateIPCHook(ctx context.Context, req *hook.Request, localResponse *hook.Response) (*hook.Response, error) {
 	if w.SocketPath == "" {
+		return w.unreachable(req, errNoSocket)
+	}
+	if req.Hook == hook.HookPostRun && !w.wantsIPCPostRun(req.ExitCode) {
+		w.logf("Skipping post-run IPC evaluation for exit code %d", req.ExitCode)
 		return nil, nil
 	}
 
-	if w.Verbose {
-		log.Printf("Using IPC evaluation")
-	}
+	w.logf("Using IPC evaluation")
 
 	// Start with the original request metadata and merge local hook metadata
 	mergedMetadata := make(map[string]interface{})
@@ -191,18 +420,42 @@ func (w *WrapperCommand) evaluateIPCHook(ctx context.Context, req *hook.Request,
 		maps.Copy(mergedMetadata, localResponse.Metadata)
 	}
 
-	ipcReq := hook.Request{
-		Command:  req.Command,
-		PID:      req.PID,
-		Hook:     req.Hook,
-		ExitCode: req.ExitCode,
-		Duration: req.Duration,
-		Metadata: mergedMetadata,
+	// Tell the interceptor what the local stage decided, for its audit records
+	if w.Attribution {
+		mergedMetadata[hook.MetadataAttribution] = map[string]interface{}{
+			hook.StageLocal: localResponse.Decision(),
+		}
 	}
 
-	resp, err := runHook(w.SocketPath, ipcReq)
+	ipcReq := hook.Request{
+		Command:       req.Command,
+		PID:           req.PID,
+		ResolvedPath:  req.ResolvedPath,
+		LookupPath:    req.LookupPath,
+		WorkingDir:    req.WorkingDir,
+		Env:           req.Env,
+		UID:           req.UID,
+		GID:           req.GID,
+		Hook:          req.Hook,
+		RequestID:     req.RequestID,
+		CorrelationID: req.CorrelationID,
+		StartedAt:     req.StartedAt,
+		Batch:         req.Batch,
+		ExitCode:      req.ExitCode,
+		Duration:      req.Duration,
+		ExitReason:    req.ExitReason,
+		Metadata:      mergedMetadata,
+	}
+
+	var resp *hook.Response
+	var err error
+	if w.SharedConnection {
+		resp, err = w.exchangeShared(ipcReq)
+	} else {
+		resp, err = runHook(w.SocketNetwork, w.SocketPath, w.TLSConfig, ipcReq, w.OutboundFilter)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("IPC hook evaluation failed: %w", err)
+		return w.unreachable(req, err)
 	}
 	return resp, nil
 }
@@ -219,9 +472,9 @@ func (w *WrapperCommand) evaluateHooks(req *hook.Request) (*hook.Response, error
 		return nil, err
 	}
 
-	// If local hook requests exit, return immediately
-	if localResponse != nil && localResponse.Exit {
-		return localResponse, nil
+	// If local hook denies the command, return immediately
+	if localResponse.Denied() {
+		return w.attribute(localResponse, hook.StageLocal, localResponse, nil, true), nil
 	}
 
 	// Evaluate IPC hook
@@ -230,16 +483,16 @@ func (w *WrapperCommand) evaluateHooks(req *hook.Request) (*hook.Response, error
 		return nil, err
 	}
 	if ipcResponse != nil {
-		return ipcResponse, nil
+		return w.attribute(ipcResponse, hook.StageIPC, localResponse, ipcResponse, false), nil
 	}
 
 	// If we had a local response but no IPC, return the local response
 	if localResponse != nil {
-		return localResponse, nil
+		return w.attribute(localResponse, hook.StageLocal, localResponse, nil, false), nil
 	}
 
 	// No hook available - continue by default
-	return &hook.Response{}, nil
+	return w.attribute(&hook.Response{}, hook.StageDefault, nil, nil, false), nil
 }
 
 // SetSocketPath sets the IPC socket path for IPC evaluation
@@ -252,168 +505,357 @@ func (w *WrapperCommand) SetVerbose(verbose bool) {
 	w.Verbose = verbose
 }
 
+// commandResult describes a finished wrapped command
+type commandResult struct {
+	exitCode int
+	// stdoutFile and stderrFile hold the captured output, or
+	// transcriptFile with OutputTranscript
+	stdoutFile     string
+	stderrFile     string
+	transcriptFile string
+	// streamed is set if the output was shown as it was written and must
+	// not be replayed
+	streamed bool
+	// timedOut is set if the command was terminated for exceeding its timeout
+	timedOut bool
+	// exitReason is set if the command could not be run at all
+	exitReason hook.ExitReason
+	// stdoutDigest and stderrDigest are set when output hashing is enabled
+	stdoutDigest *hook.OutputDigest
+	stderrDigest *hook.OutputDigest
+}
+
 // executeCommand executes the command and captures output and exit code
 // Returns filenames for stdout/stderr instead of file handles to avoid memory usage
-func (w *WrapperCommand) executeCommand(cmd string, args []string) (int, string, string, error) {
-	// Get clean PATH without wrapper directory
-	cleanPath := w.getCleanPath()
-
+func (w *WrapperCommand) executeCommand(cmd string, args []string) (commandResult, error) {
 	// Find the real command using clean PATH to avoid recursive wrapper calls
-	// Use mutex to prevent race conditions with PATH environment variable
-	pathMutex.Lock()
-	origPath := os.Getenv("PATH")
-	os.Setenv("PATH", cleanPath)
-	realCmd, err := exec.LookPath(cmd)
-	os.Setenv("PATH", origPath)
-	pathMutex.Unlock()
-
+	realCmd, origPath, err := w.lookPath(cmd)
 	if err != nil {
-		return 1, "", "", fmt.Errorf("command not found: %s", cmd)
+		code, reason := w.lookupFailure(cmd)
+		return commandResult{exitCode: code, exitReason: reason}, fmt.Errorf("%w: %s", exitReasonError(reason), cmd)
+	}
+
+	ctx := context.Background()
+	timeout := w.timeoutFor(cmd)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.runLimit(timeout))
+		defer cancel()
 	}
 
 	// Use the absolute path to the real command to avoid wrapper recursion
-	execCmd := exec.Command(realCmd, args...)
+	execCmd := exec.CommandContext(ctx, realCmd, args...)
+	var kill *time.Timer
+	if timeout > 0 {
+		// Ask the command and its children to stop first, and kill them if
+		// they do not exit within the grace period
+		setProcessGroup(execCmd)
+		execCmd.Cancel = func() error {
+			kill = time.AfterFunc(timeoutGracePeriod, func() {
+				_ = signalProcessGroup(execCmd.Process, syscall.SIGKILL)
+			})
+			return signalProcessGroup(execCmd.Process, syscall.SIGTERM)
+		}
+		execCmd.WaitDelay = timeoutGracePeriod
+	}
 	execCmd.Stdin = os.Stdin
+	execCmd.Dir = w.WorkingDir
 
 	// Set up environment with wrapper PATH so child processes can be intercepted
 	// Note: We use the original PATH (with wrapper dir) for child processes
-	execCmd.Env = w.getCleanEnvironment(origPath)
+	execCmd.Env = append(w.applyEnvChanges(w.getCleanEnvironment(origPath)), w.scratchEnv()...)
+
+	var result commandResult
+	closeOutput, err := w.captureOutput(execCmd, &result)
+	if err != nil {
+		return commandResult{exitCode: 1}, err
+	}
+	defer closeOutput()
+
+	// Hash output while it is written, rather than reading it back
+	var stdoutHash, stderrHash *hashingWriter
+	if w.OutputHashing {
+		stdoutHash = newHashingWriter(execCmd.Stdout)
+		stderrHash = newHashingWriter(execCmd.Stderr)
+		execCmd.Stdout = stdoutHash
+		execCmd.Stderr = stderrHash
+	}
+
+	var pty *ptySession
+	if w.usePTY() {
+		if pty, err = startPTY(execCmd, execCmd.Stdout); err != nil {
+			w.logf("Running %s without a pseudo-terminal: %v", cmd, err)
+		} else {
+			result.streamed = true
+		}
+	}
+	if w.StreamOutput && !result.streamed {
+		streamOutput(execCmd, &result)
+	}
+
+	var exitReason hook.ExitReason
+	run := func() (int, error) {
+		// Relay signals from the start, so none is lost before the
+		// command is running
+		relay := w.relaySignals()
+		defer relay.stop()
+
+		var err error
+		if w.ChildUmask != nil {
+			err = startWithUmask(execCmd, *w.ChildUmask)
+		} else {
+			err = execCmd.Start()
+		}
+		if err != nil {
+			var code int
+			code, exitReason = startFailure(err)
+			return code, err
+		}
+		relay.attach(execCmd)
+		if err = execCmd.Wait(); err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				return w.exitStatus(exitErr), nil
+			}
+			return 1, err
+		}
+		return 0, nil
+	}
+
+	exitCode, err := w.wrapExec(run)()
+	if kill != nil {
+		// Children left behind by the timed-out command go with it
+		kill.Stop()
+		_ = signalProcessGroup(execCmd.Process, syscall.SIGKILL)
+	}
+	if pty != nil {
+		pty.finish()
+	}
+	if err != nil {
+		w.logf("Command %s failed to run: %v", cmd, err)
+	}
+	result.exitCode = exitCode
+	result.exitReason = exitReason
+	if w.OutputHashing {
+		result.stdoutDigest = stdoutHash.digest()
+		result.stderrDigest = stderrHash.digest()
+	}
+	if timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+		result.timedOut = true
+		result.exitCode = TimeoutExitCode
+		w.logf("Command %s exceeded timeout of %s", cmd, timeout)
+	}
+
+	return result, nil
+}
+
+// captureOutput points the command's stdout and stderr at temp files,
+// recorded in result, and returns a function closing them once the command
+// has finished
+func (w *WrapperCommand) captureOutput(execCmd *exec.Cmd, result *commandResult) (func(), error) {
+	if w.OutputTranscript {
+		return w.captureTranscript(execCmd, result)
+	}
 
 	// Create temporary files for stdout and stderr to avoid memory limits
 	stdoutFile, err := os.CreateTemp("", "cmdhooks-stdout-*")
 	if err != nil {
-		return 1, "", "", fmt.Errorf("failed to create stdout temp file: %w", err)
+		return nil, fmt.Errorf("failed to create stdout temp file: %w", err)
 	}
+	w.cleanup.addFile(stdoutFile.Name())
 	stdoutFile.Close() // Close immediately, we only need the filename
 
 	stderrFile, err := os.CreateTemp("", "cmdhooks-stderr-*")
 	if err != nil {
-		os.Remove(stdoutFile.Name())
-		return 1, "", "", fmt.Errorf("failed to create stderr temp file: %w", err)
+		return nil, fmt.Errorf("failed to create stderr temp file: %w", err)
 	}
+	w.cleanup.addFile(stderrFile.Name())
 	stderrFile.Close() // Close immediately, we only need the filename
 
 	// Reopen files for writing (exec.Command needs writable files)
 	stdoutWrite, err := os.OpenFile(stdoutFile.Name(), os.O_WRONLY, 0600)
 	if err != nil {
-		os.Remove(stdoutFile.Name())
-		os.Remove(stderrFile.Name())
-		return 1, "", "", fmt.Errorf("failed to reopen stdout file: %w", err)
+		return nil, fmt.Errorf("failed to reopen stdout file: %w", err)
 	}
-	defer stdoutWrite.Close()
 
 	stderrWrite, err := os.OpenFile(stderrFile.Name(), os.O_WRONLY, 0600)
 	if err != nil {
-		os.Remove(stdoutFile.Name())
-		os.Remove(stderrFile.Name())
-		return 1, "", "", fmt.Errorf("failed to reopen stderr file: %w", err)
+		stdoutWrite.Close()
+		return nil, fmt.Errorf("failed to reopen stderr file: %w", err)
 	}
-	defer stderrWrite.Close()
 
 	execCmd.Stdout = stdoutWrite
 	execCmd.Stderr = stderrWrite
-
-	err = execCmd.Run()
-	exitCode := 0
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			exitCode = exitErr.ExitCode()
-		} else {
-			exitCode = 1
-		}
-	}
-
-	return exitCode, stdoutFile.Name(), stderrFile.Name(), nil
+	result.stdoutFile = stdoutFile.Name()
+	result.stderrFile = stderrFile.Name()
+	return func() {
+		stdoutWrite.Close()
+		stderrWrite.Close()
+	}, nil
 }
 
-// executePreRun handles pre-run hook evaluation
-func (w *WrapperCommand) executePreRun(command []string, metadata map[string]any) error {
+// executePreRun handles pre-run hook evaluation and returns the command to
+// run, which the hook may have rewritten (see hook.Response.ModifiedCommand)
+func (w *WrapperCommand) executePreRun(command []string, metadata map[string]any) ([]string, error) {
 	req := &hook.Request{
-		Command:  command,
-		PID:      os.Getpid(),
-		Hook:     hook.HookPreRun,
-		Metadata: metadata,
+		Command:       command,
+		PID:           os.Getpid(),
+		ResolvedPath:  w.resolvedPath,
+		LookupPath:    w.lookupPath,
+		WorkingDir:    w.workingDir(),
+		Env:           w.requestEnv(),
+		UID:           reportedUID(),
+		GID:           reportedGID(),
+		Hook:          hook.HookPreRun,
+		RequestID:     w.requestID,
+		CorrelationID: w.requestID,
+		StartedAt:     w.startedAt,
+		Metadata:      metadata,
 	}
 
 	response, err := w.evaluateHooks(req)
 	if err != nil {
-		return fmt.Errorf("pre-run hook evaluation error: %w", err)
+		return nil, fmt.Errorf("pre-run hook evaluation error: %w", err)
 	}
+	w.writeResultFile(command, hook.HookPreRun, response, 0)
 
 	if response.Exit {
-		if w.Verbose {
-			log.Printf("✗ Process termination requested")
-		}
-		return fmt.Errorf("process termination requested")
+		return nil, w.terminationError(strings.Join(command, " "), response)
+	}
+	if response.ExitCode != nil {
+		return nil, w.denial(strings.Join(command, " "), response)
 	}
+	w.chaosExitCode, w.chaosFailed = w.injectedFailure(response)
+	w.envUnset, w.envOverride = response.EnvUnset, response.EnvOverride
 
-	if w.Verbose {
-		log.Printf("✓ Pre-run continuing")
+	w.logf("✓ Pre-run continuing")
+
+	if response.ModifiedCommand == nil {
+		return command, nil
+	}
+	if len(response.ModifiedCommand) == 0 || response.ModifiedCommand[0] == "" {
+		return nil, fmt.Errorf("invalid modified command %q from pre-run hook: command cannot be empty", response.ModifiedCommand)
 	}
+	w.logf("Command rewritten by hook: %v -> %v", command, response.ModifiedCommand)
+	// Post-run hooks see the original command, and what ran instead
+	metadata[hook.MetadataModifiedCommand] = response.ModifiedCommand
+	return response.ModifiedCommand, nil
+}
 
-	return nil
+// terminationError reports a blocked command on stderr along with the
+// hook's reason, if it gave one, and returns the error for Run
+func (w *WrapperCommand) terminationError(command string, resp *hook.Response) error {
+	w.logf("✗ Process termination requested")
+	reason := resp.BlockReason()
+	if reason == "" {
+		return fmt.Errorf("process termination requested")
+	}
+	fmt.Fprintf(os.Stderr, "Blocked %s: %s\n", command, reason)
+	return fmt.Errorf("process termination requested: %s", reason)
 }
 
 // executePostRun handles post-run hook evaluation
-func (w *WrapperCommand) executePostRun(command []string, metadata map[string]any, exitCode int, duration time.Duration, stdoutFile, stderrFile string) error {
+func (w *WrapperCommand) executePostRun(command []string, metadata map[string]any, result commandResult, duration time.Duration) error {
 	// Pass filenames to hooks instead of reading data into memory
-	if stdoutFile != "" {
-		metadata["stdout_file"] = stdoutFile
+	if result.stdoutFile != "" {
+		metadata["stdout_file"] = result.stdoutFile
+	}
+	if result.stderrFile != "" {
+		metadata["stderr_file"] = result.stderrFile
 	}
-	if stderrFile != "" {
-		metadata["stderr_file"] = stderrFile
+	if result.transcriptFile != "" {
+		metadata[hook.MetadataOutputTranscript] = result.transcriptFile
+	}
+	if result.timedOut {
+		metadata["timed_out"] = true
 	}
 
 	metadata["execution_duration"] = duration
+	if result.stdoutDigest != nil {
+		metadata[hook.MetadataStdoutSHA256] = result.stdoutDigest.SHA256
+	}
+	if result.stderrDigest != nil {
+		metadata[hook.MetadataStderrSHA256] = result.stderrDigest.SHA256
+	}
+	if w.TranscriptDetails {
+		metadata[hook.MetadataExecution] = w.executionDetails(result)
+	}
 
 	request := &hook.Request{
-		Command:  command,
-		PID:      os.Getpid(),
-		Hook:     hook.HookPostRun,
-		Metadata: metadata,
-		ExitCode: exitCode,
-		Duration: duration,
+		Command:       command,
+		PID:           os.Getpid(),
+		ResolvedPath:  w.resolvedPath,
+		LookupPath:    w.lookupPath,
+		WorkingDir:    w.workingDir(),
+		Env:           w.requestEnv(),
+		UID:           reportedUID(),
+		GID:           reportedGID(),
+		Hook:          hook.HookPostRun,
+		RequestID:     w.requestID,
+		CorrelationID: w.requestID,
+		StartedAt:     w.startedAt,
+		Metadata:      metadata,
+		ExitCode:      result.exitCode,
+		Duration:      duration,
+		ExitReason:    result.exitReason,
 	}
 
 	response, err := w.evaluateHooks(request)
 	if err != nil {
 		return fmt.Errorf("post-run hook evaluation error: %w", err)
 	}
+	w.writeResultFile(command, hook.HookPostRun, response, result.exitCode)
 
 	if response.Exit {
-		if w.Verbose {
-			log.Printf("✗ Process termination requested")
-		}
-		return fmt.Errorf("process termination requested")
+		return w.terminationError(strings.Join(command, " "), response)
 	}
 
-	if w.Verbose {
-		log.Printf("✓ Post-run continuing")
-	}
+	w.logf("✓ Post-run continuing")
 
 	return nil
 }
 
-// outputResults writes captured stdout/stderr to user and exits with original code
-func (w *WrapperCommand) outputResults(stdoutFile, stderrFile string, exitCode int) {
+// outputResults writes captured stdout/stderr to user and returns an
+// ExitError carrying the original exit code if it is non-zero
+func (w *WrapperCommand) outputResults(result commandResult) error {
 	// Copy captured output to user's stdout/stderr
-	if stdoutFile != "" {
-		if file, err := os.Open(stdoutFile); err == nil {
-			_, _ = io.Copy(os.Stdout, file)
-			file.Close()
+	replayStart := time.Now()
+	switch {
+	case result.streamed:
+		// Already shown as it was written
+	case result.transcriptFile != "":
+		if err := replayTranscript(result.transcriptFile, os.Stdout, os.Stderr); err != nil {
+			w.logf("Failed to replay output: %v", err)
 		}
-	}
-	if stderrFile != "" {
-		if file, err := os.Open(stderrFile); err == nil {
-			_, _ = io.Copy(os.Stderr, file)
-			file.Close()
+	default:
+		if result.stdoutFile != "" {
+			if file, err := os.Open(result.stdoutFile); err == nil {
+				_, _ = io.Copy(os.Stdout, file)
+				file.Close()
+			}
+		}
+		if result.stderrFile != "" {
+			if file, err := os.Open(result.stderrFile); err == nil {
+				_, _ = io.Copy(os.Stderr, file)
+				file.Close()
+			}
 		}
 	}
+	w.logTiming(time.Since(replayStart))
 
-	// Exit with original exit code
-	if exitCode != 0 {
-		os.Exit(exitCode)
+	if result.exitCode != 0 {
+		return &ExitError{Code: result.exitCode, Reason: result.exitReason}
 	}
+	return nil
+}
+
+// workingDir returns the directory wrapped commands run in: the fixed
+// working directory if set, else the wrapper's own
+func (w *WrapperCommand) workingDir() string {
+	if w.WorkingDir != "" {
+		return w.WorkingDir
+	}
+	dir, _ := os.Getwd()
+	return dir
 }
 
 // getCleanPath returns PATH without the cmdhooks wrapper directory
@@ -448,39 +890,22 @@ func (w *WrapperCommand) getCleanEnvironment(cleanPath string) []string {
 	return append(env, "PATH="+cleanPath)
 }
 
-// runHook sends a request to the IPC socket and returns the hook response
-func runHook(socketPath string, req hook.Request) (*hook.Response, error) {
-	conn, err := net.Dial("unix", socketPath)
+// runHook sends a request to the IPC socket and returns the hook response.
+// The connection uses TLS if tlsConfig is set. If filter is set, only the
+// request it returns is transmitted.
+func runHook(network, socketPath string, tlsConfig *tls.Config, req hook.Request, filter OutboundRequestFilter) (*hook.Response, error) {
+	req, err := filterRequest(req, filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to socket: %w", err)
+		return nil, err
 	}
-	defer conn.Close()
 
-	// Send request
-	data, err := json.Marshal(req)
+	conn, err := dialHook(network, socketPath, tlsConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-	if _, err := fmt.Fprintf(conn, "%s\n", string(data)); err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-
-	// Read response with a bounded scanner
-	scanner := bufio.NewScanner(conn)
-	scanner.Buffer(make([]byte, 0, 64*1024), MaxIPCMessageBytes)
-	if !scanner.Scan() {
-		if err := scanner.Err(); err != nil {
-			return nil, fmt.Errorf("failed to read response: %w", err)
-		}
-		return nil, fmt.Errorf("no response from socket")
-	}
-
-	var resp hook.Response
-	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, err
 	}
+	defer conn.Close()
 
-	return &resp, nil
+	return conn.exchange(req)
 }
 
 // (no longer needed): env-based timeout parsing removed
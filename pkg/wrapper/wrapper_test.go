@@ -1,12 +1,17 @@
 package wrapper
 
 import (
+	"bytes"
 	"context"
+	"log"
 	"maps"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/codysoyland/cmdhooks/pkg/hook"
 )
@@ -281,6 +286,28 @@ func TestWrapperCommand_RunWrapperWithLocalHook(t *testing.T) {
 	})
 }
 
+// panickingLocalHook panics on every evaluation
+type panickingLocalHook struct{}
+
+func (panickingLocalHook) Name() string       { return "panicking" }
+func (panickingLocalHook) Commands() []string { return []string{"*"} }
+func (panickingLocalHook) EvaluateLocal(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	panic("nil map in policy")
+}
+
+func TestWrapperCommand_LocalHookPanic(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	var logs bytes.Buffer
+	wrapper := NewWrapperCommand(panickingLocalHook{}, WithLogger(log.New(&logs, "", 0)))
+
+	err := wrapper.Run([]string{"touch", marker})
+	var panicErr *hook.PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.ErrorContains(t, err, "local hook panicking error: panic: nil map in policy")
+	assert.NoFileExists(t, marker, "the command must not run")
+	assert.Contains(t, logs.String(), "wrapper_test.go", "the stack is logged")
+}
+
 func TestWrapperCommand_VerboseOutput(t *testing.T) {
 	// Test that verbose mode doesn't break functionality
 	localHook := newMockLocalHook("test", []string{"echo"})
@@ -290,6 +317,17 @@ func TestWrapperCommand_VerboseOutput(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestWrapperCommand_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	localHook := newMockLocalHook("test", []string{"echo"})
+	wrapper := NewWrapperCommand(localHook, WithLogger(log.New(&buf, "", 0)))
+
+	require.NoError(t, wrapper.Run([]string{"echo", "test"}))
+	assert.Contains(t, buf.String(), "Wrapper: echo [test]")
+	assert.Contains(t, buf.String(), "Local hook test evaluated")
+	assert.Contains(t, buf.String(), "✓ Post-run continuing")
+}
+
 func TestWrapperOptions(t *testing.T) {
 	t.Run("WithSocketPath", func(t *testing.T) {
 		tmpDir := t.TempDir()
@@ -307,6 +345,48 @@ func TestWrapperOptions(t *testing.T) {
 		option(w)
 		assert.True(t, w.Verbose)
 	})
+
+	t.Run("WithWorkingDir", func(t *testing.T) {
+		w := &WrapperCommand{}
+		WithWorkingDir("/tmp")(w)
+		assert.Equal(t, "/tmp", w.WorkingDir)
+	})
+}
+
+func TestWrapperCommand_WorkingDir(t *testing.T) {
+	t.Run("command runs in configured directory", func(t *testing.T) {
+		workDir := t.TempDir()
+		wrapper := NewWrapperCommand(nil, WithWorkingDir(workDir))
+
+		err := wrapper.Run([]string{"sh", "-c", "pwd > cwd.txt"})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(workDir, "cwd.txt"))
+		require.NoError(t, err)
+		expected, err := filepath.EvalSymlinks(workDir)
+		require.NoError(t, err)
+		assert.Equal(t, expected, strings.TrimSpace(string(data)))
+	})
+
+	t.Run("missing directory is rejected", func(t *testing.T) {
+		localHook := newMockLocalHook("test", []string{"sh"})
+		wrapper := NewWrapperCommand(localHook, WithWorkingDir(filepath.Join(t.TempDir(), "missing")))
+
+		err := wrapper.Run([]string{"sh", "-c", "true"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "is not a directory")
+		assert.Equal(t, 0, localHook.evalCount, "hooks should not run with an invalid working directory")
+	})
+
+	t.Run("working directory from environment", func(t *testing.T) {
+		workDir := t.TempDir()
+		t.Setenv(EnvWorkingDir, workDir)
+
+		opts, err := optionsFromEnv()
+		require.NoError(t, err)
+		w := NewWrapperCommand(nil, opts...)
+		assert.Equal(t, workDir, w.WorkingDir)
+	})
 }
 
 func TestMetadataPassing(t *testing.T) {
@@ -378,3 +458,61 @@ func TestMetadataPassing(t *testing.T) {
 		assert.Equal(t, expected, mergedMetadata)
 	})
 }
+
+func TestWrapperCommand_CaseInsensitiveMatching(t *testing.T) {
+	commands := []string{"curl", "wget"}
+
+	w := NewWrapperCommand(nil)
+	assert.True(t, w.hookHandlesCommand(commands, "curl"))
+	assert.False(t, w.hookHandlesCommand(commands, "Curl"), "matching is case-sensitive by default")
+
+	w = NewWrapperCommand(nil, WithCaseInsensitiveMatching(true))
+	assert.True(t, w.hookHandlesCommand(commands, "Curl"))
+	assert.True(t, w.hookHandlesCommand(commands, "WGET"))
+	assert.False(t, w.hookHandlesCommand(commands, "curl2"))
+
+	assert.True(t, w.hookHandlesCommand(commands, "/usr/bin/CURL"), "paths match by basename")
+
+	w = NewWrapperCommand(nil)
+	assert.True(t, w.hookHandlesCommand(commands, "/usr/bin/curl"), "paths match by basename")
+	assert.True(t, w.hookHandlesCommand(commands, "./curl"))
+	assert.False(t, w.hookHandlesCommand(commands, "/usr/bin/Curl"))
+	assert.True(t, w.hookHandlesCommand([]string{"git-*"}, "git-lfs"), "entries may be glob patterns")
+	assert.False(t, w.hookHandlesCommand([]string{"git-*"}, "git"))
+
+	t.Run("local hook evaluated for differently cased command", func(t *testing.T) {
+		localHook := newMockLocalHook("test", []string{"curl"})
+		w := NewWrapperCommand(localHook, WithCaseInsensitiveMatching(true))
+		resp, err := w.evaluateLocalHook(context.Background(), &hook.Request{Command: []string{"Curl"}})
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.Equal(t, 1, localHook.evalCount)
+	})
+
+	t.Run("from environment", func(t *testing.T) {
+		t.Setenv(EnvCaseInsensitive, "true")
+		opts, err := optionsFromEnv()
+		require.NoError(t, err)
+		assert.True(t, NewWrapperCommand(nil, opts...).CaseInsensitive)
+	})
+}
+
+func TestWrapperCommand_MultipleLocalHooks(t *testing.T) {
+	first := newMockLocalHook("first", []string{"curl", "wget"})
+	second := newMockLocalHook("second", []string{"curl"})
+	second.allowAll = false
+	second.responses["curl:pre_run"] = &hook.Response{Exit: true, Reason: "no curl"}
+	third := newMockLocalHook("third", []string{"*"})
+	w := NewWrapperCommand(hook.NewMulti(first, second, third))
+
+	resp, err := w.evaluateHooks(&hook.Request{Command: []string{"curl"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.True(t, resp.Exit)
+	assert.Equal(t, "no curl", resp.Reason)
+	assert.Equal(t, []int{1, 1, 0}, []int{first.evalCount, second.evalCount, third.evalCount}, "evaluation stops at the first denial")
+
+	resp, err = w.evaluateHooks(&hook.Request{Command: []string{"wget"}, Hook: hook.HookPreRun})
+	require.NoError(t, err)
+	assert.False(t, resp.Exit)
+	assert.Equal(t, []int{2, 1, 1}, []int{first.evalCount, second.evalCount, third.evalCount}, "hooks not listing wget are skipped")
+}